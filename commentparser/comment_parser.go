@@ -148,6 +148,30 @@ func (i *input) lex() {
 			break
 		}
 
+		if i.columnComment(c) {
+			startLine := i.pos.line
+			i.readRune() // Eat the marker.
+			var comment bytes.Buffer
+			for {
+				if i.eof() {
+					return
+				}
+				c = i.readRune()
+				if c == '\n' {
+					i.unreadRune(c)
+					break
+				}
+				comment.WriteRune(c)
+			}
+			i.comments = append(i.comments, &Comment{
+				StartLine: startLine,
+				EndLine:   i.pos.line,
+				Text:      comment.String(),
+			})
+			i.readRune() // Eat the newline.
+			continue
+		}
+
 		switch c {
 		case '"', '\'', '`': // String
 			// Ignore strings because they could contain comment
@@ -274,6 +298,16 @@ func (i *input) lex() {
 	}
 }
 
+// columnComment returns 'true' if the rune is a marker that makes the current
+// line a comment in a language with column-based comments.
+func (i *input) columnComment(c rune) bool {
+	column, markers := i.lang.ColumnCommentMarkers()
+	if column == 0 || i.pos.lineRune[len(i.pos.lineRune)-1] != column-1 {
+		return false
+	}
+	return strings.ContainsRune(markers, c)
+}
+
 // singleLineComment returns 'true' if we've run across a single line comment
 // in the given language.
 func (i *input) singleLineComment() bool {
@@ -285,6 +319,9 @@ func (i *input) singleLineComment() bool {
 		return i.match(language.MySQL.SingleLineCommentStart())
 	} else if i.lang == language.ObjectiveC {
 		return i.match(language.Matlab.SingleLineCommentStart())
+	} else if i.lang == language.AssemblyARM {
+		// AArch64 assemblers use C++-style comments.
+		return i.match(language.C.SingleLineCommentStart())
 	}
 
 	return false
@@ -438,6 +438,107 @@ close all;
 				},
 			},
 		},
+		{
+			description: "Fixed-Form Fortran Column Comments",
+			lang:        language.FortranFixed,
+			source: `C Copyright 2022 Example Authors
+* Licensed under the MIT license.
+c
+      PROGRAM HELLO
+      CALL PRINTC('C is not a comment here') ! inline comment
+      END
+`,
+			want: []*Comment{
+				{
+					StartLine: 1,
+					EndLine:   1,
+					Text:      " Copyright 2022 Example Authors",
+				},
+				{
+					StartLine: 2,
+					EndLine:   2,
+					Text:      " Licensed under the MIT license.",
+				},
+				{
+					StartLine: 3,
+					EndLine:   3,
+					Text:      "",
+				},
+				{
+					StartLine: 5,
+					EndLine:   5,
+					Text:      " inline comment",
+				},
+			},
+		},
+		{
+			description: "COBOL Column Comments",
+			lang:        language.COBOL,
+			source: `000100* Copyright 2022 Example Authors
+000200/ Licensed under the MIT license.
+000300 IDENTIFICATION DIVISION.
+000400 PROGRAM-ID. HELLO. *> inline comment
+`,
+			want: []*Comment{
+				{
+					StartLine: 1,
+					EndLine:   1,
+					Text:      " Copyright 2022 Example Authors",
+				},
+				{
+					StartLine: 2,
+					EndLine:   2,
+					Text:      " Licensed under the MIT license.",
+				},
+				{
+					StartLine: 4,
+					EndLine:   4,
+					Text:      " inline comment",
+				},
+			},
+		},
+		{
+			description: "NASM Assembly Comments",
+			lang:        language.AssemblyNASM,
+			source: `; Copyright 2022 Example Authors
+; SPDX-License-Identifier: MIT
+section .text
+	mov eax, 1 ; exit
+`,
+			want: []*Comment{
+				{StartLine: 1, EndLine: 1, Text: " Copyright 2022 Example Authors"},
+				{StartLine: 2, EndLine: 2, Text: " SPDX-License-Identifier: MIT"},
+				{StartLine: 4, EndLine: 4, Text: " exit"},
+			},
+		},
+		{
+			description: "GAS Assembly Comments",
+			lang:        language.AssemblyGAS,
+			source: `# Copyright 2022 Example Authors
+/* SPDX-License-Identifier: MIT */
+	.globl main
+	movl $1, %eax # exit
+`,
+			want: []*Comment{
+				{StartLine: 1, EndLine: 1, Text: " Copyright 2022 Example Authors"},
+				{StartLine: 2, EndLine: 2, Text: " SPDX-License-Identifier: MIT "},
+				{StartLine: 4, EndLine: 4, Text: " exit"},
+			},
+		},
+		{
+			description: "ARM Assembly Comments",
+			lang:        language.AssemblyARM,
+			source: `@ Copyright 2022 Example Authors
+// SPDX-License-Identifier: MIT
+	.syntax unified
+	mov r0, #1 @ exit
+`,
+			want: []*Comment{
+				{StartLine: 1, EndLine: 1, Text: " Copyright 2022 Example Authors"},
+				{StartLine: 2, EndLine: 2, Text: " SPDX-License-Identifier: MIT"},
+				{StartLine: 4, EndLine: 4, Text: " exit"},
+			},
+		},
 	}
 
 	for _, tt := range tests {
@@ -564,3 +665,43 @@ func TestCommentParser_ChunkIterator(t *testing.T) {
 		}
 	}
 }
+
+func TestDetectAssemblyDialect(t *testing.T) {
+	tests := []struct {
+		description string
+		source      string
+		want        language.Language
+	}{
+		{
+			description: "NASM",
+			source:      "; Copyright 2022 Example Authors\n\nsection .text\nglobal _start\n",
+			want:        language.AssemblyNASM,
+		},
+		{
+			description: "GAS",
+			source:      "#include <asm.h>\n# Copyright 2022 Example Authors\n\t.globl main\n",
+			want:        language.AssemblyGAS,
+		},
+		{
+			description: "ARM",
+			source:      "@ Copyright 2022 Example Authors\n\t.syntax unified\n\t.thumb\n",
+			want:        language.AssemblyARM,
+		},
+		{
+			description: "C-style",
+			source:      "#include <asm.h>\n// Copyright 2022 Example Authors\n\t.globl main\n",
+			want:        language.Assembly,
+		},
+		{
+			description: "No comments",
+			source:      "\t.globl main\nmain:\n\tret\n",
+			want:        language.Assembly,
+		},
+	}
+
+	for _, tt := range tests {
+		if got := language.DetectAssemblyDialect([]byte(tt.source)); got != tt.want {
+			t.Errorf("DetectAssemblyDialect(%q) = %v, want %v", tt.description, got, tt.want)
+		}
+	}
+}
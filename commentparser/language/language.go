@@ -75,6 +75,13 @@ const (
 	XDC // Xilinx Design Constraint files
 	Yacc
 	Yaml
+	// Languages added later are appended so that existing values keep their
+	// numbers.
+	COBOL
+	FortranFixed
+	AssemblyARM
+	AssemblyGAS
+	AssemblyNASM
 )
 
 // style is the comment styles that a language uses.
@@ -84,16 +91,21 @@ type style int
 const (
 	unknown     style = iota
 	applescript       // -- ... and (* ... *)
+	arm               // @ ..., // ... and /* ... */
 	batch             // @REM
 	bcpl              // // ... and /* ... */
 	cmake             // # ... and #[[ ... ]]
+	cobol             // * or / in column 7, and *> ...
 	fortran           // ! ...
+	fortran77         // C, c, * or ! in column 1, and ! ...
+	gas               // # ... and /* ... */
 	hash              // # ...
 	haskell           // -- ... and {- ... -}
 	html              // <!-- ... -->
 	lisp              // ;; ...
 	matlab            // % ...
 	mysql             // # ... and /* ... */
+	nasm              // ; ...
 	ruby              // # ... and =begin ... =end
 	shell             // # ... and %{ ... %}
 	sql               // -- ... and /* ... */
@@ -110,6 +122,8 @@ func ClassifyLanguage(filename string) Language {
 	switch ext[1:] { // Skip the '.'.
 	case "applescript":
 		return AppleScript
+	case "asm":
+		return AssemblyNASM
 	case "bat":
 		return Batch
 	case "blif", "eblif":
@@ -120,14 +134,18 @@ func ClassifyLanguage(filename string) Language {
 		return Clif
 	case "cmake":
 		return CMake
+	case "cob", "cbl", "cpy":
+		return COBOL
 	case "cs":
 		return CSharp
 	case "dart":
 		return Dart
 	case "ex", "exs":
 		return Elixir
-	case "f", "f90", "f95":
+	case "f90", "f95":
 		return Fortran
+	case "f", "for", "f77":
+		return FortranFixed
 	case "glslf":
 		return GLSLF
 	case "go":
@@ -194,19 +212,93 @@ func ClassifyLanguage(filename string) Language {
 	return Unknown
 }
 
+// maxDialectLines is the number of lines examined when detecting the dialect
+// of an assembly file.
+const maxDialectLines = 200
+
+// DetectAssemblyDialect guesses the dialect of assembly source from its
+// contents, because the same file extensions are used by assemblers with
+// different comment characters. It returns Assembly if no dialect stands out.
+func DetectAssemblyDialect(contents []byte) Language {
+	votes := make(map[Language]int)
+	for n, line := range strings.SplitN(string(contents), "\n", maxDialectLines+1) {
+		if n == maxDialectLines {
+			break
+		}
+		line = strings.TrimSpace(line)
+		switch {
+		case strings.HasPrefix(line, ";"):
+			votes[AssemblyNASM]++
+		case strings.HasPrefix(line, "@"):
+			votes[AssemblyARM]++
+		case strings.HasPrefix(line, "//"):
+			votes[Assembly]++
+		case strings.HasPrefix(line, "#"):
+			// C preprocessor directives are common in assembly
+			// files and don't indicate a dialect.
+			if !isPreprocessorDirective(line) {
+				votes[AssemblyGAS]++
+			}
+		}
+
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+		switch strings.ToLower(fields[0]) {
+		case "section", "segment", "bits", "global", "extern", "default", "%include", "%define", "%macro":
+			votes[AssemblyNASM]++
+		case ".syntax", ".arm", ".thumb", ".thumb_func", ".fpu", ".cpu":
+			votes[AssemblyARM]++
+		}
+	}
+
+	dialect, best := Assembly, votes[Assembly]
+	for _, lang := range []Language{AssemblyARM, AssemblyGAS, AssemblyNASM} {
+		if votes[lang] > best {
+			dialect, best = lang, votes[lang]
+		}
+	}
+	return dialect
+}
+
+// isPreprocessorDirective returns true if the line is a C preprocessor
+// directive.
+func isPreprocessorDirective(line string) bool {
+	fields := strings.Fields(strings.TrimPrefix(line, "#"))
+	if len(fields) == 0 {
+		return false
+	}
+	switch fields[0] {
+	case "include", "define", "undef", "if", "ifdef", "ifndef", "elif", "else", "endif", "error", "pragma", "line":
+		return true
+	}
+	return false
+}
+
 // commentStyle returns the language's comment style.
 func (lang Language) commentStyle() style {
 	switch lang {
 	case Assembly, C, CSharp, Dart, Flex, GLSLF, Go, Java, JavaScript, Kotlin, ObjectiveC, Rust, Shader, Swift, SWIG, TypeScript, Yacc, Verilog, SystemVerilog, SDF, SPEF:
 		return bcpl
+	case AssemblyARM:
+		return arm
+	case AssemblyGAS:
+		return gas
+	case AssemblyNASM:
+		return nasm
 	case Batch:
 		return batch
 	case BLIF, TCL:
 		return hash
 	case CMake:
 		return cmake
+	case COBOL:
+		return cobol
 	case Fortran:
 		return fortran
+	case FortranFixed:
+		return fortran77
 	case Haskell:
 		return haskell
 	case HTML, Markdown:
@@ -234,17 +326,21 @@ func (lang Language) SingleLineCommentStart() string {
 	switch lang.commentStyle() {
 	case applescript, haskell, sql:
 		return "--"
+	case arm:
+		return "@"
 	case batch:
 		return "@REM"
 	case bcpl:
 		return "//"
-	case fortran:
+	case cobol:
+		return "*>"
+	case fortran, fortran77:
 		return "!"
-	case lisp:
+	case lisp, nasm:
 		return ";"
 	case matlab:
 		return "%"
-	case shell, ruby, cmake, mysql, hash:
+	case shell, ruby, cmake, mysql, hash, gas:
 		return "#"
 	}
 	return ""
@@ -256,7 +352,7 @@ func (lang Language) MultilineCommentStart() string {
 	switch lang.commentStyle() {
 	case applescript:
 		return "(*"
-	case bcpl, mysql:
+	case arm, bcpl, gas, mysql:
 		if lang != Rust {
 			return "/*"
 		}
@@ -280,7 +376,7 @@ func (lang Language) MultilineCommentEnd() string {
 	switch lang.commentStyle() {
 	case applescript:
 		return "*)"
-	case bcpl, mysql:
+	case arm, bcpl, gas, mysql:
 		if lang != Rust {
 			return "*/"
 		}
@@ -298,6 +394,20 @@ func (lang Language) MultilineCommentEnd() string {
 	return ""
 }
 
+// ColumnCommentMarkers returns the characters that make a whole line a comment
+// when they appear in the returned column (1-based) of the line, as in
+// fixed-form Fortran and COBOL. It returns 0 if the language doesn't have
+// column-based comments.
+func (lang Language) ColumnCommentMarkers() (column int, markers string) {
+	switch lang.commentStyle() {
+	case cobol:
+		return 7, "*/"
+	case fortran77:
+		return 1, "Cc*!"
+	}
+	return 0, ""
+}
+
 // QuoteCharacter returns 'true' if the character is considered the beginning
 // of a string in the given language. The second return value is true if the
 // string allows for escaping.
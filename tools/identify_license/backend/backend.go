@@ -147,7 +147,11 @@ func (b *ClassifierBackend) classifyLicense(filename string, headers bool) error
 
 	log.Printf("Classifying license(s): %s", filename)
 	start := time.Now()
-	if lang := language.ClassifyLanguage(filename); lang == language.Unknown {
+	lang := language.ClassifyLanguage(filename)
+	if lang == language.Assembly {
+		lang = language.DetectAssemblyDialect(contents)
+	}
+	if lang == language.Unknown {
 		matchLoop(string(contents))
 	} else {
 		log.Printf("detected language: %v", lang)
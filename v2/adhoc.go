@@ -0,0 +1,51 @@
+// Copyright 2022 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build !core
+// +build !core
+
+package classifier
+
+import "errors"
+
+const (
+	// KnownName is the name of the match returned by MatchAgainst.
+	KnownName = "Known"
+	// adHocThreshold is the confidence threshold of MatchAgainst, the same as
+	// that of the classifier built from the embedded assets.
+	adHocThreshold = 0.8
+)
+
+// MatchAgainst reports the best match of the known text in the target, for
+// checking content against a single text, such as a project's own license
+// header, without building a corpus. The match is found the same way as
+// matches against a corpus are, is named KnownName and has a MatchType of
+// "License". MatchAgainst returns nil if the known text isn't found in the
+// target with a confidence of at least 0.8, and an error if the known text has
+// nothing to match, such as when it's empty or only a copyright notice.
+func MatchAgainst(known, target []byte) (*Match, error) {
+	c := NewClassifier(adHocThreshold)
+	c.AddContent("License", KnownName, "known", known)
+	if c.getIndexedDocument("License", KnownName, "known").size() == 0 {
+		return nil, errors.New("the known text has no content to match")
+	}
+	for _, m := range c.Match(target).Matches {
+		// The matches are ordered by confidence, so the first match of the
+		// known text is the best one.
+		if m.Name == KnownName {
+			return m, nil
+		}
+	}
+	return nil, nil
+}
@@ -0,0 +1,95 @@
+// Copyright 2022 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build !core
+// +build !core
+
+package classifier
+
+import (
+	"strings"
+	"testing"
+)
+
+const proprietaryHeader = `This file is the confidential and proprietary property of Example
+Corporation. Possession, use, copying or distribution of this file is
+prohibited except under the terms of a written agreement with Example
+Corporation.`
+
+func TestMatchAgainst(t *testing.T) {
+	tests := []struct {
+		name      string
+		known     string
+		target    string
+		wantMatch bool
+		wantStart int
+		wantEnd   int
+		wantErr   bool
+	}{
+		{
+			name:      "exact header",
+			known:     proprietaryHeader,
+			target:    "// Copyright 2020 Example Corporation\n//\n// " + strings.ReplaceAll(proprietaryHeader, "\n", "\n// ") + "\n\npackage main\n",
+			wantMatch: true,
+			wantStart: 3,
+			wantEnd:   6,
+		},
+		{
+			name:      "reworded header",
+			known:     proprietaryHeader,
+			target:    strings.Replace(proprietaryHeader, "Possession, use", "Use", 1),
+			wantMatch: true,
+			wantStart: 1,
+			wantEnd:   4,
+		},
+		{
+			name:   "different header",
+			known:  proprietaryHeader,
+			target: "Licensed under the Apache License, Version 2.0 (the \"License\");\nyou may not use this file except in compliance with the License.\n",
+		},
+		{
+			name:    "empty known text",
+			known:   "",
+			target:  proprietaryHeader,
+			wantErr: true,
+		},
+		{
+			name:    "copyright only",
+			known:   "Copyright 2020 Example Corporation",
+			target:  proprietaryHeader,
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m, err := MatchAgainst([]byte(tt.known), []byte(tt.target))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("MatchAgainst() error = %v, want error %v", err, tt.wantErr)
+			}
+			if (m != nil) != tt.wantMatch {
+				t.Fatalf("MatchAgainst() = %+v, want match %v", m, tt.wantMatch)
+			}
+			if m == nil {
+				return
+			}
+			if m.Name != KnownName || m.StartLine != tt.wantStart || m.EndLine != tt.wantEnd {
+				t.Errorf("MatchAgainst() = %s at lines %d-%d, want %s at lines %d-%d", m.Name, m.StartLine, m.EndLine, KnownName, tt.wantStart, tt.wantEnd)
+			}
+			if m.Confidence < adHocThreshold || m.Confidence > 1 {
+				t.Errorf("MatchAgainst() confidence = %v, want between %v and 1", m.Confidence, adHocThreshold)
+			}
+		})
+	}
+}
@@ -0,0 +1,219 @@
+// Copyright 2022 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build !core
+// +build !core
+
+package classifier
+
+import (
+	"regexp"
+	"sort"
+)
+
+// choiceWording matches the wording of statements that offer a choice
+// between licenses, such as "you may choose the MIT license or the Apache
+// License 2.0", "dual-licensed under" and Rust's "Licensed under either of
+// ... at your option".
+const choiceWording = `at\s+your\s+(?:option|choice|discretion)|(?:you|licensees?)\s+may\s+(?:choose|select|elect)|choice\s+of|dual[\s-]+licen[cs]ed|dual[\s-]+licen[cs]ing`
+
+// choiceRE matches the wording of choices, and "either", which offers a choice
+// as "either ... or" between licenses, as in "licensed under either the MIT
+// license or the Apache License 2.0". A bare "either" offers none, as in
+// "files in either directory are licensed under the Apache License 2.0, except
+// third_party/, which uses the MIT license".
+var choiceRE = phraseRE(`(?i)\b(?:either|` + choiceWording + `)\b`)
+
+// choiceWordingRE matches the wording of choices alone.
+var choiceWordingRE = phraseRE(`(?i)\b(?:` + choiceWording + `)\b`)
+
+var (
+	eitherRE = regexp.MustCompile(`(?i)\beither\b`)
+	orRE     = regexp.MustCompile(`(?i)\bor\b`)
+)
+
+// alternativelyRE matches the wording of statements that offer licenses as
+// alternatives to one named in an earlier sentence, as in the Mozilla
+// tri-license block's "Alternatively, the contents of this file may be used
+// under the terms of either the GNU General Public License ... or the GNU
+// Lesser General Public License". The choice includes a license the statement
+// doesn't name, so such statements aren't reported as choices.
+var alternativelyRE = regexp.MustCompile(`(?i)\balternatively\b`)
+
+// sentenceEndRE matches the ends of sentences, which bound a statement
+// offering a choice of licenses. Periods within version numbers and URLs
+// aren't followed by a space, so don't end sentences.
+var sentenceEndRE = regexp.MustCompile(`[.;!?](?:\s|$)`)
+
+// choiceLines is the number of lines before and after the wording of a choice
+// of licenses that its statement may span. The licenses of a choice are often
+// listed on lines of their own, in paragraphs without a sentence end.
+const choiceLines = 8
+
+// licenseNameOnlyREs match the licenseNames alone, as they're written in a
+// statement offering a choice of licenses.
+var licenseNameOnlyREs = func() []*regexp.Regexp {
+	var out []*regexp.Regexp
+	for _, l := range licenseNames {
+		out = append(out, phraseRE(`(?i)\b(?:`+l.pattern+`)\b`))
+	}
+	return out
+}()
+
+// detectAlternatives finds statements in the input that offer a choice
+// between two or more licenses, and sets the Alternatives of the references
+// to those licenses among refs, which are the references already detected.
+// If names is set, as it is for classifiers reporting references by name, the
+// references it doesn't find among refs are added and returned.
+func detectAlternatives(in []byte, refs Matches, names bool) Matches {
+	var out Matches
+	done := 0
+	for _, loc := range choiceRE.FindAllIndex(in, -1) {
+		// A sentence can hold more than one choice wording, as in "either
+		// of ... at your option".
+		if loc[0] < done {
+			continue
+		}
+		start, end := sentence(in, loc)
+		done = end
+		if alternativelyRE.Match(in[start:loc[0]]) {
+			continue
+		}
+		stated, found := statedLicenses(in[start:end])
+		if len(stated) < 2 || !choiceWordingRE.Match(in[start:end]) && !eitherOr(in[start:end], found) {
+			continue
+		}
+		startLine, endLine := lineOf(in, start), lineOf(in, end-1)
+		var chosen Matches
+		for i, name := range stated {
+			var m *Match
+			for _, r := range append(refs, out...) {
+				if r.Name == name && r.MatchType == referenceMatchType && r.StartLine <= endLine && r.EndLine >= startLine {
+					m = r
+					break
+				}
+			}
+			if m == nil {
+				if !names {
+					continue
+				}
+				loc := found[i]
+				m = &Match{
+					Name:       name,
+					Variant:    phraseText(in[start:end]),
+					MatchType:  referenceMatchType,
+					Confidence: nameReferenceConfidence,
+					StartLine:  startLine,
+					EndLine:    endLine,
+				}
+				if versioned(name) {
+					m.OrLater = orLaterSuffixRE.Match(in[start+loc[1]:])
+				}
+				out = append(out, m)
+			}
+			chosen = append(chosen, m)
+		}
+		for _, m := range chosen {
+			m.Alternatives = stated
+		}
+	}
+	return out
+}
+
+// eitherOr reports whether the statement offers the licenses it names, found
+// at the locations, as "either ... or": "either" precedes the first of them,
+// and "or" separates two of them.
+func eitherOr(stmt []byte, found [][]int) bool {
+	e := eitherRE.FindIndex(stmt)
+	if e == nil || e[1] > found[0][0] {
+		return false
+	}
+	for i := 1; i < len(found); i++ {
+		if orRE.Match(stmt[found[i-1][1]:found[i][0]]) {
+			return true
+		}
+	}
+	return false
+}
+
+// sentence returns the bounds of the sentence of the input holding loc,
+// spanning at most choiceLines lines either side of it.
+func sentence(in []byte, loc []int) (int, int) {
+	start := 0
+	for i, n := loc[0]-1, 0; i >= 0; i-- {
+		if in[i] == '\n' {
+			if n++; n > choiceLines {
+				start = i + 1
+				break
+			}
+		}
+	}
+	if e := sentenceEndRE.FindAllIndex(in[start:loc[0]], -1); e != nil {
+		start += e[len(e)-1][1]
+	}
+	end := len(in)
+	for i, n := loc[1], 0; i < len(in); i++ {
+		if in[i] == '\n' {
+			if n++; n > choiceLines {
+				end = i
+				break
+			}
+		}
+	}
+	if e := sentenceEndRE.FindIndex(in[loc[1]:end]); e != nil {
+		end = loc[1] + e[0] + 1
+	}
+	return start, end
+}
+
+// statedLicenses returns the licenses named in the statement, each once, in
+// the order they're named, and the location of the first mention of each.
+// A name that's part of a longer one, such as the "General Public License
+// version 2" of "Lesser General Public License version 2.1", isn't counted.
+func statedLicenses(stmt []byte) ([]string, [][]int) {
+	type mention struct {
+		name string
+		loc  []int
+	}
+	var mentions []mention
+	var spans [][]int
+	for i, re := range licenseNameOnlyREs {
+	next:
+		for _, loc := range re.FindAllIndex(stmt, -1) {
+			for _, s := range spans {
+				if loc[0] < s[1] && s[0] < loc[1] {
+					continue next
+				}
+			}
+			spans = append(spans, loc)
+			mentions = append(mentions, mention{licenseNames[i].name, loc})
+		}
+	}
+	// Mentions are found license by license, so are put back in the order
+	// of the statement.
+	sort.Slice(mentions, func(i, j int) bool {
+		return mentions[i].loc[0] < mentions[j].loc[0]
+	})
+	var names []string
+	var locs [][]int
+	seen := make(map[string]bool)
+	for _, m := range mentions {
+		if !seen[m.name] {
+			seen[m.name] = true
+			names = append(names, m.name)
+			locs = append(locs, m.loc)
+		}
+	}
+	return names, locs
+}
@@ -0,0 +1,163 @@
+// Copyright 2022 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build !core
+// +build !core
+
+package classifier
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestDetectAlternatives(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		refs  Matches
+		want  Matches
+	}{
+		{
+			name:  "no choice",
+			input: "This project is licensed under the MIT License.",
+			want:  nil,
+		},
+		{
+			name:  "choice of one license",
+			input: "Licensed under the GNU GPL, either version 2 of the License, or (at your option) any later version.",
+			want:  nil,
+		},
+		{
+			name:  "either",
+			input: "You may choose either the MIT license or the Apache License 2.0.",
+			want: Matches{
+				{Name: "MIT", Variant: "You may choose either the MIT license or the Apache License 2.0.", MatchType: "Reference", Confidence: 0.75, StartLine: 1, EndLine: 1, Alternatives: []string{"MIT", "Apache-2.0"}},
+				{Name: "Apache-2.0", Variant: "You may choose either the MIT license or the Apache License 2.0.", MatchType: "Reference", Confidence: 0.75, StartLine: 1, EndLine: 1, Alternatives: []string{"MIT", "Apache-2.0"}},
+			},
+		},
+		{
+			name:  "either or",
+			input: "This file is licensed under either the MIT license or the Apache License 2.0.",
+			want: Matches{
+				{Name: "MIT", Variant: "This file is licensed under either the MIT license or the Apache License 2.0.", MatchType: "Reference", Confidence: 0.75, StartLine: 1, EndLine: 1, Alternatives: []string{"MIT", "Apache-2.0"}},
+				{Name: "Apache-2.0", Variant: "This file is licensed under either the MIT license or the Apache License 2.0.", MatchType: "Reference", Confidence: 0.75, StartLine: 1, EndLine: 1, Alternatives: []string{"MIT", "Apache-2.0"}},
+			},
+		},
+		{
+			name:  "either without a choice",
+			input: "Source files in either directory are licensed under the Apache License 2.0, except third_party/ which uses the MIT license.",
+			want:  nil,
+		},
+		{
+			name: "gnu licenses across lines",
+			input: `// This file is dual-licensed under the GNU Lesser General Public License,
+// version 2.1, or the GPL-3.0 or later.
+
+// Nothing else is licensed here.`,
+			want: Matches{
+				{Name: "LGPL-2.1", Variant: "This file is dual-licensed under the GNU Lesser General Public License, version 2.1, or the GPL-3.0 or later.", MatchType: "Reference", Confidence: 0.75, StartLine: 1, EndLine: 2, Alternatives: []string{"LGPL-2.1", "GPL-3.0"}},
+				{Name: "GPL-3.0", Variant: "This file is dual-licensed under the GNU Lesser General Public License, version 2.1, or the GPL-3.0 or later.", MatchType: "Reference", Confidence: 0.75, StartLine: 1, EndLine: 2, OrLater: true, Alternatives: []string{"LGPL-2.1", "GPL-3.0"}},
+			},
+		},
+		{
+			name: "alternative to an earlier license",
+			input: ` * The contents of this file are subject to the Mozilla Public License.
+ * Alternatively, the contents of this file may be used under the terms of
+ * either the GNU General Public License Version 2 or later (the "GPL"), or
+ * the GNU Lesser General Public License Version 2.1 or later (the "LGPL").`,
+			want: nil,
+		},
+		{
+			name: "references by url",
+			input: `Licensed under either of
+ * Apache License, Version 2.0 (http://www.apache.org/licenses/LICENSE-2.0)
+ * MIT license (http://opensource.org/licenses/MIT)
+at your option.`,
+			refs: Matches{
+				{Name: "Apache-2.0", MatchType: "Reference", StartLine: 2, EndLine: 2},
+				{Name: "MIT", MatchType: "Reference", StartLine: 3, EndLine: 3},
+			},
+			want: nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := detectAlternatives([]byte(tt.input), tt.refs, true)
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("detectAlternatives() mismatch (-want +got):\n%s", diff)
+			}
+			for _, r := range tt.refs {
+				if len(r.Alternatives) != len(tt.refs) {
+					t.Errorf("detectAlternatives() set Alternatives of %s to %v, want all of the references", r.Name, r.Alternatives)
+				}
+			}
+		})
+	}
+}
+
+// TestDetectAlternativesWithoutNames verifies that the references of a choice
+// are only added for classifiers reporting references by name, while those
+// already detected still get the Alternatives.
+func TestDetectAlternativesWithoutNames(t *testing.T) {
+	in := []byte("You may choose either the MIT license (http://opensource.org/licenses/MIT) or the Apache License 2.0.")
+	if got := detectAlternatives(in, nil, false); got != nil {
+		t.Errorf("detectAlternatives() = %v, want no references added", got)
+	}
+	refs := Matches{{Name: "MIT", MatchType: "Reference", StartLine: 1, EndLine: 1}}
+	if got := detectAlternatives(in, refs, false); got != nil {
+		t.Errorf("detectAlternatives() = %v, want no references added", got)
+	}
+	if diff := cmp.Diff([]string{"MIT", "Apache-2.0"}, refs[0].Alternatives); diff != "" {
+		t.Errorf("detectAlternatives() Alternatives mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestMatchAlternatives(t *testing.T) {
+	c, err := classifier()
+	if err != nil {
+		t.Fatalf("couldn't instantiate standard test classifier: %v", err)
+	}
+	in := `# Example
+
+## License
+
+Licensed under either of
+
+ * Apache License, Version 2.0 (LICENSE-APACHE or http://www.apache.org/licenses/LICENSE-2.0)
+ * MIT license (LICENSE-MIT or http://opensource.org/licenses/MIT)
+
+at your option.
+`
+	if got, want := c.Match([]byte(in)).Matches.SPDXExpression(), "Apache-2.0 OR MIT"; got != want {
+		t.Errorf("SPDXExpression() = %q, want %q", got, want)
+	}
+}
+
+func TestMatchAlternativesByName(t *testing.T) {
+	c, err := classifier()
+	if err != nil {
+		t.Fatalf("couldn't instantiate standard test classifier: %v", err)
+	}
+	in := []byte("You may choose either the MIT license or the Apache License 2.0.")
+	if got := c.Match(in).Matches; len(got) != 0 {
+		t.Errorf("Match() = %v, want no matches without WithNameReferences", got)
+	}
+	WithNameReferences()(c)
+	if got, want := c.Match(in).Matches.SPDXExpression(), "MIT OR Apache-2.0"; got != want {
+		t.Errorf("SPDXExpression() with WithNameReferences = %q, want %q", got, want)
+	}
+}
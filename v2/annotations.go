@@ -0,0 +1,106 @@
+// Copyright 2022 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package classifier
+
+// patentGrantMatchType is the category of standalone patent grant and
+// retaliation clauses, such as section 3 of the Apache License 2.0.
+const patentGrantMatchType = "PatentGrant"
+
+// exportControlMatchType is the category of export control statements, such
+// as notices that a distribution includes cryptographic software.
+const exportControlMatchType = "ExportControl"
+
+// trademarkMatchType is the category of trademark restriction clauses, such
+// as section 6 of the Apache License 2.0, which oblige users of the text to
+// avoid the names and marks of its licensor.
+const trademarkMatchType = "Trademark"
+
+// annotationMatchTypes are the categories that describe a property of the
+// text, or qualify its license, rather than identify its license. Their
+// matches are reported wherever they occur, including within a license match,
+// and never displace other matches.
+var annotationMatchTypes = map[string]bool{
+	patentGrantMatchType:   true,
+	exportControlMatchType: true,
+	trademarkMatchType:     true,
+	exceptionMatchType:     true,
+}
+
+// WithPatentGrants reports standalone patent grant and retaliation clauses,
+// such as section 3 of the Apache License 2.0, section 11 of the GPL 3.0 or
+// the PATENTS file of Facebook's BSD-licensed projects, as PatentGrant
+// annotations, so that their presence can be reviewed independently of the
+// license they belong to. The clauses are reported wherever they occur,
+// including within a match of their license.
+func WithPatentGrants() OptionFunc {
+	return func(c *Classifier) {
+		c.patentGrants = true
+	}
+}
+
+// WithTrademarks reports trademark restriction clauses, such as section 6 of
+// the Apache License 2.0 or the trademark notice of the Creative Commons
+// licenses, as Trademark annotations. Like patent grants, they're reported
+// wherever they occur, including within a match of their license.
+func WithTrademarks() OptionFunc {
+	return func(c *Classifier) {
+		c.trademarks = true
+	}
+}
+
+// reported reports whether matches of the named document are reported, which
+// for the annotations enabled by an option depends on the option.
+func (c *Classifier) reported(name string) bool {
+	switch detectionType(name) {
+	case patentGrantMatchType:
+		return c.patentGrants
+	case trademarkMatchType:
+		return c.trademarks
+	}
+	return true
+}
+
+// splitAnnotations separates the annotation matches from the candidates so
+// they can bypass the overlap filtering between licenses.
+func splitAnnotations(candidates Matches) (rest, annotations Matches) {
+	for _, m := range candidates {
+		if annotationMatchTypes[m.MatchType] {
+			annotations = append(annotations, m)
+		} else {
+			rest = append(rest, m)
+		}
+	}
+	return rest, annotations
+}
+
+// dedupeAnnotations keeps the most confident annotation of each name for
+// every range of lines, since variants of the same clause match the same
+// text. The input must be sorted by confidence.
+func dedupeAnnotations(annotations Matches) Matches {
+	var out Matches
+	for _, a := range annotations {
+		dup := false
+		for _, o := range out {
+			if o.Name == a.Name && overlaps(a, o) {
+				dup = true
+				break
+			}
+		}
+		if !dup {
+			out = append(out, a)
+		}
+	}
+	return out
+}
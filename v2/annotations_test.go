@@ -0,0 +1,117 @@
+// Copyright 2022 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package classifier
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestSplitAnnotations(t *testing.T) {
+	license := &Match{Name: "Apache-2.0", MatchType: "License", StartLine: 1, EndLine: 200}
+	patent := &Match{Name: "Apache-2.0-Patent", MatchType: "PatentGrant", StartLine: 72, EndLine: 86}
+	crypto := &Match{Name: "Apache-Crypto-Notice", MatchType: "ExportControl", StartLine: 210, EndLine: 216}
+	trademark := &Match{Name: "Apache-2.0-Trademark", MatchType: "Trademark", StartLine: 103, EndLine: 106}
+
+	rest, annotations := splitAnnotations(Matches{license, patent, crypto, trademark})
+	if diff := cmp.Diff(Matches{license}, rest); diff != "" {
+		t.Errorf("splitAnnotations() rest mismatch (-want +got):\n%s", diff)
+	}
+	if diff := cmp.Diff(Matches{patent, crypto, trademark}, annotations); diff != "" {
+		t.Errorf("splitAnnotations() annotations mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestDedupeAnnotations(t *testing.T) {
+	best := &Match{Name: "Apache-2.0-Patent", Variant: "a.txt", MatchType: "PatentGrant", Confidence: 1.0, StartLine: 72, EndLine: 86}
+	variant := &Match{Name: "Apache-2.0-Patent", Variant: "b.txt", MatchType: "PatentGrant", Confidence: 0.9, StartLine: 73, EndLine: 86}
+	elsewhere := &Match{Name: "Apache-2.0-Patent", Variant: "a.txt", MatchType: "PatentGrant", Confidence: 0.9, StartLine: 300, EndLine: 314}
+
+	got := dedupeAnnotations(Matches{best, variant, elsewhere})
+	if diff := cmp.Diff(Matches{best, elsewhere}, got); diff != "" {
+		t.Errorf("dedupeAnnotations() mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestPatentGrantsOption(t *testing.T) {
+	// The clauses are part of their licenses, so they're only reported on
+	// request.
+	for _, license := range []struct {
+		file  string
+		grant string
+	}{
+		{filepath.Join("Apache-2.0", "a.txt"), "Apache-2.0-Patent"},
+		{filepath.Join("GPL-3.0", "license.txt"), "GPL-3.0-Patent"},
+	} {
+		text, err := ioutil.ReadFile(filepath.Join(baseLicenses, "License", license.file))
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, tt := range []struct {
+			options []OptionFunc
+			want    bool
+		}{
+			{nil, false},
+			{[]OptionFunc{WithPatentGrants()}, true},
+		} {
+			c := NewClassifier(defaultThreshold, tt.options...)
+			if err := c.LoadLicenses(baseLicenses); err != nil {
+				t.Fatalf("couldn't instantiate test classifier: %v", err)
+			}
+			got := false
+			for _, m := range c.Match(text).Matches {
+				if m.MatchType == patentGrantMatchType && m.Name == license.grant {
+					got = true
+				}
+			}
+			if got != tt.want {
+				t.Errorf("Match(%s) with %d options reported %s = %v, want %v", license.file, len(tt.options), license.grant, got, tt.want)
+			}
+		}
+	}
+}
+
+func TestTrademarksOption(t *testing.T) {
+	apache, err := ioutil.ReadFile(filepath.Join(baseLicenses, "License", "Apache-2.0", "a.txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tt := range []struct {
+		options []OptionFunc
+		want    bool
+	}{
+		{nil, false},
+		{[]OptionFunc{WithTrademarks()}, true},
+		// Each annotation is enabled by its own option.
+		{[]OptionFunc{WithPatentGrants()}, false},
+	} {
+		c := NewClassifier(defaultThreshold, tt.options...)
+		if err := c.LoadLicenses(baseLicenses); err != nil {
+			t.Fatalf("couldn't instantiate test classifier: %v", err)
+		}
+		got := false
+		for _, m := range c.Match(apache).Matches {
+			if m.MatchType == trademarkMatchType {
+				got = true
+			}
+		}
+		if got != tt.want {
+			t.Errorf("Match() with %d options reported a trademark clause = %v, want %v", len(tt.options), got, tt.want)
+		}
+	}
+}
@@ -14,26 +14,145 @@
 package assets
 
 import (
+	"crypto/sha256"
 	"embed"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
 	"io/fs"
 	"strings"
 
 	classifier "github.com/google/licenseclassifier/v2"
 )
 
-//go:embed */*/*
+//go:embed */*/* confusability.json
 var licenseFS embed.FS
 
+// metadataFile is the name of the file holding the metadata of a license.
+const metadataFile = "metadata.json"
+
+// confusabilityFile lists the licenses that are easily confused with each
+// other. It's generated from the corpus by the confusability tool.
+const confusabilityFile = "confusability.json"
+
+// Option selects the assets a classifier is loaded with.
+type Option func(*loadOptions)
+
+type loadOptions struct {
+	categories map[string]bool // The categories to load, or nil for all
+	lazy       map[string]bool // The categories to load when first needed
+	options    []classifier.OptionFunc
+}
+
+// WithCategories loads only the assets of the named categories, the
+// directories of the assets: for example "Header" for scanning source files
+// for license headers, or "License" and its related categories for
+// identifying license files.
+func WithCategories(categories ...string) Option {
+	return func(o *loadOptions) {
+		if o.categories == nil {
+			o.categories = make(map[string]bool)
+		}
+		for _, c := range categories {
+			o.categories[c] = true
+		}
+	}
+}
+
+// WithLazyCategories defers loading the assets of the named categories until
+// the classifier is first used, as with classifier.WithLazyContent.
+func WithLazyCategories(categories ...string) Option {
+	return func(o *loadOptions) {
+		for _, c := range categories {
+			o.lazy[c] = true
+		}
+	}
+}
+
+// WithClassifierOptions applies the options to the classifier.
+func WithClassifierOptions(options ...classifier.OptionFunc) Option {
+	return func(o *loadOptions) {
+		o.options = append(o.options, options...)
+	}
+}
+
 // DefaultClassifier returns a classifier loaded with the contents of the
 // assets directory.
 func DefaultClassifier() (*classifier.Classifier, error) {
-	c := classifier.NewClassifier(.8)
+	return NewClassifier(.8)
+}
 
-	err := fs.WalkDir(licenseFS, ".", func(path string, d fs.DirEntry, err error) error {
+// NewClassifier returns a classifier with the supplied threshold loaded with
+// the assets selected by the options, by default all of them.
+func NewClassifier(threshold float64, options ...Option) (*classifier.Classifier, error) {
+	o := &loadOptions{lazy: make(map[string]bool)}
+	for _, opt := range options {
+		opt(o)
+	}
+
+	categories, err := fs.ReadDir(licenseFS, ".")
+	if err != nil {
+		return nil, err
+	}
+	var eager []string
+	for _, d := range categories {
+		category := d.Name()
+		if !d.IsDir() || (o.categories != nil && !o.categories[category]) {
+			continue
+		}
+		if o.lazy[category] {
+			o.options = append(o.options, classifier.WithLazyContent(func(c *classifier.Classifier) error {
+				return addCategory(c, category)
+			}))
+			continue
+		}
+		eager = append(eager, category)
+	}
+	c := classifier.NewClassifier(threshold, o.options...)
+
+	// The metadata of a license decides how its content is tokenized, so it
+	// must be set before the content is added.
+	metadata, err := fs.Glob(licenseFS, "*/*/"+metadataFile)
+	if err != nil {
+		return nil, err
+	}
+	for _, path := range metadata {
+		b, err := licenseFS.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		var m classifier.LicenseMetadata
+		if err := json.Unmarshal(b, &m); err != nil {
+			return nil, fmt.Errorf("invalid license metadata %s: %w", path, err)
+		}
+		c.SetMetadata(strings.Split(path, "/")[1], m)
+	}
+
+	confusability, err := licenseFS.ReadFile(confusabilityFile)
+	if err != nil {
+		return nil, err
+	}
+	var conf classifier.Confusability
+	if err := json.Unmarshal(confusability, &conf); err != nil {
+		return nil, fmt.Errorf("invalid license confusability: %w", err)
+	}
+	c.SetConfusability(conf)
+
+	for _, category := range eager {
+		if err := addCategory(c, category); err != nil {
+			return nil, err
+		}
+	}
+	return c, nil
+}
+
+// addCategory adds the assets of a category to the classifier.
+func addCategory(c *classifier.Classifier, category string) error {
+	return fs.WalkDir(licenseFS, category, func(path string, d fs.DirEntry, err error) error {
 		if err != nil {
 			return err
 		}
-		if d.IsDir() {
+		if d.IsDir() || d.Name() == metadataFile {
 			return nil
 		}
 
@@ -46,12 +165,6 @@ func DefaultClassifier() (*classifier.Classifier, error) {
 		c.AddContent(category, name, variant, b)
 		return nil
 	})
-
-	if err != nil {
-		return nil, err
-	}
-	return c, nil
-
 }
 
 // ReadLicenseFile locates and reads the license archive file.  Absolute paths are used unmodified.  Relative paths are expected to be in the licenses directory of the licenseclassifier package.
@@ -63,3 +176,27 @@ func ReadLicenseFile(filename string) ([]byte, error) {
 func ReadLicenseDir() ([]fs.DirEntry, error) {
 	return licenseFS.ReadDir(".")
 }
+
+// Fingerprint returns a digest of the embedded corpus, which changes whenever
+// a license is added, removed or edited.
+func Fingerprint() (string, error) {
+	h := sha256.New()
+	err := fs.WalkDir(licenseFS, ".", func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		b, err := licenseFS.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		// The path and length delimit the file, so that moving text
+		// between files changes the digest.
+		fmt.Fprintf(h, "%s\x00%d\x00", path, len(b))
+		h.Write(b)
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil))[:16], nil
+}
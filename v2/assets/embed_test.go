@@ -0,0 +1,95 @@
+// Copyright 2022 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build !core
+// +build !core
+
+package assets
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	classifier "github.com/google/licenseclassifier/v2"
+)
+
+// categories returns the categories of the documents of the classifier.
+func categories(c *classifier.Classifier) map[string]bool {
+	out := make(map[string]bool)
+	for _, l := range c.Stats().Licenses {
+		for category := range l.Variants {
+			out[category] = true
+		}
+	}
+	return out
+}
+
+func TestNewClassifier(t *testing.T) {
+	mit, err := ReadLicenseFile("License/MIT/pristine.txt")
+	if err != nil {
+		t.Fatalf("ReadLicenseFile() = %v", err)
+	}
+	loads := 0
+	counter := classifier.WithLazyContent(func(*classifier.Classifier) error {
+		loads++
+		return nil
+	})
+
+	tests := []struct {
+		name    string
+		options []Option
+		want    map[string]bool
+		wantMIT bool
+	}{
+		{
+			name:    "categories",
+			options: []Option{WithCategories("Header", "Exception")},
+			want:    map[string]bool{"Header": true, "Exception": true},
+		},
+		{
+			name:    "lazy categories",
+			options: []Option{WithCategories("License", "Header"), WithLazyCategories("License")},
+			want:    map[string]bool{"License": true, "Header": true},
+			wantMIT: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			loads = 0
+			c, err := NewClassifier(.8, append(tt.options, WithClassifierOptions(counter))...)
+			if err != nil {
+				t.Fatalf("NewClassifier() = %v", err)
+			}
+			// Lazily loaded content waits for the classifier to be used.
+			if loads != 0 {
+				t.Errorf("NewClassifier() loaded lazy content %d times before use", loads)
+			}
+			found := false
+			for _, m := range c.Match(mit).Matches {
+				if m.Name == "MIT" && m.MatchType == "License" {
+					found = true
+				}
+			}
+			if found != tt.wantMIT {
+				t.Errorf("Match() found the MIT license = %v, want %v", found, tt.wantMIT)
+			}
+			if loads != 1 {
+				t.Errorf("NewClassifier() loaded lazy content %d times after use, want 1", loads)
+			}
+			if diff := cmp.Diff(tt.want, categories(c)); diff != "" {
+				t.Errorf("NewClassifier() categories mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
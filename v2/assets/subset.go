@@ -0,0 +1,144 @@
+// Copyright 2022 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package assets
+
+import (
+	"io"
+	"io/fs"
+	"strings"
+)
+
+// Subset returns the embedded corpus restricted to the named licenses, in
+// every category they appear in, laid out like the assets directory so that
+// it can be loaded with classifier.LoadLicensesFS. The confusability of the
+// licenses is kept whole, so matches still name the licenses left out that
+// they're easily confused with. Tools that only check content against an
+// allowlist of licenses can write the subset out with the prune_corpus tool
+// and embed it in place of the full corpus to shrink their binaries.
+func Subset(names ...string) fs.FS {
+	s := subsetFS{names: make(map[string]bool)}
+	for _, n := range names {
+		s.names[n] = true
+	}
+	return s
+}
+
+// subsetFS is the embedded corpus restricted to the licenses named.
+type subsetFS struct {
+	names map[string]bool
+}
+
+// Open implements fs.FS.
+func (s subsetFS) Open(name string) (fs.File, error) {
+	if !fs.ValidPath(name) {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrInvalid}
+	}
+	if !s.exists(name) {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrNotExist}
+	}
+	f, err := licenseFS.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	if d, ok := f.(fs.ReadDirFile); ok && s.filtered(name) {
+		entries, err := s.ReadDir(name)
+		if err != nil {
+			f.Close()
+			return nil, err
+		}
+		return &subsetDir{ReadDirFile: d, entries: entries}, nil
+	}
+	return f, nil
+}
+
+// ReadDir implements fs.ReadDirFS.
+func (s subsetFS) ReadDir(name string) ([]fs.DirEntry, error) {
+	if !fs.ValidPath(name) || !s.exists(name) {
+		return nil, &fs.PathError{Op: "readdir", Path: name, Err: fs.ErrNotExist}
+	}
+	entries, err := licenseFS.ReadDir(name)
+	if err != nil || !s.filtered(name) {
+		return entries, err
+	}
+	var out []fs.DirEntry
+	for _, e := range entries {
+		p := e.Name()
+		if name != "." {
+			p = name + "/" + p
+		}
+		if s.exists(p) {
+			out = append(out, e)
+		}
+	}
+	return out, nil
+}
+
+// filtered reports whether the entries of the named directory are filtered:
+// the root, whose categories may hold none of the licenses, and the
+// categories, whose licenses may not be among those named.
+func (s subsetFS) filtered(name string) bool {
+	return name == "." || !strings.Contains(name, "/")
+}
+
+// exists reports whether the named file is in the subset: files and
+// directories of the licenses named, and the categories holding any of them.
+func (s subsetFS) exists(name string) bool {
+	if name == "." {
+		return true
+	}
+	parts := strings.SplitN(name, "/", 3)
+	if len(parts) > 1 {
+		return s.names[parts[1]]
+	}
+	info, err := fs.Stat(licenseFS, name)
+	if err != nil || !info.IsDir() {
+		// Files at the root, such as confusability.json, are kept.
+		return err == nil
+	}
+	entries, err := licenseFS.ReadDir(name)
+	if err != nil {
+		return false
+	}
+	for _, e := range entries {
+		if s.names[e.Name()] {
+			return true
+		}
+	}
+	return false
+}
+
+// subsetDir is a directory of the subset, listing only the entries in it.
+type subsetDir struct {
+	fs.ReadDirFile
+	entries []fs.DirEntry
+}
+
+// ReadDir implements fs.ReadDirFile.
+func (d *subsetDir) ReadDir(n int) ([]fs.DirEntry, error) {
+	if n <= 0 {
+		entries := d.entries
+		d.entries = nil
+		return entries, nil
+	}
+	if len(d.entries) == 0 {
+		return nil, io.EOF
+	}
+	if n > len(d.entries) {
+		n = len(d.entries)
+	}
+	entries := d.entries[:n]
+	d.entries = d.entries[n:]
+	return entries, nil
+}
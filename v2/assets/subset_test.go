@@ -0,0 +1,73 @@
+// Copyright 2022 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package assets
+
+import (
+	"io/fs"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	classifier "github.com/google/licenseclassifier/v2"
+)
+
+func TestSubset(t *testing.T) {
+	sub := Subset("MIT", "Apache-2.0")
+
+	var licenses []string
+	err := fs.WalkDir(sub, ".", func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if parts := strings.Split(path, "/"); len(parts) == 3 {
+			licenses = append(licenses, parts[1])
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("WalkDir() = %v", err)
+	}
+	if len(licenses) == 0 {
+		t.Fatal("Subset() holds no license texts")
+	}
+	for _, l := range licenses {
+		if l != "MIT" && l != "Apache-2.0" {
+			t.Errorf("Subset() holds %s, want only MIT and Apache-2.0", l)
+		}
+	}
+
+	if _, err := fs.Stat(sub, "License/GPL-2.0"); err == nil {
+		t.Error("Stat(License/GPL-2.0) succeeded, want it left out")
+	}
+	if _, err := fs.Stat(sub, "Trademark"); err == nil {
+		t.Error("Stat(Trademark) succeeded, want categories without the licenses left out")
+	}
+	if err := fstest.TestFS(sub, "License/MIT", "confusability.json"); err != nil {
+		t.Errorf("TestFS() = %v", err)
+	}
+
+	c := classifier.NewClassifier(.8)
+	if err := c.LoadLicensesFS(sub); err != nil {
+		t.Fatalf("LoadLicensesFS() = %v", err)
+	}
+	mit, err := ReadLicenseFile("License/MIT/pristine.txt")
+	if err != nil {
+		t.Fatalf("ReadLicenseFile() = %v", err)
+	}
+	res := c.Match(mit)
+	if len(res.Matches) == 0 || res.Matches[0].Name != "MIT" {
+		t.Errorf("Match() = %v, want MIT", res.Matches)
+	}
+}
@@ -0,0 +1,188 @@
+// Copyright 2022 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package assets
+
+// The types of licenses, as those of the v1 LicenseType, which determine the
+// obligations of code under them.
+const (
+	restricted   = "restricted"
+	reciprocal   = "reciprocal"
+	notice       = "notice"
+	permissive   = "permissive"
+	unencumbered = "unencumbered"
+	forbidden    = "FORBIDDEN"
+)
+
+// licenseTypes maps the corpus names of licenses to their types. The
+// licenses the v1 classifier categorizes as by exception only are left out,
+// as v1 LicenseType reports them as unknown.
+var licenseTypes = map[string]string{
+	// Licenses that require the source of a product to be distributed along with
+	// it if it includes code under them.
+	"BCL":                              restricted,
+	"CC-BY-ND-1.0":                     restricted,
+	"CC-BY-ND-2.0":                     restricted,
+	"CC-BY-ND-2.5":                     restricted,
+	"CC-BY-ND-3.0":                     restricted,
+	"CC-BY-ND-4.0":                     restricted,
+	"CC-BY-SA-1.0":                     restricted,
+	"CC-BY-SA-2.0":                     restricted,
+	"CC-BY-SA-2.5":                     restricted,
+	"CC-BY-SA-3.0":                     restricted,
+	"CC-BY-SA-4.0":                     restricted,
+	"GPL-1.0":                          restricted,
+	"GPL-2.0":                          restricted,
+	"GPL-2.0-with-autoconf-exception":  restricted,
+	"GPL-2.0-with-bison-exception":     restricted,
+	"GPL-2.0-with-classpath-exception": restricted,
+	"GPL-2.0-with-font-exception":      restricted,
+	"GPL-2.0-with-GCC-exception":       restricted,
+	"GPL-3.0":                          restricted,
+	"GPL-3.0-with-autoconf-exception":  restricted,
+	"GPL-3.0-with-bison-exception":     restricted,
+	"LGPL-2.0":                         restricted,
+	"LGPL-2.1":                         restricted,
+	"LGPL-3.0":                         restricted,
+	"NPL-1.0":                          restricted,
+	"NPL-1.1":                          restricted,
+	"OSL-1.0":                          restricted,
+	"OSL-1.1":                          restricted,
+	"OSL-2.0":                          restricted,
+	"OSL-2.1":                          restricted,
+	"OSL-3.0":                          restricted,
+	"QPL-1.0":                          restricted,
+	"Sleepycat":                        restricted,
+
+	// Licenses that allow code under them to be used freely in unmodified form,
+	// but require modifications to it to be made available.
+	"APSL-1.0":  reciprocal,
+	"APSL-1.1":  reciprocal,
+	"APSL-1.2":  reciprocal,
+	"APSL-2.0":  reciprocal,
+	"CDDL-1.0":  reciprocal,
+	"CDDL-1.1":  reciprocal,
+	"CPL-1.0":   reciprocal,
+	"EPL-1.0":   reciprocal,
+	"EPL-2.0":   reciprocal,
+	"FreeImage": reciprocal,
+	"IPL-1.0":   reciprocal,
+	"MPL-1.0":   reciprocal,
+	"MPL-1.1":   reciprocal,
+	"MPL-2.0":   reciprocal,
+	"Ruby":      reciprocal,
+
+	// Licenses with few restrictions, other than keeping the copyright notice or
+	// advertising clause of the code in distributions of it.
+	"AFL-1.1":                  notice,
+	"AFL-1.2":                  notice,
+	"AFL-2.0":                  notice,
+	"AFL-2.1":                  notice,
+	"AFL-3.0":                  notice,
+	"Apache-1.0":               notice,
+	"Apache-1.1":               notice,
+	"Apache-2.0":               notice,
+	"Artistic-1.0":             notice,
+	"Artistic-1.0-cl8":         notice,
+	"Artistic-1.0-Perl":        notice,
+	"Artistic-2.0":             notice,
+	"BSD-2-Clause":             notice,
+	"BSD-2-Clause-FreeBSD":     notice,
+	"BSD-2-Clause-NetBSD":      notice,
+	"BSD-3-Clause":             notice,
+	"BSD-3-Clause-Attribution": notice,
+	"BSD-3-Clause-Clear":       notice,
+	"BSD-3-Clause-LBNL":        notice,
+	"BSD-4-Clause":             notice,
+	"BSD-4-Clause-UC":          notice,
+	"BSD-Protection":           notice,
+	"BSL-1.0":                  notice,
+	"CC-BY-1.0":                notice,
+	"CC-BY-2.0":                notice,
+	"CC-BY-2.5":                notice,
+	"CC-BY-3.0":                notice,
+	"CC-BY-4.0":                notice,
+	"FTL":                      notice,
+	"ImageMagick":              notice,
+	"ISC":                      notice,
+	"Libpng":                   notice,
+	"Lil-1.0":                  notice,
+	"Linux-OpenIB":             notice,
+	"LPL-1.0":                  notice,
+	"LPL-1.02":                 notice,
+	"MIT":                      notice,
+	"MS-PL":                    notice,
+	"NCSA":                     notice,
+	"OpenSSL":                  notice,
+	"PHP-3.0":                  notice,
+	"PHP-3.01":                 notice,
+	"PIL":                      notice,
+	"PostgreSQL":               notice,
+	"Python-2.0":               notice,
+	"Python-2.0-complete":      notice,
+	"SGI-B-1.0":                notice,
+	"SGI-B-1.1":                notice,
+	"SGI-B-2.0":                notice,
+	"Unicode-DFS-2015":         notice,
+	"Unicode-DFS-2016":         notice,
+	"Unicode-TOU":              notice,
+	"UPL-1.0":                  notice,
+	"W3C":                      notice,
+	"W3C-19980720":             notice,
+	"W3C-20150513":             notice,
+	"X11":                      notice,
+	"Xnet":                     notice,
+	"Zend-2.0":                 notice,
+	"Zlib":                     notice,
+	"zlib-acknowledgement":     notice,
+	"ZPL-1.1":                  notice,
+	"ZPL-2.0":                  notice,
+	"ZPL-2.1":                  notice,
+
+	// Licenses that declare code free for any use.
+	"0BSD":         unencumbered,
+	"BSD-0-Clause": unencumbered,
+	"CC0-1.0":      unencumbered,
+	"Unlicense":    unencumbered,
+
+	// Licenses that are forbidden to be used.
+	"AGPL-1.0":          forbidden,
+	"AGPL-3.0":          forbidden,
+	"CC-BY-NC-1.0":      forbidden,
+	"CC-BY-NC-2.0":      forbidden,
+	"CC-BY-NC-2.5":      forbidden,
+	"CC-BY-NC-3.0":      forbidden,
+	"CC-BY-NC-4.0":      forbidden,
+	"CC-BY-NC-ND-1.0":   forbidden,
+	"CC-BY-NC-ND-2.0":   forbidden,
+	"CC-BY-NC-ND-2.5":   forbidden,
+	"CC-BY-NC-ND-3.0":   forbidden,
+	"CC-BY-NC-ND-4.0":   forbidden,
+	"CC-BY-NC-SA-1.0":   forbidden,
+	"CC-BY-NC-SA-2.0":   forbidden,
+	"CC-BY-NC-SA-2.5":   forbidden,
+	"CC-BY-NC-SA-3.0":   forbidden,
+	"CC-BY-NC-SA-4.0":   forbidden,
+	"Commons-Clause":    forbidden,
+	"Facebook-2-Clause": forbidden,
+	"Facebook-3-Clause": forbidden,
+	"Facebook-Examples": forbidden,
+	"WTFPL":             forbidden,
+}
+
+// LicenseType returns the type of the license with the given corpus name,
+// such as "restricted" or "notice", or "" if it hasn't been categorized.
+func LicenseType(name string) string {
+	return licenseTypes[name]
+}
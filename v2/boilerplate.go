@@ -0,0 +1,77 @@
+// Copyright 2022 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package classifier
+
+// boilerplateMatchType is the category of well-known texts that aren't
+// licenses, such as codes of conduct and contribution guidelines, but share
+// enough language with licenses to produce spurious partial license matches.
+const boilerplateMatchType = "Boilerplate"
+
+// agreementMatchType is the category of contributor agreements, such as
+// Contributor License Agreements and the Developer Certificate of Origin.
+// Their grant language closely follows the licenses they accompany.
+const agreementMatchType = "Agreement"
+
+// preemptiveMatchTypes are the categories whose matches take precedence over
+// the license matches they contain.
+var preemptiveMatchTypes = map[string]bool{
+	boilerplateMatchType: true,
+	agreementMatchType:   true,
+}
+
+// suppressBoilerplate removes the candidates that lie within a boilerplate or
+// agreement match, so that the text isn't misattributed to a license.
+// Copyright matches are kept since they are reported wherever they occur.
+func suppressBoilerplate(candidates Matches) Matches {
+	var boilerplate Matches
+	for _, m := range candidates {
+		if preemptiveMatchTypes[m.MatchType] {
+			boilerplate = append(boilerplate, m)
+		}
+	}
+	if len(boilerplate) == 0 {
+		return candidates
+	}
+
+	var out Matches
+	for _, m := range candidates {
+		if !preemptiveMatchTypes[m.MatchType] && m.MatchType != "Copyright" && containedInAny(boilerplate, m) {
+			continue
+		}
+		out = append(out, m)
+	}
+	return out
+}
+
+// hideBoilerplate removes boilerplate matches from the results.
+func hideBoilerplate(matches Matches) Matches {
+	var out Matches
+	for _, m := range matches {
+		if m.MatchType != boilerplateMatchType {
+			out = append(out, m)
+		}
+	}
+	return out
+}
+
+// containedInAny returns true iff m lies within one of the matches.
+func containedInAny(matches Matches, m *Match) bool {
+	for _, o := range matches {
+		if contains(o, m) {
+			return true
+		}
+	}
+	return false
+}
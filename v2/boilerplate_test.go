@@ -0,0 +1,75 @@
+// Copyright 2022 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package classifier
+
+import (
+	"io/ioutil"
+	"path"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestSuppressBoilerplate(t *testing.T) {
+	coc := &Match{Name: "Contributor-Covenant-1.4", MatchType: "Boilerplate", StartLine: 1, EndLine: 70}
+	partial := &Match{Name: "CC-BY-4.0", MatchType: "License", StartLine: 10, EndLine: 20}
+	copyright := &Match{Name: "Copyright", MatchType: "Copyright", StartLine: 5, EndLine: 5}
+	after := &Match{Name: "MIT", MatchType: "License", StartLine: 72, EndLine: 90}
+	cla := &Match{Name: "Apache-ICLA-2.0", MatchType: "Agreement", StartLine: 100, EndLine: 200}
+	grant := &Match{Name: "Apache-2.0", MatchType: "License", StartLine: 140, EndLine: 160}
+
+	tests := []struct {
+		name  string
+		input Matches
+		want  Matches
+	}{
+		{
+			name:  "no boilerplate",
+			input: Matches{partial, after},
+			want:  Matches{partial, after},
+		},
+		{
+			name:  "license within boilerplate",
+			input: Matches{coc, partial, copyright, after},
+			want:  Matches{coc, copyright, after},
+		},
+		{
+			name:  "license within agreement",
+			input: Matches{cla, grant, after},
+			want:  Matches{cla, after},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if diff := cmp.Diff(tt.want, suppressBoilerplate(tt.input)); diff != "" {
+				t.Errorf("suppressBoilerplate() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestBoilerplateHidden(t *testing.T) {
+	coc, err := ioutil.ReadFile(path.Join(baseLicenses, "Boilerplate", "Contributor-Covenant-1.4", "coc.txt"))
+	if err != nil {
+		t.Fatalf("couldn't read code of conduct: %v", err)
+	}
+
+	c := NewClassifier(defaultThreshold, WithBoilerplateHidden())
+	c.AddContent("Boilerplate", "Contributor-Covenant-1.4", "coc.txt", coc)
+	if got := c.Match(coc).Matches; len(got) != 0 {
+		t.Errorf("Match() = %v, want no matches", got)
+	}
+}
@@ -0,0 +1,85 @@
+// Copyright 2022 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build !core
+// +build !core
+
+package classifier
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// textExtensions are the extensions of files that hold prose rather than
+// code; the empty extension covers files such as LICENSE and COPYING.
+var textExtensions = map[string]bool{
+	"":          true,
+	".markdown": true,
+	".md":       true,
+	".rst":      true,
+	".text":     true,
+	".txt":      true,
+}
+
+// maxSniffSize is the size above which files aren't read to sniff whether
+// they look like a license.
+const maxSniffSize = 1 << 20
+
+// FindLicenseCandidates returns the files below dir that are likely to hold
+// license information, in lexical order. A file is a candidate if its name
+// follows a convention for license files, unless it is code that doesn't look
+// like a license (such as license.go), or if it is a small text file whose
+// content looks like a license (such as a license under a third_party
+// directory named after the license). Hidden directories, such as .git, aren't
+// searched.
+func FindLicenseCandidates(dir string) ([]string, error) {
+	var out []string
+	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			if path != dir && strings.HasPrefix(info.Name(), ".") {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !info.Mode().IsRegular() {
+			return nil
+		}
+
+		text := textExtensions[strings.ToLower(filepath.Ext(path))]
+		named := LicenseFileRank(path) != -1
+		switch {
+		case named && (text || strings.HasSuffix(strings.ToLower(path), ".license")):
+			out = append(out, path)
+		case (named || text) && info.Size() <= maxSniffSize:
+			b, err := ioutil.ReadFile(path)
+			if err != nil {
+				return err
+			}
+			if LooksLikeLicense(b) {
+				out = append(out, path)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
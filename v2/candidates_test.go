@@ -0,0 +1,69 @@
+// Copyright 2022 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build !core
+// +build !core
+
+package classifier
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestFindLicenseCandidates(t *testing.T) {
+	mit, err := ioutil.ReadFile(filepath.Join(baseLicenses, "License", "MIT", "pristine.txt"))
+	if err != nil {
+		t.Fatalf("couldn't read MIT license: %v", err)
+	}
+	code := []byte("package license\n\nfunc Check() bool { return true }\n")
+
+	dir := t.TempDir()
+	files := map[string][]byte{
+		"LICENSE":                   mit,
+		"NOTICE.md":                 []byte("This product includes software developed by Example."),
+		"README.md":                 []byte("# Example\n\nAn example project."),
+		"license.go":                code,
+		"main.go":                   code,
+		"logo.svg.license":          []byte("SPDX-License-Identifier: MIT"),
+		"third_party/lib/MIT.txt":   mit,
+		"third_party/lib/notes.txt": []byte("Build with make."),
+		".git/LICENSE":              mit,
+	}
+	for name, b := range files {
+		p := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(p, b, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	got, err := FindLicenseCandidates(dir)
+	if err != nil {
+		t.Fatalf("FindLicenseCandidates() = %v", err)
+	}
+	var want []string
+	for _, name := range []string{"LICENSE", "NOTICE.md", "logo.svg.license", "third_party/lib/MIT.txt"} {
+		want = append(want, filepath.Join(dir, filepath.FromSlash(name)))
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("FindLicenseCandidates() mismatch (-want +got):\n%s", diff)
+	}
+}
@@ -0,0 +1,131 @@
+// Copyright 2022 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package classifier
+
+import (
+	"bytes"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// casePreserved returns the phrases of the named license whose capitalization
+// matters, as set by its metadata.
+func (c *Classifier) casePreserved(name string) []string {
+	return c.metadata[name].CasePreserved
+}
+
+// updateCased collects the distinct case-preserved phrases of the metadata of
+// all licenses, in order, after the metadata changes.
+func (c *Classifier) updateCased() {
+	seen := make(map[string]bool)
+	c.cased = nil
+	for _, m := range c.metadata {
+		for _, p := range m.CasePreserved {
+			if !seen[p] {
+				seen[p] = true
+				c.cased = append(c.cased, p)
+			}
+		}
+	}
+	sort.Strings(c.cased)
+}
+
+// tokenizeLicense tokenizes the text of a variant of the named license against
+// the dictionary, with the license's number policy, and counts the phrases of
+// the license whose capitalization matters in the text.
+func (c *Classifier) tokenizeLicense(name string, text []byte, dict *dictionary) *indexedDocument {
+	// Since bytes.NewReader().Read() will never return an error, tokenizeStream
+	// will never return an error so it's okay to ignore the return value in this
+	// case.
+	doc, _ := tokenizeStream(bytes.NewReader(text), true, dict, true, c.numberPolicy(name))
+	if phrases := c.casePreserved(name); len(phrases) > 0 {
+		doc.cased, _ = countCased(phrases, text)
+	}
+	return doc
+}
+
+// casedPhraseRE matches the phrase in any capitalization, with any spacing
+// between its words.
+func casedPhraseRE(phrase string) *regexp.Regexp {
+	words := strings.Fields(phrase)
+	for i, w := range words {
+		words[i] = regexp.QuoteMeta(w)
+	}
+	return phraseRE(`(?i)\b` + strings.Join(words, `\s+`) + `\b`)
+}
+
+// countCased returns, for each of the phrases, the number of times the text
+// holds it with its capitalization, and the number of times it holds it with
+// other capitalization.
+func countCased(phrases []string, text []byte) (exact, other []int) {
+	exact = make([]int, len(phrases))
+	other = make([]int, len(phrases))
+	for i, p := range phrases {
+		want := strings.Join(strings.Fields(p), " ")
+		for _, loc := range casedPhraseRE(p).FindAllIndex(text, -1) {
+			if phraseText(text[loc[0]:loc[1]]) == want {
+				exact[i]++
+			} else {
+				other[i]++
+			}
+		}
+	}
+	return exact, other
+}
+
+// caseChanges returns the number of tokens of the known document of the named
+// license whose capitalization the text of a match of it changes: the words
+// of each of the license's case-preserved phrases that the text holds with
+// other capitalization in place of the capitalization the known document
+// holds it with. Phrases the text lacks altogether are already counted as
+// deletions by the diff.
+func (c *Classifier) caseChanges(name string, known *indexedDocument, text []byte) int {
+	phrases := c.casePreserved(name)
+	if len(phrases) == 0 || len(known.cased) != len(phrases) {
+		return 0
+	}
+	exact, other := countCased(phrases, text)
+	changes := 0
+	for i, p := range phrases {
+		missing := known.cased[i] - exact[i]
+		if missing > other[i] {
+			missing = other[i]
+		}
+		if missing > 0 {
+			changes += missing * len(strings.Fields(p))
+		}
+	}
+	return changes
+}
+
+// scoreCase lowers the confidence of a match of the known document spanning
+// tokens start to end of the content by the changes of capitalization of the
+// license's case-preserved phrases the match holds. Content whose original
+// text isn't known, such as that matched with MatchTokens, is left alone.
+func (c *Classifier) scoreCase(name string, unknown, known *indexedDocument, conf float64, start, end int) float64 {
+	if unknown.text == nil || known.size() == 0 {
+		return conf
+	}
+	text := lineText(unknown.text, unknown.Tokens[start].Line, unknown.Tokens[end].Line)
+	changes := c.caseChanges(LicenseName(name), known, []byte(text))
+	if changes == 0 {
+		return conf
+	}
+	if c.tc.traceScoring(name) {
+		c.tc.trace("Case changes against %s: %d tokens", name, changes)
+	}
+	return conf - float64(changes)/float64(known.size())
+}
@@ -0,0 +1,131 @@
+// Copyright 2022 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package classifier
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestCountCased(t *testing.T) {
+	phrases := []string{"Licensed Work", "Change License"}
+	in := `The Licensed Work, or LICENSED WORK, is licensed under the Change
+// License; the licensed work is not the Licensed   Work.`
+	exact, other := countCased(phrases, []byte(in))
+	if diff := cmp.Diff([]int{2, 1}, exact); diff != "" {
+		t.Errorf("countCased() exact mismatch (-want +got):\n%s", diff)
+	}
+	if diff := cmp.Diff([]int{2, 0}, other); diff != "" {
+		t.Errorf("countCased() other mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestCaseChanges(t *testing.T) {
+	c := NewClassifier(.8)
+	c.SetMetadata("Defined", LicenseMetadata{CasePreserved: []string{"Licensed Work"}})
+	known := c.tokenizeLicense("Defined", []byte("The Licensed Work and the Licensed Work."), c.dict)
+
+	tests := []struct {
+		name string
+		in   string
+		want int
+	}{
+		{
+			name: "as known",
+			in:   "The Licensed Work and the Licensed Work.",
+			want: 0,
+		},
+		{
+			name: "one lowered",
+			in:   "The licensed work and the Licensed Work.",
+			want: 2,
+		},
+		{
+			name: "missing",
+			in:   "The Licensed Work and the program.",
+			want: 0,
+		},
+		{
+			name: "more than known",
+			in:   "The Licensed Work, the Licensed Work and the licensed work.",
+			want: 0,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := c.caseChanges("Defined", known, []byte(tt.in)); got != tt.want {
+				t.Errorf("caseChanges() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSaveLoadCorpusCased(t *testing.T) {
+	c := NewClassifier(.8)
+	c.SetMetadata("Defined", LicenseMetadata{CasePreserved: []string{"Licensed Work", "Change License"}})
+	c.AddContent("License", "Defined", "license.txt", []byte(definedTermsLicense))
+	var b bytes.Buffer
+	if err := c.SaveCorpus(&b); err != nil {
+		t.Fatalf("SaveCorpus() = %v", err)
+	}
+	loaded := NewClassifier(.8)
+	if err := loaded.LoadCorpus(&b); err != nil {
+		t.Fatalf("LoadCorpus() = %v", err)
+	}
+
+	lowered := strings.Replace(definedTermsLicense, "Change License", "change license", 1)
+	want := c.Match([]byte(lowered)).Matches
+	got := loaded.Match([]byte(lowered)).Matches
+	if len(got) != 1 || len(want) != 1 || got[0].Confidence != want[0].Confidence {
+		t.Fatalf("Match() after LoadCorpus = %+v, want %+v", got, want)
+	}
+	if got[0].Confidence == 1.0 {
+		t.Error("Match() after LoadCorpus ignored the case-preserved phrases")
+	}
+}
+
+func TestVerbatimMatchesCased(t *testing.T) {
+	c := NewClassifier(.8)
+	c.SetMetadata("Defined", LicenseMetadata{CasePreserved: []string{"Licensed Work", "Change License"}})
+	c.AddContent("License", "Defined", "license.txt", []byte(definedTermsLicense))
+
+	verbatim := func(text string) bool {
+		id, err := tokenizeStream(strings.NewReader(text), true, c.dict, false, c.numbers)
+		if err != nil {
+			t.Fatal(err)
+		}
+		id.text = []byte(text)
+		_, ok := c.verbatimMatches(id, nil)
+		return ok
+	}
+	// Content capitalized differently outside the phrases is matched
+	// verbatim.
+	if !verbatim(strings.Replace(definedTermsLicense, "The Licensor", "THE LICENSOR", 1)) {
+		t.Error("verbatimMatches() of recapitalized license = false, want true")
+	}
+
+	lowered := strings.Replace(definedTermsLicense, "Change License", "change license", 1)
+	if verbatim(lowered) {
+		t.Error("verbatimMatches() of lowered phrase = true, want false")
+	}
+	got := c.Match([]byte(lowered)).Matches
+	if len(got) != 1 || got[0].Confidence == 1.0 {
+		t.Errorf("Match() of lowered phrase = %+v, want one match below full confidence", got)
+	}
+}
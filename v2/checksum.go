@@ -0,0 +1,123 @@
+// Copyright 2022 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package classifier
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"io"
+)
+
+// maxVerbatim is the most contents whose candidate matches are cached.
+const maxVerbatim = 1024
+
+// checksum returns the SHA-256 hash of the normalized text of a document. The
+// tokens of the corpus and of the content matched against it share the
+// dictionary, so the hash is computed over the token IDs.
+func checksum(d *indexedDocument) [sha256.Size]byte {
+	h := sha256.New()
+	writeTokens(h, d, false)
+	var sum [sha256.Size]byte
+	copy(sum[:], h.Sum(nil))
+	return sum
+}
+
+// writeTokens writes the token IDs of the document to the hash, along with
+// their lines if lines is set.
+func writeTokens(h io.Writer, d *indexedDocument, lines bool) {
+	var buf [8]byte
+	for _, t := range d.Tokens {
+		binary.LittleEndian.PutUint32(buf[:4], uint32(t.ID))
+		if !lines {
+			h.Write(buf[:4])
+			continue
+		}
+		binary.LittleEndian.PutUint32(buf[4:], uint32(t.Line))
+		h.Write(buf[:])
+	}
+}
+
+// addChecksum records the checksum of a License document of the corpus. Only
+// documents tokenized with the classifier's number policy are recorded, since
+// content is tokenized with that policy.
+func (c *Classifier) addChecksum(name string, d *indexedDocument) {
+	if detectionType(name) != "License" || d.numbers != c.numbers || d.size() == 0 {
+		return
+	}
+	if c.checksums == nil {
+		c.checksums = make(map[[sha256.Size]byte][]string)
+	}
+	sum := checksum(d)
+	c.checksums[sum] = append(c.checksums[sum], name)
+}
+
+// removeChecksum forgets the checksum of a License document removed from the
+// corpus.
+func (c *Classifier) removeChecksum(name string, d *indexedDocument) {
+	sum := checksum(d)
+	var names []string
+	for _, n := range c.checksums[sum] {
+		if n != name {
+			names = append(names, n)
+		}
+	}
+	if len(names) == 0 {
+		delete(c.checksums, sum)
+		return
+	}
+	c.checksums[sum] = names
+}
+
+// verbatimMatches returns the matches of the licenses of the corpus whose
+// normalized text is exactly that of the content, tokenized as id, such as a
+// verbatim LICENSE file. They're looked up by the checksum of the content's
+// tokens and reported without searching for and scoring them. The content is
+// searched as usual when it holds no license's text, when only some licenses
+// are matched, or when tracing, since the trace would be incomplete.
+func (c *Classifier) verbatimMatches(id *indexedDocument, include func(name string) bool) (Matches, bool) {
+	if c.noChecksums || include != nil || c.licenses != nil || c.tc.enabled() || id.size() == 0 {
+		return nil, false
+	}
+	names := c.checksums[checksum(id)]
+	if len(names) == 0 {
+		return nil, false
+	}
+	var out Matches
+	for _, l := range names {
+		d := c.docs[l]
+		size := d.size()
+		// A license whose case-preserved phrases the content capitalizes
+		// differently, or whose minimum match is larger than its text, isn't
+		// matched verbatim.
+		if c.scoreCase(l, id, d, 1.0, 0, size-1) < 1.0 || size < c.minMatchTokens(LicenseName(l)) {
+			return nil, false
+		}
+		out = append(out, &Match{
+			Name:            LicenseName(l),
+			Variant:         variantName(l),
+			MatchType:       detectionType(l),
+			Confidence:      1.0,
+			StartLine:       id.Tokens[0].Line,
+			EndLine:         id.Tokens[size-1].Line,
+			StartTokenIndex: 0,
+			EndTokenIndex:   size - 1,
+
+			KnownEndTokenIndex: size - 1,
+			KnownTokens:        size,
+			Stats:              MatchStats{KnownTokensMatched: size, ContentTokens: size},
+		})
+	}
+	return out, true
+}
@@ -0,0 +1,123 @@
+// Copyright 2022 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package classifier
+
+import (
+	"bytes"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestVerbatimMatches(t *testing.T) {
+	apache, err := ioutil.ReadFile("assets/License/Apache-2.0/a.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	verbatim := append([]byte("Copyright 2022 Some Author\n\n"), apache...)
+	edited := append([]byte("Copyright 2022 Some Author\n\nModified: "), apache...)
+
+	c, err := classifier()
+	if err != nil {
+		t.Fatalf("couldn't instantiate standard test classifier: %v", err)
+	}
+	searched := NewClassifier(defaultThreshold, WithChecksumsDisabled())
+	if err := searched.LoadLicenses(baseLicenses); err != nil {
+		t.Fatalf("couldn't instantiate test classifier: %v", err)
+	}
+
+	id, err := tokenizeStream(bytes.NewReader(verbatim), true, c.dict, false, c.numbers)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := c.verbatimMatches(id, nil); !ok {
+		t.Error("verbatimMatches() of verbatim license = false, want true")
+	}
+	if diff := cmp.Diff(searched.Match(verbatim), c.Match(verbatim)); diff != "" {
+		t.Errorf("Match() of verbatim license: unexpected diff (-want +got):\n%s", diff)
+	}
+
+	id, err = tokenizeStream(bytes.NewReader(edited), true, c.dict, false, c.numbers)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := c.verbatimMatches(id, nil); ok {
+		t.Error("verbatimMatches() of edited license = true, want false")
+	}
+	if diff := cmp.Diff(searched.Match(edited), c.Match(edited)); diff != "" {
+		t.Errorf("Match() of edited license: unexpected diff (-want +got):\n%s", diff)
+	}
+}
+
+// TestVerbatimMatchesCorpus checks that every license of the corpus matched
+// verbatim is reported as searching for it would report it, except for the
+// partial matches of similar licenses that a search also finds in its text.
+func TestVerbatimMatchesCorpus(t *testing.T) {
+	c, err := classifier()
+	if err != nil {
+		t.Fatalf("couldn't instantiate standard test classifier: %v", err)
+	}
+	searched := NewClassifier(defaultThreshold, WithChecksumsDisabled())
+	if err := searched.LoadLicenses(baseLicenses); err != nil {
+		t.Fatalf("couldn't instantiate test classifier: %v", err)
+	}
+	files, err := filepath.Glob(filepath.Join(baseLicenses, "License", "*", "*.txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, f := range files {
+		b, err := ioutil.ReadFile(f)
+		if err != nil {
+			t.Fatal(err)
+		}
+		want := searched.Match(b)
+		var kept Matches
+		for _, m := range want.Matches {
+			if m.MatchType != "License" || m.Confidence == 1.0 {
+				kept = append(kept, m)
+			}
+		}
+		want.Matches = kept
+		if diff := cmp.Diff(want, c.Match(b)); diff != "" {
+			t.Errorf("Match(%s): unexpected diff (-want +got):\n%s", f, diff)
+		}
+	}
+}
+
+func BenchmarkVerbatim(b *testing.B) {
+	apache, err := ioutil.ReadFile("assets/License/Apache-2.0/a.txt")
+	if err != nil {
+		b.Fatal(err)
+	}
+	for _, bc := range []struct {
+		name    string
+		options []OptionFunc
+	}{
+		{"checksums", nil},
+		{"searched", []OptionFunc{WithChecksumsDisabled()}},
+	} {
+		c := NewClassifier(defaultThreshold, bc.options...)
+		if err := c.LoadLicenses(baseLicenses); err != nil {
+			b.Fatalf("couldn't instantiate test classifier: %v", err)
+		}
+		b.Run(bc.name, func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				c.Match(apache)
+			}
+		})
+	}
+}
@@ -16,13 +16,20 @@ package classifier
 
 import (
 	"bytes"
+	"context"
+	"crypto/sha256"
+	"errors"
 	"fmt"
 	"io"
-	"io/ioutil"
+	"io/fs"
+	"math"
 	"os"
-	"path/filepath"
+	pathpkg "path"
+	"runtime"
 	"sort"
 	"strings"
+	"sync"
+	"sync/atomic"
 )
 
 // Match is the information about a single instance of a detected match.
@@ -35,6 +42,83 @@ type Match struct {
 	EndLine         int
 	StartTokenIndex int
 	EndTokenIndex   int
+	// KnownStartTokenIndex and KnownEndTokenIndex are the indexes of the
+	// first and last tokens of the text of the matched variant in the corpus
+	// that the content holds, and KnownTokens is the number of tokens of that
+	// text, for License and Header matches. They tell which part of a license
+	// was found, such as its preamble, its terms or its appendix.
+	KnownStartTokenIndex int
+	KnownEndTokenIndex   int
+	KnownTokens          int
+	// Stats details the differences between the content and the text of the
+	// matched variant that the confidence of a License or Header match is
+	// computed from.
+	Stats MatchStats
+	// AlternateVariants are the other variants of the license that match
+	// the same lines with the same confidence, if any. Variant is the
+	// preferred one among them.
+	AlternateVariants []string
+	// SimilarTo names the licenses that are easily confused with a License
+	// match, as a hint for reviewers, if the classifier knows them.
+	SimilarTo []string
+	// AppliesTo names the license an Exception match qualifies, the License
+	// or Header match holding or next to it, if any.
+	AppliesTo string
+	// Family is the family of the license of the match, such as
+	// "GPL-family", if requested with WithFamilies and the license belongs
+	// to one.
+	Family string
+	// TruncationSuspected is set when the content holds the start of the
+	// license but lacks its end, as when a license file was cut short, so
+	// that reviewers can ask for the full text.
+	TruncationSuspected bool
+	// OrLater is set for Header and Reference matches of the GNU licenses
+	// whose content grants the choice of any later version of the license,
+	// as in "either version 2 of the License, or (at your option) any later
+	// version", so that SPDXExpression reports, for example,
+	// GPL-2.0-or-later rather than GPL-2.0-only. Only SPDXExpression
+	// reflects it: the Name of the match is the corpus name of the license,
+	// such as GPL-2.0, either way, and identify_license, which reports
+	// Names, doesn't report it.
+	OrLater bool
+	// Alternatives names the licenses, including the match's, that the
+	// content offers a choice between, in the order it names them, as in
+	// "you may choose either the MIT license or the Apache License 2.0", so
+	// that SPDXExpression reports "MIT OR Apache-2.0" rather than "Apache-2.0
+	// AND MIT". It's empty for licenses the content isn't offered under as
+	// one of a choice.
+	Alternatives []string
+	// Warning is set for License and Header matches whose confidence is
+	// below the threshold of the classifier but at least its warning
+	// threshold, as set by WithWarnThreshold. They are reported only where
+	// no confident match is found.
+	Warning bool
+	// Text is the text of the lines of the match in the content, if
+	// requested with WithMatchText. For content matched with MatchTokens,
+	// it's the tokens of the match joined by spaces.
+	Text string
+}
+
+// MatchStats counts the tokens a match holds and the differences between the
+// content and the known text of a license, to explain the confidence of the
+// match. Insertions are runs of text in the content that the known text lacks,
+// and deletions are runs of the known text that the content lacks; a changed
+// word is both.
+type MatchStats struct {
+	// KnownTokensMatched is the number of tokens of the known text that the
+	// content holds unchanged.
+	KnownTokensMatched int
+	// ContentTokens is the number of tokens of the content claimed by the
+	// match.
+	ContentTokens int
+	// Insertions is the number of runs of text inserted into the content,
+	// and InsertedTokens the number of tokens they hold.
+	Insertions     int
+	InsertedTokens int
+	// Deletions is the number of runs of the known text deleted from the
+	// content, and DeletedTokens the number of tokens they hold.
+	Deletions     int
+	DeletedTokens int
 }
 
 // Results captures the summary information and matches detected by the
@@ -42,6 +126,10 @@ type Match struct {
 type Results struct {
 	Matches         Matches
 	TotalInputLines int
+	// Truncated is set when a limit set with WithMaxTokens,
+	// WithMaxCandidates or WithMaxDiffSize left part of the content
+	// unmatched, so that the matches may be incomplete.
+	Truncated bool
 }
 
 // Matches is a sortable slice of Match.
@@ -60,65 +148,398 @@ func (d Matches) Less(i, j int) bool {
 	if di.StartTokenIndex != dj.StartTokenIndex {
 		return di.StartTokenIndex < dj.StartTokenIndex
 	}
-	// Should never get here, but tiebreak based on the larger license.
-	return di.EndTokenIndex > dj.EndTokenIndex
+	// Tiebreak based on the larger license.
+	if di.EndTokenIndex != dj.EndTokenIndex {
+		return di.EndTokenIndex > dj.EndTokenIndex
+	}
+	// Ties of the same text, such as variants of a license, are ordered
+	// deterministically rather than by the order they were matched in.
+	if di.Name != dj.Name {
+		return di.Name < dj.Name
+	}
+	if di.MatchType != dj.MatchType {
+		return di.MatchType < dj.MatchType
+	}
+	return preferVariant(di.Variant, dj.Variant)
+}
+
+// confidenceQuantum is the granularity of reported confidence values.
+const confidenceQuantum = 1000
+
+// quantizeConfidence truncates a confidence value to three decimal places, so
+// that the reported value doesn't change with floating-point differences
+// between platforms and versions of the diffing code. Truncating, rather than
+// rounding, keeps a value below 1.0 from being reported as an exact match and a
+// value above the threshold from falling below it.
+func quantizeConfidence(conf float64) float64 {
+	// The epsilon absorbs the representation error of values such as 0.29
+	// being scaled to 289.99999999999994.
+	return math.Floor(conf*confidenceQuantum+1e-9) / confidenceQuantum
+}
+
+// reportedConfidence returns the confidence reported for a match scored conf,
+// which is quantized unless WithRawConfidence is set. Matches are compared
+// against the thresholds by it, so that a match isn't reported as a warning
+// with a confidence that meets the threshold.
+func (c *Classifier) reportedConfidence(conf float64) float64 {
+	if c.rawConfidence {
+		return conf
+	}
+	return quantizeConfidence(conf)
+}
+
+// Match reports instances of the supplied content in the corpus. If include
+// isn't nil, only the corpus documents it accepts, by indexed name, are
+// considered. Matching stops with the context's error once it's done.
+func (c *Classifier) match(ctx context.Context, in io.Reader, include func(name string) bool) (Results, error) {
+	return c.guardMatch(func() (Results, error) {
+		return c.matchReader(ctx, in, include)
+	})
+}
+
+// guardMatch calls f to match content once the corpus is loaded, holding the
+// corpus steady while it runs. A panic in f is returned as a *PanicError.
+func (c *Classifier) guardMatch(f func() (Results, error)) (res Results, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			res, err = Results{}, newPanicError("", r)
+		}
+	}()
+	if err := c.loadLazy(); err != nil {
+		return Results{}, err
+	}
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return f()
 }
 
-// Match reports instances of the supplied content in the corpus.
-func (c *Classifier) match(in io.Reader) (Results, error) {
-	id, err := tokenizeStream(in, true, c.dict, false)
+// matchReader reports instances of the read content in the corpus.
+func (c *Classifier) matchReader(ctx context.Context, in io.Reader, include func(name string) bool) (Results, error) {
+	// The raw content is retained since some detections, such as references to
+	// other licenses and public-domain dedications, work on the original text
+	// rather than the tokens. It's read as it's tokenized, so that no more of
+	// it than the tokens kept by WithMaxTokens is read or searched.
+	var raw bytes.Buffer
+	id, cut, err := tokenizePrefix(io.TeeReader(in, &raw), true, c.dict, false, c.numbers, c.maxTokens)
 	if err != nil {
 		return Results{}, err
 	}
+	b := raw.Bytes()
+	if cut >= 0 {
+		b = b[:cut]
+	}
+	id.text = b
+	truncated := c.limitTokens(id)
+	refs := c.detectText(b)
+	if truncated {
+		refs = limitMatches(refs, id)
+	}
+
+	// Licenses whose metadata overrides the number policy are compared against
+	// the content tokenized with their policy. Matches are filtered by line, so
+	// matches found in the different tokenizations can be compared.
+	targets := map[NumberPolicy]*indexedDocument{c.numbers: id}
+	target := func(numbers NumberPolicy) *indexedDocument {
+		t, ok := targets[numbers]
+		if !ok {
+			t, _ = tokenizeStream(bytes.NewReader(b), true, c.dict, false, numbers)
+			t.text = b
+			c.limitTokens(t)
+			targets[numbers] = t
+		}
+		return t
+	}
+	res, err := c.matchDocument(ctx, id, target, refs, include)
+	res.Truncated = res.Truncated || truncated
+	c.markOrLater(b, target, res.Matches)
+	if c.matchText {
+		for _, m := range res.Matches {
+			m.Text = lineText(b, m.StartLine, m.EndLine)
+		}
+	}
+	return res, err
+}
+
+// lineText returns the text of lines start to end, counting from 1, of b,
+// without the newline ending the last of them. It's empty if end is before
+// start.
+func lineText(b []byte, start, end int) string {
+	if end < start {
+		return ""
+	}
+	line := 1
+	from := -1
+	if start <= 1 {
+		from = 0
+	}
+	for i, r := range b {
+		if r != '\n' {
+			continue
+		}
+		if line == end {
+			return string(b[from:i])
+		}
+		line++
+		if line == start {
+			from = i + 1
+		}
+	}
+	if from == -1 {
+		return ""
+	}
+	return string(b[from:])
+}
+
+// matchDocument reports instances of the content, tokenized as id, in the
+// corpus. The content tokenized for each number policy is returned by target,
+// and refs holds the references to licenses found in its text.
+func (c *Classifier) matchDocument(ctx context.Context, id *indexedDocument, target func(NumberPolicy) *indexedDocument, refs Matches, include func(name string) bool) (Results, error) {
+	if err := ctx.Err(); err != nil {
+		return Results{}, err
+	}
+	// Content whose normalized text is exactly that of a license of the corpus,
+	// such as a verbatim LICENSE file, is common enough that the license is
+	// reported without searching for it. Only the annotations that qualify
+	// it, such as exceptions, are still searched for.
+	verbatim, ok := c.verbatimMatches(id, include)
+	if ok {
+		include = func(name string) bool {
+			return annotationMatchTypes[detectionType(name)]
+		}
+	}
+	// The cache is the classifier's own, so it can hold the candidates of a
+	// subset of the licenses of a shared corpus.
+	if c.licenses != nil {
+		filter := include
+		include = func(name string) bool {
+			return c.licenses[LicenseName(name)] && (filter == nil || filter(name))
+		}
+	}
 
 	firstPass := make(map[string]*indexedDocument)
-	for l, d := range c.docs {
-		sim := id.tokenSimilarity(d)
+	for _, l := range c.order(c.docs) {
+		if (include != nil && !include(l)) || !c.reported(l) {
+			continue
+		}
+		d := c.docs[l]
+		t := target(d.numbers)
+		// A match's confidence is at most the fraction of the license's
+		// tokens that the content holds, so licenses much longer than the
+		// content can't reach the threshold.
+		if !c.noLengthFilter && float64(t.size()) < c.matchThreshold()*float64(d.size()) {
+			if c.tc.traceTokenize(l) {
+				c.tc.trace("Length of %s exceeds content: %d > %d", l, d.size(), t.size())
+			}
+			continue
+		}
+		sim := t.tokenSimilarity(d)
 
 		if c.tc.traceTokenize(l) {
 			c.tc.trace("Token similarity for %s: %.2f", l, sim)
 		}
 
-		if sim >= c.threshold {
+		if sim >= c.prefilter {
 			firstPass[l] = d
 		}
 	}
 
-	if len(firstPass) == 0 {
+	c.addConfusable(firstPass, include)
+
+	if len(firstPass) == 0 && verbatim == nil {
 		return Results{
-			Matches:         nil,
+			Matches:         addReferences(nil, refs),
 			TotalInputLines: 0,
 		}, nil
 	}
 
-	// Perform the expensive work of generating a searchset to look for token runs.
-	id.generateSearchSet(c.q)
-
 	var candidates Matches
 	candidates = append(candidates, id.Matches...)
+	candidates = append(candidates, verbatim...)
+
+	// The licenses are scored concurrently, so the searchsets of the content,
+	// which are built on first use, are built under a lock.
+	var mu sync.Mutex
+	searchTarget := func(numbers NumberPolicy) *indexedDocument {
+		mu.Lock()
+		defer mu.Unlock()
+		t := target(numbers)
+		if t.s == nil {
+			// Perform the expensive work of generating a searchset to look for token runs.
+			t.generateSearchSet(c.q)
+		}
+		return t
+	}
+	names := c.order(firstPass)
+	// The q-grams of the content are looked up in the index of the corpus
+	// once, rather than in the searchset of each license, and the licenses
+	// sharing none with it are skipped.
+	hits := c.probeIndex(firstPass, searchTarget)
+	if hits != nil {
+		var shared []string
+		for _, l := range names {
+			if len(hits[l]) > 0 {
+				shared = append(shared, l)
+			} else if c.tc.traceSearchset(l) {
+				c.tc.trace("No q-grams of %s in content", l)
+			}
+		}
+		names = shared
+	}
+	found := make([]Matches, len(names))
+	limited := make([]bool, len(names))
+	errs := make([]error, len(names))
+	c.parallel(c.matchWorkers(), len(names), func(i int) {
+		if ctx.Err() == nil {
+			found[i], limited[i], errs[i] = c.matchLicense(ctx, names[i], firstPass[names[i]], searchTarget, hits)
+		}
+	})
+	if err := ctx.Err(); err != nil {
+		return Results{}, err
+	}
+	var panicErr error
+	truncated := false
+	for i := range names {
+		truncated = truncated || limited[i]
+		if errs[i] != nil {
+			// The matches of the other licenses are still reported.
+			if panicErr == nil {
+				panicErr = errs[i]
+			}
+			continue
+		}
+		candidates = append(candidates, found[i]...)
+	}
+	res := c.report(candidates, refs, id)
+	res.Truncated = truncated
+	return res, panicErr
+}
+
+// probeIndex returns the q-grams that the content, tokenized for each number
+// policy by target, shares with each of the documents, by name, as found in
+// the q-gram index of the corpus. It returns nil if the corpus isn't indexed,
+// as in low-memory mode, in which case each document's q-grams are looked up
+// in its own searchset.
+func (c *Classifier) probeIndex(docs map[string]*indexedDocument, target func(NumberPolicy) *indexedDocument) map[string][]qgramHit {
+	if c.lowMemory {
+		return nil
+	}
+	idx := c.index.get(c.docs)
+	if idx == nil {
+		return nil
+	}
+	return idx.probe(docs, target)
+}
 
-	for l, d := range firstPass {
-		matches := c.findPotentialMatches(d.s, id.s, c.threshold)
-		for _, m := range matches {
-			startIndex := m.TargetStart
-			endIndex := m.TargetEnd
-			conf, startOffset, endOffset := c.score(l, id, d, startIndex, endIndex)
-			if conf >= c.threshold && (endIndex-startIndex-startOffset-endOffset) > 0 {
-				candidates = append(candidates, &Match{
-					Name:            LicenseName(l),
-					Variant:         variantName(l),
-					MatchType:       detectionType(l),
-					Confidence:      conf,
-					StartLine:       id.Tokens[startIndex+startOffset].Line,
-					EndLine:         id.Tokens[endIndex-endOffset-1].Line,
-					StartTokenIndex: startIndex + startOffset,
-					EndTokenIndex:   endIndex - endOffset - 1,
-				})
+// matchLicense returns the candidate matches of a corpus document in the
+// content, tokenized for each number policy by target, which also builds the
+// searchset of the content. If the corpus is indexed, hits holds the q-grams
+// the content shares with each document, as found by probeIndex. It also
+// reports whether the limits set with WithMaxCandidates and WithMaxDiffSize
+// left regions of the content unscored. A panic while matching the document
+// is returned as a *PanicError. Scoring stops once the context is done, which
+// the caller checks for.
+func (c *Classifier) matchLicense(ctx context.Context, l string, doc *indexedDocument, target func(NumberPolicy) *indexedDocument, hits map[string][]qgramHit) (candidates Matches, truncated bool, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			candidates, err = nil, newPanicError(l, r)
+		}
+	}()
+	d := c.expand(l, doc)
+	t := target(d.numbers)
+	var matched matchRanges
+	if hits != nil {
+		matched = hitRanges(hits[l], d.s.q, t.s.q)
+	} else {
+		matched = targetMatchedRanges(d.s, t.s)
+	}
+	matches := c.potentialMatches(d.s, matched, len(t.s.Tokens), c.matchThreshold())
+	// The regions are ordered from the most tokens in common with the
+	// license to the least, so the most promising are kept.
+	if c.maxCandidates > 0 && len(matches) > c.maxCandidates {
+		matches = matches[:c.maxCandidates]
+		truncated = true
+	}
+	for _, m := range matches {
+		if ctx.Err() != nil {
+			return nil, false, nil
+		}
+		startIndex := m.TargetStart
+		endIndex := m.TargetEnd
+		if c.exceedsDiffSize(endIndex-startIndex, d.size()) {
+			if c.tc.traceScoring(l) {
+				c.tc.trace("Region [%d-%d] too large to diff against %s", startIndex, endIndex, l)
 			}
+			truncated = true
+			continue
+		}
+		conf, startOffset, endOffset, cov := c.score(l, t, d, startIndex, endIndex)
+		matched := endIndex - startIndex - startOffset - endOffset
+		if matched > 0 {
+			conf = c.scoreCase(l, t, d, conf, startIndex+startOffset, endIndex-endOffset-1)
+		}
+		if c.accept(l, conf) && matched > 0 && matched >= c.minMatchTokens(LicenseName(l)) && cov.held >= c.minMatchCoverage(LicenseName(l)) {
+			candidates = append(candidates, &Match{
+				Name:            LicenseName(l),
+				Variant:         variantName(l),
+				MatchType:       detectionType(l),
+				Confidence:      conf,
+				StartLine:       t.Tokens[startIndex+startOffset].Line,
+				EndLine:         t.Tokens[endIndex-endOffset-1].Line,
+				StartTokenIndex: startIndex + startOffset,
+				EndTokenIndex:   endIndex - endOffset - 1,
+
+				KnownStartTokenIndex: cov.start,
+				KnownEndTokenIndex:   cov.end,
+				KnownTokens:          d.size(),
+				Stats:                cov.stats,
 
+				TruncationSuspected: cov.tail >= truncatedTail,
+				Warning:             c.warning(conf),
+			})
 		}
 	}
+	return candidates, truncated, nil
+}
+
+// report filters the candidate matches found in the content down to the
+// matches reported for it.
+func (c *Classifier) report(candidates, refs Matches, id *indexedDocument) Results {
 	sort.Sort(candidates)
+	// Boilerplate is matched first so that licenses sharing its language
+	// aren't reported for it.
+	candidates = suppressBoilerplate(candidates)
+	candidates, annotations := splitAnnotations(candidates)
+	candidates, warnings := splitWarnings(candidates)
+	out := collapseVariants(filterOverlaps(candidates))
+	out = append(out, outsideMatches(collapseVariants(filterOverlaps(warnings)), out)...)
+	out = append(out, dedupeAnnotations(annotations)...)
+	associateExceptions(out)
+	out = addReferences(out, refs)
+	if c.hideBoilerplate {
+		out = hideBoilerplate(out)
+	}
+	for _, m := range out {
+		if m.MatchType == "License" {
+			m.SimilarTo = c.similarTo(m.Name)
+		}
+	}
+	if c.families {
+		c.setFamilies(out)
+	}
+	c.resolveNames(out)
+	for _, m := range out {
+		m.Confidence = c.reportedConfidence(m.Confidence)
+	}
+	sort.Stable(out)
+	return Results{
+		Matches:         out,
+		TotalInputLines: id.Tokens[len(id.Tokens)-1].Line,
+	}
+}
+
+// filterOverlaps filters the candidates, sorted by confidence, down to those
+// not overlapping a better candidate.
+func filterOverlaps(candidates Matches) Matches {
 	retain := make([]bool, len(candidates))
 	for i, c := range candidates {
 		// Filter out overlapping licenses based primarily on confidence. Since
@@ -185,31 +606,84 @@ func (c *Classifier) match(in io.Reader) (Results, error) {
 			out = append(out, candidates[i])
 		}
 	}
-	return Results{
-		Matches:         out,
-		TotalInputLines: id.Tokens[len(id.Tokens)-1].Line,
-	}, nil
+	return out
 }
 
 // Classifier provides methods for identifying open source licenses in text
 // content.
 type Classifier struct {
+	// mu guards the corpus against AddLicense and RemoveLicense while
+	// matching.
+	mu sync.RWMutex
+
 	tc        *TraceConfiguration
 	dict      *dictionary
 	docs      map[string]*indexedDocument
 	threshold float64
-	q         int // The value of q for q-grams in this corpus
+	prefilter float64 // The minimum token similarity for candidate licenses
+	warn      float64 // The minimum confidence of warnings, if below threshold
+	q         int     // The value of q for q-grams in this corpus
+
+	hideBoilerplate bool
+	lowMemory       bool
+	rawConfidence   bool
+	sequential      bool
+	workers         int // The number of licenses scored concurrently, if set
+	maxTokens       int // The most tokens of content matched, if set
+	maxCandidates   int // The most regions scored against each license, if set
+	maxDiff         int // The most tokens of a region and a license diffed, if set
+	noLengthFilter  bool
+	noChecksums     bool
+	patentGrants    bool  // Report PatentGrant annotations
+	trademarks      bool  // Report Trademark annotations
+	lint            bool  // Validate the directories loaded with LoadLicenses
+	shared          bool  // The corpus is shared with other classifiers
+	ready           int32 // Set atomically once WarmUp succeeds
+
+	numbers   NumberPolicy               // The policy for numbers in added content
+	minTokens int                        // The minimum number of tokens in a match
+	minCover  float64                    // The minimum coverage of a match
+	families  bool                       // Whether to report the families of matches
+	matchText bool                       // Whether to report the text of matches
+	nameRefs  bool                       // Whether to report references by name
+	licenses  map[string]bool            // The licenses matched, or nil for all
+	metadata  map[string]LicenseMetadata // Per-license overrides, by license name
+	cased     []string                   // The case-preserved phrases of the metadata
+
+	confusability Confusability // Licenses easily confused with each other
+	resolver      NameResolver  // Maps the names of matches to reported names
+
+	// checksums maps the checksums of the License documents of the corpus to
+	// their names, for matching content holding one of them verbatim.
+	checksums map[[sha256.Size]byte][]string
+
+	index qgramIndexCache // The q-grams of the corpus, built on first use
+
+	lazy     []ContentLoader // Content added when the classifier is first used
+	lazyOnce sync.Once
+	lazyErr  error
 }
 
 // NewClassifier creates a classifier with an empty corpus.
-func NewClassifier(threshold float64) *Classifier {
+func NewClassifier(threshold float64, options ...OptionFunc) *Classifier {
 	classifier := &Classifier{
 		tc:        new(TraceConfiguration),
 		dict:      newDictionary(),
 		docs:      make(map[string]*indexedDocument),
 		threshold: threshold,
+		prefilter: threshold,
 		q:         computeQ(threshold),
 	}
+	for _, o := range options {
+		o(classifier)
+	}
+	if t := classifier.matchThreshold(); t < threshold {
+		// Warnings are searched for like matches.
+		classifier.q = computeQ(t)
+		if classifier.prefilter > t {
+			classifier.prefilter = t
+		}
+	}
 	return classifier
 }
 
@@ -233,7 +707,7 @@ func NewClassifier(threshold float64) *Classifier {
 // It is an invariant of the classifier that calling Match(Normalize(in)) will
 // return the same results as Match(in).
 func (c *Classifier) Normalize(in []byte) []byte {
-	doc, err := tokenizeStream(bytes.NewReader(in), false, c.dict, true)
+	doc, err := tokenizeStream(bytes.NewReader(in), false, c.dict, true, c.numbers)
 	if err != nil {
 		panic("should not be reachable, since bytes.NewReader().Read() should never fail")
 	}
@@ -274,13 +748,32 @@ func (c *Classifier) Normalize(in []byte) []byte {
 }
 
 // LoadLicenses adds the contents of the supplied directory to the corpus of the
-// classifier.
+// classifier. With WithCorpusLint, a directory with problems is rejected with a
+// *CorpusError and nothing of it is added.
 func (c *Classifier) LoadLicenses(dir string) error {
-	var files []string
-	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+	return c.loadLicenses(os.DirFS(dir), dir)
+}
+
+// LoadLicensesFS adds the contents of the file system, laid out like the
+// directories read by LoadLicenses, to the corpus of the classifier. It lets
+// callers load corpora that are embedded or held in memory without writing
+// them to disk.
+func (c *Classifier) LoadLicensesFS(fsys fs.FS) error {
+	return c.loadLicenses(fsys, "")
+}
+
+// loadLicenses adds the contents of the file system to the corpus. The paths
+// of its files are reported in errors below root.
+func (c *Classifier) loadLicenses(fsys fs.FS, root string) error {
+	var files, metadata []string
+	err := fs.WalkDir(fsys, ".", func(path string, d fs.DirEntry, err error) error {
 		if err != nil {
 			return nil
 		}
+		if pathpkg.Base(path) == metadataFile {
+			metadata = append(metadata, path)
+			return nil
+		}
 		if !strings.HasSuffix(path, "txt") {
 			return nil
 		}
@@ -290,26 +783,254 @@ func (c *Classifier) LoadLicenses(dir string) error {
 	if err != nil {
 		return err
 	}
-
-	for _, f := range files {
-		relativePath := strings.Replace(f, dir, "", 1)
-		sep := fmt.Sprintf("%c", os.PathSeparator)
-		segments := strings.Split(relativePath, sep)
-		if len(segments) < 3 {
-			c.tc.trace("Insufficient segment count for path: %s", relativePath)
-			continue
+	if err := c.loadConfusability(fsys, root); err != nil {
+		return err
+	}
+	// The metadata decides how the licenses are tokenized, so it's loaded
+	// first.
+	for _, path := range metadata {
+		if err := c.loadMetadata(fsys, root, path); err != nil {
+			return err
 		}
-		category, name, variant := segments[1], segments[2], segments[3]
-		b, err := ioutil.ReadFile(f)
+	}
+
+	// Tokenizing and indexing dominate the cost of loading, so each file is
+	// tokenized against its own dictionary in parallel. The dictionaries are
+	// then merged, before the searchsets are built in parallel against the
+	// merged dictionary.
+	docs := make([]*loadedDocument, len(files))
+	errs := make([]error, len(files))
+	c.parallelize(len(files), func(i int) {
+		docs[i], errs[i] = c.loadDocument(fsys, files[i])
+	})
+	for i, err := range errs {
 		if err != nil {
 			return err
 		}
+		if docs[i] == nil {
+			c.tc.trace("Insufficient segment count for path: %s", files[i])
+		}
+	}
+	if c.lint {
+		if err := c.lintCorpus(root, files, docs); err != nil {
+			return err
+		}
+	}
+
+	c.unshare()
+	if len(c.docs) == 0 {
+		c.docs = make(map[string]*indexedDocument, len(files))
+	}
+	if len(c.dict.words) == 0 {
+		largest := 0
+		for _, d := range docs {
+			if d != nil && len(d.dict.words) > largest {
+				largest = len(d.dict.words)
+			}
+		}
+		c.dict = newDictionarySize(largest)
+	}
+	// The new words of the files are added before the documents are
+	// remapped, so that the IDs of colliding words don't depend on the order
+	// of the files.
+	var words []string
+	for _, d := range docs {
+		if d == nil {
+			continue
+		}
+		for _, t := range d.doc.Tokens {
+			if w := d.dict.getWord(t.ID); c.dict.getIndex(w) == unknownIndex {
+				words = append(words, w)
+			}
+		}
+	}
+	c.dict.addAll(words)
+	for _, d := range docs {
+		if d == nil {
+			continue
+		}
+		d.doc.remap(d.dict, c.dict)
+	}
+	c.parallelize(len(docs), func(i int) {
+		if d := docs[i]; d != nil {
+			c.indexDocument(c.generateDocName(d.category, d.name, d.variant), d.doc)
+		}
+	})
+	for _, d := range docs {
+		if d == nil {
+			continue
+		}
+		name := c.generateDocName(d.category, d.name, d.variant)
+		c.docs[name] = d.doc
+		c.addChecksum(name, d.doc)
+	}
+	c.index.reset()
+	return nil
+}
+
+// loadedDocument is a corpus file tokenized against its own dictionary.
+type loadedDocument struct {
+	category, name, variant string
+	doc                     *indexedDocument
+	dict                    *dictionary
+}
+
+// loadDocument reads and tokenizes the corpus file at path in the file system
+// with the number policy for its license. It returns nil if the path doesn't
+// name a license.
+func (c *Classifier) loadDocument(fsys fs.FS, path string) (*loadedDocument, error) {
+	segments := strings.Split(path, "/")
+	if len(segments) < 3 {
+		return nil, nil
+	}
+	b, err := fs.ReadFile(fsys, path)
+	if err != nil {
+		return nil, err
+	}
+	dict := newDictionary()
+	doc := c.tokenizeLicense(segments[1], b, dict)
+	return &loadedDocument{
+		category: segments[0],
+		name:     segments[1],
+		variant:  segments[2],
+		doc:      doc,
+		dict:     dict,
+	}, nil
+}
+
+// parallelize calls f for every index in [0, n), running up to GOMAXPROCS
+// calls concurrently, and returns once all of them have completed. A
+// sequential classifier makes the calls in order on the calling goroutine.
+func (c *Classifier) parallelize(n int, f func(i int)) {
+	workers := runtime.GOMAXPROCS(0)
+	if c.sequential {
+		workers = 1
+	}
+	c.parallel(workers, n, f)
+}
+
+// matchWorkers returns the number of licenses a match scores concurrently:
+// the number set with WithWorkers, or 1 by default. Traced matches are scored
+// one license at a time, so that their traces don't interleave.
+func (c *Classifier) matchWorkers() int {
+	if c.sequential || c.tc.enabled() || c.workers < 1 {
+		return 1
+	}
+	return c.workers
+}
+
+// parallel calls f for every index in [0, n), running up to the given number
+// of calls concurrently, and returns once all of them have completed. With a
+// single worker, the calls are made in order on the calling goroutine.
+func (c *Classifier) parallel(workers, n int, f func(i int)) {
+	if workers <= 1 {
+		for i := 0; i < n; i++ {
+			f(i)
+		}
+		return
+	}
+	if workers > n {
+		workers = n
+	}
+	next := make(chan int)
+	var wg sync.WaitGroup
+	wg.Add(workers)
+	for w := 0; w < workers; w++ {
+		go func() {
+			defer wg.Done()
+			for i := range next {
+				f(i)
+			}
+		}()
+	}
+	for i := 0; i < n; i++ {
+		next <- i
+	}
+	close(next)
+	wg.Wait()
+}
 
-		c.AddContent(category, name, variant, []byte(string(b)))
+// order returns the names of the documents in the order they are matched in:
+// in name order for a sequential classifier, and in no particular order
+// otherwise.
+func (c *Classifier) order(docs map[string]*indexedDocument) []string {
+	names := make([]string, 0, len(docs))
+	for n := range docs {
+		names = append(names, n)
+	}
+	if c.sequential {
+		sort.Strings(names)
+	}
+	return names
+}
+
+// Close releases the corpus held by the classifier so the memory it pins can
+// be reclaimed, for example before loading a replacement corpus. After Close
+// the classifier has an empty corpus and reports no matches; content may be
+// added to it again. The metadata and confusability of the corpus are
+// released with it, so those of a replacement corpus must be set again
+// before it's loaded. Close must not be called concurrently with matching.
+// It always returns nil.
+func (c *Classifier) Close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.dict = newDictionary()
+	c.docs = make(map[string]*indexedDocument)
+	c.checksums = nil
+	c.metadata = nil
+	c.cased = nil
+	c.confusability = nil
+	c.index.reset()
+	c.shared = false
+	// Content added lazily after Close is loaded when the classifier is next
+	// used, and the error of loading the content released isn't reported.
+	c.lazy = nil
+	c.lazyOnce = sync.Once{}
+	c.lazyErr = nil
+	atomic.StoreInt32(&c.ready, 0)
+	return nil
+}
+
+// WarmUp prepares a loaded classifier for matching and verifies that its
+// corpus is usable by matching a license of the corpus that it reports, as
+// restricted by WithLicenses and the annotation options, against itself. Once it
+// succeeds, Ready reports true. It must not be called concurrently with
+// changes to the corpus.
+func (c *Classifier) WarmUp() error {
+	if err := c.loadLazy(); err != nil {
+		return err
+	}
+	if len(c.docs) == 0 {
+		return errors.New("the classifier has an empty corpus")
+	}
+	var names []string
+	for name, d := range c.docs {
+		if !c.lowMemory && d.s == nil {
+			return fmt.Errorf("license %s isn't indexed", name)
+		}
+		if (c.licenses == nil || c.licenses[LicenseName(name)]) && c.reported(name) {
+			names = append(names, name)
+		}
+	}
+	if len(names) == 0 {
+		return errors.New("the classifier reports none of the licenses of its corpus")
 	}
+	sort.Strings(names)
+	d := c.expand(names[0], c.docs[names[0]])
+	if res := c.Match([]byte(d.normalized())); len(res.Matches) == 0 {
+		return fmt.Errorf("license %s doesn't match itself", names[0])
+	}
+	atomic.StoreInt32(&c.ready, 1)
 	return nil
 }
 
+// Ready reports whether the classifier has been warmed up and is ready to
+// match content. It is safe to call concurrently with any other method, so it
+// can serve as a health check while the classifier is being loaded.
+func (c *Classifier) Ready() bool {
+	return atomic.LoadInt32(&c.ready) == 1
+}
+
 // SetTraceConfiguration installs a tracing configuration for the classifier.
 func (c *Classifier) SetTraceConfiguration(in *TraceConfiguration) {
 	c.tc = in
@@ -317,18 +1038,109 @@ func (c *Classifier) SetTraceConfiguration(in *TraceConfiguration) {
 }
 
 // Match finds matches within an unknown text. This will not modify the contents
-// of the supplied byte slice.
+// of the supplied byte slice. If matching a license panics, the matches of the
+// other licenses are returned; use MatchFrom to learn about the panic.
 func (c *Classifier) Match(in []byte) Results {
 	// Since bytes.NewReader().Read() will never return an error, tokenizeStream
-	// will never return an error so it's okay to ignore the return value in this
-	// case.
+	// will never return an error, so the only error is a recovered panic.
 	res, _ := c.MatchFrom(bytes.NewReader(in))
 	return res
 }
 
-// MatchFrom finds matches within the read content.
+// MatchFrom finds matches within the read content. A panic while matching a
+// license is returned as a *PanicError along with the matches of the other
+// licenses.
 func (c *Classifier) MatchFrom(in io.Reader) (Results, error) {
-	return c.match(in)
+	return c.match(context.Background(), in, nil)
+}
+
+// MatchWithContext finds matches within an unknown text like Match, but gives
+// up once the context is done, returning the context's error, so that callers
+// can bound the time spent on content that is slow to match. The context is
+// checked between the licenses the content is compared with and between the
+// regions of the content scored against each of them.
+func (c *Classifier) MatchWithContext(ctx context.Context, in []byte) (Results, error) {
+	return c.MatchFromWithContext(ctx, bytes.NewReader(in))
+}
+
+// MatchFromWithContext finds matches within the read content like MatchFrom,
+// but gives up once the context is done, returning the context's error.
+func (c *Classifier) MatchFromWithContext(ctx context.Context, in io.Reader) (Results, error) {
+	return c.match(ctx, in, nil)
+}
+
+// MatchTokens finds matches within content that has already been tokenized,
+// for pipelines that store normalized token streams, skipping the tokenizer.
+// The tokens must be normalized as the classifier does, like the lowercased
+// words of the output of Normalize, and lines holds the line of each of them.
+// Since the original text isn't available, copyright notices and references
+// to licenses aren't reported, and licenses whose metadata sets a number
+// policy are matched against the tokens as given.
+func (c *Classifier) MatchTokens(tokens []string, lines []int) (Results, error) {
+	if len(tokens) != len(lines) {
+		return Results{}, fmt.Errorf("got %d tokens but %d lines", len(tokens), len(lines))
+	}
+	return c.guardMatch(func() (Results, error) {
+		id := &indexedDocument{
+			Tokens:  make([]indexedToken, len(tokens)),
+			dict:    c.dict,
+			numbers: c.numbers,
+		}
+		for i, t := range tokens {
+			id.Tokens[i] = indexedToken{Line: lines[i], ID: c.dict.getIndex(t)}
+		}
+		id.generateFrequencies()
+		id.runes = diffWordsToRunes(id, 0, id.size())
+		id.Norm = id.normalized()
+		truncated := c.limitTokens(id)
+		target := func(NumberPolicy) *indexedDocument { return id }
+		res, err := c.matchDocument(context.Background(), id, target, nil, nil)
+		res.Truncated = res.Truncated || truncated
+		if c.matchText {
+			for _, m := range res.Matches {
+				if m.StartTokenIndex <= m.EndTokenIndex && m.EndTokenIndex < len(tokens) {
+					m.Text = strings.Join(tokens[m.StartTokenIndex:m.EndTokenIndex+1], " ")
+				}
+			}
+		}
+		return res, err
+	})
+}
+
+// MatchHeaders finds the license headers of the named license families within
+// an unknown text, such as the Apache-2.0 header a project requires in all of
+// its files. Only the header variants of those licenses are considered, which
+// makes it much cheaper than Match. A family names a license, such as
+// "Apache-2.0", along with its related licenses named with it as a prefix,
+// such as "Apache-2.0-Modified"; if no families are given, the headers of all
+// licenses are considered. This will not modify the contents of the supplied
+// byte slice.
+func (c *Classifier) MatchHeaders(in []byte, families ...string) Matches {
+	include := func(name string) bool {
+		if detectionType(name) != "Header" {
+			return false
+		}
+		if len(families) == 0 {
+			return true
+		}
+		l := LicenseName(name)
+		for _, f := range families {
+			if l == f || strings.HasPrefix(l, f+"-") {
+				return true
+			}
+		}
+		return false
+	}
+	// Since bytes.NewReader().Read() will never return an error, match will
+	// only fail if the lazily loaded content does.
+	res, _ := c.match(context.Background(), bytes.NewReader(in), include)
+	var out Matches
+	for _, m := range res.Matches {
+		if m.MatchType == "Header" {
+			out = append(out, m)
+		}
+	}
+	return out
 }
 
 func detectionType(in string) string {
@@ -16,18 +16,23 @@ package classifier
 
 import (
 	"bytes"
+	"context"
 	"errors"
+	"flag"
+	"fmt"
+	"io/fs"
 	"io/ioutil"
 	"log"
 	"os"
 	"path"
 	"path/filepath"
-	"sort"
+	"runtime"
 	"strings"
 	"testing"
+	"testing/fstest"
 	"testing/iotest"
+	"time"
 
-	"github.com/davecgh/go-spew/spew"
 	"github.com/google/go-cmp/cmp"
 )
 
@@ -51,6 +56,11 @@ func getScenarioFilenames() ([]string, error) {
 		if err != nil {
 			return err
 		}
+		// The annotation scenarios expect matches that are only reported
+		// with options, so they're run by TestAnnotationScenarios.
+		if info.IsDir() && info.Name() == "annotations" {
+			return filepath.SkipDir
+		}
 		if strings.HasSuffix(path, "md") || info.IsDir() {
 			return nil
 		}
@@ -61,22 +71,26 @@ func getScenarioFilenames() ([]string, error) {
 	return files, err
 }
 
-func TestMatchScenarios(t *testing.T) {
-	c, err := classifier()
+// TestNegativeScenarios ensures the scenarios that guard against false
+// positives keep expecting no matches at all.
+func TestNegativeScenarios(t *testing.T) {
+	files, err := filepath.Glob(path.Join("scenarios", "negative", "*"))
 	if err != nil {
-		t.Fatalf("couldn't instantiate standard test classifier: %v", err)
-	}
-
-	files, err := getScenarioFilenames()
-	if err != nil {
-		t.Fatalf("encountered error walking scenarios directory: %v", err)
+		t.Fatalf("encountered error listing negative scenarios: %v", err)
 	}
 
+	n := 0
 	for _, f := range files {
-		s := readScenario(f)
-
-		m := c.Match(s.data)
-		checkMatches(t, m.Matches, f, s.expected)
+		if strings.HasSuffix(f, "md") {
+			continue
+		}
+		n++
+		if s := readScenario(f); len(s.expected) != 0 {
+			t.Errorf("negative scenario %q expects %v, want no matches", f, s.expected)
+		}
+	}
+	if n == 0 {
+		t.Error("found no negative scenarios")
 	}
 }
 
@@ -223,49 +237,93 @@ func TestMatchFrom(t *testing.T) {
 	if !errors.Is(err, iotest.ErrTimeout) {
 		t.Errorf("got %v want %v", err, iotest.ErrTimeout)
 	}
+}
 
-	files, err := getScenarioFilenames()
-
+func TestMatchWithContext(t *testing.T) {
+	c, err := classifier()
 	if err != nil {
-		t.Fatalf("encountered error walking scenarios directory: %v", err)
+		t.Fatalf("couldn't instantiate standard Google classifier: %v", err)
 	}
-
-	for _, f := range files {
-		s := readScenario(f)
-		r := bytes.NewReader(s.data)
-		m, err := c.MatchFrom(r)
-		if err != nil {
-			t.Errorf("unexpected error: %v", err)
-		}
-		checkMatches(t, m.Matches, f, s.expected)
+	in, err := ioutil.ReadFile(path.Join(baseLicenses, "License", "Apache-2.0", "pristine.txt"))
+	if err != nil {
+		t.Fatalf("couldn't read Apache-2.0 license: %v", err)
 	}
-}
 
-// checkMatches diffs the resulting matches against the expected content and
-// sets test results.
-func checkMatches(t *testing.T, m Matches, f string, e []string) {
-	found := make(map[string]bool)
-	// Uniquify the licenses found
-	for _, l := range m {
-		found[l.Name] = true
+	res, err := c.MatchWithContext(context.Background(), in)
+	if err != nil {
+		t.Fatalf("MatchWithContext() = %v", err)
+	}
+	if diff := cmp.Diff(c.Match(in), res); diff != "" {
+		t.Errorf("MatchWithContext() mismatch with Match() (-want +got):\n%s", diff)
 	}
 
-	var names []string
-	for l := range found {
-		names = append(names, l)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := c.MatchFromWithContext(ctx, bytes.NewReader(in)); !errors.Is(err, context.Canceled) {
+		t.Errorf("MatchFromWithContext() with canceled context = %v, want %v", err, context.Canceled)
 	}
-	sort.Strings(names)
 
-	if len(names) != len(e) {
-		t.Errorf("Match(%q) number matches: %v, want %v: %v", f, len(names), len(e), spew.Sdump(m))
-		return
+	ctx, cancel = context.WithTimeout(context.Background(), time.Nanosecond)
+	defer cancel()
+	<-ctx.Done()
+	if _, err := c.MatchWithContext(ctx, in); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("MatchWithContext() past deadline = %v, want %v", err, context.DeadlineExceeded)
 	}
+}
 
-	for i := 0; i < len(names); i++ {
-		w := strings.TrimSpace(e[i])
-		if got, want := names[i], w; got != want {
-			t.Errorf("Match(%q) = %q, want %q", f, got, want)
+func TestMatchHeaders(t *testing.T) {
+	c := NewClassifier(defaultThreshold)
+	for _, f := range []string{"Header/Apache-2.0/header.txt", "Header/GPL-2.0/header.txt", "License/Apache-2.0/pristine.txt"} {
+		b, err := ioutil.ReadFile(path.Join(baseLicenses, f))
+		if err != nil {
+			t.Fatalf("couldn't read %s: %v", f, err)
 		}
+		segments := strings.Split(f, "/")
+		c.AddContent(segments[0], segments[1], segments[2], b)
+	}
+	in, err := ioutil.ReadFile(path.Join(baseLicenses, "Header/Apache-2.0/header.txt"))
+	if err != nil {
+		t.Fatalf("couldn't read Apache-2.0 header: %v", err)
+	}
+	in = append([]byte("Copyright 2022 Google Inc.\n\n"), in...)
+
+	tests := []struct {
+		name     string
+		families []string
+		want     int
+	}{
+		{
+			name: "all families",
+			want: 1,
+		},
+		{
+			name:     "named family",
+			families: []string{"Apache-2.0"},
+			want:     1,
+		},
+		{
+			name:     "family prefix",
+			families: []string{"Apache"},
+			want:     1,
+		},
+		{
+			name:     "other family",
+			families: []string{"GPL-2.0"},
+			want:     0,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := c.MatchHeaders(in, tt.families...)
+			if len(got) != tt.want {
+				t.Fatalf("MatchHeaders() = %v, want %d matches", got, tt.want)
+			}
+			for _, m := range got {
+				if m.MatchType != "Header" || m.Name != "Apache-2.0" {
+					t.Errorf("MatchHeaders() returned %s %s, want the Apache-2.0 header", m.MatchType, m.Name)
+				}
+			}
+		})
 	}
 }
 
@@ -363,3 +421,368 @@ ball football`,
 	}
 
 }
+
+func TestLoadLicensesDictionary(t *testing.T) {
+	// Loading in parallel must assign the same token IDs as adding the
+	// files one at a time, once the words of the files have been added in
+	// sorted order.
+	want := NewClassifier(defaultThreshold)
+	fsys := os.DirFS(baseLicenses)
+	metadata, err := fs.Glob(fsys, path.Join("*", "*", metadataFile))
+	if err != nil {
+		t.Fatalf("couldn't find license metadata: %v", err)
+	}
+	for _, p := range metadata {
+		if err := want.loadMetadata(fsys, baseLicenses, p); err != nil {
+			t.Fatalf("couldn't read license metadata: %v", err)
+		}
+	}
+	var files [][]string
+	var contents [][]byte
+	err = filepath.Walk(baseLicenses, func(p string, info os.FileInfo, err error) error {
+		if err != nil || !strings.HasSuffix(p, "txt") {
+			return err
+		}
+		segments := strings.Split(p, string(os.PathSeparator))
+		if len(segments) < 4 {
+			return nil
+		}
+		b, err := ioutil.ReadFile(p)
+		if err != nil {
+			return err
+		}
+		files = append(files, segments[1:4])
+		contents = append(contents, b)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("couldn't read licenses: %v", err)
+	}
+	var words []string
+	for i, f := range files {
+		d := newDictionary()
+		want.tokenizeLicense(f[1], contents[i], d)
+		for _, w := range d.words {
+			words = append(words, w)
+		}
+	}
+	want.dict.addAll(words)
+	for i, f := range files {
+		want.AddContent(f[0], f[1], f[2], contents[i])
+	}
+
+	got := NewClassifier(defaultThreshold)
+	if err := got.LoadLicenses(baseLicenses); err != nil {
+		t.Fatalf("LoadLicenses() = %v", err)
+	}
+	if diff := cmp.Diff(want.dict.words, got.dict.words); diff != "" {
+		t.Errorf("LoadLicenses() dictionary mismatch (-want +got):\n%s", diff)
+	}
+	if len(got.docs) != len(want.docs) {
+		t.Fatalf("LoadLicenses() loaded %d documents, want %d", len(got.docs), len(want.docs))
+	}
+	for name, w := range want.docs {
+		g, ok := got.docs[name]
+		if !ok {
+			t.Errorf("LoadLicenses() didn't load %s", name)
+			continue
+		}
+		if diff := cmp.Diff(w.Tokens, g.Tokens); diff != "" {
+			t.Errorf("LoadLicenses() tokens of %s mismatch (-want +got):\n%s", name, diff)
+		}
+	}
+}
+
+func TestLoadLicensesFS(t *testing.T) {
+	mit, err := ioutil.ReadFile(path.Join(baseLicenses, "License", "MIT", "pristine.txt"))
+	if err != nil {
+		t.Fatalf("couldn't read MIT license: %v", err)
+	}
+	fsys := fstest.MapFS{
+		"License/MIT/pristine.txt":  {Data: mit},
+		"License/MIT/metadata.json": {Data: []byte(`{"family": "Permissive"}`)},
+		"README.txt":                {Data: []byte("Not a license.")},
+	}
+
+	c := NewClassifier(defaultThreshold)
+	if err := c.LoadLicensesFS(fsys); err != nil {
+		t.Fatalf("LoadLicensesFS() = %v", err)
+	}
+	if got, want := len(c.docs), 1; got != want {
+		t.Fatalf("LoadLicensesFS() loaded %d documents, want %d", got, want)
+	}
+	if got, want := c.Family("MIT"), "Permissive"; got != want {
+		t.Errorf("LoadLicensesFS() family of MIT = %q, want %q", got, want)
+	}
+	results := c.Match(mit)
+	if len(results.Matches) != 1 || results.Matches[0].Name != "MIT" {
+		t.Errorf("Match() = %v, want a match of MIT", results.Matches)
+	}
+
+	fsys["License/MIT/metadata.json"] = &fstest.MapFile{Data: []byte("{")}
+	err = NewClassifier(defaultThreshold).LoadLicensesFS(fsys)
+	if err == nil || !strings.Contains(err.Error(), "License/MIT/metadata.json") {
+		t.Errorf("LoadLicensesFS() = %v, want an error naming the metadata file", err)
+	}
+}
+
+func BenchmarkLoadLicenses(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		c := NewClassifier(defaultThreshold)
+		if err := c.LoadLicenses(baseLicenses); err != nil {
+			b.Fatalf("LoadLicenses() = %v", err)
+		}
+	}
+}
+
+func TestMatchTokens(t *testing.T) {
+	c, err := classifier()
+	if err != nil {
+		t.Fatalf("couldn't instantiate standard test classifier: %v", err)
+	}
+	in, err := ioutil.ReadFile(path.Join(baseLicenses, "License", "Apache-2.0", "pristine.txt"))
+	if err != nil {
+		t.Fatalf("couldn't read Apache-2.0 license: %v", err)
+	}
+	var tokens []string
+	var lines []int
+	for i, l := range strings.Split(strings.ToLower(string(c.Normalize(in))), "\n") {
+		for _, w := range strings.Fields(l) {
+			tokens = append(tokens, w)
+			lines = append(lines, i+1)
+		}
+	}
+
+	res, err := c.MatchTokens(tokens, lines)
+	if err != nil {
+		t.Fatalf("MatchTokens() = %v", err)
+	}
+	license := func(ms Matches) *Match {
+		for _, m := range ms {
+			if m.MatchType == "License" {
+				return m
+			}
+		}
+		return nil
+	}
+	got, want := license(res.Matches), license(c.Match(in).Matches)
+	if got == nil || want == nil || got.Name != want.Name || got.Confidence != want.Confidence {
+		t.Errorf("MatchTokens() = %v, want %v", got, want)
+	}
+
+	if _, err := c.MatchTokens(tokens, lines[1:]); err == nil {
+		t.Error("MatchTokens() with fewer lines than tokens succeeded, want error")
+	}
+}
+
+func TestClose(t *testing.T) {
+	c, err := classifier()
+	if err != nil {
+		t.Fatalf("couldn't instantiate standard test classifier: %v", err)
+	}
+	in, err := ioutil.ReadFile(path.Join(baseLicenses, "License", "MIT", "pristine.txt"))
+	if err != nil {
+		t.Fatalf("couldn't read MIT license: %v", err)
+	}
+	if got := c.Match(in).Matches; len(got) == 0 {
+		t.Fatal("Match() found no matches before Close()")
+	}
+	c.SetMetadata("MIT", LicenseMetadata{CasePreserved: []string{"MIT"}})
+	c.SetConfusability(Confusability{"License/MIT/pristine.txt": {{Name: "License/MIT-0/license.txt", Similarity: 0.9}}})
+
+	if err := c.Close(); err != nil {
+		t.Fatalf("Close() = %v", err)
+	}
+	if got := c.Match(in).Matches; len(got) != 0 {
+		t.Errorf("Match() after Close() = %v, want no matches", got)
+	}
+	// The state derived from the corpus is released with it.
+	if c.metadata != nil || c.cased != nil || c.confusability != nil {
+		t.Errorf("Close() kept metadata %v, case-preserved phrases %v and confusability %v", c.metadata, c.cased, c.confusability)
+	}
+
+	// A closed classifier can be reloaded.
+	c.AddContent("License", "MIT", "pristine.txt", in)
+	if got := c.Match(in).Matches; len(got) != 1 {
+		t.Errorf("Match() after reload = %v, want 1 match", got)
+	}
+}
+
+func TestQuantizeConfidence(t *testing.T) {
+	tests := []struct {
+		in, want float64
+	}{
+		{in: 1.0, want: 1.0},
+		{in: 0.9999, want: 0.999},
+		{in: 0.29, want: 0.29},
+		{in: 0.8, want: 0.8},
+		{in: 0.85349, want: 0.853},
+	}
+	for _, tt := range tests {
+		if got := quantizeConfidence(tt.in); got != tt.want {
+			t.Errorf("quantizeConfidence(%v) = %v, want %v", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestWarmUp(t *testing.T) {
+	c := NewClassifier(defaultThreshold)
+	if c.Ready() {
+		t.Error("Ready() = true for an empty classifier")
+	}
+	if err := c.WarmUp(); err == nil {
+		t.Error("WarmUp() of an empty classifier succeeded, want error")
+	}
+
+	in, err := ioutil.ReadFile(path.Join(baseLicenses, "License", "MIT", "pristine.txt"))
+	if err != nil {
+		t.Fatalf("couldn't read MIT license: %v", err)
+	}
+	for _, lowMemory := range []bool{false, true} {
+		var options []OptionFunc
+		if lowMemory {
+			options = append(options, WithLowMemory())
+		}
+		c := NewClassifier(defaultThreshold, options...)
+		c.AddContent("License", "MIT", "pristine.txt", in)
+		if c.Ready() {
+			t.Errorf("Ready() = true before WarmUp() with low memory %v", lowMemory)
+		}
+		if err := c.WarmUp(); err != nil {
+			t.Errorf("WarmUp() = %v with low memory %v", err, lowMemory)
+		}
+		if !c.Ready() {
+			t.Errorf("Ready() = false after WarmUp() with low memory %v", lowMemory)
+		}
+		c.Close()
+		if c.Ready() {
+			t.Errorf("Ready() = true after Close() with low memory %v", lowMemory)
+		}
+	}
+}
+
+// TestWarmUpWithLicenses verifies that WarmUp matches a license the
+// classifier reports rather than the first of the corpus.
+func TestWarmUpWithLicenses(t *testing.T) {
+	c := NewClassifier(defaultThreshold, WithLicenses("MIT"))
+	if err := c.LoadLicenses(baseLicenses); err != nil {
+		t.Fatalf("couldn't load licenses: %v", err)
+	}
+	if err := c.WarmUp(); err != nil {
+		t.Errorf("WarmUp() = %v", err)
+	}
+	if !c.Ready() {
+		t.Error("Ready() = false after WarmUp()")
+	}
+
+	c = NewClassifier(defaultThreshold, WithLicenses("NoSuchLicense"))
+	in := []byte("This software is released into the public domain by its authors.")
+	c.AddContent("License", "Short", "license.txt", in)
+	if err := c.WarmUp(); err == nil {
+		t.Error("WarmUp() succeeded for a classifier reporting none of its licenses, want error")
+	}
+}
+
+// TestCloseReloadSoak verifies that repeatedly loading and closing a corpus
+// doesn't retain the memory of earlier corpora.
+func TestCloseReloadSoak(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping soak test in short mode")
+	}
+
+	heap := func() uint64 {
+		var m runtime.MemStats
+		runtime.GC()
+		runtime.ReadMemStats(&m)
+		return m.HeapAlloc
+	}
+
+	c := NewClassifier(defaultThreshold)
+	var baseline, loaded uint64
+	const cycles = 3
+	for i := 0; i < cycles; i++ {
+		if err := c.LoadLicenses(baseLicenses); err != nil {
+			t.Fatalf("LoadLicenses() = %v", err)
+		}
+		loaded = heap()
+		c.Close()
+		if i == 0 {
+			baseline = heap()
+		}
+	}
+	closed := heap()
+
+	corpus := int64(loaded) - int64(baseline)
+	if corpus <= 0 {
+		t.Fatalf("loading the corpus didn't increase the heap: loaded %d, baseline %d", loaded, baseline)
+	}
+	if growth := int64(closed) - int64(baseline); growth > corpus/10 {
+		t.Errorf("heap grew by %d bytes over %d reload cycles of a %d byte corpus", growth, cycles, corpus)
+	}
+	runtime.KeepAlive(c)
+}
+
+var (
+	corpusCeiling = flag.Float64("corpus_ceiling", 0.95, "confidence above which an asset of the corpus must not match a different license in TestCorpusSelfMatch")
+	corpusReport  = flag.String("corpus_report", "", "file to write the matches of every asset of the corpus found by TestCorpusSelfMatch to, as tab-separated values")
+)
+
+// TestCorpusSelfMatch checks that every asset of the corpus matches itself
+// with full confidence, and doesn't match a different license above
+// --corpus_ceiling, so that a newly added license or variant can't shadow
+// the others.
+func TestCorpusSelfMatch(t *testing.T) {
+	if testing.Short() {
+		t.Skip("matching the whole corpus against itself is slow")
+	}
+	c := NewClassifier(defaultThreshold, WithPatentGrants(), WithTrademarks())
+	if err := c.LoadLicenses(baseLicenses); err != nil {
+		t.Fatalf("couldn't instantiate test classifier: %v", err)
+	}
+
+	var report bytes.Buffer
+	report.WriteString("asset\tmatch_type\tname\tvariant\tconfidence\n")
+	err := filepath.Walk(baseLicenses, func(path string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() || filepath.Ext(path) != ".txt" {
+			return err
+		}
+		rel, err := filepath.Rel(baseLicenses, path)
+		if err != nil {
+			return err
+		}
+		// Assets are laid out as category/name/variant.
+		parts := strings.Split(filepath.ToSlash(rel), "/")
+		if len(parts) != 3 {
+			return nil
+		}
+		category, name := parts[0], parts[1]
+
+		b, err := ioutil.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		var self bool
+		for _, m := range c.Match(b).Matches {
+			fmt.Fprintf(&report, "%s\t%s\t%s\t%s\t%v\n", rel, m.MatchType, m.Name, m.Variant, m.Confidence)
+			switch {
+			case m.Name == name && m.MatchType == category:
+				self = self || m.Confidence == 1.0
+			case m.Name != name && m.MatchType == "License" && m.Confidence > *corpusCeiling:
+				t.Errorf("%s matched the different license %s (%s) with confidence %v, above the ceiling of %v", rel, m.Name, m.Variant, m.Confidence, *corpusCeiling)
+			}
+		}
+		if !self {
+			t.Errorf("%s didn't match itself with full confidence", rel)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("couldn't read the corpus: %v", err)
+	}
+
+	if *corpusReport != "" {
+		if err := ioutil.WriteFile(*corpusReport, report.Bytes(), 0644); err != nil {
+			t.Fatalf("couldn't write the report: %v", err)
+		}
+	}
+}
@@ -0,0 +1,339 @@
+// Copyright 2022 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package classifiertest runs scenarios against a license classifier, so that
+// the accuracy of a classifier and its corpus can be regression-tested. A
+// scenario is a piece of content paired with the names of the licenses
+// expected to be found in it.
+//
+// A scenario file, like those of the scenarios directory of this module,
+// holds any number of description lines followed by a line of the form
+//
+//	EXPECTED:A,B,C
+//
+// listing the licenses expected in the content that follows it, or nothing if
+// no license is expected.
+//
+// Scenarios run in parallel and can be split into shards, and each run
+// produces a Report that can be written as JSON and used as the baseline of
+// later runs to spot changes in the confidence of the matches.
+package classifiertest
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/fs"
+	"io/ioutil"
+	"math"
+	"os"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	classifier "github.com/google/licenseclassifier/v2"
+)
+
+// expectedMarker starts the line of a scenario file listing the licenses
+// expected in it.
+const expectedMarker = "EXPECTED:"
+
+// Scenario is content paired with the licenses expected to be found in it.
+type Scenario struct {
+	// Name identifies the scenario in results, such as the path of its
+	// file.
+	Name string
+	// Expected holds the sorted names of the licenses expected in Data.
+	Expected []string
+	Data     []byte
+}
+
+// ParseScenario parses the contents of a scenario file as the named scenario.
+func ParseScenario(name string, b []byte) (*Scenario, error) {
+	parts := strings.SplitN(string(b), expectedMarker, 2)
+	if len(parts) != 2 {
+		return nil, fmt.Errorf("scenario %s has no %s line", name, expectedMarker)
+	}
+	// The description before the marker is ignored; the list of licenses
+	// runs to the end of its line and the content follows it.
+	parts = strings.SplitN(parts[1], "\n", 2)
+	s := &Scenario{Name: name, Expected: []string{}}
+	for _, e := range strings.Split(parts[0], ",") {
+		if e = strings.TrimSpace(e); e != "" {
+			s.Expected = append(s.Expected, e)
+		}
+	}
+	sort.Strings(s.Expected)
+	if len(parts) == 2 {
+		s.Data = []byte(parts[1])
+	}
+	return s, nil
+}
+
+// ReadScenarios reads all the scenario files of the file system, sorted by
+// their paths, which name them. Markdown files, which document the scenarios,
+// are skipped.
+func ReadScenarios(fsys fs.FS) ([]*Scenario, error) {
+	var scenarios []*Scenario
+	err := fs.WalkDir(fsys, ".", func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() || strings.HasSuffix(path, ".md") {
+			return nil
+		}
+		b, err := fs.ReadFile(fsys, path)
+		if err != nil {
+			return err
+		}
+		s, err := ParseScenario(path, b)
+		if err != nil {
+			return err
+		}
+		scenarios = append(scenarios, s)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return scenarios, nil
+}
+
+// Runner runs scenarios against a classifier.
+type Runner struct {
+	Classifier *classifier.Classifier
+	// Workers is the number of scenarios Run matches concurrently. If it
+	// isn't positive, GOMAXPROCS scenarios are.
+	Workers int
+	// Shards is the number of shards the scenarios are split into, and
+	// Shard the zero-based index of the shard to run. With fewer than two
+	// shards, all the scenarios are run.
+	Shard, Shards int
+	// Baseline is the report of an earlier run that the confidences of the
+	// matches are compared with, if any.
+	Baseline *Report
+	// MaxDrift is the most the confidence of a license found in a scenario
+	// may drift from Baseline before the scenario fails. If it's zero,
+	// drift is reported but doesn't fail scenarios.
+	MaxDrift float64
+}
+
+// Result is the outcome of a scenario.
+type Result struct {
+	Name string `json:"name"`
+	// Pass reports whether exactly the expected licenses were found, and
+	// their confidences didn't drift more than allowed.
+	Pass     bool     `json:"pass"`
+	Expected []string `json:"expected"`
+	// Found holds the sorted names of the licenses found.
+	Found []string `json:"found"`
+	// Confidence holds the highest confidence each license was found with.
+	Confidence map[string]float64 `json:"confidence,omitempty"`
+	// Drift holds the change in the confidence of each license since the
+	// baseline, for the licenses whose confidence changed.
+	Drift map[string]float64 `json:"drift,omitempty"`
+}
+
+// Report is the outcome of a run, in a form suited to be written as JSON for
+// other tools.
+type Report struct {
+	Passed int `json:"passed"`
+	Failed int `json:"failed"`
+	// Results holds the results of the scenarios run, in the order of the
+	// scenarios.
+	Results []*Result `json:"results"`
+}
+
+// ReadReport reads a report written by Report.WriteJSON.
+func ReadReport(r io.Reader) (*Report, error) {
+	var report Report
+	if err := json.NewDecoder(r).Decode(&report); err != nil {
+		return nil, fmt.Errorf("invalid scenario report: %w", err)
+	}
+	return &report, nil
+}
+
+// WriteJSON writes the report as indented JSON.
+func (r *Report) WriteJSON(w io.Writer) error {
+	b, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(append(b, '\n'))
+	return err
+}
+
+// ShardFromEnv returns the shard of the scenarios to run as set by the test
+// runner in the TEST_SHARD_INDEX and TEST_TOTAL_SHARDS environment variables,
+// as Bazel does, or 0 and 0 if they aren't set. It acknowledges the sharding
+// by touching the file named by TEST_SHARD_STATUS_FILE, if any.
+func ShardFromEnv() (shard, shards int) {
+	shards, err := strconv.Atoi(os.Getenv("TEST_TOTAL_SHARDS"))
+	if err != nil || shards < 2 {
+		return 0, 0
+	}
+	shard, err = strconv.Atoi(os.Getenv("TEST_SHARD_INDEX"))
+	if err != nil || shard < 0 || shard >= shards {
+		return 0, 0
+	}
+	if status := os.Getenv("TEST_SHARD_STATUS_FILE"); status != "" {
+		ioutil.WriteFile(status, nil, 0644)
+	}
+	return shard, shards
+}
+
+// shard returns the scenarios of the shard of the runner.
+func (r *Runner) shard(scenarios []*Scenario) []*Scenario {
+	if r.Shards < 2 {
+		return scenarios
+	}
+	var out []*Scenario
+	for i, s := range scenarios {
+		if i%r.Shards == r.Shard {
+			out = append(out, s)
+		}
+	}
+	return out
+}
+
+// baseline returns the results of the baseline by scenario name.
+func (r *Runner) baseline() map[string]*Result {
+	if r.Baseline == nil {
+		return nil
+	}
+	out := make(map[string]*Result)
+	for _, res := range r.Baseline.Results {
+		out[res.Name] = res
+	}
+	return out
+}
+
+// Run matches the scenarios of the shard of the runner, Workers at a time,
+// and reports the results.
+func (r *Runner) Run(scenarios []*Scenario) *Report {
+	scenarios = r.shard(scenarios)
+	baseline := r.baseline()
+	results := make([]*Result, len(scenarios))
+
+	workers := r.Workers
+	if workers <= 0 {
+		workers = runtime.GOMAXPROCS(0)
+	}
+	next := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range next {
+				results[i] = r.result(scenarios[i], baseline[scenarios[i].Name])
+			}
+		}()
+	}
+	for i := range scenarios {
+		next <- i
+	}
+	close(next)
+	wg.Wait()
+
+	report := &Report{Results: results}
+	for _, res := range results {
+		if res.Pass {
+			report.Passed++
+		} else {
+			report.Failed++
+		}
+	}
+	return report
+}
+
+// Test runs each scenario of the shard of the runner as a parallel subtest of
+// t, which fails if the scenario does. Workers is ignored; the -parallel flag
+// of the test decides how many scenarios are matched at once.
+func (r *Runner) Test(t *testing.T, scenarios []*Scenario) {
+	baseline := r.baseline()
+	for _, s := range r.shard(scenarios) {
+		s := s
+		t.Run(s.Name, func(t *testing.T) {
+			t.Parallel()
+			res := r.result(s, baseline[s.Name])
+			if res.Pass {
+				return
+			}
+			if !equal(res.Found, res.Expected) {
+				t.Errorf("Match(%q) found %v, want %v", s.Name, res.Found, res.Expected)
+			}
+			for name, d := range res.Drift {
+				if r.MaxDrift > 0 && math.Abs(d) > r.MaxDrift {
+					t.Errorf("Match(%q) confidence of %s drifted by %v, want at most %v", s.Name, name, d, r.MaxDrift)
+				}
+			}
+		})
+	}
+}
+
+// result matches the scenario and compares the matches with its expectations
+// and the result of the scenario in the baseline, if any.
+func (r *Runner) result(s *Scenario, baseline *Result) *Result {
+	res := &Result{
+		Name:       s.Name,
+		Expected:   s.Expected,
+		Found:      []string{},
+		Confidence: make(map[string]float64),
+	}
+	for _, m := range r.Classifier.Match(s.Data).Matches {
+		if c, ok := res.Confidence[m.Name]; !ok || m.Confidence > c {
+			res.Confidence[m.Name] = m.Confidence
+		}
+	}
+	for name := range res.Confidence {
+		res.Found = append(res.Found, name)
+	}
+	sort.Strings(res.Found)
+	res.Pass = equal(res.Found, res.Expected)
+
+	if baseline == nil {
+		return res
+	}
+	for name, c := range res.Confidence {
+		before, ok := baseline.Confidence[name]
+		if !ok || c == before {
+			continue
+		}
+		if res.Drift == nil {
+			res.Drift = make(map[string]float64)
+		}
+		d := c - before
+		res.Drift[name] = d
+		if r.MaxDrift > 0 && math.Abs(d) > r.MaxDrift {
+			res.Pass = false
+		}
+	}
+	return res
+}
+
+func equal(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,135 @@
+// Copyright 2022 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package classifiertest
+
+import (
+	"bytes"
+	"io/ioutil"
+	"testing"
+	"testing/fstest"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	classifier "github.com/google/licenseclassifier/v2"
+)
+
+func newClassifier(t *testing.T) *classifier.Classifier {
+	t.Helper()
+	c := classifier.NewClassifier(.8)
+	if err := c.LoadLicenses("../assets"); err != nil {
+		t.Fatalf("couldn't load licenses: %v", err)
+	}
+	return c
+}
+
+func TestParseScenario(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		want    *Scenario
+		wantErr bool
+	}{
+		{
+			name:  "licenses",
+			input: "A description.\nEXPECTED:MIT, Copyright\nSome content\n",
+			want:  &Scenario{Name: "licenses", Expected: []string{"Copyright", "MIT"}, Data: []byte("Some content\n")},
+		},
+		{
+			name:  "no licenses",
+			input: "EXPECTED:\nSome content",
+			want:  &Scenario{Name: "no licenses", Expected: []string{}, Data: []byte("Some content")},
+		},
+		{
+			name:    "no marker",
+			input:   "Some content",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseScenario(tt.name, []byte(tt.input))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseScenario() error = %v, want error %v", err, tt.wantErr)
+			}
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("ParseScenario() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestRun(t *testing.T) {
+	mit, err := ioutil.ReadFile("../assets/License/MIT/pristine.txt")
+	if err != nil {
+		t.Fatalf("couldn't read MIT license: %v", err)
+	}
+	fsys := fstest.MapFS{
+		"mit":   {Data: append([]byte("EXPECTED:MIT\n"), mit...)},
+		"none":  {Data: []byte("EXPECTED:\nNothing to see here.")},
+		"wrong": {Data: append([]byte("EXPECTED:Apache-2.0\n"), mit...)},
+	}
+	scenarios, err := ReadScenarios(fsys)
+	if err != nil {
+		t.Fatalf("ReadScenarios() = %v", err)
+	}
+	r := &Runner{Classifier: newClassifier(t), Workers: 2}
+
+	report := r.Run(scenarios)
+	if report.Passed != 2 || report.Failed != 1 {
+		t.Errorf("Run() passed %d and failed %d, want 2 and 1", report.Passed, report.Failed)
+	}
+	for _, res := range report.Results {
+		if got, want := res.Pass, res.Name != "wrong"; got != want {
+			t.Errorf("Run() scenario %s passed %v, want %v", res.Name, got, want)
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := report.WriteJSON(&buf); err != nil {
+		t.Fatalf("WriteJSON() = %v", err)
+	}
+	baseline, err := ReadReport(&buf)
+	if err != nil {
+		t.Fatalf("ReadReport() = %v", err)
+	}
+	if diff := cmp.Diff(report, baseline, cmpopts.EquateEmpty()); diff != "" {
+		t.Errorf("ReadReport() mismatch (-want +got):\n%s", diff)
+	}
+
+	// A baseline that found MIT with less confidence makes the scenario
+	// drift, and fail once the drift exceeds MaxDrift.
+	baseline.Results[0].Confidence["MIT"] = 0.9
+	r.Baseline = baseline
+	res := r.Run(scenarios).Results[0]
+	if !res.Pass || res.Drift["MIT"] <= 0 {
+		t.Errorf("Run() with baseline = %+v, want a passing scenario with drift", res)
+	}
+	r.MaxDrift = 0.05
+	if res := r.Run(scenarios).Results[0]; res.Pass {
+		t.Errorf("Run() with MaxDrift = %+v, want a failing scenario", res)
+	}
+
+	// The shards hold every scenario once.
+	var names []string
+	for shard := 0; shard < 2; shard++ {
+		r := &Runner{Classifier: r.Classifier, Shard: shard, Shards: 2}
+		for _, res := range r.Run(scenarios).Results {
+			names = append(names, res.Name)
+		}
+	}
+	if diff := cmp.Diff([]string{"mit", "wrong", "none"}, names); diff != "" {
+		t.Errorf("Run() of shards mismatch (-want +got):\n%s", diff)
+	}
+}
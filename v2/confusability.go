@@ -0,0 +1,175 @@
+// Copyright 2022 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package classifier
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	pathpkg "path"
+	"path/filepath"
+	"sort"
+)
+
+// confusabilityFile is the name of the file holding the confusability of the
+// licenses in a directory loaded by LoadLicenses, at the top of the directory.
+const confusabilityFile = "confusability.json"
+
+// SimilarLicense is a license whose text is similar to that of another.
+type SimilarLicense struct {
+	Name       string  `json:"name"`
+	Similarity float64 `json:"similarity"`
+}
+
+// Confusability maps the names of licenses to the licenses their texts are
+// easily confused with, most similar first.
+type Confusability map[string][]SimilarLicense
+
+// ComputeConfusability compares the texts of every pair of licenses in the
+// corpus and returns the pairs that are at least threshold similar, where the
+// similarity of two licenses is that of their most similar variants. It
+// compares the full texts of the licenses, so it's expensive and meant to be
+// run when the corpus is built rather than at runtime; the result can be
+// shipped with the corpus as confusability.json.
+func (c *Classifier) ComputeConfusability(threshold float64) Confusability {
+	var names []string
+	for n := range c.docs {
+		if detectionType(n) == "License" {
+			names = append(names, n)
+		}
+	}
+	sort.Strings(names)
+
+	similarity := make(map[[2]string]float64)
+	for i, a := range names {
+		for _, b := range names[i+1:] {
+			la, lb := LicenseName(a), LicenseName(b)
+			if la == lb {
+				continue
+			}
+			da, db := c.docs[a], c.docs[b]
+			// Texts whose tokens differ too much can't be similar enough.
+			if da.tokenSimilarity(db) < threshold || db.tokenSimilarity(da) < threshold {
+				continue
+			}
+			sim := c.docSimilarity(a, b)
+			if sim < threshold {
+				continue
+			}
+			if la > lb {
+				la, lb = lb, la
+			}
+			if key := [2]string{la, lb}; sim > similarity[key] {
+				similarity[key] = sim
+			}
+		}
+	}
+
+	out := make(Confusability)
+	for key, sim := range similarity {
+		out[key[0]] = append(out[key[0]], SimilarLicense{Name: key[1], Similarity: quantizeConfidence(sim)})
+		out[key[1]] = append(out[key[1]], SimilarLicense{Name: key[0], Similarity: quantizeConfidence(sim)})
+	}
+	for _, similar := range out {
+		sortSimilar(similar)
+	}
+	return out
+}
+
+// docSimilarity returns the similarity of the texts of two corpus documents,
+// measured against the longer of them so that it's symmetric.
+func (c *Classifier) docSimilarity(a, b string) float64 {
+	da, db := c.expand(a, c.docs[a]), c.expand(b, c.docs[b])
+	diffs := docDiff(a, da, 0, da.size(), db, 0, db.size())
+	return confidencePercentage(max(da.size(), db.size()), diffLevenshteinWord(diffs))
+}
+
+// sortSimilar orders similar licenses by decreasing similarity, and then by
+// name.
+func sortSimilar(similar []SimilarLicense) {
+	sort.Slice(similar, func(i, j int) bool {
+		if similar[i].Similarity != similar[j].Similarity {
+			return similar[i].Similarity > similar[j].Similarity
+		}
+		return similar[i].Name < similar[j].Name
+	})
+}
+
+// SetConfusability sets the licenses that are easily confused with each
+// other. Whenever a license is considered for a match, the licenses it's
+// easily confused with are considered as well, so that the better of them is
+// reported, and License matches list them in their SimilarTo field as hints
+// for reviewers.
+func (c *Classifier) SetConfusability(conf Confusability) {
+	c.confusability = conf
+}
+
+// loadConfusability reads the confusability file at the top of the file
+// system, if there is one. Errors name the file below root.
+func (c *Classifier) loadConfusability(fsys fs.FS, root string) error {
+	b, err := fs.ReadFile(fsys, confusabilityFile)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	var conf Confusability
+	if err := json.Unmarshal(b, &conf); err != nil {
+		return fmt.Errorf("invalid license confusability %s: %w", pathpkg.Join(filepath.ToSlash(root), confusabilityFile), err)
+	}
+	c.SetConfusability(conf)
+	return nil
+}
+
+// addConfusable adds the corpus documents of the licenses that are easily
+// confused with those in the candidates, among those include accepts if it
+// isn't nil.
+func (c *Classifier) addConfusable(candidates map[string]*indexedDocument, include func(name string) bool) {
+	if len(c.confusability) == 0 {
+		return
+	}
+	present := make(map[string]bool)
+	for l := range candidates {
+		present[LicenseName(l)] = true
+	}
+	need := make(map[string]bool)
+	for n := range present {
+		for _, s := range c.confusability[n] {
+			if !present[s.Name] {
+				need[s.Name] = true
+			}
+		}
+	}
+	if len(need) == 0 {
+		return
+	}
+	for l, d := range c.docs {
+		if detectionType(l) == "License" && need[LicenseName(l)] && (include == nil || include(l)) {
+			candidates[l] = d
+		}
+	}
+}
+
+// similarTo returns the names of the licenses that are easily confused with
+// the named license.
+func (c *Classifier) similarTo(name string) []string {
+	var out []string
+	for _, s := range c.confusability[name] {
+		out = append(out, s.Name)
+	}
+	return out
+}
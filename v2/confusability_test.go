@@ -0,0 +1,102 @@
+// Copyright 2022 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package classifier
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+const (
+	plainLicense = `Permission is hereby granted to any person obtaining a copy of this
+software to use, copy, modify, merge, publish and distribute it, provided
+that the above copyright notice and this permission notice appear in all
+copies. The software is provided as is, without warranty of any kind.`
+	// attributionLicense is plainLicense with an additional attribution
+	// clause, which makes the two easily confused.
+	attributionLicense = `Permission is hereby granted to any person obtaining a copy of this
+software to use, copy, modify, merge, publish and distribute it, provided
+that the above copyright notice and this permission notice appear in all
+copies. Products using the software must acknowledge its authors. The
+software is provided as is, without warranty of any kind.`
+	unrelatedLicense = `Redistribution of this work in any form requires the written consent
+of the original author, who retains all rights not expressly granted here.`
+)
+
+func confusabilityClassifier(options ...OptionFunc) *Classifier {
+	c := NewClassifier(defaultThreshold, options...)
+	c.AddContent("License", "Plain", "license.txt", []byte(plainLicense))
+	c.AddContent("License", "Attribution", "license.txt", []byte(attributionLicense))
+	c.AddContent("License", "Unrelated", "license.txt", []byte(unrelatedLicense))
+	return c
+}
+
+func TestComputeConfusability(t *testing.T) {
+	got := confusabilityClassifier().ComputeConfusability(0.8)
+	want := Confusability{
+		"Attribution": {{Name: "Plain", Similarity: 0.854}},
+		"Plain":       {{Name: "Attribution", Similarity: 0.854}},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("ComputeConfusability() mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestConfusability(t *testing.T) {
+	// Dropping a word keeps the attribution license from passing a strict
+	// pre-filter, while all the tokens of the plain license are still there.
+	in := []byte(strings.Replace(attributionLicense, "acknowledge its authors", "acknowledge authors", 1))
+
+	tests := []struct {
+		name          string
+		confusability bool
+		want          []string
+		wantSimilar   []string
+	}{
+		{
+			name: "without confusability",
+			want: []string{"Plain"},
+		},
+		{
+			name:          "with confusability",
+			confusability: true,
+			want:          []string{"Attribution"},
+			wantSimilar:   []string{"Plain"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := confusabilityClassifier(WithPrefilterThreshold(1.0))
+			if tt.confusability {
+				c.SetConfusability(c.ComputeConfusability(0.8))
+			}
+			var got []string
+			var similar []string
+			for _, m := range c.Match(in).Matches {
+				got = append(got, m.Name)
+				similar = append(similar, m.SimilarTo...)
+			}
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("Match() mismatch (-want +got):\n%s", diff)
+			}
+			if diff := cmp.Diff(tt.wantSimilar, similar); diff != "" {
+				t.Errorf("Match() SimilarTo mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,279 @@
+// Copyright 2022 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package classifier
+
+import (
+	"crypto/sha256"
+	"encoding/gob"
+	"fmt"
+	"io"
+	"sort"
+	"sync/atomic"
+)
+
+// Corpus is an indexed set of license texts that can be shared by several
+// classifiers, for example one per worker, without copying the index. A
+// Corpus is immutable; classifiers that add content to a shared corpus copy it
+// first.
+type Corpus struct {
+	dict *dictionary
+	docs map[string]*indexedDocument
+	q    int // The value of q for q-grams in this corpus
+
+	checksums map[[sha256.Size]byte][]string
+	index     *qgramIndex
+}
+
+// Corpus returns the corpus of the classifier so it can be shared with other
+// classifiers. Content the classifier loads lazily is loaded first.
+func (c *Classifier) Corpus() *Corpus {
+	// An error loading the content is reported by the classifier's WarmUp
+	// and MatchFrom.
+	c.loadLazy()
+	c.shared = true
+	return &Corpus{
+		dict: c.dict,
+		docs: c.docs,
+		q:    c.q,
+
+		checksums: c.checksums,
+		index:     c.index.get(c.docs),
+	}
+}
+
+// NewClassifierFromCorpus creates a classifier that matches against a corpus
+// shared with other classifiers. The corpus is indexed for the threshold of
+// the classifier it came from, so the threshold must be at least as lenient
+// as the one the corpus can find matches for.
+func NewClassifierFromCorpus(corpus *Corpus, threshold float64, options ...OptionFunc) (*Classifier, error) {
+	c := NewClassifier(threshold, options...)
+	if c.q < corpus.q {
+		return nil, fmt.Errorf("threshold %v requires q-grams of length %d but the corpus is indexed with length %d", c.matchThreshold(), c.q, corpus.q)
+	}
+	c.dict = corpus.dict
+	c.docs = corpus.docs
+	c.q = corpus.q
+	c.checksums = corpus.checksums
+	c.index.set(corpus.index)
+	c.shared = true
+	return c, nil
+}
+
+// unshare gives the classifier its own copy of a shared corpus so that it can
+// be modified without affecting the other classifiers using it.
+func (c *Classifier) unshare() {
+	if !c.shared {
+		return
+	}
+	dict := newDictionarySize(len(c.dict.words))
+	for id, w := range c.dict.words {
+		dict.words[id] = w
+		dict.indices[w] = id
+	}
+	docs := make(map[string]*indexedDocument, len(c.docs))
+	for name, d := range c.docs {
+		// The documents themselves aren't modified by adding content, so
+		// only the dictionary they refer to needs replacing.
+		e := *d
+		e.dict = dict
+		docs[name] = &e
+	}
+	checksums := make(map[[sha256.Size]byte][]string, len(c.checksums))
+	for sum, names := range c.checksums {
+		checksums[sum] = append([]string(nil), names...)
+	}
+	c.dict = dict
+	c.docs = docs
+	c.checksums = checksums
+	c.shared = false
+}
+
+// savedCorpusVersion is the version of the format written by SaveCorpus, which
+// changes whenever the data saved or its meaning does.
+const savedCorpusVersion = 4
+
+// savedCorpus is the form of a corpus written by SaveCorpus. Only the data
+// that is costly to compute is saved: the tokens and q-gram checksums of the
+// documents, from which the rest of their index is rebuilt cheaply.
+type savedCorpus struct {
+	Version int
+	Q       int
+	// Words holds the words of the dictionary in order of their token IDs,
+	// which IDs holds.
+	Words []string
+	IDs   []tokenID
+	Docs  []savedDocument
+	// Metadata and Confusability are saved as slices in name order rather
+	// than as maps, which gob encodes in random order.
+	Metadata      []savedMetadata
+	Confusability []savedConfusability
+}
+
+type savedMetadata struct {
+	Name     string
+	Metadata LicenseMetadata
+}
+
+type savedConfusability struct {
+	Name    string
+	Similar []SimilarLicense
+}
+
+type savedDocument struct {
+	Name    string
+	Tokens  []indexedToken
+	Numbers NumberPolicy
+	// Q is the length of the q-grams of the searchset of the document,
+	// which is shorter than that of the corpus for very short documents,
+	// and Checksums holds the checksums of its q-grams in order.
+	Q         int
+	Checksums []uint32
+	// Cased counts the case-preserved phrases of the license in the text
+	// of the document.
+	Cased []int
+}
+
+// SaveCorpus writes the corpus of the classifier, along with the metadata and
+// confusability of its licenses, so that LoadCorpus can restore it without
+// tokenizing and indexing the license texts again. Content the classifier
+// loads lazily is loaded first.
+func (c *Classifier) SaveCorpus(w io.Writer) error {
+	if err := c.loadLazy(); err != nil {
+		return err
+	}
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	saved := savedCorpus{
+		Version: savedCorpusVersion,
+		Q:       c.q,
+	}
+	for id := range c.dict.words {
+		saved.IDs = append(saved.IDs, id)
+	}
+	sort.Slice(saved.IDs, func(i, j int) bool { return saved.IDs[i] < saved.IDs[j] })
+	for _, id := range saved.IDs {
+		saved.Words = append(saved.Words, c.dict.words[id])
+	}
+	// The documents, metadata and confusability are saved in name order, so
+	// that saving a corpus always writes the same bytes.
+	names := make([]string, 0, len(c.metadata))
+	for name := range c.metadata {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		saved.Metadata = append(saved.Metadata, savedMetadata{Name: name, Metadata: c.metadata[name]})
+	}
+	names = make([]string, 0, len(c.confusability))
+	for name := range c.confusability {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		saved.Confusability = append(saved.Confusability, savedConfusability{Name: name, Similar: c.confusability[name]})
+	}
+	names = make([]string, 0, len(c.docs))
+	for name := range c.docs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		d := c.expand(name, c.docs[name])
+		saved.Docs = append(saved.Docs, savedDocument{
+			Name:      name,
+			Tokens:    d.Tokens,
+			Numbers:   d.numbers,
+			Q:         d.s.q,
+			Checksums: d.s.Checksums,
+			Cased:     d.cased,
+		})
+	}
+	return gob.NewEncoder(w).Encode(&saved)
+}
+
+// LoadCorpus replaces the corpus of the classifier, along with the metadata
+// and confusability of its licenses, with a corpus written by SaveCorpus. As
+// with NewClassifierFromCorpus, the corpus must have been saved by a
+// classifier whose threshold is at least as strict as that of this one.
+func (c *Classifier) LoadCorpus(r io.Reader) error {
+	var saved savedCorpus
+	if err := gob.NewDecoder(r).Decode(&saved); err != nil {
+		return fmt.Errorf("invalid saved corpus: %w", err)
+	}
+	if saved.Version != savedCorpusVersion {
+		return fmt.Errorf("saved corpus has version %d, want %d", saved.Version, savedCorpusVersion)
+	}
+	if q := computeQ(c.matchThreshold()); q < saved.Q {
+		return fmt.Errorf("threshold %v requires q-grams of length %d but the corpus is indexed with length %d", c.matchThreshold(), q, saved.Q)
+	}
+
+	if len(saved.Words) != len(saved.IDs) {
+		return fmt.Errorf("invalid saved corpus: %d words but %d token IDs", len(saved.Words), len(saved.IDs))
+	}
+	dict := newDictionarySize(len(saved.Words))
+	for i, word := range saved.Words {
+		id := saved.IDs[i]
+		dict.words[id] = word
+		dict.indices[word] = id
+	}
+	var metadata map[string]LicenseMetadata
+	if len(saved.Metadata) > 0 {
+		metadata = make(map[string]LicenseMetadata, len(saved.Metadata))
+		for _, sm := range saved.Metadata {
+			metadata[sm.Name] = sm.Metadata
+		}
+	}
+	var confusability Confusability
+	if len(saved.Confusability) > 0 {
+		confusability = make(Confusability, len(saved.Confusability))
+		for _, sc := range saved.Confusability {
+			confusability[sc.Name] = sc.Similar
+		}
+	}
+	docs := make(map[string]*indexedDocument, len(saved.Docs))
+	for _, sd := range saved.Docs {
+		d := &indexedDocument{
+			Tokens:  sd.Tokens,
+			dict:    dict,
+			numbers: sd.Numbers,
+			cased:   sd.Cased,
+		}
+		d.generateFrequencies()
+		if !c.lowMemory {
+			d.runes = diffWordsToRunes(d, 0, d.size())
+			d.s = restoreSearchSet(d, sd.Q, sd.Checksums)
+			d.s.origin = sd.Name
+		}
+		docs[sd.Name] = d
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.dict = dict
+	c.docs = docs
+	c.q = saved.Q
+	c.shared = false
+	c.metadata = metadata
+	c.updateCased()
+	c.confusability = confusability
+	c.checksums = nil
+	for _, sd := range saved.Docs {
+		c.addChecksum(sd.Name, docs[sd.Name])
+	}
+	c.index.reset()
+	atomic.StoreInt32(&c.ready, 0)
+	return nil
+}
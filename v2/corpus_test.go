@@ -0,0 +1,231 @@
+// Copyright 2022 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package classifier
+
+import (
+	"bytes"
+	"io/ioutil"
+	"path"
+	"reflect"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+)
+
+func TestNewClassifierFromCorpus(t *testing.T) {
+	mit, err := ioutil.ReadFile(path.Join(baseLicenses, "License", "MIT", "pristine.txt"))
+	if err != nil {
+		t.Fatalf("couldn't read MIT license: %v", err)
+	}
+	isc, err := ioutil.ReadFile(path.Join(baseLicenses, "License", "ISC", "pristine.txt"))
+	if err != nil {
+		t.Fatalf("couldn't read ISC license: %v", err)
+	}
+
+	base := NewClassifier(defaultThreshold)
+	base.AddContent("License", "MIT", "pristine.txt", mit)
+	corpus := base.Corpus()
+
+	if _, err := NewClassifierFromCorpus(corpus, .5); err == nil {
+		t.Error("NewClassifierFromCorpus() with a threshold the corpus can't serve succeeded, want error")
+	}
+
+	strict, err := NewClassifierFromCorpus(corpus, .95)
+	if err != nil {
+		t.Fatalf("NewClassifierFromCorpus() = %v", err)
+	}
+	if strict.dict != base.dict {
+		t.Error("NewClassifierFromCorpus() copied the corpus dictionary")
+	}
+	if got := strict.Match(mit).Matches; len(got) != 1 || got[0].Name != "MIT" {
+		t.Errorf("Match() = %v, want MIT", got)
+	}
+
+	// Adding content to one classifier must not affect the others sharing
+	// the corpus.
+	strict.AddContent("License", "ISC", "pristine.txt", isc)
+	if got := strict.Match(isc).Matches; len(got) != 1 || got[0].Name != "ISC" {
+		t.Errorf("Match() after AddContent() = %v, want ISC", got)
+	}
+	if got := base.Match(isc).Matches; len(got) != 0 {
+		t.Errorf("Match() on the sharing classifier = %v, want no matches", got)
+	}
+	if _, ok := base.dict.indices["isc"]; ok {
+		t.Error("AddContent() added words to the shared dictionary")
+	}
+
+	base.AddContent("License", "ISC", "pristine.txt", isc)
+	if len(corpus.docs) != 1 {
+		t.Errorf("AddContent() on the originating classifier modified the corpus: %d documents, want 1", len(corpus.docs))
+	}
+}
+
+func TestSharedCorpusSubsets(t *testing.T) {
+	base, err := classifier()
+	if err != nil {
+		t.Fatalf("couldn't instantiate standard test classifier: %v", err)
+	}
+	mit, err := ioutil.ReadFile(path.Join(baseLicenses, "License", "MIT", "pristine.txt"))
+	if err != nil {
+		t.Fatalf("couldn't read MIT license: %v", err)
+	}
+	corpus := base.Corpus()
+
+	tests := []struct {
+		name     string
+		licenses []string
+		want     bool
+	}{
+		{
+			name: "all licenses",
+			want: true,
+		},
+		{
+			name:     "subset with MIT",
+			licenses: []string{"Apache-2.0", "MIT"},
+			want:     true,
+		},
+		{
+			name:     "subset without MIT",
+			licenses: []string{"Apache-2.0"},
+			want:     false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var options []OptionFunc
+			if tt.licenses != nil {
+				options = append(options, WithLicenses(tt.licenses...))
+			}
+			c, err := NewClassifierFromCorpus(corpus, defaultThreshold, options...)
+			if err != nil {
+				t.Fatalf("NewClassifierFromCorpus() = %v", err)
+			}
+			if c.dict != base.dict {
+				t.Error("NewClassifierFromCorpus() copied the corpus dictionary")
+			}
+			// Matching twice checks that the first match leaves the
+			// shared corpus alone.
+			for i := 0; i < 2; i++ {
+				found := false
+				for _, m := range c.Match(mit).Matches {
+					if m.Name == "MIT" {
+						found = true
+					}
+				}
+				if found != tt.want {
+					t.Errorf("Match() found MIT = %v, want %v", found, tt.want)
+				}
+			}
+		})
+	}
+}
+
+func TestSaveLoadCorpus(t *testing.T) {
+	want, err := classifier()
+	if err != nil {
+		t.Fatalf("couldn't instantiate standard test classifier: %v", err)
+	}
+	var buf bytes.Buffer
+	if err := want.SaveCorpus(&buf); err != nil {
+		t.Fatalf("SaveCorpus() = %v", err)
+	}
+	saved := buf.Bytes()
+	// Saving the corpus again writes the same bytes.
+	var again bytes.Buffer
+	if err := want.SaveCorpus(&again); err != nil {
+		t.Fatalf("SaveCorpus() = %v", err)
+	}
+	if !bytes.Equal(saved, again.Bytes()) {
+		t.Error("SaveCorpus() wrote different bytes for the same corpus")
+	}
+
+	got := NewClassifier(defaultThreshold)
+	if err := got.LoadCorpus(bytes.NewReader(saved)); err != nil {
+		t.Fatalf("LoadCorpus() = %v", err)
+	}
+	if diff := cmp.Diff(want.dict.words, got.dict.words); diff != "" {
+		t.Errorf("LoadCorpus() dictionary mismatch (-want +got):\n%s", diff)
+	}
+	if len(got.docs) != len(want.docs) {
+		t.Fatalf("LoadCorpus() loaded %d documents, want %d", len(got.docs), len(want.docs))
+	}
+	for name, w := range want.docs {
+		g := got.docs[name]
+		if g == nil {
+			t.Errorf("LoadCorpus() didn't load %s", name)
+			continue
+		}
+		if !reflect.DeepEqual(w.s.Hashes, g.s.Hashes) || !reflect.DeepEqual(w.s.nodes, g.s.nodes) || w.s.q != g.s.q {
+			t.Errorf("LoadCorpus() searchset of %s differs from the original", name)
+		}
+		if !reflect.DeepEqual(w.f, g.f) || !reflect.DeepEqual(w.runes, g.runes) {
+			t.Errorf("LoadCorpus() index of %s differs from the original", name)
+		}
+	}
+	if diff := cmp.Diff(want.checksums, got.checksums); diff != "" {
+		t.Errorf("LoadCorpus() checksums mismatch (-want +got):\n%s", diff)
+	}
+	if diff := cmp.Diff(want.metadata, got.metadata); diff != "" {
+		t.Errorf("LoadCorpus() metadata mismatch (-want +got):\n%s", diff)
+	}
+	if diff := cmp.Diff(want.confusability, got.confusability); diff != "" {
+		t.Errorf("LoadCorpus() confusability mismatch (-want +got):\n%s", diff)
+	}
+
+	files, err := getScenarioFilenames()
+	if err != nil {
+		t.Fatalf("encountered error walking scenarios directory: %v", err)
+	}
+	// Matches reported with the same confidence, such as copyright notices,
+	// aren't in a set order.
+	byLine := cmpopts.SortSlices(func(a, b *Match) bool {
+		if a.StartLine != b.StartLine {
+			return a.StartLine < b.StartLine
+		}
+		return a.Name < b.Name
+	})
+	for _, f := range files {
+		s := readScenario(f)
+		if diff := cmp.Diff(want.Match(s.data), got.Match(s.data), byLine); diff != "" {
+			t.Errorf("Match(%q) on loaded corpus mismatch (-want +got):\n%s", f, diff)
+		}
+	}
+
+	if err := NewClassifier(.5).LoadCorpus(bytes.NewReader(saved)); err == nil {
+		t.Error("LoadCorpus() with a threshold the corpus can't serve succeeded, want error")
+	}
+	if err := NewClassifier(defaultThreshold).LoadCorpus(bytes.NewReader(saved[:len(saved)/2])); err == nil {
+		t.Error("LoadCorpus() of a truncated corpus succeeded, want error")
+	}
+}
+
+func BenchmarkLoadCorpus(b *testing.B) {
+	c, err := classifier()
+	if err != nil {
+		b.Fatalf("couldn't instantiate standard test classifier: %v", err)
+	}
+	var buf bytes.Buffer
+	if err := c.SaveCorpus(&buf); err != nil {
+		b.Fatalf("SaveCorpus() = %v", err)
+	}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if err := NewClassifier(defaultThreshold).LoadCorpus(bytes.NewReader(buf.Bytes())); err != nil {
+			b.Fatalf("LoadCorpus() = %v", err)
+		}
+	}
+}
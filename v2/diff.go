@@ -15,8 +15,10 @@
 package classifier
 
 import (
+	"sort"
 	"strings"
 
+	"github.com/google/licenseclassifier/v2/textcompare"
 	"github.com/sergi/go-diff/diffmatchpatch"
 )
 
@@ -25,12 +27,15 @@ import (
 // https://github.com/google/diff-match-patch/wiki/Line-or-Word-Diffs
 
 // diffRange returns the indices of the beginning and end locations of the diff
-// that reconstruct (as best possible) the source value.
-func diffRange(known string, diffs []diffmatchpatch.Diff) (start, end int) {
+// that reconstruct (as best possible) the source value. The equal and inserted
+// diffs hold the words of the known document in order, so the source is
+// reconstructed once they add up to its normalized length, which saves keeping
+// the normalized text of corpus documents.
+func diffRange(normLen int, diffs []diffmatchpatch.Diff) (start, end int) {
 	var foundStart bool
-	var seen string
+	seen := 0
 	for end = 0; end < len(diffs); end++ {
-		if len(seen) > 1 && seen[:len(seen)-1] == known {
+		if seen > 1 && seen-1 == normLen {
 			break
 		}
 		switch diffs[end].Type {
@@ -39,24 +44,194 @@ func diffRange(known string, diffs []diffmatchpatch.Diff) (start, end int) {
 				start = end
 				foundStart = true
 			}
-			seen += diffs[end].Text + " "
+			seen += len(diffs[end].Text) + 1
 		}
 	}
 	return start, end
 }
 
 func docDiff(id string, doc1 *indexedDocument, doc1Start, doc1End int, doc2 *indexedDocument, doc2Start, doc2End int) []diffmatchpatch.Diff {
-	chars1 := doc1.runes[doc1Start:doc1End]
-	chars2 := doc2.runes[doc2Start:doc2End]
+	// The diff library builds its half matches by appending to slices of its
+	// inputs, which writes into them, so it's given copies rather than the
+	// runes of the documents, which concurrent scoring shares.
+	chars1 := append([]rune(nil), doc1.runes[doc1Start:doc1End]...)
+	chars2 := append([]rune(nil), doc2.runes[doc2Start:doc2End]...)
 
-	dmp := diffmatchpatch.New()
-	diffs := dmp.DiffMainRunes(chars1, chars2, false)
+	var diffs []diffmatchpatch.Diff
+	if len(chars1) >= anchoredDiffMinLength && len(chars2) >= anchoredDiffMinLength {
+		diffs = anchoredDiff(chars1, chars2)
+	} else {
+		dmp := diffmatchpatch.New()
+		diffs = dmp.DiffMainRunes(chars1, chars2, false)
+	}
 
 	// Recover the words from the previous rune encoding and return the textual diffs.
 	diffs = diffRunesToWords(diffs, doc1.dict)
 	return diffs
 }
 
+// anchoredDiffMinLength is the length, in tokens, of the texts above which
+// docDiff splits the diff at anchors. The cost of diffing grows quadratically
+// with the length of the differing texts, which dominates matching long
+// licenses such as the GPL family.
+const anchoredDiffMinLength = 1000
+
+// anchorLength is the length, in tokens, of the runs used as anchors.
+const anchorLength = 8
+
+// anchor is the position of a run of tokens that occurs exactly once in each of
+// the texts being diffed.
+type anchor struct {
+	a, b int
+}
+
+// anchoredDiff diffs the texts by aligning them at anchors, runs of tokens
+// that occur exactly once in each text, and only diffing the text between
+// consecutive anchors. Since the anchors are unique, they are matched
+// identically by a full diff except for pathological inputs, and the diffed
+// ranges are much shorter than the texts.
+func anchoredDiff(a, b []rune) []diffmatchpatch.Diff {
+	dmp := diffmatchpatch.New()
+	var diffs []diffmatchpatch.Diff
+	ai, bi := 0, 0
+	for _, an := range findAnchors(a, b) {
+		diffs = append(diffs, dmp.DiffMainRunes(a[ai:an.a], b[bi:an.b], false)...)
+		diffs = append(diffs, diffmatchpatch.Diff{Type: diffmatchpatch.DiffEqual, Text: string(a[an.a : an.a+anchorLength])})
+		ai, bi = an.a+anchorLength, an.b+anchorLength
+	}
+	diffs = append(diffs, dmp.DiffMainRunes(a[ai:], b[bi:], false)...)
+	return mergeDiffs(diffs)
+}
+
+// findAnchors returns non-overlapping anchors that appear in the same order in
+// both texts, ordered by their position.
+func findAnchors(a, b []rune) []anchor {
+	if len(a) < anchorLength || len(b) < anchorLength {
+		return nil
+	}
+	// Runs are keyed by a hash of their tokens; a position of -1 marks a hash
+	// that isn't unique in its text, whether because the run repeats or
+	// because of a collision.
+	positions := func(hashes []uint64) map[uint64]int {
+		out := make(map[uint64]int, len(hashes))
+		for i, h := range hashes {
+			if _, ok := out[h]; ok {
+				out[h] = -1
+			} else {
+				out[h] = i
+			}
+		}
+		return out
+	}
+	ha, hb := runHashes(a), runHashes(b)
+	pa, pb := positions(ha), positions(hb)
+
+	var candidates []anchor
+	for i, h := range ha {
+		if pa[h] != i {
+			continue
+		}
+		if j, ok := pb[h]; ok && j >= 0 && equalRunes(a[i:i+anchorLength], b[j:j+anchorLength]) {
+			candidates = append(candidates, anchor{a: i, b: j})
+		}
+	}
+
+	// The candidates are ordered by their position in a; the longest
+	// subsequence also ordered by position in b is the largest consistent
+	// alignment.
+	ordered := longestIncreasing(candidates)
+
+	var out []anchor
+	next := anchor{}
+	for _, an := range ordered {
+		if an.a >= next.a && an.b >= next.b {
+			out = append(out, an)
+			next = anchor{a: an.a + anchorLength, b: an.b + anchorLength}
+		}
+	}
+	return out
+}
+
+// runHashes returns a rolling hash of each run of anchorLength tokens in the
+// text, indexed by the position of the run.
+func runHashes(text []rune) []uint64 {
+	const base = 1000003
+	var pow uint64 = 1
+	for i := 1; i < anchorLength; i++ {
+		pow *= base
+	}
+	out := make([]uint64, 0, len(text)-anchorLength+1)
+	var h uint64
+	for i, r := range text {
+		if i >= anchorLength {
+			h -= uint64(text[i-anchorLength]) * pow
+		}
+		h = h*base + uint64(r)
+		if i >= anchorLength-1 {
+			out = append(out, h)
+		}
+	}
+	return out
+}
+
+func equalRunes(a, b []rune) bool {
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// longestIncreasing returns the longest subsequence of the anchors whose
+// positions in b are increasing.
+func longestIncreasing(anchors []anchor) []anchor {
+	// tails[l] is the index of the anchor ending the best subsequence of
+	// length l+1 found so far.
+	var tails []int
+	prev := make([]int, len(anchors))
+	for i, an := range anchors {
+		l := sort.Search(len(tails), func(j int) bool {
+			return anchors[tails[j]].b >= an.b
+		})
+		if l > 0 {
+			prev[i] = tails[l-1]
+		} else {
+			prev[i] = -1
+		}
+		if l == len(tails) {
+			tails = append(tails, i)
+		} else {
+			tails[l] = i
+		}
+	}
+	if len(tails) == 0 {
+		return nil
+	}
+	out := make([]anchor, len(tails))
+	for i, k := len(tails)-1, tails[len(tails)-1]; i >= 0; i, k = i-1, prev[k] {
+		out[i] = anchors[k]
+	}
+	return out
+}
+
+// mergeDiffs combines adjacent diffs of the same type, which result from
+// diffing the text between anchors separately.
+func mergeDiffs(diffs []diffmatchpatch.Diff) []diffmatchpatch.Diff {
+	var out []diffmatchpatch.Diff
+	for _, d := range diffs {
+		if d.Text == "" {
+			continue
+		}
+		if n := len(out); n > 0 && out[n-1].Type == d.Type {
+			out[n-1].Text += d.Text
+			continue
+		}
+		out = append(out, d)
+	}
+	return out
+}
+
 func diffWordsToRunes(doc *indexedDocument, start, end int) []rune {
 	// Creates a slice of runes using the indexed values as a basis for runes.
 	// The go-diff code basically does exactly this using ephemeral dictionaries
@@ -95,10 +270,7 @@ func diffRunesToWords(diffs []diffmatchpatch.Diff, dict *dictionary) []diffmatch
 // This function depends on the behavior of the tokenizer such that strings are separated
 // by exactly one space and don't start or end with whitespace.
 func wordLen(text string) int {
-	if text == "" {
-		return 0
-	}
-	return strings.Count(text, " ") + 1
+	return textcompare.WordCount(text)
 }
 
 // textLength returns the number of tokens in the diff. This value is used to
@@ -15,6 +15,9 @@
 package classifier
 
 import (
+	"io/ioutil"
+	"path"
+	"strings"
 	"testing"
 
 	"github.com/google/go-cmp/cmp"
@@ -274,7 +277,7 @@ func TestDiffing(t *testing.T) {
 			kd := c.getIndexedDocument("", "known", "")
 			ud := c.createTargetIndexedDocument([]byte(test.unknown))
 			diffs := docDiff("known", ud, 0, ud.size(), kd, 0, kd.size())
-			start, end := diffRange(kd.normalized(), diffs)
+			start, end := diffRange(kd.normalizedLength(), diffs)
 			if start != test.start {
 				t.Errorf("start: got %d want %d", start, test.start)
 			}
@@ -287,3 +290,81 @@ func TestDiffing(t *testing.T) {
 		})
 	}
 }
+
+// gplDocuments returns the GPL-3.0 text and a copy of it with scattered edits,
+// indexed against the same corpus.
+func gplDocuments(tb testing.TB) (known, unknown *indexedDocument) {
+	b, err := ioutil.ReadFile(path.Join(baseLicenses, "License", "GPL-3.0", "license.txt"))
+	if err != nil {
+		tb.Fatalf("couldn't read GPL-3.0: %v", err)
+	}
+	c := NewClassifier(.8)
+	c.AddContent("License", "GPL-3.0", "license.txt", b)
+	modified := strings.NewReplacer(
+		"Free Software Foundation", "FSF",
+		"modify", "change",
+		"Program", "Software",
+	).Replace(string(b))
+	return c.getIndexedDocument("License", "GPL-3.0", "license.txt"), c.createTargetIndexedDocument([]byte(modified))
+}
+
+func TestAnchoredDiff(t *testing.T) {
+	known, unknown := gplDocuments(t)
+	if known.size() < anchoredDiffMinLength {
+		t.Fatalf("GPL-3.0 has %d tokens, too few to exercise anchoring", known.size())
+	}
+
+	dmp := diffmatchpatch.New()
+	full := dmp.DiffMainRunes(unknown.runes, known.runes, false)
+	anchored := anchoredDiff(unknown.runes, known.runes)
+
+	// The anchored diff must describe the same edit and at the same cost.
+	if got, want := dmp.DiffText1(anchored), dmp.DiffText1(full); got != want {
+		t.Error("anchoredDiff() doesn't reproduce the unknown text")
+	}
+	if got, want := dmp.DiffText2(anchored), dmp.DiffText2(full); got != want {
+		t.Error("anchoredDiff() doesn't reproduce the known text")
+	}
+	if got, want := diffLevenshteinWord(diffRunesToWords(anchored, known.dict)), diffLevenshteinWord(diffRunesToWords(full, known.dict)); got != want {
+		t.Errorf("anchoredDiff() distance = %d, want %d", got, want)
+	}
+}
+
+func TestFindAnchors(t *testing.T) {
+	a := []rune("abcdefghijklmnopqrstuvwxyz")
+	b := []rune("XYZabcdefghij0123nopqrstuvwxyz")
+	got := findAnchors(a, b)
+	want := []anchor{{a: 0, b: 3}, {a: 13, b: 17}}
+	if diff := cmp.Diff(want, got, cmp.AllowUnexported(anchor{})); diff != "" {
+		t.Errorf("findAnchors() mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func BenchmarkDocDiff(b *testing.B) {
+	known, unknown := gplDocuments(b)
+	// Rewriting every tenth word models a heavily edited copy, whose many
+	// differences make a full diff expensive.
+	edited := append([]rune(nil), unknown.runes...)
+	for i := 0; i < len(edited); i += 10 {
+		edited[i] = 0
+	}
+	for _, bm := range []struct {
+		name   string
+		target []rune
+	}{
+		{"lightly edited", unknown.runes},
+		{"heavily edited", edited},
+	} {
+		b.Run(bm.name+"/full", func(b *testing.B) {
+			dmp := diffmatchpatch.New()
+			for i := 0; i < b.N; i++ {
+				dmp.DiffMainRunes(bm.target, known.runes, false)
+			}
+		})
+		b.Run(bm.name+"/anchored", func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				anchoredDiff(bm.target, known.runes)
+			}
+		})
+	}
+}
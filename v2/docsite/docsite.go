@@ -0,0 +1,356 @@
+// Copyright 2022 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package docsite classifies the licensing of documentation sites built with
+// generators such as MkDocs, Hugo and Docusaurus. Documentation is often
+// licensed differently from the code it documents, commonly under a Creative
+// Commons license, and states its license in a footer, a section of a page or
+// the site configuration rather than in a license file. The package extracts
+// those parts of the pages and configuration of a site and classifies them,
+// attributing each finding to its page.
+package docsite
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	classifier "github.com/google/licenseclassifier/v2"
+	"github.com/google/licenseclassifier/v2/assets"
+)
+
+// Headings of the sections extracted from parts of a page other than its
+// Markdown sections.
+const (
+	FrontMatterSection = "front matter"
+	FooterSection      = "footer"
+	ConfigSection      = "config"
+)
+
+// Section is a part of a page that states the licensing of the page, such as
+// a "License" section or a footer. Line numbers are 1-based and relative to
+// the page.
+type Section struct {
+	// Heading is the text of the section's heading, or FrontMatterSection,
+	// FooterSection or ConfigSection for the other parts of a page.
+	Heading   string
+	StartLine int
+	EndLine   int
+	Text      []byte
+}
+
+// Page is a page, or configuration file, of a documentation site.
+type Page struct {
+	Path string
+	// Title is the title given in the page's front matter, if any.
+	Title string
+	// License is the license declared in the page's front matter, if any,
+	// as written, and LicenseLine is the line declaring it.
+	License     string
+	LicenseLine int
+	Sections    []Section
+}
+
+var (
+	headingRE = regexp.MustCompile(`^(#{1,6})\s+(.*?)[\s#]*$`)
+	fenceRE   = regexp.MustCompile("^\\s*(```|~~~)")
+	// licensingRE matches the headings of sections that state the
+	// licensing of a page.
+	licensingRE   = regexp.MustCompile(`(?i)\b(?:licen[cs]e[ds]?|licensing|copyright|attribution|legal)\b`)
+	footerStartRE = regexp.MustCompile(`(?i)<footer\b`)
+	footerEndRE   = regexp.MustCompile(`(?i)</footer>`)
+	// keyRE matches the keys of front matter and configuration that state
+	// licensing, in YAML, TOML, JSON and JavaScript syntax.
+	keyRE = regexp.MustCompile(`(?i)^\s*["']?(title|license|copyright)["']?\s*[:=]\s*(.*?)\s*,?\s*$`)
+)
+
+// markdownExts are the extensions of the pages the generators build from.
+var markdownExts = map[string]bool{
+	".md":       true,
+	".mdx":      true,
+	".markdown": true,
+}
+
+// configFiles are the names of the site configuration files of the
+// generators, which hold the copyright notice of the site's footer.
+var configFiles = map[string]bool{
+	"mkdocs.yml":           true,
+	"mkdocs.yaml":          true,
+	"hugo.toml":            true,
+	"hugo.yaml":            true,
+	"hugo.json":            true,
+	"config.toml":          true,
+	"config.yaml":          true,
+	"docusaurus.config.js": true,
+	"docusaurus.config.ts": true,
+}
+
+// IsPage reports whether the file is a page or configuration file of a
+// documentation site.
+func IsPage(path string) bool {
+	return markdownExts[strings.ToLower(filepath.Ext(path))] || configFiles[filepath.Base(path)]
+}
+
+// Extract returns the parts of the content of the page at the path that state
+// its licensing: the license and copyright of its front matter, sections
+// whose headings mention licensing, copyright or attribution, and footers. For
+// a site configuration file, the license and copyright settings are returned.
+func Extract(path string, content []byte) *Page {
+	p := &Page{Path: path}
+	lines := bytes.Split(content, []byte("\n"))
+	if configFiles[filepath.Base(path)] {
+		p.Sections = keySections(lines, 0, ConfigSection, p)
+		return p
+	}
+
+	body := 0
+	if end := frontMatterEnd(lines); end > 0 {
+		p.Sections = keySections(lines[1:end], 1, FrontMatterSection, p)
+		body = end + 1
+	}
+
+	var section *Section
+	level := 0
+	closeSection := func(end int) {
+		if section != nil {
+			section.EndLine = end
+			section.Text = bytes.Join(lines[section.StartLine-1:end], []byte("\n"))
+			p.Sections = append(p.Sections, *section)
+			section = nil
+		}
+	}
+	fenced := false
+	footer := 0
+	for i := body; i < len(lines); i++ {
+		l := lines[i]
+		if fenceRE.Match(l) {
+			fenced = !fenced
+		}
+		if fenced {
+			continue
+		}
+		if footer == 0 && footerStartRE.Match(l) {
+			footer = i + 1
+		}
+		if footer != 0 && footerEndRE.Match(l) {
+			p.Sections = append(p.Sections, Section{
+				Heading:   FooterSection,
+				StartLine: footer,
+				EndLine:   i + 1,
+				Text:      bytes.Join(lines[footer-1:i+1], []byte("\n")),
+			})
+			footer = 0
+		}
+		m := headingRE.FindSubmatch(l)
+		if m == nil {
+			continue
+		}
+		if section != nil && len(m[1]) <= level {
+			closeSection(trimEnd(lines[:i]))
+		}
+		if section == nil && licensingRE.Match(m[2]) {
+			section = &Section{Heading: string(m[2]), StartLine: i + 1}
+			level = len(m[1])
+		}
+	}
+	closeSection(trimEnd(lines))
+	sort.SliceStable(p.Sections, func(i, j int) bool {
+		return p.Sections[i].StartLine < p.Sections[j].StartLine
+	})
+	return p
+}
+
+// frontMatterEnd returns the index of the line closing the YAML or TOML front
+// matter that starts the lines, or 0 if they don't start with front matter.
+func frontMatterEnd(lines [][]byte) int {
+	if len(lines) == 0 {
+		return 0
+	}
+	delim := string(bytes.TrimSpace(lines[0]))
+	if delim != "---" && delim != "+++" {
+		return 0
+	}
+	for i := 1; i < len(lines); i++ {
+		if string(bytes.TrimSpace(lines[i])) == delim {
+			return i
+		}
+	}
+	return 0
+}
+
+// keySections returns a section for each license or copyright setting in the
+// lines, which start after offset lines of the page, recording the title and
+// license settings in the page.
+func keySections(lines [][]byte, offset int, heading string, p *Page) []Section {
+	var out []Section
+	for i, l := range lines {
+		m := keyRE.FindSubmatch(l)
+		if m == nil {
+			continue
+		}
+		key, value := strings.ToLower(string(m[1])), unquote(string(m[2]))
+		switch key {
+		case "title":
+			if p.Title == "" {
+				p.Title = value
+			}
+			continue
+		case "license":
+			if p.License == "" {
+				p.License = value
+				p.LicenseLine = offset + i + 1
+			}
+		}
+		out = append(out, Section{
+			Heading:   heading,
+			StartLine: offset + i + 1,
+			EndLine:   offset + i + 1,
+			Text:      []byte(value),
+		})
+	}
+	return out
+}
+
+// unquote strips the quotes around a setting's value.
+func unquote(s string) string {
+	if len(s) >= 2 && strings.ContainsAny(s[:1], "\"'`") && s[len(s)-1] == s[0] {
+		return s[1 : len(s)-1]
+	}
+	return s
+}
+
+// trimEnd returns the number of lines without the blank lines that end them.
+func trimEnd(lines [][]byte) int {
+	n := len(lines)
+	for n > 0 && len(bytes.TrimSpace(lines[n-1])) == 0 {
+		n--
+	}
+	return n
+}
+
+// Finding is a match found in a section of a page.
+type Finding struct {
+	Page    string
+	Title   string
+	Section string
+	// Match is the match, with line numbers relative to the page.
+	Match *classifier.Match
+}
+
+// Classifier classifies the licensing of documentation sites.
+type Classifier struct {
+	c *classifier.Classifier
+}
+
+// NewClassifier creates a classifier that matches against the embedded
+// license corpus, reporting matches found with at least the given confidence.
+// Since pages mostly name their license rather than include its text, as in
+// "Content licensed under CC BY 4.0", references to licenses by name are
+// reported.
+func NewClassifier(threshold float64) (*Classifier, error) {
+	c, err := assets.NewClassifier(threshold, assets.WithClassifierOptions(classifier.WithNameReferences()))
+	if err != nil {
+		return nil, err
+	}
+	return &Classifier{c: c}, nil
+}
+
+// NewFromClassifier creates a classifier that uses c, for tools that already
+// have one loaded. Unless c was created with classifier.WithNameReferences,
+// only the licenses that pages include the text of, or declare in their front
+// matter, are reported.
+func NewFromClassifier(c *classifier.Classifier) *Classifier {
+	return &Classifier{c: c}
+}
+
+// ClassifyPage classifies the sections of the page at the path that state its
+// licensing, as found by Extract. The license declared by the page's front
+// matter, or by a site configuration, is reported as a Reference match of the
+// license as written, such as CC-BY-4.0.
+func (c *Classifier) ClassifyPage(path string, content []byte) ([]Finding, error) {
+	p := Extract(path, content)
+	var out []Finding
+	for _, s := range p.Sections {
+		if p.LicenseLine > 0 && s.StartLine == p.LicenseLine {
+			out = append(out, Finding{Page: p.Path, Title: p.Title, Section: s.Heading, Match: &classifier.Match{
+				Name:       p.License,
+				Variant:    s.Heading,
+				MatchType:  "Reference",
+				Confidence: 1.0,
+				StartLine:  p.LicenseLine,
+				EndLine:    p.LicenseLine,
+			}})
+			continue
+		}
+		res, err := c.c.MatchFrom(bytes.NewReader(s.Text))
+		if err != nil {
+			return nil, err
+		}
+		for _, m := range res.Matches {
+			m.StartLine += s.StartLine - 1
+			m.EndLine += s.StartLine - 1
+			out = append(out, Finding{Page: p.Path, Title: p.Title, Section: s.Heading, Match: m})
+		}
+	}
+	return out, nil
+}
+
+// ClassifySite classifies the pages and configuration of the documentation
+// site rooted at the directory, skipping hidden directories and installed
+// Node.js packages. Findings are ordered by page, then by line.
+func (c *Classifier) ClassifySite(root string) ([]Finding, error) {
+	var out []Finding
+	err := filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			if path != root && (strings.HasPrefix(info.Name(), ".") || info.Name() == "node_modules") {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !IsPage(path) {
+			return nil
+		}
+		b, err := ioutil.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(root, path)
+		if err != nil {
+			return err
+		}
+		findings, err := c.ClassifyPage(filepath.ToSlash(rel), b)
+		if err != nil {
+			return err
+		}
+		out = append(out, findings...)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	sort.SliceStable(out, func(i, j int) bool {
+		if out[i].Page != out[j].Page {
+			return out[i].Page < out[j].Page
+		}
+		return out[i].Match.StartLine < out[j].Match.StartLine
+	})
+	return out, nil
+}
@@ -0,0 +1,186 @@
+// Copyright 2022 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package docsite
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	classifier "github.com/google/licenseclassifier/v2"
+)
+
+const page = `---
+title: "Getting started"
+license: CC-BY-4.0
+---
+
+# Getting started
+
+## License of the examples
+
+The examples are licensed as follows.
+
+### Details
+
+More details.
+
+## Next steps
+
+` + "```" + `
+# License
+` + "```" + `
+
+<footer>
+Content licensed under CC BY 4.0.
+</footer>
+`
+
+func TestExtract(t *testing.T) {
+	got := Extract("docs/index.md", []byte(page))
+	want := &Page{
+		Path:        "docs/index.md",
+		Title:       "Getting started",
+		License:     "CC-BY-4.0",
+		LicenseLine: 3,
+		Sections: []Section{
+			{Heading: FrontMatterSection, StartLine: 3, EndLine: 3, Text: []byte("CC-BY-4.0")},
+			{Heading: "License of the examples", StartLine: 8, EndLine: 14, Text: []byte("## License of the examples\n\nThe examples are licensed as follows.\n\n### Details\n\nMore details.")},
+			{Heading: FooterSection, StartLine: 22, EndLine: 24, Text: []byte("<footer>\nContent licensed under CC BY 4.0.\n</footer>")},
+		},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("Extract() mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestExtractConfig(t *testing.T) {
+	tests := []struct {
+		path    string
+		content string
+		want    []Section
+	}{
+		{
+			path:    "mkdocs.yml",
+			content: "site_name: Example\ncopyright: 'Copyright &copy; 2022 Example Authors'\n",
+			want: []Section{
+				{Heading: ConfigSection, StartLine: 2, EndLine: 2, Text: []byte("Copyright &copy; 2022 Example Authors")},
+			},
+		},
+		{
+			path:    "docusaurus.config.js",
+			content: "module.exports = {\n  footer: {\n    copyright: `Copyright © 2022 Example, Inc.`,\n  },\n};\n",
+			want: []Section{
+				{Heading: ConfigSection, StartLine: 3, EndLine: 3, Text: []byte("Copyright © 2022 Example, Inc.")},
+			},
+		},
+		{
+			path:    "hugo.toml",
+			content: "baseURL = 'https://example.org/'\ncopyright = \"Example Authors\"\n",
+			want: []Section{
+				{Heading: ConfigSection, StartLine: 2, EndLine: 2, Text: []byte("Example Authors")},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			if got := Extract(tt.path, []byte(tt.content)).Sections; !cmp.Equal(tt.want, got) {
+				t.Errorf("Extract() mismatch (-want +got):\n%s", cmp.Diff(tt.want, got))
+			}
+		})
+	}
+}
+
+func TestClassifyPage(t *testing.T) {
+	c, err := NewClassifier(.8)
+	if err != nil {
+		t.Fatalf("NewClassifier() = %v", err)
+	}
+	got, err := c.ClassifyPage("docs/index.md", []byte(page))
+	if err != nil {
+		t.Fatalf("ClassifyPage() = %v", err)
+	}
+	type finding struct {
+		Section, Name, MatchType string
+		Line                     int
+	}
+	var findings []finding
+	for _, f := range got {
+		if f.Page != "docs/index.md" || f.Title != "Getting started" {
+			t.Errorf("ClassifyPage() finding of page %q titled %q, want docs/index.md titled Getting started", f.Page, f.Title)
+		}
+		findings = append(findings, finding{f.Section, f.Match.Name, f.Match.MatchType, f.Match.StartLine})
+	}
+	// The license declared by the front matter and the license the footer
+	// names are both reported, on the lines of the page they're on.
+	want := []finding{
+		{FrontMatterSection, "CC-BY-4.0", "Reference", 3},
+		{FooterSection, "CC-BY-4.0", "Reference", 23},
+	}
+	if diff := cmp.Diff(want, findings); diff != "" {
+		t.Errorf("ClassifyPage() mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestClassifySite(t *testing.T) {
+	mit, err := ioutil.ReadFile("../assets/License/MIT/pristine.txt")
+	if err != nil {
+		t.Fatalf("couldn't read MIT license: %v", err)
+	}
+	lc := classifier.NewClassifier(.8)
+	lc.AddContent("License", "MIT", "pristine.txt", mit)
+	c := NewFromClassifier(lc)
+
+	dir, err := ioutil.TempDir("", "docsite")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	files := map[string]string{
+		"docs/index.md":                 "---\ntitle: Home\n---\n\n# Home\n\n## License\n\n" + string(mit),
+		"docs/other.md":                 "# Other\n\n" + string(mit),
+		"node_modules/pkg/README.md":    "# License\n\n" + string(mit),
+		"docs/assets/LICENSE-notes.txt": "# License\n\n" + string(mit),
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	got, err := c.ClassifySite(dir)
+	if err != nil {
+		t.Fatalf("ClassifySite() = %v", err)
+	}
+	// Only the license section of the home page is classified: the other
+	// page has no license section, and installed packages and files other
+	// than pages are skipped.
+	if len(got) != 1 {
+		t.Fatalf("ClassifySite() returned %d findings, want 1: %+v", len(got), got)
+	}
+	f := got[0]
+	if f.Page != "docs/index.md" || f.Title != "Home" || f.Section != "License" || f.Match.Name != "MIT" {
+		t.Errorf("ClassifySite() = {%s %s %s %s}, want {docs/index.md Home License MIT}", f.Page, f.Title, f.Section, f.Match.Name)
+	}
+	if f.Match.StartLine < 9 {
+		t.Errorf("ClassifySite() match starts at line %d, want at least 9, after the section's heading", f.Match.StartLine)
+	}
+}
@@ -18,8 +18,11 @@ package classifier
 import (
 	"bytes"
 	"fmt"
+	"hash/fnv"
 	"os"
+	"sort"
 	"strings"
+	"unicode/utf8"
 )
 
 type tokenID int // type to ensure safety when manipulating token identifiers.
@@ -44,6 +47,12 @@ type indexedDocument struct {
 	dict    *dictionary     // The corpus dictionary for this document
 	s       *searchSet      // The searchset for this document
 	runes   []rune
+	numbers NumberPolicy // The policy the numbers in this document were tokenized with
+	// cased counts, for a corpus document, the times its text holds each of
+	// the case-preserved phrases of its license with their capitalization.
+	cased []int
+	// text is the original text of content being matched, if known.
+	text []byte
 }
 
 func (d *indexedDocument) generateSearchSet(q int) {
@@ -69,6 +78,19 @@ func (d *indexedDocument) normalized() string {
 	return w.String()
 }
 
+// normalizedLength returns the length of the normalized text of the document,
+// computed from its tokens so that corpus documents needn't keep the text.
+func (d *indexedDocument) normalizedLength() int {
+	if d.size() == 0 {
+		return 0
+	}
+	n := d.size() - 1
+	for _, t := range d.Tokens {
+		n += len(d.dict.getWord(t.ID))
+	}
+	return n
+}
+
 func computeQ(threshold float64) int {
 	// q is the lower bound for token runs (q-grams) that must exist
 	// in content that can be recognized at the specified threshold.
@@ -96,32 +118,180 @@ func max(a, b int) int {
 // AddContent incorporates the provided textual content into the classifier for
 // matching. This will not modify the supplied content.
 func (c *Classifier) AddContent(category, name, variant string, content []byte) {
-	// Since bytes.NewReader().Read() will never return an error, tokenizeStream
-	// will never return an error so it's okay to ignore the return value in this
-	// case.
-	doc, _ := tokenizeStream(bytes.NewReader(content), true, c.dict, true)
+	c.unshare()
+	doc := c.tokenizeLicense(name, content, c.dict)
 	c.addDocument(category, name, variant, doc)
 }
 
+// AddLicense adds the text of a license variant of the category, such as
+// "License" or "Header", to the corpus, replacing the variant if the corpus
+// already holds it. Unlike AddContent, it may be called while the classifier
+// is matching content, so that licenses can be added to a classifier in use,
+// for example from configuration. Metadata for the license must be set with
+// SetMetadata before it's added. Names must only hold letters, digits and the
+// characters ".", "_", "+" and "-".
+func (c *Classifier) AddLicense(category, name, variant string, text []byte) error {
+	for _, s := range []string{category, name, variant} {
+		if !licenseNameRE.MatchString(s) {
+			return fmt.Errorf("invalid license name %q", s)
+		}
+	}
+	// Content loaded lazily is loaded first so that it can't replace the
+	// license later.
+	if err := c.loadLazy(); err != nil {
+		return err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.unshare()
+	doc := c.tokenizeLicense(name, text, c.dict)
+	if doc.size() == 0 {
+		return fmt.Errorf("license %s/%s/%s has no text", category, name, variant)
+	}
+	c.removeDocument(c.generateDocName(category, name, variant))
+	c.addDocument(category, name, variant, doc)
+	return nil
+}
+
+// RemoveLicense removes a license variant of the category from the corpus, or
+// all the variants of the license in the category if variant is empty. It
+// reports whether anything was removed. Like AddLicense, it may be called
+// while the classifier is matching content.
+func (c *Classifier) RemoveLicense(category, name, variant string) bool {
+	if err := c.loadLazy(); err != nil {
+		return false
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	var names []string
+	for n := range c.docs {
+		if detectionType(n) == category && LicenseName(n) == name && (variant == "" || n == c.generateDocName(category, name, variant)) {
+			names = append(names, n)
+		}
+	}
+	if len(names) == 0 {
+		return false
+	}
+	c.unshare()
+	for _, n := range names {
+		c.removeDocument(n)
+	}
+	c.forgetLicense(name)
+	return true
+}
+
+// forgetLicense drops the metadata of the named license, and its entries in
+// the confusability, once no document of it is left in the corpus.
+func (c *Classifier) forgetLicense(name string) {
+	for n := range c.docs {
+		if LicenseName(n) == name {
+			return
+		}
+	}
+	if _, ok := c.metadata[name]; ok {
+		delete(c.metadata, name)
+		c.updateCased()
+	}
+	if len(c.confusability) == 0 {
+		return
+	}
+	// The confusability may be the caller's of SetConfusability, so it's
+	// copied rather than modified.
+	conf := make(Confusability, len(c.confusability))
+	for n, similar := range c.confusability {
+		if n == name {
+			continue
+		}
+		var kept []SimilarLicense
+		for _, s := range similar {
+			if s.Name != name {
+				kept = append(kept, s)
+			}
+		}
+		if len(kept) > 0 {
+			conf[n] = kept
+		}
+	}
+	c.confusability = conf
+}
+
+// removeDocument removes the indexed document from the corpus, if it's there.
+func (c *Classifier) removeDocument(indexName string) {
+	d, ok := c.docs[indexName]
+	if !ok {
+		return
+	}
+	delete(c.docs, indexName)
+	c.removeChecksum(indexName, d)
+	c.index.reset()
+}
+
 // addDocument takes a textual document and incorporates it into the classifier for matching.
 func (c *Classifier) addDocument(category, name, variant string, id *indexedDocument) {
 	// For documents that are part of the corpus, we add them to the dictionary and
 	// compute their associated search data eagerly so they are ready for matching against
 	// candidates.
 	indexName := c.generateDocName(category, name, variant)
-	id.generateSearchSet(c.q)
-	id.s.origin = indexName
+	c.indexDocument(indexName, id)
 	c.docs[indexName] = id
+	c.addChecksum(indexName, id)
+	c.index.reset()
+}
+
+// indexDocument computes the search data for a corpus document.
+func (c *Classifier) indexDocument(indexName string, id *indexedDocument) {
+	// The normalized text is only needed for content, since the length of
+	// that of corpus documents can be computed from their tokens.
+	id.Norm = ""
+	if c.lowMemory {
+		// Only the data needed by the pre-filter is kept resident; the rest is
+		// regenerated by expand for the documents that pass it.
+		id.runes = nil
+	} else {
+		id.generateSearchSet(c.q)
+		id.s.origin = indexName
+	}
+}
+
+// expand returns a corpus document with the data needed for matching and
+// scoring it. Documents stripped in low-memory mode are rebuilt in a copy so
+// that the corpus itself is never modified while matching.
+func (c *Classifier) expand(name string, d *indexedDocument) *indexedDocument {
+	if d.s != nil {
+		return d
+	}
+	e := *d
+	e.runes = diffWordsToRunes(&e, 0, e.size())
+	e.generateSearchSet(c.q)
+	e.s.origin = name
+	return &e
 }
 
 // createTargetIndexedDocument creates an indexed document without adding the
 // words to the classifier dictionary. This should be used for matching targets, not
 // populating the corpus.
 func (c *Classifier) createTargetIndexedDocument(in []byte) *indexedDocument {
-	doc, _ := tokenizeStream(bytes.NewReader(in), true, c.dict, false)
+	doc, _ := tokenizeStream(bytes.NewReader(in), true, c.dict, false, c.numbers)
 	return doc
 }
 
+// remap moves a document tokenized against the from dictionary to the to
+// dictionary, adding its words to the latter in order of first appearance.
+func (d *indexedDocument) remap(from, to *dictionary) {
+	ids := make(map[tokenID]tokenID, len(from.words))
+	for i, t := range d.Tokens {
+		id, ok := ids[t.ID]
+		if !ok {
+			id = to.add(from.getWord(t.ID))
+			ids[t.ID] = id
+		}
+		d.Tokens[i].ID = id
+	}
+	d.dict = to
+	d.generateFrequencies()
+	d.runes = diffWordsToRunes(d, 0, d.size())
+}
+
 func (c *Classifier) generateDocName(category, name, variant string) string {
 	return fmt.Sprintf("%s%c%s%c%s", category, os.PathSeparator, name, os.PathSeparator, variant)
 }
@@ -138,24 +308,86 @@ type dictionary struct {
 }
 
 func newDictionary() *dictionary {
+	return newDictionarySize(0)
+}
+
+// newDictionarySize creates a dictionary with room for size words.
+func newDictionarySize(size int) *dictionary {
 	return &dictionary{
-		words:   make(map[tokenID]string),
-		indices: make(map[string]tokenID),
+		words:   make(map[tokenID]string, size),
+		indices: make(map[string]tokenID, size),
 	}
 }
 
 // add inserts the provided word into the dictionary if it does not already exist.
+// The ID of a word is derived from a hash of it rather than from the order
+// words are added in, and the IDs of words already in the dictionary never
+// change, so that the indexes and caches built from the IDs of the corpus stay
+// valid as licenses are added. A word whose hash collides with that of a word
+// already in the dictionary takes the next free ID, which does depend on the
+// order the two were added in; addAll adds words in an order of their own.
 func (d *dictionary) add(word string) tokenID {
 	if idx := d.getIndex(word); idx != unknownIndex {
 		return idx
 	}
-	// token IDs start from 1, 0 is reserved for the invalid ID
-	idx := tokenID(len(d.words) + 1)
+	idx := wordID(word)
+	for {
+		if _, taken := d.words[idx]; !taken {
+			break
+		}
+		idx = nextID(idx)
+	}
 	d.words[idx] = word
 	d.indices[word] = idx
 	return idx
 }
 
+// addAll adds the words to the dictionary in sorted order, so that the IDs of
+// words whose hashes collide with each other don't depend on the order the
+// words are given in.
+func (d *dictionary) addAll(words []string) {
+	sorted := append([]string(nil), words...)
+	sort.Strings(sorted)
+	for _, w := range sorted {
+		d.add(w)
+	}
+}
+
+// surrogates are the number of UTF-16 surrogate code points, which can't be
+// token IDs: the IDs are diffed as runes, and surrogates don't survive their
+// conversion to strings.
+const surrogates = 0xE000 - 0xD800
+
+// tokenIDs is the number of token IDs. They range over the valid code
+// points, except 0, which is reserved for the invalid ID.
+const tokenIDs = utf8.MaxRune - surrogates
+
+// wordID returns the ID of a word when it doesn't collide with another.
+func wordID(word string) tokenID {
+	h := fnv.New64a()
+	h.Write([]byte(word))
+	return idAt(h.Sum64() % tokenIDs)
+}
+
+// idAt returns the token ID at position i, from 0 to tokenIDs-1, of the
+// sequence of token IDs.
+func idAt(i uint64) tokenID {
+	id := tokenID(i + 1)
+	if id >= 0xD800 {
+		id += surrogates
+	}
+	return id
+}
+
+// nextID returns the token ID following id, wrapping around.
+func nextID(id tokenID) tokenID {
+	i := uint64(id - 1)
+	if id >= 0xE000 {
+		i -= surrogates
+	}
+	return idAt((i + 1) % tokenIDs)
+}
+
 var unknownWord = "UNKNOWN"
 var unknownIndex = tokenID(0)
 
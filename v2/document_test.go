@@ -16,7 +16,13 @@ package classifier
 
 import (
 	"fmt"
+	"io/ioutil"
+	"path"
+	"runtime"
 	"testing"
+	"unicode/utf8"
+
+	"github.com/google/go-cmp/cmp"
 )
 
 func TestDictionary(t *testing.T) {
@@ -37,10 +43,10 @@ func TestDictionary(t *testing.T) {
 	if got := len(d.indices); got != 1 {
 		t.Errorf("dictionary has %d indices, expected 1", got)
 	}
-	if got := d.getIndex("hello"); got != 1 {
-		t.Errorf("dictionary index: got %d, want 1", got)
+	if got, want := d.getIndex("hello"), wordID("hello"); got != want {
+		t.Errorf("dictionary index: got %d, want %d", got, want)
 	}
-	if got := d.getWord(1); got != "hello" {
+	if got := d.getWord(wordID("hello")); got != "hello" {
 		t.Errorf("dictionary word: got %q, want %q", got, "hello")
 	}
 
@@ -53,15 +59,15 @@ func TestDictionary(t *testing.T) {
 	if got := len(d.indices); got != 1 {
 		t.Errorf("dictionary has %d indices, expected 1", got)
 	}
-	if got := d.getIndex("hello"); got != 1 {
-		t.Errorf("dictionary index: got %d, want 1", got)
+	if got, want := d.getIndex("hello"), wordID("hello"); got != want {
+		t.Errorf("dictionary index: got %d, want %d", got, want)
 	}
-	if got := d.getWord(1); got != "hello" {
+	if got := d.getWord(wordID("hello")); got != "hello" {
 		t.Errorf("dictionary word: got %q, want %q", got, "hello")
 	}
 
 	// Fetching an unknown index returns the special value
-	if got := d.getWord(2); got != unknownWord {
+	if got := d.getWord(nextID(wordID("hello"))); got != unknownWord {
 		t.Errorf("dictionary word: got %q, want %q", got, unknownWord)
 	}
 
@@ -71,6 +77,61 @@ func TestDictionary(t *testing.T) {
 	}
 }
 
+func TestStableTokenIDs(t *testing.T) {
+	a, b := newDictionary(), newDictionary()
+	words := []string{"permission", "is", "hereby", "granted", "free", "of", "charge"}
+	for i := range words {
+		a.add(words[i])
+		b.add(words[len(words)-1-i])
+	}
+	if diff := cmp.Diff(a.indices, b.indices); diff != "" {
+		t.Errorf("token IDs depend on the order words are added in (-a +b):\n%s", diff)
+	}
+
+	// Words whose hashes collide are assigned IDs in sorted order when
+	// added together.
+	ids := make(map[tokenID]string)
+	var collision []string
+	for i := 0; collision == nil; i++ {
+		w := fmt.Sprintf("w%d", i)
+		if o, ok := ids[wordID(w)]; ok {
+			collision = []string{w, o}
+		}
+		ids[wordID(w)] = w
+	}
+	a, b = newDictionary(), newDictionary()
+	a.addAll(collision)
+	b.addAll([]string{collision[1], collision[0]})
+	if diff := cmp.Diff(a.indices, b.indices); diff != "" {
+		t.Errorf("token IDs of colliding words depend on the order they're added in (-a +b):\n%s", diff)
+	}
+
+	// A word whose ID is taken by a colliding word takes the next free ID.
+	d := newDictionary()
+	d.words[wordID("granted")] = "collision"
+	if got, want := d.add("granted"), nextID(wordID("granted")); got != want {
+		t.Errorf("add() of a colliding word = %d, want %d", got, want)
+	}
+}
+
+func TestNextID(t *testing.T) {
+	tests := []struct {
+		id, want tokenID
+	}{
+		{1, 2},
+		{0xD7FF, 0xE000},
+		{utf8.MaxRune, 1},
+	}
+	for _, tt := range tests {
+		if got := nextID(tt.id); got != tt.want {
+			t.Errorf("nextID(%#x) = %#x, want %#x", tt.id, got, tt.want)
+		}
+	}
+	if got := idAt(tokenIDs - 1); got != utf8.MaxRune {
+		t.Errorf("idAt(tokenIDs-1) = %#x, want the last code point", got)
+	}
+}
+
 func TestComputeQ(t *testing.T) {
 	tests := []struct {
 		threshold float64
@@ -110,3 +171,138 @@ func TestComputeQ(t *testing.T) {
 		})
 	}
 }
+
+func TestNormalizedLength(t *testing.T) {
+	c := NewClassifier(defaultThreshold)
+	for _, text := range []string{"", "one", "The quick brown fox jumps over the lazy dog."} {
+		d := c.createTargetIndexedDocument([]byte(text))
+		if got, want := d.normalizedLength(), len(d.normalized()); got != want {
+			t.Errorf("normalizedLength() of %q = %d, want %d", text, got, want)
+		}
+	}
+}
+
+func TestAddRemoveLicense(t *testing.T) {
+	internal := []byte(`Acme Corp Internal License. Permission is granted to employees of Acme
+Corp to use, copy and modify this software for internal purposes only. Any
+distribution of this software outside of Acme Corp is prohibited.`)
+	mit, err := ioutil.ReadFile(path.Join(baseLicenses, "License", "MIT", "pristine.txt"))
+	if err != nil {
+		t.Fatalf("couldn't read MIT license: %v", err)
+	}
+	c, err := classifier()
+	if err != nil {
+		t.Fatalf("couldn't instantiate standard test classifier: %v", err)
+	}
+	names := func(in []byte) []string {
+		var out []string
+		for _, m := range c.Match(in).Matches {
+			out = append(out, m.Name)
+		}
+		return out
+	}
+
+	if err := c.AddLicense("License", "Acme-Internal", "license.txt", internal); err != nil {
+		t.Fatalf("AddLicense() = %v", err)
+	}
+	if diff := cmp.Diff([]string{"Acme-Internal"}, names(internal)); diff != "" {
+		t.Errorf("Match() of added license mismatch (-want +got):\n%s", diff)
+	}
+	// Adding the variant again replaces it.
+	if err := c.AddLicense("License", "Acme-Internal", "license.txt", internal); err != nil {
+		t.Fatalf("AddLicense() = %v", err)
+	}
+	if got, want := len(c.checksums[checksum(c.getIndexedDocument("License", "Acme-Internal", "license.txt"))]), 1; got != want {
+		t.Errorf("AddLicense() recorded %d checksums of the replaced license, want %d", got, want)
+	}
+
+	if !c.RemoveLicense("License", "Acme-Internal", "") {
+		t.Error("RemoveLicense() = false, want true")
+	}
+	if got := names(internal); len(got) != 0 {
+		t.Errorf("Match() of removed license = %v, want no matches", got)
+	}
+	if c.RemoveLicense("License", "Acme-Internal", "") {
+		t.Error("RemoveLicense() of removed license = true, want false")
+	}
+
+	// Removing a license of the corpus drops its checksum, so content holding
+	// its text verbatim is no longer reported as it.
+	if diff := cmp.Diff([]string{"MIT"}, names(mit)); diff != "" {
+		t.Errorf("Match() of MIT mismatch (-want +got):\n%s", diff)
+	}
+	if !c.RemoveLicense("License", "MIT", "pristine.txt") {
+		t.Error("RemoveLicense() of MIT = false, want true")
+	}
+	for _, m := range c.Match(mit).Matches {
+		if m.Name == "MIT" && m.Variant == "pristine.txt" {
+			t.Error("Match() found removed variant of MIT")
+		}
+	}
+
+	for _, args := range [][]string{{"License", "../MIT", "license.txt"}, {"", "MIT", "license.txt"}} {
+		if err := c.AddLicense(args[0], args[1], args[2], internal); err == nil {
+			t.Errorf("AddLicense(%q) = nil, want an error", args)
+		}
+	}
+	if err := c.AddLicense("License", "Empty", "license.txt", []byte(" ")); err == nil {
+		t.Error("AddLicense() of empty license = nil, want an error")
+	}
+}
+
+func TestRemoveLicenseForgetsLicense(t *testing.T) {
+	text := []byte("Acme Corp Internal License. Distribution outside of Acme Corp is prohibited.")
+	c := NewClassifier(defaultThreshold)
+	for _, variant := range []string{"a.txt", "b.txt"} {
+		if err := c.AddLicense("License", "Acme", variant, text); err != nil {
+			t.Fatalf("AddLicense() = %v", err)
+		}
+	}
+	c.SetMetadata("Acme", LicenseMetadata{CasePreserved: []string{"Acme Corp"}})
+	conf := Confusability{
+		"Acme":  {{Name: "Other", Similarity: 0.9}},
+		"Other": {{Name: "Acme", Similarity: 0.9}, {Name: "Third", Similarity: 0.85}},
+	}
+	c.SetConfusability(conf)
+
+	// While a variant is left, the license is kept.
+	c.RemoveLicense("License", "Acme", "a.txt")
+	if _, ok := c.metadata["Acme"]; !ok {
+		t.Error("RemoveLicense() of a variant dropped the metadata of the license")
+	}
+
+	c.RemoveLicense("License", "Acme", "")
+	if _, ok := c.metadata["Acme"]; ok {
+		t.Error("RemoveLicense() left the metadata of the license behind")
+	}
+	if len(c.cased) != 0 {
+		t.Errorf("RemoveLicense() left case-preserved phrases %v behind", c.cased)
+	}
+	want := Confusability{"Other": {{Name: "Third", Similarity: 0.85}}}
+	if diff := cmp.Diff(want, c.confusability); diff != "" {
+		t.Errorf("RemoveLicense() confusability mismatch (-want +got):\n%s", diff)
+	}
+	if len(conf["Other"]) != 2 {
+		t.Error("RemoveLicense() modified the confusability set")
+	}
+}
+
+// BenchmarkCorpusHeap reports the heap retained by a classifier holding the
+// corpus, along with the time taken to load it.
+func BenchmarkCorpusHeap(b *testing.B) {
+	var heap uint64
+	for i := 0; i < b.N; i++ {
+		var before, after runtime.MemStats
+		runtime.GC()
+		runtime.ReadMemStats(&before)
+		c, err := classifier()
+		if err != nil {
+			b.Fatalf("couldn't instantiate standard test classifier: %v", err)
+		}
+		runtime.GC()
+		runtime.ReadMemStats(&after)
+		heap += after.HeapAlloc - before.HeapAlloc
+		runtime.KeepAlive(c)
+	}
+	b.ReportMetric(float64(heap)/float64(b.N), "heap-bytes/op")
+}
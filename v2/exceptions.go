@@ -0,0 +1,94 @@
+// Copyright 2022 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package classifier
+
+import "strings"
+
+// exceptionMatchType is the category of license exceptions, the additional
+// permissions granted along with a license, such as the Classpath exception
+// to the GPL. They're named by their SPDX exception identifiers.
+const exceptionMatchType = "Exception"
+
+// maxExceptionGap is the most lines between an exception and a license match
+// outside of it for the exception to apply to the license.
+const maxExceptionGap = 10
+
+// exceptionFamilies lists the licenses that exceptions apply to, by the
+// prefixes of their names. Exceptions not listed apply to the GNU licenses.
+var exceptionFamilies = map[string][]string{
+	"LLVM-exception": {"Apache"},
+}
+
+var gnuFamilies = []string{"GPL", "LGPL", "AGPL"}
+
+// appliesTo reports whether the exception can apply to the named license.
+func appliesTo(exception, license string) bool {
+	families, ok := exceptionFamilies[exception]
+	if !ok {
+		families = gnuFamilies
+	}
+	for _, f := range families {
+		if license == f || strings.HasPrefix(license, f+"-") {
+			return true
+		}
+	}
+	return false
+}
+
+// associateExceptions sets the AppliesTo of each exception match to the name
+// of the license it qualifies: the License or Header match of a license the
+// exception can apply to that holds or overlaps it, or failing that the
+// nearest such match within maxExceptionGap lines of it. Exceptions further
+// from any such match apply to the license of those matches if they're all
+// of the same license, as when a license file appends the exception to a
+// rendering of the license that only partly matched. Other exceptions are
+// left unassociated.
+func associateExceptions(matches Matches) {
+	for _, e := range matches {
+		if e.MatchType != exceptionMatchType {
+			continue
+		}
+		var best *Match
+		bestGap := maxExceptionGap + 1
+		names := make(map[string]bool)
+		for _, m := range matches {
+			if (m.MatchType != "License" && m.MatchType != "Header") || !appliesTo(e.Name, m.Name) {
+				continue
+			}
+			names[m.Name] = true
+			gap := 0
+			switch {
+			case overlaps(e, m) || overlaps(m, e):
+			case m.EndLine < e.StartLine:
+				gap = e.StartLine - m.EndLine
+			default:
+				gap = m.StartLine - e.EndLine
+			}
+			// Licenses holding the exception are preferred to those next to
+			// it, and more confident matches to less confident ones.
+			if gap < bestGap || (gap == bestGap && m.Confidence > best.Confidence) {
+				best, bestGap = m, gap
+			}
+		}
+		switch {
+		case best != nil:
+			e.AppliesTo = best.Name
+		case len(names) == 1:
+			for n := range names {
+				e.AppliesTo = n
+			}
+		}
+	}
+}
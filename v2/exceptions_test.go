@@ -0,0 +1,133 @@
+// Copyright 2022 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package classifier
+
+import (
+	"io/ioutil"
+	"path"
+	"testing"
+)
+
+func TestAssociateExceptions(t *testing.T) {
+	tests := []struct {
+		name    string
+		matches Matches
+		want    string
+	}{
+		{
+			name: "held by license",
+			matches: Matches{
+				{Name: "GPL-2.0", MatchType: "License", StartLine: 1, EndLine: 300},
+				{Name: "Classpath-exception-2.0", MatchType: "Exception", StartLine: 280, EndLine: 295},
+			},
+			want: "GPL-2.0",
+		},
+		{
+			name: "after header",
+			matches: Matches{
+				{Name: "GPL-2.0", MatchType: "Header", StartLine: 3, EndLine: 15},
+				{Name: "Classpath-exception-2.0", MatchType: "Exception", StartLine: 17, EndLine: 30},
+			},
+			want: "GPL-2.0",
+		},
+		{
+			name: "nearest license",
+			matches: Matches{
+				{Name: "GPL-2.0", MatchType: "Header", StartLine: 3, EndLine: 15},
+				{Name: "Bison-exception-2.2", MatchType: "Exception", StartLine: 20, EndLine: 30},
+				{Name: "GPL-3.0", MatchType: "Header", StartLine: 32, EndLine: 45},
+			},
+			want: "GPL-3.0",
+		},
+		{
+			name: "family of the exception",
+			matches: Matches{
+				{Name: "MIT", MatchType: "License", StartLine: 1, EndLine: 20},
+				{Name: "Apache-2.0", MatchType: "License", StartLine: 30, EndLine: 230},
+				{Name: "LLVM-exception", MatchType: "Exception", StartLine: 21, EndLine: 28},
+			},
+			want: "Apache-2.0",
+		},
+		{
+			name: "only license of the family",
+			matches: Matches{
+				{Name: "GPL-2.0", MatchType: "Header", StartLine: 400, EndLine: 415},
+				{Name: "Classpath-exception-2.0", MatchType: "Exception", StartLine: 500, EndLine: 515},
+			},
+			want: "GPL-2.0",
+		},
+		{
+			name: "several distant licenses",
+			matches: Matches{
+				{Name: "GPL-2.0", MatchType: "Header", StartLine: 1, EndLine: 15},
+				{Name: "GPL-3.0", MatchType: "Header", StartLine: 400, EndLine: 415},
+				{Name: "Classpath-exception-2.0", MatchType: "Exception", StartLine: 200, EndLine: 215},
+			},
+			want: "",
+		},
+		{
+			name: "no license",
+			matches: Matches{
+				{Name: "MIT", MatchType: "License", StartLine: 1, EndLine: 20},
+				{Name: "GCC-exception-2.0", MatchType: "Exception", StartLine: 21, EndLine: 28},
+			},
+			want: "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			associateExceptions(tt.matches)
+			for _, m := range tt.matches {
+				if m.MatchType == "Exception" && m.AppliesTo != tt.want {
+					t.Errorf("associateExceptions() applied %s to %q, want %q", m.Name, m.AppliesTo, tt.want)
+				}
+			}
+		})
+	}
+}
+
+func TestMatchException(t *testing.T) {
+	c, err := classifier()
+	if err != nil {
+		t.Fatalf("couldn't instantiate standard test classifier: %v", err)
+	}
+	header, err := ioutil.ReadFile(path.Join(baseLicenses, "Header", "GPL-2.0", "a.txt"))
+	if err != nil {
+		t.Fatalf("couldn't read GPL-2.0 header: %v", err)
+	}
+	exception, err := ioutil.ReadFile(path.Join(baseLicenses, "Exception", "Classpath-exception-2.0", "license.txt"))
+	if err != nil {
+		t.Fatalf("couldn't read Classpath exception: %v", err)
+	}
+
+	in := append(append(header, '\n'), exception...)
+	matches := c.Match(in).Matches
+	var found bool
+	for _, m := range matches {
+		if m.MatchType == "Exception" {
+			found = true
+			if m.Name != "Classpath-exception-2.0" || m.AppliesTo != "GPL-2.0" {
+				t.Errorf("Match() found exception %s applying to %q, want Classpath-exception-2.0 applying to GPL-2.0", m.Name, m.AppliesTo)
+			}
+		}
+	}
+	if !found {
+		t.Error("Match() didn't find the exception")
+	}
+	if got, want := matches.SPDXExpression(), "GPL-2.0-or-later WITH Classpath-exception-2.0"; got != want {
+		t.Errorf("SPDXExpression() = %q, want %q", got, want)
+	}
+}
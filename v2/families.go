@@ -0,0 +1,73 @@
+// Copyright 2022 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package classifier
+
+import "strings"
+
+// licenseFamilies maps the prefixes of license names to the families of the
+// licenses, which group the versions and variants of a license and closely
+// related licenses.
+var licenseFamilies = map[string]string{
+	"AFL":      "AFL-family",
+	"AGPL":     "GPL-family",
+	"Apache":   "Apache-family",
+	"APSL":     "APSL-family",
+	"Artistic": "Artistic-family",
+	"BSD":      "BSD-family",
+	"CC":       "CC-family",
+	"CC0":      "CC-family",
+	"CDDL":     "CDDL-family",
+	"EPL":      "EPL-family",
+	"EUPL":     "EUPL-family",
+	"GPL":      "GPL-family",
+	"LGPL":     "GPL-family",
+	"MIT":      "MIT-family",
+	"MPL":      "MPL-family",
+	"OSL":      "OSL-family",
+	"ZPL":      "ZPL-family",
+}
+
+// WithFamilies sets the Family of License, Header and Reference matches to
+// the family of their license, such as "GPL-family" for GPL-2.0, LGPL-2.1 and
+// AGPL-3.0, for policies that apply to all the licenses of a family.
+func WithFamilies() OptionFunc {
+	return func(c *Classifier) {
+		c.families = true
+	}
+}
+
+// Family returns the family of the named license of the corpus, or "" if it
+// doesn't belong to one. The metadata of the license can set its family, for
+// example for licenses of a custom corpus; otherwise licenses are grouped
+// into families by the first element of their names.
+func (c *Classifier) Family(name string) string {
+	if m, ok := c.metadata[name]; ok && m.Family != "" {
+		return m.Family
+	}
+	prefix := name
+	if i := strings.IndexByte(name, '-'); i != -1 {
+		prefix = name[:i]
+	}
+	return licenseFamilies[prefix]
+}
+
+// setFamilies sets the families of the matches that identify a license.
+func (c *Classifier) setFamilies(matches Matches) {
+	for _, m := range matches {
+		if spdxMatchTypes[m.MatchType] {
+			m.Family = c.Family(m.Name)
+		}
+	}
+}
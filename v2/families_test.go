@@ -0,0 +1,68 @@
+// Copyright 2022 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package classifier
+
+import "testing"
+
+func TestFamily(t *testing.T) {
+	c := NewClassifier(defaultThreshold)
+	c.SetMetadata("Custom-BSD", LicenseMetadata{Family: "BSD-family"})
+
+	tests := []struct {
+		name string
+		want string
+	}{
+		{"GPL-2.0", "GPL-family"},
+		{"LGPL-2.1", "GPL-family"},
+		{"AGPL-3.0", "GPL-family"},
+		{"GPL-2.0-with-classpath-exception", "GPL-family"},
+		{"BSD-3-Clause", "BSD-family"},
+		{"CC-BY-SA-4.0", "CC-family"},
+		{"CC0-1.0", "CC-family"},
+		{"Apache-with-LLVM-Exception", "Apache-family"},
+		{"MIT", "MIT-family"},
+		{"Custom-BSD", "BSD-family"},
+		{"Zlib", ""},
+		{"LGPLLR", ""},
+	}
+	for _, tt := range tests {
+		if got := c.Family(tt.name); got != tt.want {
+			t.Errorf("Family(%q) = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestWithFamilies(t *testing.T) {
+	in := []byte("Permission to use, copy, modify and distribute this software for any purpose with or without fee is hereby granted.")
+	for _, enabled := range []bool{false, true} {
+		var options []OptionFunc
+		if enabled {
+			options = append(options, WithFamilies())
+		}
+		c := NewClassifier(defaultThreshold, options...)
+		c.AddContent("License", "BSD-Permission", "license.txt", in)
+		ms := c.Match(in).Matches
+		if len(ms) != 1 {
+			t.Fatalf("Match() returned %d matches, want 1", len(ms))
+		}
+		want := ""
+		if enabled {
+			want = "BSD-family"
+		}
+		if ms[0].Family != want {
+			t.Errorf("Match() with families %v reported family %q, want %q", enabled, ms[0].Family, want)
+		}
+	}
+}
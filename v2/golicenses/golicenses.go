@@ -0,0 +1,108 @@
+// Copyright 2022 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package golicenses classifies the license files of Go modules for
+// go-licenses and similar tools. It is kept stable as the classifier evolves,
+// and its compatibility tests pin the results tools rely on, so that they
+// needn't depend on the match types, names and categories of the classifier
+// directly.
+package golicenses
+
+import (
+	"io/ioutil"
+	"sort"
+
+	classifier "github.com/google/licenseclassifier/v2"
+	"github.com/google/licenseclassifier/v2/assets"
+)
+
+// DefaultConfidenceThreshold is the confidence go-licenses requires of the
+// licenses it reports.
+const DefaultConfidenceThreshold = 0.9
+
+// License is a license found in a license file.
+type License struct {
+	// Name is the SPDX identifier of the license, or an SPDX expression
+	// for a license with an exception, such as "GPL-2.0-only WITH
+	// Classpath-exception-2.0".
+	Name string
+	// Type is the category of the license.
+	Type Type
+	// Confidence is the confidence of the match, from 0 to 1.
+	Confidence float64
+}
+
+// Classifier identifies the licenses of license files.
+type Classifier struct {
+	c *classifier.Classifier
+}
+
+// NewClassifier creates a classifier that matches against the embedded
+// license corpus, reporting licenses found with at least the given
+// confidence.
+func NewClassifier(threshold float64) (*Classifier, error) {
+	c, err := assets.NewClassifier(threshold, assets.WithCategories("License"))
+	if err != nil {
+		return nil, err
+	}
+	return &Classifier{c: c}, nil
+}
+
+// NewFromClassifier creates a classifier backed by a v2 classifier, such as
+// one with a custom corpus.
+func NewFromClassifier(c *classifier.Classifier) *Classifier {
+	return &Classifier{c: c}
+}
+
+// Identify returns the licenses found in the license file at licensePath,
+// most confident first, each once. Only the texts of licenses are
+// identified; headers and references to licenses are not.
+func (c *Classifier) Identify(licensePath string) ([]License, error) {
+	b, err := ioutil.ReadFile(licensePath)
+	if err != nil {
+		return nil, err
+	}
+	return c.IdentifyContent(b), nil
+}
+
+// IdentifyContent returns the licenses found in the contents of a license
+// file, as Identify does.
+func (c *Classifier) IdentifyContent(contents []byte) []License {
+	seen := make(map[string]int)
+	var licenses []License
+	for _, m := range c.c.Match(contents).Matches {
+		if m.MatchType != "License" {
+			continue
+		}
+		if i, ok := seen[m.Name]; ok {
+			if m.Confidence > licenses[i].Confidence {
+				licenses[i].Confidence = m.Confidence
+			}
+			continue
+		}
+		seen[m.Name] = len(licenses)
+		licenses = append(licenses, License{
+			Name:       classifier.Matches{m}.SPDXExpression(),
+			Type:       LicenseType(m.Name),
+			Confidence: m.Confidence,
+		})
+	}
+	sort.SliceStable(licenses, func(i, j int) bool {
+		if licenses[i].Confidence != licenses[j].Confidence {
+			return licenses[i].Confidence > licenses[j].Confidence
+		}
+		return licenses[i].Name < licenses[j].Name
+	})
+	return licenses
+}
@@ -0,0 +1,113 @@
+// Copyright 2022 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package golicenses
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+// TestIdentify pins the results go-licenses relies on. A change to them breaks
+// its users, so update the wants only for deliberate changes of behavior.
+func TestIdentify(t *testing.T) {
+	c, err := NewClassifier(DefaultConfidenceThreshold)
+	if err != nil {
+		t.Fatalf("NewClassifier() = %v", err)
+	}
+
+	tests := []struct {
+		file     string
+		wantName string
+		wantType Type
+	}{
+		{"Apache-2.0/pristine.txt", "Apache-2.0", Notice},
+		{"BSD-3-Clause/pristine.txt", "BSD-3-Clause", Notice},
+		{"GPL-2.0/license.txt", "GPL-2.0-only", Restricted},
+		{"GPL-2.0-with-classpath-exception/license.txt", "GPL-2.0-only WITH Classpath-exception-2.0", Restricted},
+		{"MIT/pristine.txt", "MIT", Notice},
+		{"MPL-2.0/pristine.txt", "MPL-2.0", Reciprocal},
+		{"Unlicense/license.txt", "Unlicense", Unencumbered},
+		{"WTFPL/license.txt", "WTFPL", Forbidden},
+		{"JSON/license.txt", "JSON", Unknown},
+	}
+	for _, tt := range tests {
+		t.Run(tt.file, func(t *testing.T) {
+			got, err := c.Identify(filepath.Join("../assets/License", tt.file))
+			if err != nil {
+				t.Fatalf("Identify() = %v", err)
+			}
+			if len(got) == 0 {
+				t.Fatal("Identify() found no licenses")
+			}
+			if got[0].Name != tt.wantName || got[0].Type != tt.wantType {
+				t.Errorf("Identify() = %+v, want %s of type %q", got[0], tt.wantName, tt.wantType)
+			}
+			if got[0].Confidence < DefaultConfidenceThreshold {
+				t.Errorf("Identify() confidence = %v, want at least %v", got[0].Confidence, DefaultConfidenceThreshold)
+			}
+		})
+	}
+
+	if _, err := c.Identify("../assets/License/no-such-file"); err == nil {
+		t.Error("Identify() of a missing file succeeded, want error")
+	}
+}
+
+func TestIdentifyContent(t *testing.T) {
+	c, err := NewClassifier(DefaultConfidenceThreshold)
+	if err != nil {
+		t.Fatalf("NewClassifier() = %v", err)
+	}
+	if got := c.IdentifyContent([]byte("Nothing to see here.")); len(got) != 0 {
+		t.Errorf("IdentifyContent() = %+v, want no licenses", got)
+	}
+
+	// A license file holding two licenses reports each once.
+	mit, err := ioutil.ReadFile("../assets/License/MIT/pristine.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	apache, err := ioutil.ReadFile("../assets/License/Apache-2.0/pristine.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	contents := append(append(append([]byte{}, mit...), "\n\n"...), apache...)
+	var names []string
+	for _, l := range c.IdentifyContent(contents) {
+		names = append(names, l.Name)
+	}
+	if diff := cmp.Diff([]string{"Apache-2.0", "MIT"}, names); diff != "" {
+		t.Errorf("IdentifyContent() mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestLicenseType(t *testing.T) {
+	for name, want := range map[string]Type{
+		"AGPL-3.0":   Forbidden,
+		"LGPL-2.1":   Restricted,
+		"EPL-1.0":    Reciprocal,
+		"ISC":        Notice,
+		"CC0-1.0":    Unencumbered,
+		"Beerware":   Unknown,
+		"no-license": Unknown,
+	} {
+		if got := LicenseType(name); got != want {
+			t.Errorf("LicenseType(%q) = %q, want %q", name, got, want)
+		}
+	}
+}
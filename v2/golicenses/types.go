@@ -0,0 +1,38 @@
+// Copyright 2022 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package golicenses
+
+import "github.com/google/licenseclassifier/v2/assets"
+
+// Type is the category of a license, which determines the obligations of code
+// under it.
+type Type string
+
+// The categories of licenses, as those of the v1 LicenseType.
+const (
+	Restricted   Type = "restricted"
+	Reciprocal   Type = "reciprocal"
+	Notice       Type = "notice"
+	Permissive   Type = "permissive"
+	Unencumbered Type = "unencumbered"
+	Forbidden    Type = "FORBIDDEN"
+	Unknown      Type = ""
+)
+
+// LicenseType returns the category of the license with the given corpus name,
+// or Unknown if it hasn't been categorized.
+func LicenseType(name string) Type {
+	return Type(assets.LicenseType(name))
+}
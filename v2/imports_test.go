@@ -0,0 +1,107 @@
+// Copyright 2022 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package classifier
+
+import (
+	"go/parser"
+	gotoken "go/token"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// libraryImports are the imports outside the standard library allowed in the
+// non-test sources of the library, so that consumers who only match content
+// don't build the dependencies of the tests and tools.
+var libraryImports = map[string]bool{
+	"github.com/google/licenseclassifier/v2/textcompare": true,
+	"github.com/sergi/go-diff/diffmatchpatch":            true,
+}
+
+func TestLibraryImports(t *testing.T) {
+	for _, dir := range []string{".", "textcompare"} {
+		files, err := filepath.Glob(filepath.Join(dir, "*.go"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, f := range files {
+			if strings.HasSuffix(f, "_test.go") {
+				continue
+			}
+			parsed, err := parser.ParseFile(gotoken.NewFileSet(), f, nil, parser.ImportsOnly)
+			if err != nil {
+				t.Fatalf("couldn't parse %s: %v", f, err)
+			}
+			for _, imp := range parsed.Imports {
+				path, _ := strconv.Unquote(imp.Path.Value)
+				if strings.Contains(strings.Split(path, "/")[0], ".") && !libraryImports[path] {
+					t.Errorf("%s imports %s, which isn't allowed in the library", f, path)
+				}
+			}
+		}
+	}
+}
+
+// TestCoreBuild checks that the library, the programs built on it and their
+// tests build with the core tag, which leaves out the detections in the raw
+// text and the APIs beyond matching. Tests of the APIs left out are tagged to
+// be left out along with them.
+func TestCoreBuild(t *testing.T) {
+	if testing.Short() {
+		t.Skip("building the library is slow")
+	}
+	goTool, err := exec.LookPath("go")
+	if err != nil {
+		t.Skip("the go tool isn't available")
+	}
+	if out, err := exec.Command(goTool, "build", "-tags", "core", "./...").CombinedOutput(); err != nil {
+		t.Errorf("go build -tags core ./... failed: %v\n%s", err, out)
+	}
+	// Vetting compiles the tests too.
+	if out, err := exec.Command(goTool, "vet", "-tags", "core", "./...").CombinedOutput(); err != nil {
+		t.Errorf("go vet -tags core ./... failed: %v\n%s", err, out)
+	}
+}
+
+// TestSQLiteBuild checks that identify_license builds with the sqlite tag,
+// which links the SQLite driver required by the module.
+func TestSQLiteBuild(t *testing.T) {
+	if testing.Short() {
+		t.Skip("building the driver is slow")
+	}
+	goTool, err := exec.LookPath("go")
+	if err != nil {
+		t.Skip("the go tool isn't available")
+	}
+	if out, err := exec.Command(goTool, "env", "CGO_ENABLED").Output(); err != nil || strings.TrimSpace(string(out)) != "1" {
+		t.Skip("the SQLite driver requires cgo")
+	}
+	bin := filepath.Join(t.TempDir(), "identify_license")
+	if out, err := exec.Command(goTool, "build", "-tags", "sqlite", "-o", bin, "./tools/identify_license").CombinedOutput(); err != nil {
+		t.Errorf("go build -tags sqlite ./tools/identify_license failed: %v\n%s", err, out)
+	}
+}
+
+func TestDump(t *testing.T) {
+	type r struct{ A, B int }
+	if got, want := dump([]*r{{1, 2}, {3, 4}}), "(len=2)\n  0: &{A:1 B:2}\n  1: &{A:3 B:4}\n"; got != want {
+		t.Errorf("dump() = %q, want %q", got, want)
+	}
+	if got, want := dump(r{1, 2}), "{A:1 B:2}\n"; got != want {
+		t.Errorf("dump() = %q, want %q", got, want)
+	}
+}
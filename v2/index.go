@@ -0,0 +1,139 @@
+// Copyright 2022 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package classifier
+
+import (
+	"sort"
+	"sync"
+)
+
+// qgramIndex is an inverted index of the q-grams of the documents of the
+// corpus, mapping the checksum of each q-gram to the documents holding it and
+// where. The q-grams of content are looked up in it once for all the licenses
+// of the corpus, rather than once for each license that passes the
+// pre-filter, and licenses sharing no q-gram with the content aren't scored
+// at all.
+type qgramIndex struct {
+	names    []string
+	postings map[uint32][]qgramPosting
+}
+
+// qgramPosting is an occurrence of a q-gram in the document of the index
+// numbered doc, starting at its token start.
+type qgramPosting struct {
+	doc, start int32
+}
+
+// qgramHit is a q-gram of content starting at its token target that's also
+// found in a document of the corpus, starting at its token src.
+type qgramHit struct {
+	target, src int
+}
+
+// newQgramIndex indexes the q-grams of the documents. Documents whose
+// searchsets aren't kept resident, as in low-memory mode, can't be indexed, in
+// which case it returns nil.
+func newQgramIndex(docs map[string]*indexedDocument) *qgramIndex {
+	x := &qgramIndex{postings: make(map[uint32][]qgramPosting)}
+	for name := range docs {
+		x.names = append(x.names, name)
+	}
+	// The documents are indexed in name order, so that the postings of each
+	// q-gram list the occurrences in each document in order, as the hashes
+	// of its searchset do.
+	sort.Strings(x.names)
+	for i, name := range x.names {
+		s := docs[name].s
+		if s == nil {
+			return nil
+		}
+		for start, cs := range s.Checksums {
+			x.postings[cs] = append(x.postings[cs], qgramPosting{int32(i), int32(start)})
+		}
+	}
+	return x
+}
+
+// probe returns the q-grams that the content, tokenized for each number policy
+// by target, shares with each of the documents, by name. The hits of each
+// document are ordered as targetMatchedRanges visits them. Documents sharing
+// no q-gram with the content are left out.
+func (x *qgramIndex) probe(docs map[string]*indexedDocument, target func(NumberPolicy) *indexedDocument) map[string][]qgramHit {
+	policies := make(map[NumberPolicy]bool)
+	for _, d := range docs {
+		policies[d.numbers] = true
+	}
+	hits := make([][]qgramHit, len(x.names))
+	want := make([]bool, len(x.names))
+	for p := range policies {
+		// Each document is compared against the content tokenized with its
+		// own number policy.
+		for i, name := range x.names {
+			d, ok := docs[name]
+			want[i] = ok && d.numbers == p
+		}
+		t := target(p)
+		for _, n := range t.s.nodes {
+			for _, post := range x.postings[n.checksum] {
+				if want[post.doc] {
+					hits[post.doc] = append(hits[post.doc], qgramHit{n.tokens.Start, int(post.start)})
+				}
+			}
+		}
+	}
+	out := make(map[string][]qgramHit)
+	for i, h := range hits {
+		if len(h) > 0 {
+			out[x.names[i]] = h
+		}
+	}
+	return out
+}
+
+// qgramIndexCache holds the q-gram index of the corpus of a classifier, which
+// is built on first use and rebuilt after the corpus changes.
+type qgramIndexCache struct {
+	mu    sync.Mutex
+	built bool
+	x     *qgramIndex
+}
+
+// get returns the index of the documents, building it if it isn't already.
+// It returns nil if the documents can't be indexed.
+func (x *qgramIndexCache) get(docs map[string]*indexedDocument) *qgramIndex {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	if !x.built {
+		x.x = newQgramIndex(docs)
+		x.built = true
+	}
+	return x.x
+}
+
+// set replaces the index, for example with that of a shared corpus.
+func (x *qgramIndexCache) set(idx *qgramIndex) {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	x.x = idx
+	x.built = true
+}
+
+// reset discards the index, for example when the corpus changes.
+func (x *qgramIndexCache) reset() {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	x.x = nil
+	x.built = false
+}
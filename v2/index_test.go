@@ -0,0 +1,146 @@
+// Copyright 2022 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package classifier
+
+import (
+	"io/ioutil"
+	"path"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+)
+
+func TestQgramIndexProbe(t *testing.T) {
+	c, err := classifier()
+	if err != nil {
+		t.Fatalf("couldn't instantiate standard test classifier: %v", err)
+	}
+	in, err := ioutil.ReadFile(path.Join(baseLicenses, "License", "Apache-2.0", "pristine.txt"))
+	if err != nil {
+		t.Fatalf("couldn't read Apache-2.0 license: %v", err)
+	}
+	id := c.createTargetIndexedDocument(in)
+	target := func(numbers NumberPolicy) *indexedDocument {
+		t := c.createTargetIndexedDocument(in)
+		if numbers == c.numbers {
+			t = id
+		}
+		if t.s == nil {
+			t.generateSearchSet(c.q)
+		}
+		return t
+	}
+
+	hits := newQgramIndex(c.docs).probe(c.docs, target)
+	if len(hits["License/Apache-2.0/pristine.txt"]) == 0 {
+		t.Error("probe() found no q-grams of License/Apache-2.0/pristine.txt")
+	}
+	for name, d := range c.docs {
+		tgt := target(d.numbers)
+		want := targetMatchedRanges(d.s, tgt.s)
+		got := hitRanges(hits[name], d.s.q, tgt.s.q)
+		if diff := cmp.Diff(want, got); diff != "" {
+			t.Errorf("q-grams of %s mismatch (-searchset +index):\n%s", name, diff)
+		}
+	}
+}
+
+func TestQgramIndexMatches(t *testing.T) {
+	c, err := classifier()
+	if err != nil {
+		t.Fatalf("couldn't instantiate standard test classifier: %v", err)
+	}
+	files, err := getScenarioFilenames()
+	if err != nil {
+		t.Fatalf("encountered error walking scenarios directory: %v", err)
+	}
+	// Licenses matched verbatim would be reported without searching the
+	// index.
+	c.noChecksums = true
+	// Matches that tie, such as copyright notices on the same token, may be
+	// reported in either order.
+	order := cmpopts.SortSlices(func(a, b *Match) bool {
+		if a.StartLine != b.StartLine {
+			return a.StartLine < b.StartLine
+		}
+		if a.Name != b.Name {
+			return a.Name < b.Name
+		}
+		return a.Variant < b.Variant
+	})
+	for _, f := range files {
+		in := readScenario(f).data
+		c.index.set(nil)
+		want := c.Match(in)
+		c.index.reset()
+		if diff := cmp.Diff(want, c.Match(in), order); diff != "" {
+			t.Errorf("Match(%q) mismatch with q-gram index (-want +got):\n%s", f, diff)
+		}
+	}
+}
+
+func TestQgramIndexReset(t *testing.T) {
+	mit, err := ioutil.ReadFile(path.Join(baseLicenses, "License", "MIT", "pristine.txt"))
+	if err != nil {
+		t.Fatalf("couldn't read MIT license: %v", err)
+	}
+	isc, err := ioutil.ReadFile(path.Join(baseLicenses, "License", "ISC", "pristine.txt"))
+	if err != nil {
+		t.Fatalf("couldn't read ISC license: %v", err)
+	}
+	c := NewClassifier(.8)
+	c.AddContent("License", "MIT", "pristine.txt", mit)
+	if res := c.Match(mit); len(res.Matches) == 0 {
+		t.Fatal("Match() found no MIT match")
+	}
+	// Content added after the index was built is indexed too.
+	c.AddContent("License", "ISC", "pristine.txt", isc)
+	if res := c.Match(isc); len(res.Matches) == 0 || res.Matches[0].Name != "ISC" {
+		t.Errorf("Match() = %v, want ISC", res.Matches)
+	}
+}
+
+func BenchmarkQgramIndex(b *testing.B) {
+	c, err := classifier()
+	if err != nil {
+		b.Fatalf("couldn't instantiate standard test classifier: %v", err)
+	}
+	c.noChecksums = true
+	in, err := ioutil.ReadFile(path.Join(baseLicenses, "License", "GPL-3.0", "license.txt"))
+	if err != nil {
+		b.Fatalf("couldn't read GPL-3.0 license: %v", err)
+	}
+	for _, bm := range []struct {
+		name    string
+		indexed bool
+	}{
+		{"indexed", true},
+		{"searchsets", false},
+	} {
+		b.Run(bm.name, func(b *testing.B) {
+			if bm.indexed {
+				c.index.reset()
+				c.Match(in)
+			} else {
+				c.index.set(nil)
+			}
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				c.Match(in)
+			}
+		})
+	}
+}
@@ -0,0 +1,44 @@
+// Copyright 2022 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package classifier
+
+// ContentLoader adds content to a classifier, for example with AddContent or
+// LoadLicenses.
+type ContentLoader func(c *Classifier) error
+
+// WithLazyContent defers adding the content of load until the classifier is
+// first used for matching, is warmed up or has its corpus shared, so that a
+// deployment that never needs the content doesn't pay for holding it. If load
+// fails, WarmUp and MatchFrom return its error and Match reports no matches.
+func WithLazyContent(load ContentLoader) OptionFunc {
+	return func(c *Classifier) {
+		c.lazy = append(c.lazy, load)
+	}
+}
+
+// loadLazy adds the lazily loaded content to the classifier the first time it
+// is called, and returns the error from doing so on every call.
+func (c *Classifier) loadLazy() error {
+	c.lazyOnce.Do(func() {
+		for _, load := range c.lazy {
+			if err := load(c); err != nil {
+				c.lazyErr = err
+				return
+			}
+		}
+		c.lazy = nil
+	})
+	return c.lazyErr
+}
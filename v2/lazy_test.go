@@ -0,0 +1,85 @@
+// Copyright 2022 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package classifier
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+)
+
+func TestLazyContent(t *testing.T) {
+	text := []byte("This software is released into the public domain by its authors.")
+	loads := 0
+	load := func(c *Classifier) error {
+		loads++
+		c.AddContent("License", "Short", "license.txt", text)
+		return nil
+	}
+
+	c := NewClassifier(defaultThreshold, WithLazyContent(load))
+	if loads != 0 {
+		t.Fatalf("content was loaded %d times before the classifier was used", loads)
+	}
+	if got := len(c.Match(text).Matches); got != 1 {
+		t.Errorf("Match() returned %d matches, want 1", got)
+	}
+	if got := len(c.Match(text).Matches); got != 1 {
+		t.Errorf("Match() returned %d matches, want 1", got)
+	}
+	if err := c.WarmUp(); err != nil {
+		t.Errorf("WarmUp() = %v", err)
+	}
+	if loads != 1 {
+		t.Errorf("content was loaded %d times, want 1", loads)
+	}
+}
+
+func TestLazyContentError(t *testing.T) {
+	wantErr := errors.New("no content")
+	c := NewClassifier(defaultThreshold, WithLazyContent(func(*Classifier) error {
+		return wantErr
+	}))
+	if err := c.WarmUp(); err != wantErr {
+		t.Errorf("WarmUp() = %v, want %v", err, wantErr)
+	}
+	if _, err := c.MatchFrom(bytes.NewReader([]byte("content"))); err != wantErr {
+		t.Errorf("MatchFrom() = %v, want %v", err, wantErr)
+	}
+}
+
+func TestLazyContentAfterClose(t *testing.T) {
+	text := []byte("This software is released into the public domain by its authors.")
+	c := NewClassifier(defaultThreshold, WithLazyContent(func(*Classifier) error {
+		return errors.New("no content")
+	}))
+	if err := c.WarmUp(); err == nil {
+		t.Fatal("WarmUp() succeeded, want the error of loading the content")
+	}
+
+	// Content added lazily after Close is loaded, and the earlier error is
+	// forgotten.
+	c.Close()
+	WithLazyContent(func(c *Classifier) error {
+		c.AddContent("License", "Short", "license.txt", text)
+		return nil
+	})(c)
+	if err := c.WarmUp(); err != nil {
+		t.Errorf("WarmUp() after Close = %v", err)
+	}
+	if got := len(c.Match(text).Matches); got != 1 {
+		t.Errorf("Match() after Close returned %d matches, want 1", got)
+	}
+}
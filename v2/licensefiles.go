@@ -0,0 +1,42 @@
+// Copyright 2022 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package classifier
+
+import (
+	"path/filepath"
+	"strings"
+)
+
+// licenseFilePrefixes are the base name prefixes of files that conventionally
+// hold license texts or parts of them, ordered by how likely the file is to
+// hold the start of a license.
+var licenseFilePrefixes = []string{"license", "licence", "copying", "copyright", "unlicense", "patents", "notice", "legal"}
+
+// LicenseFileRank returns how likely a file is to hold the start of a license
+// based on its name: lower values are more likely. It returns -1 if the name
+// doesn't follow a convention for files holding license text, such as
+// LICENSE, COPYING, NOTICE, PATENTS, LEGAL or a *.license sidecar.
+func LicenseFileRank(path string) int {
+	base := strings.ToLower(filepath.Base(path))
+	for i, p := range licenseFilePrefixes {
+		if strings.HasPrefix(base, p) {
+			return i
+		}
+	}
+	if strings.HasSuffix(base, ".license") {
+		return len(licenseFilePrefixes)
+	}
+	return -1
+}
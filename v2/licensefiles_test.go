@@ -0,0 +1,37 @@
+// Copyright 2022 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package classifier
+
+import "testing"
+
+func TestLicenseFileRank(t *testing.T) {
+	tests := []struct {
+		path string
+		want int
+	}{
+		{path: "LICENSE", want: 0},
+		{path: "a/b/LICENSE-MIT.txt", want: 0},
+		{path: "COPYING", want: 2},
+		{path: "NOTICE.md", want: 6},
+		{path: "LEGAL", want: 7},
+		{path: "logo.svg.license", want: 8},
+		{path: "README.md", want: -1},
+	}
+	for _, tt := range tests {
+		if got := LicenseFileRank(tt.path); got != tt.want {
+			t.Errorf("LicenseFileRank(%q) = %d, want %d", tt.path, got, tt.want)
+		}
+	}
+}
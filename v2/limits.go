@@ -0,0 +1,53 @@
+// Copyright 2022 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package classifier
+
+// limitTokens cuts the content d down to the number of tokens set with
+// WithMaxTokens, along with the matches found in its text beyond the last
+// line it keeps, and reports whether it was cut.
+func (c *Classifier) limitTokens(d *indexedDocument) bool {
+	if c.maxTokens <= 0 || d.size() <= c.maxTokens {
+		return false
+	}
+	d.Tokens = d.Tokens[:c.maxTokens]
+	d.Matches = limitMatches(d.Matches, d)
+	d.generateFrequencies()
+	d.runes = diffWordsToRunes(d, 0, d.size())
+	d.Norm = d.normalized()
+	return true
+}
+
+// limitMatches returns the matches starting no later than the last line of
+// the tokens of d, which has been cut by limitTokens.
+func limitMatches(ms Matches, d *indexedDocument) Matches {
+	last := 0
+	if d.size() > 0 {
+		last = d.Tokens[d.size()-1].Line
+	}
+	var out Matches
+	for _, m := range ms {
+		if m.StartLine <= last {
+			out = append(out, m)
+		}
+	}
+	return out
+}
+
+// exceedsDiffSize reports whether diffing a region of n tokens of content
+// against a document of size tokens exceeds the limit set with
+// WithMaxDiffSize.
+func (c *Classifier) exceedsDiffSize(n, size int) bool {
+	return c.maxDiff > 0 && n+size > c.maxDiff
+}
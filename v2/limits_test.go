@@ -0,0 +1,169 @@
+// Copyright 2022 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package classifier
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"io/ioutil"
+	"path"
+	"strings"
+	"testing"
+	"testing/iotest"
+)
+
+func limitsClassifier(t *testing.T, options ...OptionFunc) (c *Classifier, mit, isc []byte) {
+	t.Helper()
+	mit, err := ioutil.ReadFile(path.Join(baseLicenses, "License", "MIT", "pristine.txt"))
+	if err != nil {
+		t.Fatalf("couldn't read MIT license: %v", err)
+	}
+	isc, err = ioutil.ReadFile(path.Join(baseLicenses, "License", "ISC", "pristine.txt"))
+	if err != nil {
+		t.Fatalf("couldn't read ISC license: %v", err)
+	}
+	c = NewClassifier(.8, options...)
+	c.AddContent("License", "MIT", "pristine.txt", mit)
+	c.AddContent("License", "ISC", "pristine.txt", isc)
+	return c, mit, isc
+}
+
+func matchNames(ms Matches) []string {
+	var names []string
+	for _, m := range ms {
+		if m.MatchType == "License" {
+			names = append(names, m.Name)
+		}
+	}
+	return names
+}
+
+func TestNoLimits(t *testing.T) {
+	c, mit, isc := limitsClassifier(t)
+	in := bytes.Join([][]byte{mit, isc}, []byte("\n\n"))
+	res := c.Match(in)
+	if got := matchNames(res.Matches); len(got) != 2 || res.Truncated {
+		t.Errorf("Match() = %v with Truncated %v, want MIT and ISC with Truncated false", got, res.Truncated)
+	}
+}
+
+func TestWithMaxTokens(t *testing.T) {
+	c, mit, isc := limitsClassifier(t)
+	n := c.createTargetIndexedDocument(mit).size()
+	WithMaxTokens(n + 5)(c)
+
+	// The ISC license follows the tokens kept, so only MIT is matched.
+	in := bytes.Join([][]byte{mit, isc}, []byte("\n\n"))
+	res := c.Match(in)
+	if got := matchNames(res.Matches); len(got) != 1 || got[0] != "MIT" || !res.Truncated {
+		t.Errorf("Match() = %v with Truncated %v, want MIT with Truncated true", got, res.Truncated)
+	}
+
+	// Content within the limit isn't truncated.
+	if res := c.Match(mit); res.Truncated {
+		t.Error("Match() of content within the limit has Truncated set")
+	}
+
+	tokens := bytes.Fields(bytes.ToLower(in))
+	words := make([]string, len(tokens))
+	lines := make([]int, len(tokens))
+	for i, w := range tokens {
+		words[i] = string(w)
+		lines[i] = 1
+	}
+	res, err := c.MatchTokens(words, lines)
+	if err != nil {
+		t.Fatalf("MatchTokens() returned error: %v", err)
+	}
+	if !res.Truncated {
+		t.Error("MatchTokens() of content exceeding the limit doesn't have Truncated set")
+	}
+}
+
+func TestWithMaxTokensStopsReading(t *testing.T) {
+	c, mit, _ := limitsClassifier(t, WithNameReferences())
+	n := c.createTargetIndexedDocument(mit).size()
+	WithMaxTokens(n + 5)(c)
+
+	// A reference past the tokens kept isn't searched for, and the content
+	// isn't read as far as the reader failing.
+	filler := bytes.Repeat([]byte("Lorem ipsum dolor sit amet, consectetur adipiscing elit.\n"), 100)
+	rest := append(append([]byte(nil), filler...), "Licensed under the Apache License, Version 2.0.\n"...)
+	in := io.MultiReader(bytes.NewReader(mit), bytes.NewReader(rest), iotest.ErrReader(errors.New("read too far")))
+	res, err := c.MatchFrom(in)
+	if err != nil {
+		t.Fatalf("MatchFrom() returned error: %v", err)
+	}
+	if !res.Truncated {
+		t.Error("MatchFrom() of content exceeding the limit doesn't have Truncated set")
+	}
+	for _, m := range res.Matches {
+		if m.Name != "MIT" {
+			t.Errorf("MatchFrom() found %s %s past the tokens kept", m.MatchType, m.Name)
+		}
+	}
+}
+
+func TestTokenizePrefix(t *testing.T) {
+	in := "one two\nthree four\nfive six\n"
+	doc, cut, err := tokenizePrefix(strings.NewReader(in), true, newDictionary(), true, NumbersDefault, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Tokenizing stops at the end of the line holding the fourth token.
+	if want := len("one two\nthree four\n"); cut != want {
+		t.Errorf("tokenizePrefix() cut = %d, want %d", cut, want)
+	}
+	if doc.size() != 4 {
+		t.Errorf("tokenizePrefix() produced %d tokens, want 4", doc.size())
+	}
+	if _, cut, _ := tokenizePrefix(strings.NewReader(in), true, newDictionary(), true, NumbersDefault, 6); cut != -1 {
+		t.Errorf("tokenizePrefix() of content within the limit cut = %d, want -1", cut)
+	}
+}
+
+func TestWithMaxCandidates(t *testing.T) {
+	c, mit, _ := limitsClassifier(t)
+	filler := bytes.Repeat([]byte("Lorem ipsum dolor sit amet, consectetur adipiscing elit.\n"), 20)
+	in := bytes.Join([][]byte{mit, mit, mit}, filler)
+	if got := matchNames(c.Match(in).Matches); len(got) != 3 {
+		t.Fatalf("Match() = %v, want three MIT matches", got)
+	}
+
+	WithMaxCandidates(1)(c)
+	res := c.Match(in)
+	if got := matchNames(res.Matches); len(got) != 1 || got[0] != "MIT" || !res.Truncated {
+		t.Errorf("Match() = %v with Truncated %v, want one MIT match with Truncated true", got, res.Truncated)
+	}
+	if res := c.Match(mit); res.Truncated {
+		t.Error("Match() of content within the limit has Truncated set")
+	}
+}
+
+func TestWithMaxDiffSize(t *testing.T) {
+	c, mit, _ := limitsClassifier(t, WithMaxDiffSize(50))
+	// The license is edited so that it's diffed rather than matched
+	// verbatim.
+	edited := append([]byte("Modified: "), mit...)
+	res := c.Match(edited)
+	if got := matchNames(res.Matches); len(got) != 0 || !res.Truncated {
+		t.Errorf("Match() = %v with Truncated %v, want no matches with Truncated true", got, res.Truncated)
+	}
+	// A license matched verbatim isn't diffed, so the limit doesn't apply.
+	if res := c.Match(mit); res.Truncated || len(matchNames(res.Matches)) != 1 {
+		t.Errorf("Match() of verbatim license = %v with Truncated %v, want one match with Truncated false", matchNames(res.Matches), res.Truncated)
+	}
+}
@@ -0,0 +1,123 @@
+// Copyright 2022 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package classifier
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// lintDuplicateConfidence is the confidence at which a file of a corpus being
+// loaded that matches a different license of the corpus is taken to be a
+// misnamed copy of it.
+const lintDuplicateConfidence = 0.99
+
+// licenseNameRE matches valid names of licenses and variants.
+var licenseNameRE = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._+-]*$`)
+
+// WithCorpusLint validates the files of the directories loaded with
+// LoadLicenses and the file systems loaded with LoadLicensesFS, which then
+// fail with a *CorpusError listing the problems found rather than load a
+// corpus that degrades matching. It's meant for custom corpora: files must be
+// laid out as category/name/variant.txt with names made of letters, digits
+// and the characters ".", "_", "+" and "-", must hold some text, mustn't have
+// the same normalized text as a file of a different license, and mustn't
+// match a different license already in the corpus with nearly full
+// confidence, which usually means a copy of a license under the wrong name.
+func WithCorpusLint() OptionFunc {
+	return func(c *Classifier) {
+		c.lint = true
+	}
+}
+
+// CorpusError lists the problems found in a corpus directory loaded with
+// WithCorpusLint.
+type CorpusError struct {
+	// Dir is the directory of the corpus, or "" for a file system loaded
+	// with LoadLicensesFS.
+	Dir string
+	// Problems describe each problem found, naming the file it was found
+	// in relative to Dir.
+	Problems []string
+}
+
+func (e *CorpusError) Error() string {
+	corpus := "license corpus"
+	if e.Dir != "" {
+		corpus += " " + e.Dir
+	}
+	return fmt.Sprintf("%s has %d problems:\n\t%s", corpus, len(e.Problems), strings.Join(e.Problems, "\n\t"))
+}
+
+// lintCorpus checks the files of the corpus in dir, tokenized as docs, against
+// each other and the corpus of the classifier. The paths of the files are
+// slash-separated and relative to dir. Files that aren't laid out as corpus
+// files have nil documents.
+func (c *Classifier) lintCorpus(dir string, files []string, docs []*loadedDocument) error {
+	var problems []string
+	report := func(path, format string, args ...interface{}) {
+		problems = append(problems, path+": "+fmt.Sprintf(format, args...))
+	}
+
+	if err := c.loadLazy(); err != nil {
+		return err
+	}
+	texts := make(map[string]string) // The license names, by normalized text
+	for name, d := range c.docs {
+		texts[d.normalized()] = LicenseName(name)
+	}
+	for i, d := range docs {
+		path := files[i]
+		if d == nil || len(strings.Split(path, "/")) != 3 {
+			report(path, "not laid out as category/name/variant.txt")
+			continue
+		}
+		for _, s := range []string{d.category, d.name, strings.TrimSuffix(d.variant, ".txt")} {
+			if !licenseNameRE.MatchString(s) {
+				report(path, "%q should only hold letters, digits and the characters \"._+-\"", s)
+			}
+		}
+		if d.doc.size() == 0 {
+			report(path, "holds no text")
+			continue
+		}
+
+		text := d.doc.normalized()
+		if other, ok := texts[text]; ok {
+			if other != d.name {
+				report(path, "has the same text as %s; remove one of them or merge them as variants of one license", other)
+			}
+			continue
+		}
+		texts[text] = d.name
+		if len(c.docs) == 0 {
+			continue
+		}
+		for _, m := range c.Match([]byte(text)).Matches {
+			if m.MatchType == d.category && m.Name != d.name && m.Confidence >= lintDuplicateConfidence {
+				report(path, "matches %s with confidence %v; it's likely a copy of %s under the wrong name", m.Name, m.Confidence, m.Name)
+				break
+			}
+		}
+	}
+
+	if len(problems) == 0 {
+		return nil
+	}
+	sort.Strings(problems)
+	return &CorpusError{Dir: dir, Problems: problems}
+}
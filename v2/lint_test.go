@@ -0,0 +1,137 @@
+// Copyright 2022 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package classifier
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+const (
+	lintLicense = "Permission to use, copy, modify and distribute this software for any purpose with or without fee is hereby granted, provided that the above notice appears in all copies."
+	lintOther   = "The software is provided as is and the authors disclaim all warranties with regard to this software, including all implied warranties of merchantability and fitness."
+)
+
+// writeCorpus writes the files, by path, below a new directory and returns it.
+func writeCorpus(t *testing.T, files map[string]string) string {
+	t.Helper()
+	dir := t.TempDir()
+	for name, text := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(path, []byte(text), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func TestCorpusLint(t *testing.T) {
+	tests := []struct {
+		name     string
+		existing string // The text of a license already in the corpus
+		files    map[string]string
+		want     []string
+	}{
+		{
+			name: "valid",
+			files: map[string]string{
+				"License/Custom/license.txt": lintLicense,
+				"License/Custom/b.txt":       lintLicense,
+				"License/Other-1.0/a.txt":    lintOther,
+			},
+		},
+		{
+			name:     "variant of a corpus license",
+			existing: lintLicense + "\n\n" + lintOther,
+			files: map[string]string{
+				"License/Existing/b.txt": "Copyright the authors.\n\n" + lintLicense + "\n\n" + lintOther,
+			},
+		},
+		{
+			name: "empty file",
+			files: map[string]string{
+				"License/Custom/license.txt": "",
+			},
+			want: []string{"License/Custom/license.txt: holds no text"},
+		},
+		{
+			name: "duplicate text",
+			files: map[string]string{
+				"License/Custom/license.txt": lintLicense,
+				"License/Copy/license.txt":   lintLicense,
+			},
+			want: []string{"License/Custom/license.txt: has the same text as Copy"},
+		},
+		{
+			name:     "copy of a corpus license",
+			existing: lintLicense + "\n\n" + lintOther,
+			files: map[string]string{
+				"License/Mine/license.txt": "Copyright the authors.\n\n" + lintLicense + "\n\n" + lintOther,
+			},
+			want: []string{"License/Mine/license.txt: matches Existing"},
+		},
+		{
+			name: "bad names",
+			files: map[string]string{
+				"License/My License/license.txt": lintLicense,
+				"License/license.txt":            lintOther,
+			},
+			want: []string{
+				`License/My License/license.txt: "My License" should only hold`,
+				"License/license.txt: not laid out as category/name/variant.txt",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := writeCorpus(t, tt.files)
+			c := NewClassifier(defaultThreshold, WithCorpusLint())
+			if tt.existing != "" {
+				c.AddContent("License", "Existing", "license.txt", []byte(tt.existing))
+			}
+			err := c.LoadLicenses(dir)
+			var ce *CorpusError
+			if len(tt.want) == 0 {
+				if err != nil {
+					t.Fatalf("LoadLicenses() = %v, want no error", err)
+				}
+				return
+			}
+			if !errors.As(err, &ce) {
+				t.Fatalf("LoadLicenses() = %v, want a *CorpusError", err)
+			}
+			var got []string
+			for i, p := range ce.Problems {
+				if i < len(tt.want) && strings.HasPrefix(filepath.ToSlash(p), tt.want[i]) {
+					p = tt.want[i]
+				}
+				got = append(got, p)
+			}
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("LoadLicenses() problems mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
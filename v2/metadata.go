@@ -0,0 +1,151 @@
+// Copyright 2022 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package classifier
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	pathpkg "path"
+	"path/filepath"
+	"strings"
+)
+
+// metadataFile is the name of the file holding the metadata of a license in a
+// directory loaded by LoadLicenses. It sits next to the license's variants,
+// as in License/CC-BY-4.0/metadata.json.
+const metadataFile = "metadata.json"
+
+// LicenseMetadata holds the settings of a license that override those of the
+// classifier. Settings that aren't set leave those of the classifier in place.
+type LicenseMetadata struct {
+	// Numbers is the policy used to tokenize numbers in the license and in
+	// the content matched against it. Licenses whose versions differ only in
+	// numbers, such as the Creative Commons licenses, or that depend on their
+	// section numbering can use NumbersKeep to be told apart reliably.
+	Numbers *NumberPolicy `json:"numbers,omitempty"`
+	// MinMatchTokens is the number of tokens a match of the license must
+	// cover to be reported. Very short licenses can use it to avoid being
+	// reported for unrelated sentences that happen to resemble them.
+	MinMatchTokens *int `json:"min_match_tokens,omitempty"`
+	// MinCoverage is the fraction of the tokens of the license, between 0
+	// and 1, that a match of the license must hold to be reported. Long
+	// licenses can use it to avoid being reported for content holding only
+	// part of their text.
+	MinCoverage *float64 `json:"min_coverage,omitempty"`
+	// CasePreserved are phrases of the license whose capitalization
+	// matters, such as the defined terms "Licensed Work" and "Change
+	// License" of the Business Source License. Content is matched against
+	// the lowercased text of the license, so the verification of a match
+	// counts each time the content holds such a phrase in other
+	// capitalization where the license holds it as given as a change of the
+	// phrase's words. Like the number policy, they must be set before the
+	// license is loaded.
+	CasePreserved []string `json:"case_preserved,omitempty"`
+	// Family is the family the license belongs to, such as "BSD-family",
+	// overriding the family derived from its name.
+	Family string `json:"family,omitempty"`
+}
+
+var numberPolicyNames = map[NumberPolicy]string{
+	NumbersDefault: "default",
+	NumbersKeep:    "keep",
+	NumbersMask:    "mask",
+}
+
+// String returns the name used for the policy in license metadata.
+func (p NumberPolicy) String() string {
+	if name, ok := numberPolicyNames[p]; ok {
+		return name
+	}
+	return fmt.Sprintf("NumberPolicy(%d)", int(p))
+}
+
+// MarshalText implements encoding.TextMarshaler.
+func (p NumberPolicy) MarshalText() ([]byte, error) {
+	if _, ok := numberPolicyNames[p]; !ok {
+		return nil, fmt.Errorf("unknown number policy %d", int(p))
+	}
+	return []byte(p.String()), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler.
+func (p *NumberPolicy) UnmarshalText(text []byte) error {
+	for policy, name := range numberPolicyNames {
+		if name == string(text) {
+			*p = policy
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown number policy %q", text)
+}
+
+// SetMetadata sets the metadata of the named license. The number policy applies
+// to content for the license that is added to the classifier afterwards, so it
+// must be set before the license is loaded.
+func (c *Classifier) SetMetadata(name string, m LicenseMetadata) {
+	if c.metadata == nil {
+		c.metadata = make(map[string]LicenseMetadata)
+	}
+	c.metadata[name] = m
+	c.updateCased()
+}
+
+// numberPolicy returns the number policy for the named license.
+func (c *Classifier) numberPolicy(name string) NumberPolicy {
+	if m, ok := c.metadata[name]; ok && m.Numbers != nil {
+		return *m.Numbers
+	}
+	return c.numbers
+}
+
+// minMatchTokens returns the number of tokens a match of the named license
+// must cover.
+func (c *Classifier) minMatchTokens(name string) int {
+	if m, ok := c.metadata[name]; ok && m.MinMatchTokens != nil {
+		return *m.MinMatchTokens
+	}
+	return c.minTokens
+}
+
+// minMatchCoverage returns the fraction of the tokens of the named license a
+// match of it must hold.
+func (c *Classifier) minMatchCoverage(name string) float64 {
+	if m, ok := c.metadata[name]; ok && m.MinCoverage != nil {
+		return *m.MinCoverage
+	}
+	return c.minCover
+}
+
+// loadMetadata reads the metadata file at path in the file system, which is
+// named by its location, and sets it as the metadata of the license it belongs
+// to. Errors name the file below root.
+func (c *Classifier) loadMetadata(fsys fs.FS, root, path string) error {
+	segments := strings.Split(path, "/")
+	if len(segments) < 3 {
+		c.tc.trace("Insufficient segment count for path: %s", path)
+		return nil
+	}
+	b, err := fs.ReadFile(fsys, path)
+	if err != nil {
+		return err
+	}
+	var m LicenseMetadata
+	if err := json.Unmarshal(b, &m); err != nil {
+		return fmt.Errorf("invalid license metadata %s: %w", pathpkg.Join(filepath.ToSlash(root), path), err)
+	}
+	c.SetMetadata(segments[1], m)
+	return nil
+}
@@ -0,0 +1,202 @@
+// Copyright 2022 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package classifier
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const numberedLicense = `Numbered License version 1.0
+
+1. Permission is granted to use, copy and distribute this software.
+
+2. The software is provided as is, without any warranty of any kind.
+
+3. This notice must be retained in all copies of the software.
+`
+
+func TestNumberPolicyText(t *testing.T) {
+	for _, p := range []NumberPolicy{NumbersDefault, NumbersKeep, NumbersMask} {
+		b, err := p.MarshalText()
+		if err != nil {
+			t.Fatalf("%v.MarshalText() = %v", p, err)
+		}
+		var got NumberPolicy
+		if err := got.UnmarshalText(b); err != nil {
+			t.Fatalf("UnmarshalText(%q) = %v", b, err)
+		}
+		if got != p {
+			t.Errorf("UnmarshalText(%q) = %v, want %v", b, got, p)
+		}
+	}
+
+	var p NumberPolicy
+	if err := p.UnmarshalText([]byte("digits")); err == nil {
+		t.Error("UnmarshalText(\"digits\") succeeded, want error")
+	}
+}
+
+func numberPolicy(p NumberPolicy) *NumberPolicy {
+	return &p
+}
+
+func TestMetadataNumbers(t *testing.T) {
+	// Renumbering the sections makes no difference unless the license keeps
+	// its numbers.
+	renumbered := strings.NewReplacer("1.", "A.", "2.", "B.", "3.", "C.").Replace(numberedLicense)
+	renumbered = strings.Replace(renumbered, "version A.0", "version 1.0", 1)
+
+	tests := []struct {
+		name     string
+		metadata *LicenseMetadata
+		in       string
+		want     float64
+	}{
+		{
+			name: "default",
+			in:   renumbered,
+			want: 1.0,
+		},
+		{
+			name:     "keep",
+			metadata: &LicenseMetadata{Numbers: numberPolicy(NumbersKeep)},
+			in:       numberedLicense,
+			want:     1.0,
+		},
+		{
+			name:     "keep renumbered",
+			metadata: &LicenseMetadata{Numbers: numberPolicy(NumbersKeep)},
+			in:       renumbered,
+			want:     0.925,
+		},
+		{
+			name:     "mask other version",
+			metadata: &LicenseMetadata{Numbers: numberPolicy(NumbersMask)},
+			in:       strings.Replace(numberedLicense, "version 1.0", "version 2.0", 1),
+			want:     1.0,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := NewClassifier(.8)
+			if tt.metadata != nil {
+				c.SetMetadata("Numbered", *tt.metadata)
+			}
+			c.AddContent("License", "Numbered", "license.txt", []byte(numberedLicense))
+
+			results := c.Match([]byte(tt.in))
+			if len(results.Matches) != 1 {
+				t.Fatalf("Match() = %+v, want a single match", results.Matches)
+			}
+			if got := results.Matches[0].Confidence; got != tt.want {
+				t.Errorf("Match() confidence = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+const definedTermsLicense = `Defined Terms License
+
+The Licensor grants you the right to copy and modify the Licensed Work. On
+the Change Date, the Licensed Work is licensed under the Change License. The
+licensed work is provided as is, without warranty of any kind.
+`
+
+func TestMetadataCasePreserved(t *testing.T) {
+	lowered := strings.NewReplacer("Licensed Work", "licensed work", "Change License", "change license").Replace(definedTermsLicense)
+
+	tests := []struct {
+		name     string
+		metadata *LicenseMetadata
+		in       string
+		want     float64
+	}{
+		{
+			name: "default",
+			in:   lowered,
+			want: 1.0,
+		},
+		{
+			name:     "preserved",
+			metadata: &LicenseMetadata{CasePreserved: []string{"Licensed Work", "Change License"}},
+			in:       definedTermsLicense,
+			want:     1.0,
+		},
+		{
+			name:     "preserved lowered",
+			metadata: &LicenseMetadata{CasePreserved: []string{"Licensed Work", "Change License"}},
+			in:       lowered,
+			want:     0.853,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := NewClassifier(.8)
+			if tt.metadata != nil {
+				c.SetMetadata("Defined", *tt.metadata)
+			}
+			c.AddContent("License", "Defined", "license.txt", []byte(definedTermsLicense))
+
+			results := c.Match([]byte(tt.in))
+			if len(results.Matches) != 1 {
+				t.Fatalf("Match() = %+v, want a single match", results.Matches)
+			}
+			if got := results.Matches[0].Confidence; got != tt.want {
+				t.Errorf("Match() confidence = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadLicensesMetadata(t *testing.T) {
+	dir := t.TempDir()
+	licenseDir := filepath.Join(dir, "License", "Numbered")
+	if err := os.MkdirAll(licenseDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(licenseDir, "license.txt"), []byte(numberedLicense), 0644); err != nil {
+		t.Fatal(err)
+	}
+	metadata := filepath.Join(licenseDir, metadataFile)
+	if err := ioutil.WriteFile(metadata, []byte(`{"numbers": "keep"}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	c := NewClassifier(.8)
+	if err := c.LoadLicenses(dir); err != nil {
+		t.Fatalf("LoadLicenses() = %v", err)
+	}
+	if len(c.docs) != 1 {
+		t.Fatalf("LoadLicenses() loaded %d documents, want 1", len(c.docs))
+	}
+	for name, d := range c.docs {
+		if d.numbers != NumbersKeep {
+			t.Errorf("LoadLicenses() tokenized %s with %v, want %v", name, d.numbers, NumbersKeep)
+		}
+	}
+
+	if err := ioutil.WriteFile(metadata, []byte(`{"numbers": "digits"}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := NewClassifier(.8).LoadLicenses(dir); err == nil {
+		t.Error("LoadLicenses() with invalid metadata succeeded, want error")
+	}
+}
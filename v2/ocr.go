@@ -0,0 +1,131 @@
+// Copyright 2022 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build !core
+// +build !core
+
+package classifier
+
+import (
+	"bytes"
+	"context"
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+// Profile selects how content is normalized before it's matched.
+type Profile int
+
+const (
+	// ProfileDefault matches the content as it is.
+	ProfileDefault Profile = iota
+	// ProfileOCR tolerates the artifacts of text extracted from PDFs and
+	// scanned documents: lines holding only a page number are dropped,
+	// ligatures and soft hyphens are expanded or removed, and words in which
+	// OCR confused letters with look-alike digits, such as "1icense" or
+	// "C0PYRIGHT", are folded back to the words of the corpus they spell.
+	ProfileOCR
+)
+
+// MatchWithProfile finds matches within an unknown text after normalizing it
+// with the profile. Line numbers of the matches refer to the lines of the
+// supplied text. This will not modify the contents of the supplied byte slice.
+func (c *Classifier) MatchWithProfile(in []byte, p Profile) Results {
+	if p != ProfileOCR {
+		return c.Match(in)
+	}
+	// Words are folded against the dictionary, so the content is normalized
+	// under the same guard as it's matched.
+	res, _ := c.guardMatch(func() (Results, error) {
+		return c.matchReader(context.Background(), bytes.NewReader(c.normalizeOCR(in)), nil)
+	})
+	return res
+}
+
+// pageNumber matches a line holding only a page number, such as "12",
+// "- 12 -", "Page 12" or "Page 12 of 30".
+var pageNumber = regexp.MustCompile(`(?i)^\s*(?:page\s+)?[-–—]?\s*\d+\s*(?:(?:of|/)\s*\d+\s*)?[-–—]?\s*$`)
+
+// ocrReplacer expands typographic ligatures and removes soft hyphens, which
+// text extracted from PDFs often contains.
+var ocrReplacer = strings.NewReplacer(
+	"­", "",
+	"ﬀ", "ff",
+	"ﬁ", "fi",
+	"ﬂ", "fl",
+	"ﬃ", "ffi",
+	"ﬄ", "ffl",
+)
+
+// ocrConfusables map the characters OCR mistakes for letters to the letters.
+// A character can stand for several letters, so the replacers are tried in
+// turn.
+var ocrConfusables = []*strings.Replacer{
+	strings.NewReplacer("0", "o", "1", "l", "|", "l", "5", "s"),
+	strings.NewReplacer("0", "o", "1", "i", "|", "i", "5", "s"),
+}
+
+// normalizeOCR applies the normalization of ProfileOCR to the content,
+// preserving its lines.
+func (c *Classifier) normalizeOCR(in []byte) []byte {
+	lines := bytes.Split([]byte(ocrReplacer.Replace(string(in))), []byte("\n"))
+	for i, line := range lines {
+		if pageNumber.Match(line) {
+			lines[i] = nil
+			continue
+		}
+		var out []byte
+		for len(line) > 0 {
+			start := bytes.IndexFunc(line, func(r rune) bool { return !unicode.IsSpace(r) })
+			if start == -1 {
+				out = append(out, line...)
+				break
+			}
+			end := bytes.IndexFunc(line[start:], unicode.IsSpace)
+			if end == -1 {
+				end = len(line)
+			} else {
+				end += start
+			}
+			out = append(out, line[:start]...)
+			out = append(out, c.foldConfusables(string(line[start:end]))...)
+			line = line[end:]
+		}
+		line = out
+		lines[i] = line
+	}
+	return bytes.Join(lines, []byte("\n"))
+}
+
+// foldConfusables returns the word with the characters OCR confuses with
+// letters replaced by the letters, if that turns a word unknown to the corpus
+// into a known one, and the word unchanged otherwise.
+func (c *Classifier) foldConfusables(word string) string {
+	if !strings.ContainsAny(word, "01|5") || !strings.ContainsAny(strings.ToLower(word), "abcdefghijklmnopqrstuvwxyz") {
+		return word
+	}
+	// Digits and punctuation are dropped when tokenizing words, so the word
+	// is looked up as written.
+	if c.dict.getIndex(strings.ToLower(strings.TrimFunc(word, unicode.IsPunct))) != unknownIndex {
+		return word
+	}
+	for _, r := range ocrConfusables {
+		folded := r.Replace(word)
+		if c.dict.getIndex(cleanupToken(1, strings.ToLower(folded), true, c.numbers)) != unknownIndex {
+			return folded
+		}
+	}
+	return word
+}
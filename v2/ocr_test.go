@@ -0,0 +1,125 @@
+// Copyright 2022 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build !core
+// +build !core
+
+package classifier
+
+import (
+	"fmt"
+	"io/ioutil"
+	"path"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestNormalizeOCR(t *testing.T) {
+	c := NewClassifier(defaultThreshold)
+	c.AddContent("License", "Test", "license.txt", []byte("Permission to copy this software is granted under the license."))
+
+	tests := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{
+			name: "confusables",
+			in:   "Perm1ssion to c0py this s0ftware under the 1icense,",
+			want: "Permission to copy this software under the license,",
+		},
+		{
+			name: "unknown words kept",
+			in:   "Version 2.0 of MD5 and x86 in 1999",
+			want: "Version 2.0 of MD5 and x86 in 1999",
+		},
+		{
+			name: "page numbers",
+			in:   "the license\n12\n- 13 -\nPage 14 of 20\ngranted",
+			want: "the license\n\n\n\ngranted",
+		},
+		{
+			name: "ligatures",
+			in:   "so­ftware ﬁle",
+			want: "software file",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := string(c.normalizeOCR([]byte(tt.in))); got != tt.want {
+				t.Errorf("normalizeOCR() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMatchWithProfile(t *testing.T) {
+	mit, err := ioutil.ReadFile(path.Join(baseLicenses, "License", "MIT", "pristine.txt"))
+	if err != nil {
+		t.Fatalf("couldn't read MIT license: %v", err)
+	}
+	c := NewClassifier(defaultThreshold)
+	c.AddContent("License", "MIT", "pristine.txt", mit)
+
+	lines := strings.Split(string(mit), "\n")
+	var scanned []string
+	for i, l := range lines {
+		if i > 0 && i%5 == 0 {
+			scanned = append(scanned, "Page 1 of 3")
+		}
+		l = strings.NewReplacer("software", "s0ftware", "copy", "c0py", "license", "1icense", "the ", "the| ").Replace(l)
+		scanned = append(scanned, l)
+	}
+	in := []byte(strings.Join(scanned, "\n"))
+
+	got := c.MatchWithProfile(in, ProfileOCR).Matches
+	if len(got) != 1 || got[0].Name != "MIT" || got[0].Confidence != 1.0 {
+		t.Fatalf("MatchWithProfile() = %v, want a single exact MIT match", got)
+	}
+	if noisy := c.Match(in).Matches; len(noisy) > 0 && noisy[0].Confidence >= got[0].Confidence {
+		t.Errorf("Match() confidence = %v without the OCR profile, want less than %v", noisy[0].Confidence, got[0].Confidence)
+	}
+	if got[0].EndLine <= len(lines)-1 {
+		t.Errorf("MatchWithProfile() EndLine = %d, want a line of the scanned text past %d", got[0].EndLine, len(lines)-1)
+	}
+}
+
+// TestAddLicenseConcurrently adds and removes licenses while matching, with
+// and without a profile, which the race detector checks.
+func TestAddLicenseConcurrently(t *testing.T) {
+	c, err := classifier()
+	if err != nil {
+		t.Fatalf("couldn't instantiate standard test classifier: %v", err)
+	}
+	text := []byte("Acme Corp Internal License. Distribution outside of Acme Corp is prohibited.")
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 5; j++ {
+				c.Match(text)
+				c.MatchWithProfile(text, ProfileOCR)
+			}
+		}()
+	}
+	for i := 0; i < 5; i++ {
+		if err := c.AddLicense("License", fmt.Sprintf("Acme-%d", i), "license.txt", text); err != nil {
+			t.Errorf("AddLicense() = %v", err)
+		}
+		c.RemoveLicense("License", fmt.Sprintf("Acme-%d", i), "")
+	}
+	wg.Wait()
+}
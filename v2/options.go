@@ -0,0 +1,233 @@
+// Copyright 2022 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package classifier
+
+// OptionFunc is a function that configures a Classifier when it is created.
+type OptionFunc func(*Classifier)
+
+// WithPrefilterThreshold sets the token similarity a license must reach in the
+// frequency pre-filter to be considered for full matching. By default this is
+// the confidence threshold of the classifier. The pre-filter compares token
+// counts without regard to order, so heavily reordered or interleaved texts
+// can score below the confidence threshold there even though they would match.
+// A lower value finds such matches at the cost of scoring more candidates; the
+// candidates are still scored against the confidence threshold, so it adds no
+// matches below it. A value above the confidence threshold isn't clamped: it
+// drops the matches of licenses that don't reach it in the pre-filter, even
+// matches whose confidence is above the confidence threshold.
+func WithPrefilterThreshold(threshold float64) OptionFunc {
+	return func(c *Classifier) {
+		c.prefilter = threshold
+	}
+}
+
+// WithBoilerplateHidden omits matches of well-known non-license boilerplate,
+// such as codes of conduct, from the results. The boilerplate is still matched
+// and still suppresses the partial license matches it would otherwise produce.
+func WithBoilerplateHidden() OptionFunc {
+	return func(c *Classifier) {
+		c.hideBoilerplate = true
+	}
+}
+
+// WithLengthFilterDisabled considers every license for matching regardless of
+// the length of the content. By default licenses are skipped when the content
+// has too few tokens to hold enough of the license to reach the confidence
+// threshold, which makes scanning small files much faster. The filter can't
+// change which matches are reported, so disabling it is only useful for
+// debugging.
+func WithLengthFilterDisabled() OptionFunc {
+	return func(c *Classifier) {
+		c.noLengthFilter = true
+	}
+}
+
+// WithChecksumsDisabled always searches content for matches. By default,
+// content whose normalized text is exactly that of a license of the corpus,
+// such as a verbatim LICENSE file, is looked up by the checksum of its tokens
+// and reported as that license without searching for and scoring it; only the
+// annotations that qualify the license, such as exceptions, are searched for.
+func WithChecksumsDisabled() OptionFunc {
+	return func(c *Classifier) {
+		c.noChecksums = true
+	}
+}
+
+// WithLowMemory reduces the memory held by the corpus for environments with
+// little memory available. Only the token sequences and frequency tables of
+// the corpus stay resident; the search data and normalized text needed to
+// match a license are rebuilt each time it passes the pre-filter, and the
+// q-grams of the corpus aren't indexed, which makes matching slower.
+func WithLowMemory() OptionFunc {
+	return func(c *Classifier) {
+		c.lowMemory = true
+	}
+}
+
+// WithRawConfidence reports confidence values at full precision rather than
+// truncated to three decimal places. Raw values can differ in their last
+// digits between platforms and releases, so they are unsuitable for comparing
+// against recorded results. Releases before confidence values were truncated
+// reported raw values, which this option restores.
+func WithRawConfidence() OptionFunc {
+	return func(c *Classifier) {
+		c.rawConfidence = true
+	}
+}
+
+// WithNumberPolicy sets how numbers are tokenized in content added to the
+// classifier and in content matched against it. The metadata of a license can
+// override the policy for that license. By default numbers that mark sections
+// at the start of a line are dropped.
+func WithNumberPolicy(numbers NumberPolicy) OptionFunc {
+	return func(c *Classifier) {
+		c.numbers = numbers
+	}
+}
+
+// WithMinMatchTokens suppresses matches that cover fewer than n tokens of the
+// content, which are mostly matches of very short licenses and headers against
+// unrelated sentences that happen to resemble them. The metadata of a license
+// can override the minimum for that license. By default matches of any length
+// are reported.
+func WithMinMatchTokens(n int) OptionFunc {
+	return func(c *Classifier) {
+		c.minTokens = n
+	}
+}
+
+// WithMinCoverage suppresses matches that hold less than the fraction, between
+// 0 and 1, of the tokens of the license they match. Confidence tolerates some
+// of the license being missing, which for a long license can amount to whole
+// paragraphs; the minimum coverage bounds how much can be. The metadata of a
+// license can override the minimum for that license. By default matches are
+// reported regardless of their coverage.
+func WithMinCoverage(fraction float64) OptionFunc {
+	return func(c *Classifier) {
+		c.minCover = fraction
+	}
+}
+
+// WithLicenses restricts matching to the named licenses of the corpus, such as
+// "MIT" or "Apache-2.0", in all their categories. Together with
+// NewClassifierFromCorpus, it lets classifiers for different tenants match
+// different subsets of a single shared corpus. Other licenses are still
+// loaded and named by SimilarTo.
+func WithLicenses(names ...string) OptionFunc {
+	return func(c *Classifier) {
+		if c.licenses == nil {
+			c.licenses = make(map[string]bool)
+		}
+		for _, n := range names {
+			c.licenses[n] = true
+		}
+	}
+}
+
+// WithWarnThreshold reports License and Header matches whose confidence is
+// below the threshold of the classifier but at least the warning threshold as
+// warnings, with their Warning field set, so that policies can fail on
+// confident matches and only flag the fuzzy ones for review. Warnings are
+// reported only where no confident match is found. Searching for the less
+// similar matches makes matching slower.
+func WithWarnThreshold(threshold float64) OptionFunc {
+	return func(c *Classifier) {
+		c.warn = threshold
+	}
+}
+
+// WithMatchText reports the text of each match in its Text field: the lines
+// of the content it was found on, as the identify_license tool reports them
+// with --include_text. The text is copied out of the content, so it costs
+// memory in proportion to the size of the matches.
+func WithMatchText() OptionFunc {
+	return func(c *Classifier) {
+		c.matchText = true
+	}
+}
+
+// WithNameReferences reports statements that license content by naming a
+// license, such as "Licensed under the MIT License" or "governed by a
+// BSD-style license", as Reference matches, for content that names its
+// license without including its text. Since a name is weaker evidence than the
+// text of a license, these matches have a confidence of 0.75, or 0.5 for
+// "-style" licenses. Names within a match of a license's text aren't
+// reported. It has no effect in the core build, which detects no references.
+func WithNameReferences() OptionFunc {
+	return func(c *Classifier) {
+		c.nameRefs = true
+	}
+}
+
+// WithWorkers sets the number of candidate licenses a match scores
+// concurrently. Finding and scoring the regions of the content that resemble
+// each license dominate the time spent matching a license file, so services
+// matching one file at a time can score up to n licenses at once, for example
+// runtime.GOMAXPROCS(0), to match it sooner. By default, the licenses are
+// scored one at a time on the calling goroutine, since tools matching many
+// files concurrently already keep the CPUs busy. The matches reported don't
+// depend on the number of workers. A number below 1 keeps the default.
+func WithWorkers(n int) OptionFunc {
+	return func(c *Classifier) {
+		c.workers = n
+	}
+}
+
+// WithSequential runs the classifier on the calling goroutine only, and
+// considers the licenses in name order, so that loading and matching are
+// deterministic down to the order of their traces. It's meant for debugging,
+// fuzzing and platforms without threads, such as WebAssembly; loading a corpus
+// is slower than with the default concurrent loading.
+func WithSequential() OptionFunc {
+	return func(c *Classifier) {
+		c.sequential = true
+	}
+}
+
+// WithMaxTokens limits the content matched to its first n tokens, bounding
+// the time and memory spent on very large inputs: content is read no further
+// than the line holding the last token kept. Matches, including references
+// found in the text, are only looked for in the tokens kept, and the results
+// of content cut short have Truncated set. A number below 1 sets no limit, the
+// default.
+func WithMaxTokens(n int) OptionFunc {
+	return func(c *Classifier) {
+		c.maxTokens = n
+	}
+}
+
+// WithMaxCandidates limits the number of regions of the content scored against
+// each license to n, keeping the regions with the most q-grams in common with
+// the license. Content repeating a license many times, such as a concatenation
+// of vendored license files, otherwise has every repetition diffed against
+// every license it resembles. The results of content holding more regions
+// have Truncated set. A number below 1 sets no limit, the default.
+func WithMaxCandidates(n int) OptionFunc {
+	return func(c *Classifier) {
+		c.maxCandidates = n
+	}
+}
+
+// WithMaxDiffSize limits the size of the diffs that score regions of the
+// content against licenses to n tokens, counting those of the region and of
+// the license. The time spent diffing grows faster than the sizes diffed, so
+// regions of pathological content that would exceed it are left unscored, and
+// the results of the content have Truncated set. A number below 1 sets no
+// limit, the default.
+func WithMaxDiffSize(n int) OptionFunc {
+	return func(c *Classifier) {
+		c.maxDiff = n
+	}
+}
@@ -0,0 +1,493 @@
+// Copyright 2022 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package classifier
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"path"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+)
+
+func TestPrefilterThreshold(t *testing.T) {
+	mit, err := ioutil.ReadFile(path.Join(baseLicenses, "License", "MIT", "pristine.txt"))
+	if err != nil {
+		t.Fatalf("couldn't read MIT license: %v", err)
+	}
+	// Dropping the notice condition keeps the text above the confidence
+	// threshold while removing tokens that occur nowhere else in it.
+	in := bytes.Replace(mit, []byte("The above copyright notice and this permission notice shall be included in all\ncopies or substantial portions of the Software.\n"), nil, 1)
+	if bytes.Equal(in, mit) {
+		t.Fatal("couldn't remove the notice condition from the MIT license")
+	}
+
+	tests := []struct {
+		name    string
+		options []OptionFunc
+		want    int
+	}{
+		{
+			name: "default",
+			want: 1,
+		},
+		{
+			name:    "lower",
+			options: []OptionFunc{WithPrefilterThreshold(.5)},
+			want:    1,
+		},
+		{
+			name:    "exact",
+			options: []OptionFunc{WithPrefilterThreshold(1.0)},
+			want:    0,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := NewClassifier(defaultThreshold, tt.options...)
+			c.AddContent("License", "MIT", "pristine.txt", mit)
+			if got := c.Match(in).Matches; len(got) != tt.want {
+				t.Errorf("Match() = %v, want %d matches", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLowMemory(t *testing.T) {
+	mit, err := ioutil.ReadFile(path.Join(baseLicenses, "License", "MIT", "pristine.txt"))
+	if err != nil {
+		t.Fatalf("couldn't read MIT license: %v", err)
+	}
+
+	c := NewClassifier(defaultThreshold, WithLowMemory())
+	c.AddContent("License", "MIT", "pristine.txt", mit)
+	d := c.getIndexedDocument("License", "MIT", "pristine.txt")
+	if d.s != nil || d.runes != nil || d.Norm != "" {
+		t.Fatal("AddContent() kept search data resident in low-memory mode")
+	}
+
+	want := NewClassifier(defaultThreshold)
+	want.AddContent("License", "MIT", "pristine.txt", mit)
+	if diff := cmp.Diff(want.Match(mit).Matches, c.Match(mit).Matches); diff != "" {
+		t.Errorf("Match() mismatch in low-memory mode (-want +got):\n%s", diff)
+	}
+	if d.s != nil {
+		t.Error("Match() modified the corpus in low-memory mode")
+	}
+}
+
+func TestRawConfidence(t *testing.T) {
+	mit, err := ioutil.ReadFile(path.Join(baseLicenses, "License", "MIT", "pristine.txt"))
+	if err != nil {
+		t.Fatalf("couldn't read MIT license: %v", err)
+	}
+	// Removing a single word yields a confidence that isn't a multiple of
+	// the quantum.
+	in := bytes.Replace(mit, []byte("free of charge, "), []byte("free "), 1)
+
+	quantized := NewClassifier(defaultThreshold)
+	quantized.AddContent("License", "MIT", "pristine.txt", mit)
+	raw := NewClassifier(defaultThreshold, WithRawConfidence())
+	raw.AddContent("License", "MIT", "pristine.txt", mit)
+
+	q, r := quantized.Match(in).Matches, raw.Match(in).Matches
+	if len(q) != 1 || len(r) != 1 {
+		t.Fatalf("Match() = %v and %v, want one match each", q, r)
+	}
+	if r[0].Confidence == q[0].Confidence {
+		t.Errorf("raw confidence %v equals quantized confidence", r[0].Confidence)
+	}
+	if got, want := q[0].Confidence, quantizeConfidence(r[0].Confidence); got != want {
+		t.Errorf("Match() confidence = %v, want %v", got, want)
+	}
+}
+
+func TestMatchText(t *testing.T) {
+	mit, err := ioutil.ReadFile(path.Join(baseLicenses, "License", "MIT", "pristine.txt"))
+	if err != nil {
+		t.Fatalf("couldn't read MIT license: %v", err)
+	}
+	license := strings.TrimRight(string(mit), "\n")
+	in := []byte("Some code\nof a project.\n\n" + license + "\n\nMore code.\n")
+
+	c := NewClassifier(defaultThreshold)
+	c.AddContent("License", "MIT", "pristine.txt", mit)
+	if ms := c.Match(in).Matches; len(ms) != 1 || ms[0].Text != "" {
+		t.Fatalf("Match() = %v, want one match without text", ms)
+	}
+
+	c = NewClassifier(defaultThreshold, WithMatchText())
+	c.AddContent("License", "MIT", "pristine.txt", mit)
+	ms := c.Match(in).Matches
+	if len(ms) != 1 {
+		t.Fatalf("Match() = %v, want one match", ms)
+	}
+	// The text spans the lines of the license, without the code around it.
+	if ms[0].Text != license {
+		t.Errorf("Match() text = %q, want %q", ms[0].Text, license)
+	}
+
+	// Content matched as tokens reports the tokens of the match.
+	var tokens []string
+	var lines []int
+	for i, l := range strings.Split(strings.ToLower(string(c.Normalize(in))), "\n") {
+		for _, w := range strings.Fields(l) {
+			tokens = append(tokens, w)
+			lines = append(lines, i+1)
+		}
+	}
+	res, err := c.MatchTokens(tokens, lines)
+	if err != nil {
+		t.Fatalf("MatchTokens() = %v", err)
+	}
+	if len(res.Matches) != 1 {
+		t.Fatalf("MatchTokens() = %v, want one match", res.Matches)
+	}
+	m := res.Matches[0]
+	if want := strings.Join(tokens[m.StartTokenIndex:m.EndTokenIndex+1], " "); m.Text != want || !strings.HasPrefix(want, "permission is hereby granted") {
+		t.Errorf("MatchTokens() text = %q, want %q", m.Text, want)
+	}
+}
+
+func TestNameReferences(t *testing.T) {
+	mit, err := ioutil.ReadFile(path.Join(baseLicenses, "License", "MIT", "pristine.txt"))
+	if err != nil {
+		t.Fatalf("couldn't read MIT license: %v", err)
+	}
+	named := []byte("// Example code.\n// Licensed under the MIT License.\n")
+	// The notice that precedes the MIT license's text names it too.
+	full := append([]byte("MIT License\n\nThis project is licensed under the MIT License:\n\n"), mit...)
+
+	c := NewClassifier(defaultThreshold)
+	c.AddContent("License", "MIT", "pristine.txt", mit)
+	if ms := c.Match(named).Matches; len(ms) != 0 {
+		t.Errorf("Match() = %v, want no matches without WithNameReferences", ms)
+	}
+
+	c = NewClassifier(defaultThreshold, WithNameReferences())
+	c.AddContent("License", "MIT", "pristine.txt", mit)
+	var got []string
+	for _, m := range c.Match(named).Matches {
+		got = append(got, fmt.Sprintf("%s:%s:%v", m.MatchType, m.Name, m.Confidence))
+	}
+	want := []string{"Reference:MIT:0.75"}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("Match() mismatch (-want +got):\n%s", diff)
+	}
+	for _, m := range c.Match(full).Matches {
+		if m.MatchType == "Reference" {
+			t.Errorf("Match() = %v, want no reference beside the license's text", m)
+		}
+	}
+}
+
+func TestLineText(t *testing.T) {
+	b := []byte("one\ntwo\nthree\nfour")
+	tests := []struct {
+		start, end int
+		want       string
+	}{
+		{1, 1, "one"},
+		{1, 2, "one\ntwo"},
+		{2, 3, "two\nthree"},
+		{3, 4, "three\nfour"},
+		{4, 4, "four"},
+		{5, 5, ""},
+		{3, 2, ""},
+		{1, 0, ""},
+	}
+	for _, tt := range tests {
+		if got := lineText(b, tt.start, tt.end); got != tt.want {
+			t.Errorf("lineText(%d, %d) = %q, want %q", tt.start, tt.end, got, tt.want)
+		}
+	}
+}
+
+func TestMinMatchTokens(t *testing.T) {
+	short := []byte("This software is distributed under the short license of its authors.")
+	in := append([]byte("Some notes about the project.\n\n"), short...)
+	minTokens := func(n int) *int { return &n }
+
+	tests := []struct {
+		name     string
+		options  []OptionFunc
+		metadata *LicenseMetadata
+		want     int
+	}{
+		{
+			name: "default",
+			want: 1,
+		},
+		{
+			name:    "below minimum",
+			options: []OptionFunc{WithMinMatchTokens(20)},
+			want:    0,
+		},
+		{
+			name:    "at minimum",
+			options: []OptionFunc{WithMinMatchTokens(11)},
+			want:    1,
+		},
+		{
+			name:     "metadata raises minimum",
+			metadata: &LicenseMetadata{MinMatchTokens: minTokens(20)},
+			want:     0,
+		},
+		{
+			name:     "metadata lowers minimum",
+			options:  []OptionFunc{WithMinMatchTokens(20)},
+			metadata: &LicenseMetadata{MinMatchTokens: minTokens(0)},
+			want:     1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := NewClassifier(defaultThreshold, tt.options...)
+			if tt.metadata != nil {
+				c.SetMetadata("Short", *tt.metadata)
+			}
+			c.AddContent("License", "Short", "license.txt", short)
+			if got := len(c.Match(in).Matches); got != tt.want {
+				t.Errorf("Match() returned %d matches, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMinCoverage(t *testing.T) {
+	first := "Permission to use, copy, modify and distribute this software for any purpose with or without fee is hereby granted, provided that the above notice appears in all copies."
+	second := "The software is provided as is and the authors disclaim all warranties with regard to this software, including all implied warranties of merchantability and fitness."
+	long := []byte(first + "\n\n" + second)
+	// The content lacks the last words of the license, about 8% of its
+	// tokens, which leaves the match above the default threshold.
+	in := []byte(first + "\n\n" + strings.TrimSuffix(second, " of merchantability and fitness.") + ".")
+	minCoverage := func(f float64) *float64 { return &f }
+
+	tests := []struct {
+		name     string
+		options  []OptionFunc
+		metadata *LicenseMetadata
+		want     int
+	}{
+		{
+			name: "default",
+			want: 1,
+		},
+		{
+			name:    "below minimum",
+			options: []OptionFunc{WithMinCoverage(0.95)},
+			want:    0,
+		},
+		{
+			name:    "above minimum",
+			options: []OptionFunc{WithMinCoverage(0.9)},
+			want:    1,
+		},
+		{
+			name:     "metadata raises minimum",
+			metadata: &LicenseMetadata{MinCoverage: minCoverage(0.95)},
+			want:     0,
+		},
+		{
+			name:     "metadata lowers minimum",
+			options:  []OptionFunc{WithMinCoverage(0.95)},
+			metadata: &LicenseMetadata{MinCoverage: minCoverage(0)},
+			want:     1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := NewClassifier(defaultThreshold, tt.options...)
+			if tt.metadata != nil {
+				c.SetMetadata("Long", *tt.metadata)
+			}
+			c.AddContent("License", "Long", "license.txt", long)
+			if got := len(c.Match(in).Matches); got != tt.want {
+				t.Errorf("Match() returned %d matches, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCorpusMinCoverage(t *testing.T) {
+	// A warning threshold widens the search to matches that hold less of the
+	// license, which its minimum coverage then drops.
+	c := NewClassifier(defaultThreshold, WithWarnThreshold(.7))
+	if err := c.LoadLicenses(baseLicenses); err != nil {
+		t.Fatalf("LoadLicenses() = %v", err)
+	}
+	gpl, err := ioutil.ReadFile(path.Join(baseLicenses, "License", "GPL-2.0", "license.txt"))
+	if err != nil {
+		t.Fatalf("couldn't read GPL-2.0 license: %v", err)
+	}
+	// Both parts of the license score above the threshold, but only the
+	// longer holds enough of the license to be reported.
+	tests := []struct {
+		name     string
+		fraction float64
+		want     bool
+	}{
+		{name: "most of the license", fraction: .95, want: true},
+		{name: "part of the license", fraction: .85, want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			found := false
+			for _, m := range c.Match(gpl[:int(float64(len(gpl))*tt.fraction)]).Matches {
+				if m.Name == "GPL-2.0" && m.MatchType == "License" {
+					found = true
+				}
+			}
+			if found != tt.want {
+				t.Errorf("Match() found the GPL-2.0 license = %v, want %v", found, tt.want)
+			}
+		})
+	}
+}
+
+func TestSequential(t *testing.T) {
+	var traces []string
+	c := NewClassifier(defaultThreshold, WithSequential())
+	c.SetTraceConfiguration(&TraceConfiguration{
+		TracePhases:   "tokenize",
+		TraceLicenses: "*",
+		Tracer: func(f string, args ...interface{}) {
+			traces = append(traces, fmt.Sprintf(f, args...))
+		},
+	})
+	for _, name := range []string{"Delta", "Alpha", "Charlie", "Bravo"} {
+		c.AddContent("License", name, "license.txt", []byte(name+" may be used by anyone for any purpose."))
+	}
+	want := []string{
+		"Token similarity for License/Alpha/license.txt: 0.89",
+		"Token similarity for License/Bravo/license.txt: 0.89",
+		"Token similarity for License/Charlie/license.txt: 0.89",
+		"Token similarity for License/Delta/license.txt: 1.00",
+	}
+	for i := 0; i < 2; i++ {
+		traces = nil
+		if got := c.Match([]byte("Delta may be used by anyone for any purpose.")).Matches; len(got) != 1 {
+			t.Errorf("Match() = %v, want 1 match", got)
+		}
+		if diff := cmp.Diff(want, traces); diff != "" {
+			t.Errorf("Match() traces mismatch (-want +got):\n%s", diff)
+		}
+	}
+}
+
+func TestLengthFilter(t *testing.T) {
+	mit, err := ioutil.ReadFile(path.Join(baseLicenses, "License", "MIT", "pristine.txt"))
+	if err != nil {
+		t.Fatalf("couldn't read MIT license: %v", err)
+	}
+	filtered := NewClassifier(defaultThreshold)
+	unfiltered := NewClassifier(defaultThreshold, WithLengthFilterDisabled())
+	for _, c := range []*Classifier{filtered, unfiltered} {
+		c.AddContent("License", "MIT", "pristine.txt", mit)
+		c.AddContent("License", "Short", "license.txt", []byte("Permission is hereby granted, free of charge, to any person."))
+	}
+
+	// The short license can't match the shortened MIT text, and the MIT
+	// license can't match the first sentence alone.
+	for _, in := range [][]byte{mit, mit[:len(mit)/2], []byte("Permission is hereby granted, free of charge, to any person.")} {
+		want, got := unfiltered.Match(in).Matches, filtered.Match(in).Matches
+		if diff := cmp.Diff(want, got); diff != "" {
+			t.Errorf("Match() mismatch with length filter (-want +got):\n%s", diff)
+		}
+	}
+}
+
+func BenchmarkLengthFilter(b *testing.B) {
+	c, err := classifier()
+	if err != nil {
+		b.Fatalf("couldn't instantiate standard test classifier: %v", err)
+	}
+	in := []byte("// Copyright 2022 Google Inc.\n//\n// Use of this source code is governed by a BSD-style license that can be\n// found in the LICENSE file.\n\npackage main\n")
+	for _, bm := range []struct {
+		name     string
+		disabled bool
+	}{
+		{"enabled", false},
+		{"disabled", true},
+	} {
+		b.Run(bm.name, func(b *testing.B) {
+			c.noLengthFilter = bm.disabled
+			for i := 0; i < b.N; i++ {
+				c.Match(in)
+			}
+		})
+	}
+}
+
+func TestWithWorkers(t *testing.T) {
+	c, err := classifier()
+	if err != nil {
+		t.Fatalf("couldn't instantiate standard test classifier: %v", err)
+	}
+	files, err := getScenarioFilenames()
+	if err != nil {
+		t.Fatalf("encountered error walking scenarios directory: %v", err)
+	}
+	// Licenses matched verbatim would be reported without scoring them on
+	// the workers.
+	c.noChecksums = true
+	// Matches that tie, such as copyright notices on the same token, may be
+	// reported in either order, whatever the number of workers.
+	order := cmpopts.SortSlices(func(a, b *Match) bool {
+		if a.StartLine != b.StartLine {
+			return a.StartLine < b.StartLine
+		}
+		if a.Name != b.Name {
+			return a.Name < b.Name
+		}
+		return a.Variant < b.Variant
+	})
+	for _, f := range files {
+		in := readScenario(f).data
+		c.workers = 1
+		want := c.Match(in)
+		c.workers = 8
+		if diff := cmp.Diff(want, c.Match(in), order); diff != "" {
+			t.Errorf("Match(%q) mismatch with 8 workers (-want +got):\n%s", f, diff)
+		}
+	}
+}
+
+func BenchmarkWorkers(b *testing.B) {
+	c, err := classifier()
+	if err != nil {
+		b.Fatalf("couldn't instantiate standard test classifier: %v", err)
+	}
+	c.noChecksums = true
+	in, err := ioutil.ReadFile(path.Join(baseLicenses, "License", "GPL-3.0", "license.txt"))
+	if err != nil {
+		b.Fatalf("couldn't read GPL-3.0 license: %v", err)
+	}
+	for _, workers := range []int{1, 2, 4, 8} {
+		b.Run(fmt.Sprintf("workers=%d", workers), func(b *testing.B) {
+			c.workers = workers
+			for i := 0; i < b.N; i++ {
+				c.Match(in)
+			}
+		})
+	}
+}
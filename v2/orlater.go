@@ -0,0 +1,136 @@
+// Copyright 2022 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package classifier
+
+import (
+	"strings"
+
+	"github.com/sergi/go-diff/diffmatchpatch"
+)
+
+// orLaterRE matches the version clause of the GNU licenses' notices, as the
+// words of the content from the word "version" on, when it grants the choice
+// of any later version of the license, as in "version 2 of the License, or
+// (at your option) any later version" and "version 2 or later".
+var orLaterRE = phraseRE(`^version \S*\d\S*(?: of the (?:gnu )?(?:license|gpl))? or (?:at your option )?(?:any )?later\b`)
+
+// orLaterPlusRE matches the names of the GNU licenses that grant the choice
+// of any later version by a plus sign, as in "GPLv2+", which the tokenizer
+// drops.
+var orLaterPlusRE = phraseRE(`(?i)\b[AL]?GPL\s*-?\s*v?\d(?:\.\d)?\+`)
+
+// orLaterSuffixRE matches the start of the text following the name of a GNU
+// license that grants the choice of any later version, as in "the GPL-2.0+" or
+// "the GNU General Public License, version 2, or any later version".
+var orLaterSuffixRE = phraseRE(`(?i)^(?:\+|[\s,;]*(?:or\s+(?:\(at\s+your\s+option\)\s+)?(?:any\s+)?later\b))`)
+
+// orLaterWords is the number of words of the content following a Header
+// match that its version clause may run into. The variant of the header
+// matched may end before the content's clause does, as when the content adds
+// "or (at your option) any later version" to a variant without it.
+const orLaterWords = 8
+
+// versioned reports whether the license is a GNU license, with or without an
+// exception, that its notices can license either in a single version or in
+// that version or any later one.
+func versioned(name string) bool {
+	return strings.Contains(spdxExpression(name), "-only")
+}
+
+// markOrLater sets OrLater on the Header matches of the GNU licenses whose
+// version clause in the content grants the choice of any later version. The
+// content is diffed against the variant of the header matched to find the
+// clause, the word "version" the two share, and only the words from there on
+// are inspected, so that an adjacent notice saying "or later" of something
+// else doesn't change the match. The clause is read from the content rather
+// than from the variant, since the variants differ in it by only a few tokens
+// and the content may hold either with a high confidence. The full texts of
+// the licenses are silent on it, so License matches are left alone.
+func (c *Classifier) markOrLater(in []byte, target func(NumberPolicy) *indexedDocument, matches Matches) {
+	for _, m := range matches {
+		if m.MatchType != "Header" || !versioned(m.Name) {
+			continue
+		}
+		if orLaterPlusRE.MatchString(lineText(in, m.StartLine, m.EndLine)) {
+			m.OrLater = true
+			continue
+		}
+		name := c.generateDocName(m.MatchType, m.Name, m.Variant)
+		doc, ok := c.docs[name]
+		if !ok {
+			continue
+		}
+		d := c.expand(name, doc)
+		t := target(d.numbers)
+		m.OrLater = orLaterClause(versionClause(t, docDiff(name, t, m.StartTokenIndex, m.EndTokenIndex+1, d, 0, d.size()), m.EndTokenIndex+1))
+	}
+}
+
+// versionClause returns the words of the content t from the version clause
+// of a header on, given the diffs of the match of the header, which ends
+// before the token at index end, against its variant. The clause starts at
+// the first "version" followed by a number that the content shares with the
+// variant, or failing that at the first the content holds. It may run up to
+// orLaterWords words past the match.
+func versionClause(t *indexedDocument, diffs []diffmatchpatch.Diff, end int) []string {
+	var words []string
+	var shared []bool
+	for _, d := range diffs {
+		if d.Type == diffmatchpatch.DiffInsert || d.Text == "" {
+			continue
+		}
+		for _, w := range strings.Split(d.Text, " ") {
+			words = append(words, w)
+			shared = append(shared, d.Type == diffmatchpatch.DiffEqual)
+		}
+	}
+	for i := end; i < len(t.Tokens) && i < end+orLaterWords; i++ {
+		words = append(words, t.dict.getWord(t.Tokens[i].ID))
+		shared = append(shared, false)
+	}
+
+	start := -1
+	for i := 0; i+1 < len(words); i++ {
+		if words[i] != "version" || !strings.ContainsAny(words[i+1], "0123456789") {
+			continue
+		}
+		if shared[i] {
+			return words[i:]
+		}
+		if start < 0 {
+			start = i
+		}
+	}
+	if start < 0 {
+		return nil
+	}
+	return words[start:]
+}
+
+// orLaterClause reports whether the words of a version clause, as returned
+// by versionClause, grant the choice of any later version.
+func orLaterClause(words []string) bool {
+	return len(words) > 0 && orLaterRE.MatchString(strings.Join(words, " "))
+}
+
+// orLaterExpression returns the SPDX expression of the license, granting any
+// later version if orLater is set and the license is versioned.
+func orLaterExpression(name string, orLater bool) string {
+	e := spdxExpression(name)
+	if orLater {
+		e = strings.Replace(e, "-only", "-or-later", 1)
+	}
+	return e
+}
@@ -0,0 +1,112 @@
+// Copyright 2022 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package classifier
+
+import (
+	"io/ioutil"
+	"path"
+	"strings"
+	"testing"
+)
+
+func TestOrLaterClause(t *testing.T) {
+	tests := []struct {
+		name  string
+		words string
+		want  bool
+	}{
+		{
+			name:  "any later version",
+			words: "version 2 of the license or at your option any later version this program is distributed",
+			want:  true,
+		},
+		{
+			name:  "or later",
+			words: "version 2.1 or later",
+			want:  true,
+		},
+		{
+			name:  "single version",
+			words: "version 2 as published by the free software foundation",
+			want:  false,
+		},
+		{
+			name:  "later clause of another sentence",
+			words: "version 2 this program is distributed or later",
+			want:  false,
+		},
+		{
+			name: "no clause",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var words []string
+			if tt.words != "" {
+				words = strings.Split(tt.words, " ")
+			}
+			if got := orLaterClause(words); got != tt.want {
+				t.Errorf("orLaterClause(%q) = %v, want %v", tt.words, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMatchOrLater(t *testing.T) {
+	c, err := classifier()
+	if err != nil {
+		t.Fatalf("couldn't instantiate standard test classifier: %v", err)
+	}
+	header, err := ioutil.ReadFile(path.Join(baseLicenses, "Header", "GPL-2.0", "a.txt"))
+	if err != nil {
+		t.Fatalf("couldn't read GPL-2.0 header: %v", err)
+	}
+
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{
+			name:  "or later",
+			input: string(header),
+			want:  "GPL-2.0-or-later",
+		},
+		{
+			name:  "only",
+			input: strings.Replace(string(header), "either version 2 of the License, or\n(at your option) any later version.", "version 2 of the License.", 1),
+			want:  "GPL-2.0-only",
+		},
+		{
+			name:  "adjacent notice",
+			input: strings.Replace(string(header), "either version 2 of the License, or\n(at your option) any later version.", "version 2 of the License.", 1) + "\nThe documentation is licensed under the GNU Free Documentation License,\nVersion 1.3 or later.\n",
+			want:  "GPL-2.0-only",
+		},
+		{
+			name:  "plus",
+			input: strings.Replace(string(header), "either version 2 of the License, or\n(at your option) any later version.", "version 2 of the License\n(GPLv2+).", 1),
+			want:  "GPL-2.0-or-later",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := c.Match([]byte(tt.input)).Matches.SPDXExpression(); got != tt.want {
+				t.Errorf("SPDXExpression() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
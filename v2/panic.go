@@ -0,0 +1,46 @@
+// Copyright 2022 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package classifier
+
+import (
+	"fmt"
+	"runtime/debug"
+)
+
+// PanicError reports a panic recovered while matching content, which is a bug
+// in the classifier. When the panic occurred while matching a single license,
+// the matches of the other licenses are still returned along with the error.
+type PanicError struct {
+	// License is the corpus document that was being matched, or empty if the
+	// panic occurred outside the work for a single license.
+	License string
+	// Value is the value the code panicked with.
+	Value interface{}
+	// Stack is the stack trace of the panic, for bug reports.
+	Stack []byte
+}
+
+func (e *PanicError) Error() string {
+	if e.License == "" {
+		return fmt.Sprintf("panic while matching: %v", e.Value)
+	}
+	return fmt.Sprintf("panic while matching %s: %v", e.License, e.Value)
+}
+
+// newPanicError returns the error for a panic recovered while matching the
+// license, capturing the stack of the panicking goroutine.
+func newPanicError(license string, v interface{}) *PanicError {
+	return &PanicError{License: license, Value: v, Stack: debug.Stack()}
+}
@@ -0,0 +1,46 @@
+// Copyright 2022 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package classifier
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+)
+
+func TestMatchRecoversPanics(t *testing.T) {
+	c := NewClassifier(defaultThreshold)
+	c.AddContent("License", "Good", "license.txt", []byte("the good license grants every right to whoever receives this software"))
+	c.AddContent("License", "Broken", "license.txt", []byte("the broken license takes every right from whoever receives this software"))
+	// Corrupt the document so that matching it indexes past its tokens.
+	broken := c.getIndexedDocument("License", "Broken", "license.txt")
+	broken.runes = []rune{broken.runes[0]}
+
+	in := []byte("the good license grants every right to whoever receives this software\n\nthe broken license takes every right from whoever receives this software")
+	res, err := c.MatchFrom(bytes.NewReader(in))
+	var pe *PanicError
+	if !errors.As(err, &pe) {
+		t.Fatalf("MatchFrom() error = %v, want a *PanicError", err)
+	}
+	if pe.License != "License/Broken/license.txt" || len(pe.Stack) == 0 {
+		t.Errorf("MatchFrom() error = %+v, want a panic for License/Broken/license.txt with its stack", pe)
+	}
+	if len(res.Matches) != 1 || res.Matches[0].Name != "Good" {
+		t.Errorf("MatchFrom() = %v, want the match of Good", res.Matches)
+	}
+	if got := c.Match(in); len(got.Matches) != 1 {
+		t.Errorf("Match() = %v, want the match of Good", got.Matches)
+	}
+}
@@ -0,0 +1,92 @@
+// Copyright 2022 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build !core
+// +build !core
+
+package classifier
+
+import "regexp"
+
+// publicDomainName is the Name of public-domain matches, whatever form the
+// dedication takes.
+const publicDomainName = "PublicDomain"
+
+// publicDomainPatterns are the forms of public-domain dedication, in order of
+// precedence. A region matched by one pattern isn't matched by later ones, so
+// that a notice is reported once however many of its phrases match.
+// Statements that merely describe the content as being in the public domain
+// are less conclusive than dedications, so are reported with less confidence,
+// and only when their subject is the content itself, such as "this file",
+// rather than anything prose might mention, such as an algorithm.
+var publicDomainPatterns = []struct {
+	variant    string
+	re         *regexp.Regexp
+	confidence float64
+}{
+	// The SQLite blessing: "The author disclaims copyright to this source
+	// code. In place of a legal notice, here is a blessing: ..."
+	{"blessing", phraseRE(`(?i)\bauthors?\s+(?:hereby\s+)?disclaims?\s+copyright\s+to\s+this\s+source\s+code\b`), 1.0},
+	// Works of the US government aren't subject to copyright under 17 U.S.C.
+	// § 105.
+	{"us-government", phraseRE(`(?i)\bnot\s+subject\s+to\s+copyright\s+protection\s+in\s+the\s+United\s+States\b`), 1.0},
+	{"us-government", phraseRE(`(?i)\b(?:work\s+of\s+the\s+(?:U\.?\s?S\.?|United\s+States)\s+(?:federal\s+)?government|(?:U\.?\s?S\.?|United\s+States)\s+(?:federal\s+)?government\s+work)\b`), 0.9},
+	{"dedication", phraseRE(`(?i)\b(?:released|placed|dedicated|put|committed|donated|given)\s+(?:in|into|to)\s+the\s+public\s+domain\b`), 1.0},
+	{"statement", phraseRE(`(?i)\b(?:this|these|the|all(?:\s+the)?)\s+(?:source\s+)?(?:files?|code|software|programs?|librar(?:y|ies)|works?|packages?|modules?|contents?|materials?)\s+(?:is|are)\s+(?:(?:hereby|entirely|now)\s+)?in\s+the\s+public\s+domain\b`), 0.9},
+}
+
+// publicDomainExclusions match statements that dedicate something other than
+// the content to the public domain, such as the Creative Commons notice that
+// the texts of its licenses are dedicated to the public domain.
+var publicDomainExclusions = []*regexp.Regexp{
+	phraseRE(`(?i)\btext\s+of\s+the\s+Creative\s+Commons\s+public\s+licenses\s+is\s+dedicated\s+to\s+the\s+public\s+domain\b`),
+}
+
+// detectPublicDomain finds public-domain dedications in the input, such as the
+// SQLite blessing, "released into the public domain by the author" or a notice
+// of a work of the US government. A match named PublicDomain is reported for
+// each region of lines holding one, with the form of the dedication recorded
+// as the Variant.
+func detectPublicDomain(in []byte) Matches {
+	var out Matches
+	var excluded [][]int
+	for _, re := range publicDomainExclusions {
+		excluded = append(excluded, re.FindAllIndex(in, -1)...)
+	}
+	for _, p := range publicDomainPatterns {
+	next:
+		for _, loc := range p.re.FindAllIndex(in, -1) {
+			for _, e := range excluded {
+				if loc[0] < e[1] && e[0] < loc[1] {
+					continue next
+				}
+			}
+			start, end := lineOf(in, loc[0]), lineOf(in, loc[1]-1)
+			for _, m := range out {
+				if start <= m.EndLine && m.StartLine <= end {
+					continue next
+				}
+			}
+			out = append(out, &Match{
+				Name:       publicDomainName,
+				Variant:    p.variant,
+				MatchType:  publicDomainMatchType,
+				Confidence: p.confidence,
+				StartLine:  start,
+				EndLine:    end,
+			})
+		}
+	}
+	return out
+}
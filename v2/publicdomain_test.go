@@ -0,0 +1,163 @@
+// Copyright 2022 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build !core
+// +build !core
+
+package classifier
+
+import (
+	"io/ioutil"
+	"path"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestDetectPublicDomain(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  Matches
+	}{
+		{
+			name:  "no dedication",
+			input: "Copyright 2022 Google Inc. All rights reserved.",
+			want:  nil,
+		},
+		{
+			name: "sqlite blessing",
+			input: `2001 September 15
+
+The author disclaims copyright to this source code.  In place of
+a legal notice, here is a blessing:
+
+   May you do good and not evil.`,
+			want: Matches{
+				{Name: "PublicDomain", Variant: "blessing", MatchType: "PublicDomain", Confidence: 1.0, StartLine: 3, EndLine: 3},
+			},
+		},
+		{
+			name: "released by the author",
+			input: `This code was written by Jane Doe and is released into
+the public domain by the author.`,
+			want: Matches{
+				{Name: "PublicDomain", Variant: "dedication", MatchType: "PublicDomain", Confidence: 1.0, StartLine: 1, EndLine: 2},
+			},
+		},
+		{
+			name: "wrapped in a comment",
+			input: `/*
+ * This file is released into the public
+ * domain.
+ */`,
+			want: Matches{
+				{Name: "PublicDomain", Variant: "dedication", MatchType: "PublicDomain", Confidence: 1.0, StartLine: 2, EndLine: 3},
+			},
+		},
+		{
+			name: "us government work",
+			input: `This software is a work of the U.S. Government and is not subject to
+copyright protection in the United States.`,
+			want: Matches{
+				{Name: "PublicDomain", Variant: "us-government", MatchType: "PublicDomain", Confidence: 1.0, StartLine: 1, EndLine: 2},
+			},
+		},
+		{
+			name:  "us government work without notice",
+			input: "This software is a work of the U.S. Government.",
+			want: Matches{
+				{Name: "PublicDomain", Variant: "us-government", MatchType: "PublicDomain", Confidence: 0.9, StartLine: 1, EndLine: 1},
+			},
+		},
+		{
+			name:  "statement",
+			input: "This file is in the public domain.",
+			want: Matches{
+				{Name: "PublicDomain", Variant: "statement", MatchType: "PublicDomain", Confidence: 0.9, StartLine: 1, EndLine: 1},
+			},
+		},
+		{
+			name: "creative commons notice",
+			input: `The text of the Creative Commons
+public licenses is dedicated to the public domain under the CC0 Public
+Domain Dedication.`,
+			want: nil,
+		},
+		{
+			name:  "statement about something else",
+			input: "The algorithm is in the public domain, but this implementation isn't.",
+			want:  nil,
+		},
+		{
+			name:  "negated statement",
+			input: "This file is not in the public domain.",
+			want:  nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := detectPublicDomain([]byte(tt.input))
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("detectPublicDomain() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestMatchPublicDomain(t *testing.T) {
+	unlicense, err := ioutil.ReadFile(path.Join(baseLicenses, "License", "Unlicense", "license.txt"))
+	if err != nil {
+		t.Fatalf("couldn't read Unlicense: %v", err)
+	}
+	c := NewClassifier(.8)
+	c.AddContent("License", "Unlicense", "license.txt", unlicense)
+
+	tests := []struct {
+		name     string
+		input    []byte
+		want     []string
+		wantSPDX string
+	}{
+		{
+			name:     "short dedication",
+			input:    []byte("// This file is placed in the public domain.\npackage foo\n"),
+			want:     []string{"PublicDomain"},
+			wantSPDX: "LicenseRef-PublicDomain",
+		},
+		{
+			name:     "within the unlicense",
+			input:    unlicense,
+			want:     []string{"Unlicense"},
+			wantSPDX: "Unlicense",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res := c.Match(tt.input)
+			var got []string
+			for _, m := range res.Matches {
+				got = append(got, m.Name)
+			}
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("Match() mismatch (-want +got):\n%s", diff)
+			}
+			if got := res.Matches.SPDXExpression(); got != tt.wantSPDX {
+				t.Errorf("SPDXExpression() = %q, want %q", got, tt.wantSPDX)
+			}
+		})
+	}
+}
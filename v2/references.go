@@ -0,0 +1,303 @@
+// Copyright 2022 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build !core
+// +build !core
+
+package classifier
+
+import (
+	"bytes"
+	"regexp"
+	"strings"
+)
+
+// referentLicenses maps the projects that content is commonly licensed "under
+// the same terms as" to the licenses of those projects.
+var referentLicenses = map[string][]string{
+	"perl":   {"Artistic-1.0-Perl", "GPL-1.0"},
+	"php":    {"PHP-3.01"},
+	"python": {"Python-2.0"},
+	"ruby":   {"Ruby"},
+	"tcl":    {"tcl_tk"},
+}
+
+// sameTermsRE matches statements such as "This library is free software; you
+// can redistribute it and/or modify it under the same terms as Perl itself."
+// The referent may be followed by its version, as in "perl5" or "Perl 5", and
+// the statement may be wrapped across the lines of a comment.
+var sameTermsRE = phraseRE(`(?i)\bsame\s+(?:terms|licen[cs]e|licen[cs]e\s+terms|licensing\s+terms|conditions)\s+as\s+(?:the\s+)?(perl|php|python|ruby|tcl)(?:\s*v?\d+(?:\.\d+)*)?\b`)
+
+// licenseURLs are the URLs at which licenses are published, which source
+// headers often give in place of the license's text or name. Each pattern
+// follows the scheme and host of a URL, and is expanded to the name of the
+// license it matches, which is reported if it's one of urlLicenses.
+var licenseURLs = []struct {
+	re   *regexp.Regexp
+	name string
+}{
+	{licenseURLRE(`apache\.org/licenses/LICENSE-(\d\.\d)`), "Apache-$1"},
+	{licenseURLRE(`opensource\.org/licenses?/([\w.+-]*[\w+])`), "$1"},
+	{licenseURLRE(`spdx\.org/licenses/([\w.+-]*[\w+])`), "$1"},
+	{licenseURLRE(`gnu\.org/licenses/(?:old-licenses/)?(a?gpl|lgpl)-(\d\.\d)`), "$1-$2"},
+	{licenseURLRE(`mozilla\.org/(?:en-US/)?MPL/(\d\.\d)`), "MPL-$1"},
+	{licenseURLRE(`eclipse\.org/legal/epl-v?(\d)\.?(\d)`), "EPL-$1.$2"},
+	{licenseURLRE(`creativecommons\.org/licenses/(by(?:-nc)?(?:-nd|-sa)?)/(\d\.\d)`), "CC-$1-$2"},
+	{licenseURLRE(`creativecommons\.org/publicdomain/zero/1\.0`), "CC0-1.0"},
+}
+
+// licenseURLRE compiles the pattern of a license URL, which may be given with
+// or without its scheme and a "www." prefix.
+func licenseURLRE(pattern string) *regexp.Regexp {
+	return regexp.MustCompile(`(?i)\b(?:https?://)?(?:www\.)?` + pattern)
+}
+
+// urlLicenses maps the lower-cased names that license URLs are expanded to,
+// including the aliases of the older opensource.org URLs, to the names of the
+// licenses in the corpus.
+var urlLicenses = func() map[string]string {
+	out := make(map[string]string)
+	for _, l := range []string{
+		"0BSD", "AFL-3.0", "AGPL-1.0", "AGPL-3.0", "Apache-1.0", "Apache-1.1",
+		"Apache-2.0", "Artistic-2.0", "BSD-2-Clause", "BSD-3-Clause",
+		"BSL-1.0", "CC-BY-1.0", "CC-BY-2.0", "CC-BY-2.5", "CC-BY-3.0",
+		"CC-BY-4.0", "CC-BY-NC-4.0", "CC-BY-NC-ND-4.0", "CC-BY-NC-SA-4.0",
+		"CC-BY-ND-4.0", "CC-BY-SA-3.0", "CC-BY-SA-4.0", "CC0-1.0", "CDDL-1.0",
+		"CDDL-1.1", "EPL-1.0", "EPL-2.0", "EUPL-1.1", "EUPL-1.2", "GPL-2.0",
+		"GPL-3.0", "ISC", "LGPL-2.0", "LGPL-2.1", "LGPL-3.0", "MIT", "MPL-1.0",
+		"MPL-1.1", "MPL-2.0", "MS-PL", "MS-RL", "NCSA", "OFL-1.1",
+		"PostgreSQL", "UPL-1.0", "Unlicense", "Zlib",
+	} {
+		out[strings.ToLower(l)] = l
+	}
+	// The corpus names the GNU licenses by their version alone.
+	for name, e := range spdxExpressions {
+		if !strings.Contains(e, " ") {
+			out[strings.ToLower(e)] = name
+		}
+	}
+	for alias, l := range map[string]string{
+		"mit-license":  "MIT",
+		"apache2.0":    "Apache-2.0",
+		"mozilla1.1":   "MPL-1.1",
+		"zlib-license": "Zlib",
+	} {
+		out[alias] = l
+	}
+	return out
+}()
+
+// urlExtRE matches the file extensions license URLs may end with.
+var urlExtRE = regexp.MustCompile(`(?i)\.(?:php|html?|txt|json)$`)
+
+// detectReferences finds statements in the input that license the content by
+// reference to the license of another project, or by the URL of a license. A
+// match is reported for each license the referent is licensed under, with the
+// referent recorded as the Variant, and for each license URL, with the URL
+// recorded as the Variant.
+func detectReferences(in []byte) Matches {
+	var out Matches
+	for _, loc := range sameTermsRE.FindAllSubmatchIndex(in, -1) {
+		referent := strings.ToLower(string(in[loc[2]:loc[3]]))
+		start, end := lineOf(in, loc[0]), lineOf(in, loc[1]-1)
+		for _, l := range referentLicenses[referent] {
+			out = append(out, &Match{
+				Name:       l,
+				Variant:    referent,
+				MatchType:  referenceMatchType,
+				Confidence: 1.0,
+				StartLine:  start,
+				EndLine:    end,
+			})
+		}
+	}
+	return append(out, detectLicenseURLs(in)...)
+}
+
+// detectLicenseURLs finds the URLs of licenses in the input.
+func detectLicenseURLs(in []byte) Matches {
+	var out Matches
+	for _, u := range licenseURLs {
+		for _, loc := range u.re.FindAllSubmatchIndex(in, -1) {
+			name := strings.ToLower(urlExtRE.ReplaceAllString(string(u.re.Expand(nil, []byte(u.name), in, loc)), ""))
+			// SPDX identifies the GNU licenses granting any later
+			// version with an -or-later suffix, formerly a plus.
+			trimmed := strings.TrimSuffix(strings.TrimSuffix(name, "-or-later"), "+")
+			l, ok := urlLicenses[trimmed]
+			if !ok {
+				continue
+			}
+			out = append(out, &Match{
+				Name:       l,
+				Variant:    string(in[loc[0]:loc[1]]),
+				MatchType:  referenceMatchType,
+				Confidence: 1.0,
+				StartLine:  lineOf(in, loc[0]),
+				EndLine:    lineOf(in, loc[1]-1),
+				OrLater:    trimmed != name && versioned(l),
+			})
+		}
+	}
+	return out
+}
+
+// The confidence of references to licenses by name, which are reported only
+// with WithNameReferences. A license's name alone doesn't establish which
+// variant of its text applies, and "-style" names are looser still.
+const (
+	nameReferenceConfidence  = 0.75
+	styleReferenceConfidence = 0.5
+)
+
+// licenseNames are the patterns of the names of common licenses, in the order
+// they're tried, since the names of the GNU licenses include one another.
+var licenseNames = []struct {
+	name    string
+	pattern string
+}{
+	{"AGPL-3.0", `(?:GNU\s+)?(?:Affero\s+General\s+Public\s+Licen[cs]e|AGPL)(?:,?\s+(?:version\s+|v)?|-)3(?:\.0)?`},
+	{"LGPL-2.1", `(?:GNU\s+)?(?:Lesser\s+General\s+Public\s+Licen[cs]e|LGPL)(?:,?\s+(?:version\s+|v)?|-)2\.1`},
+	{"LGPL-3.0", `(?:GNU\s+)?(?:Lesser\s+General\s+Public\s+Licen[cs]e|LGPL)(?:,?\s+(?:version\s+|v)?|-)3(?:\.0)?`},
+	{"GPL-2.0", `(?:GNU\s+)?(?:General\s+Public\s+Licen[cs]e|GPL)(?:,?\s+(?:version\s+|v)?|-)2(?:\.0)?`},
+	{"GPL-3.0", `(?:GNU\s+)?(?:General\s+Public\s+Licen[cs]e|GPL)(?:,?\s+(?:version\s+|v)?|-)3(?:\.0)?`},
+	{"Apache-2.0", `Apache\s+(?:Licen[cs]e,?\s+)?(?:version\s+|v)?2(?:\.0)?`},
+	{"MPL-2.0", `(?:Mozilla\s+Public\s+Licen[cs]e|MPL)(?:,?\s+(?:version\s+|v)?|-)2\.0`},
+	{"EPL-2.0", `(?:Eclipse\s+Public\s+Licen[cs]e|EPL)(?:,?\s+(?:version\s+|v)?|-)2\.0`},
+	{"BSD-3-Clause", `(?:BSD[\s-]*3[\s-]*Clause|3[\s-]*Clause\s+BSD|New\s+BSD|Modified\s+BSD)`},
+	{"BSD-2-Clause", `(?:BSD[\s-]*2[\s-]*Clause|2[\s-]*Clause\s+BSD|Simplified\s+BSD|FreeBSD)`},
+	{"BSL-1.0", `Boost\s+Software\s+Licen[cs]e`},
+	{"MIT", `MIT`},
+	{"ISC", `ISC`},
+	{"Unlicense", `Unlicense`},
+	{"CC0-1.0", `CC0`},
+	{"CC-BY-SA-4.0", `(?:Creative\s+Commons\s+Attribution[\s-]+Share[\s-]*Alike|CC[\s-]+BY[\s-]+SA)[\s-]+(?:version\s+|v)?4\.0`},
+	{"CC-BY-4.0", `(?:Creative\s+Commons\s+Attribution|CC[\s-]+BY)[\s-]+(?:version\s+|v)?4\.0`},
+}
+
+// licenseNameREs match statements that content is licensed under one of the
+// licenseNames, such as "Licensed under the MIT License" or "distributed under
+// the terms of the GNU General Public License, version 2".
+var licenseNameREs = func() []*regexp.Regexp {
+	var out []*regexp.Regexp
+	for _, l := range licenseNames {
+		out = append(out, phraseRE(`(?i)\b(?:licen[cs]ed|distributed|released|available|provided)\s+under\s+(?:the\s+)?(?:terms\s+(?:and\s+conditions\s+)?of\s+(?:the\s+)?)?(?:`+l.pattern+`)\b`))
+	}
+	return out
+}()
+
+// styleLicenses maps the licenses content is said to be licensed under a
+// "-style" license of, as in Go's "governed by a BSD-style license", to the
+// license most commonly meant.
+var styleLicenses = map[string]string{
+	"apache": "Apache-2.0",
+	"bsd":    "BSD-3-Clause",
+	"mit":    "MIT",
+}
+
+// styleRE matches mentions of "-style" licenses.
+var styleRE = phraseRE(`(?i)\b(apache|bsd|mit)[\s-]+style\s+licen[cs]e\b`)
+
+// detectNameReferences finds statements in the input that license the
+// content by naming a license, for content that doesn't include the license's
+// text. The statement is recorded as the Variant. Statements naming a license
+// already referenced on the same lines, typically by its URL, aren't reported.
+func detectNameReferences(in []byte, refs Matches) Matches {
+	var out Matches
+	add := func(name string, loc []int, confidence float64) *Match {
+		m := &Match{
+			Name:       name,
+			Variant:    phraseText(in[loc[0]:loc[1]]),
+			MatchType:  referenceMatchType,
+			Confidence: confidence,
+			StartLine:  lineOf(in, loc[0]),
+			EndLine:    lineOf(in, loc[1]-1),
+		}
+		for _, r := range append(refs, out...) {
+			if r.Name == m.Name && (overlaps(r, m) || overlaps(m, r)) {
+				return nil
+			}
+		}
+		out = append(out, m)
+		return m
+	}
+	for i, re := range licenseNameREs {
+		for _, loc := range re.FindAllIndex(in, -1) {
+			if m := add(licenseNames[i].name, loc, nameReferenceConfidence); m != nil && versioned(m.Name) {
+				m.OrLater = orLaterSuffixRE.Match(in[loc[1]:])
+			}
+		}
+	}
+	for _, loc := range styleRE.FindAllSubmatchIndex(in, -1) {
+		add(styleLicenses[strings.ToLower(string(in[loc[2]:loc[3]]))], loc, styleReferenceConfidence)
+	}
+	return out
+}
+
+// detectText returns the matches found in the raw text of the content rather
+// than against the corpus: references to licenses, public-domain dedications
+// and the choices between licenses they're offered as.
+func (c *Classifier) detectText(in []byte) Matches {
+	refs := append(detectReferences(in), detectPublicDomain(in)...)
+	if c.nameRefs {
+		refs = append(refs, detectNameReferences(in, refs)...)
+	}
+	return append(refs, detectAlternatives(in, refs, c.nameRefs)...)
+}
+
+// lineOf returns the 1-based line number of the byte offset in the input.
+func lineOf(in []byte, offset int) int {
+	return bytes.Count(in[:offset], []byte("\n")) + 1
+}
+
+// addReferences appends the references, including public-domain dedications,
+// to the matches, dropping those that lie within a match of license text, such
+// as the CC0 or Unlicense, or that name a license whose text is matched
+// elsewhere in the content, since they then add no information. Public-domain
+// dedications within an annotation, such as the Creative Commons trademark
+// notice, are the annotation's rather than the content's, so are dropped too.
+// A match of a license's text takes over the Alternatives of a reference it
+// drops.
+func addReferences(matches, refs Matches) Matches {
+	for _, r := range refs {
+		covered := false
+		for _, m := range matches {
+			if covers(m, r) {
+				covered = true
+				// The text of a license offered as one of a choice
+				// takes the choice over from the reference.
+				if m.Name == r.Name && m.Alternatives == nil {
+					m.Alternatives = r.Alternatives
+				}
+				break
+			}
+		}
+		if !covered {
+			matches = append(matches, r)
+		}
+	}
+	return matches
+}
+
+// covers reports whether the match m makes the reference r redundant.
+func covers(m, r *Match) bool {
+	switch {
+	case m.MatchType == "Copyright", m.MatchType == referenceMatchType, m.MatchType == publicDomainMatchType:
+		return false
+	case annotationMatchTypes[m.MatchType] && r.MatchType != publicDomainMatchType:
+		return false
+	case m.Name == r.Name && (m.MatchType == "License" || m.MatchType == "Header"):
+		return true
+	}
+	return contains(m, r)
+}
@@ -0,0 +1,243 @@
+// Copyright 2022 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build !core
+// +build !core
+
+package classifier
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestDetectReferences(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  Matches
+	}{
+		{
+			name:  "no reference",
+			input: "This library is free software.",
+			want:  nil,
+		},
+		{
+			name: "perl",
+			input: `This library is free software; you can redistribute it and/or modify
+it under the same terms as Perl itself.`,
+			want: Matches{
+				{Name: "Artistic-1.0-Perl", Variant: "perl", MatchType: "Reference", Confidence: 1.0, StartLine: 2, EndLine: 2},
+				{Name: "GPL-1.0", Variant: "perl", MatchType: "Reference", Confidence: 1.0, StartLine: 2, EndLine: 2},
+			},
+		},
+		{
+			name: "ruby across lines",
+			input: `Distributed under the same
+license as Ruby.`,
+			want: Matches{
+				{Name: "Ruby", Variant: "ruby", MatchType: "Reference", Confidence: 1.0, StartLine: 1, EndLine: 2},
+			},
+		},
+		{
+			name: "apache url",
+			input: `// Licensed under the terms at
+//     http://www.apache.org/licenses/LICENSE-2.0`,
+			want: Matches{
+				{Name: "Apache-2.0", Variant: "http://www.apache.org/licenses/LICENSE-2.0", MatchType: "Reference", Confidence: 1.0, StartLine: 2, EndLine: 2},
+			},
+		},
+		{
+			name:  "spdx or later url",
+			input: "See https://spdx.org/licenses/GPL-2.0-or-later.html",
+			want: Matches{
+				{Name: "GPL-2.0", Variant: "https://spdx.org/licenses/GPL-2.0-or-later.html", MatchType: "Reference", Confidence: 1.0, StartLine: 1, EndLine: 1, OrLater: true},
+			},
+		},
+		{
+			name:  "spdx only url",
+			input: "See https://spdx.org/licenses/GPL-2.0-only.html",
+			want: Matches{
+				{Name: "GPL-2.0", Variant: "https://spdx.org/licenses/GPL-2.0-only.html", MatchType: "Reference", Confidence: 1.0, StartLine: 1, EndLine: 1},
+			},
+		},
+		{
+			name:  "opensource.org url with extension",
+			input: "See https://opensource.org/licenses/mit-license.php.",
+			want: Matches{
+				{Name: "MIT", Variant: "https://opensource.org/licenses/mit-license.php", MatchType: "Reference", Confidence: 1.0, StartLine: 1, EndLine: 1},
+			},
+		},
+		{
+			name:  "spdx url of a gnu license",
+			input: "License: https://spdx.org/licenses/GPL-2.0-only.html",
+			want: Matches{
+				{Name: "GPL-2.0", Variant: "https://spdx.org/licenses/GPL-2.0-only.html", MatchType: "Reference", Confidence: 1.0, StartLine: 1, EndLine: 1},
+			},
+		},
+		{
+			name:  "gnu url",
+			input: "<https://www.gnu.org/licenses/old-licenses/lgpl-2.1.html>",
+			want: Matches{
+				{Name: "LGPL-2.1", Variant: "https://www.gnu.org/licenses/old-licenses/lgpl-2.1", MatchType: "Reference", Confidence: 1.0, StartLine: 1, EndLine: 1},
+			},
+		},
+		{
+			name:  "creative commons url",
+			input: "Licensed under https://creativecommons.org/licenses/by-sa/4.0/",
+			want: Matches{
+				{Name: "CC-BY-SA-4.0", Variant: "https://creativecommons.org/licenses/by-sa/4.0", MatchType: "Reference", Confidence: 1.0, StartLine: 1, EndLine: 1},
+			},
+		},
+		{
+			name:  "eclipse url",
+			input: "http://www.eclipse.org/legal/epl-v10.html",
+			want: Matches{
+				{Name: "EPL-1.0", Variant: "http://www.eclipse.org/legal/epl-v10", MatchType: "Reference", Confidence: 1.0, StartLine: 1, EndLine: 1},
+			},
+		},
+		{
+			name:  "unknown opensource.org page",
+			input: "https://opensource.org/licenses/alphabetical",
+			want:  nil,
+		},
+		{
+			name: "wrapped in a comment",
+			input: `# This program is free software; you can redistribute it and/or modify it under the same terms as
+# Perl itself.`,
+			want: Matches{
+				{Name: "Artistic-1.0-Perl", Variant: "perl", MatchType: "Reference", Confidence: 1.0, StartLine: 1, EndLine: 2},
+				{Name: "GPL-1.0", Variant: "perl", MatchType: "Reference", Confidence: 1.0, StartLine: 1, EndLine: 2},
+			},
+		},
+		{
+			name:  "versioned referent",
+			input: "You may distribute under the same terms as perl5 itself.",
+			want: Matches{
+				{Name: "Artistic-1.0-Perl", Variant: "perl", MatchType: "Reference", Confidence: 1.0, StartLine: 1, EndLine: 1},
+				{Name: "GPL-1.0", Variant: "perl", MatchType: "Reference", Confidence: 1.0, StartLine: 1, EndLine: 1},
+			},
+		},
+		{
+			name:  "unrelated use of same terms",
+			input: "Use the same terms as the rest of the documentation.",
+			want:  nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := detectReferences([]byte(tt.input))
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("detectReferences() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestAddReferences(t *testing.T) {
+	license := &Match{Name: "Artistic-1.0-Perl", MatchType: "License", StartLine: 1, EndLine: 100}
+	inside := &Match{Name: "GPL-1.0", MatchType: "Reference", StartLine: 10, EndLine: 10}
+	outside := &Match{Name: "Ruby", MatchType: "Reference", StartLine: 101, EndLine: 102}
+
+	got := addReferences(Matches{license}, Matches{inside, outside})
+	if diff := cmp.Diff(Matches{license, outside}, got); diff != "" {
+		t.Errorf("addReferences() mismatch (-want +got):\n%s", diff)
+	}
+
+	// A reference to a license whose text is matched elsewhere is dropped.
+	mit := &Match{Name: "MIT", MatchType: "License", StartLine: 10, EndLine: 30}
+	named := &Match{Name: "MIT", MatchType: "Reference", StartLine: 1, EndLine: 1}
+	got = addReferences(Matches{mit}, Matches{named})
+	if diff := cmp.Diff(Matches{mit}, got); diff != "" {
+		t.Errorf("addReferences() mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestDetectNameReferences(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		refs  Matches
+		want  Matches
+	}{
+		{
+			name:  "no name",
+			input: "Licensed under the terms in the LICENSE file.",
+			want:  nil,
+		},
+		{
+			name:  "mit",
+			input: "This project is licensed under the MIT License.",
+			want: Matches{
+				{Name: "MIT", Variant: "licensed under the MIT", MatchType: "Reference", Confidence: 0.75, StartLine: 1, EndLine: 1},
+			},
+		},
+		{
+			name: "gpl version across lines",
+			input: `This program is distributed under the terms of the GNU General
+Public License, version 2.`,
+			want: Matches{
+				{Name: "GPL-2.0", Variant: "distributed under the terms of the GNU General Public License, version 2", MatchType: "Reference", Confidence: 0.75, StartLine: 1, EndLine: 2},
+			},
+		},
+		{
+			name:  "gpl or later",
+			input: "Licensed under the GNU GPL, version 3, or (at your option) any later version.",
+			want: Matches{
+				{Name: "GPL-3.0", Variant: "Licensed under the GNU GPL, version 3", MatchType: "Reference", Confidence: 0.75, StartLine: 1, EndLine: 1, OrLater: true},
+			},
+		},
+		{
+			name:  "lgpl plus",
+			input: "Released under the LGPL-2.1+.",
+			want: Matches{
+				{Name: "LGPL-2.1", Variant: "Released under the LGPL-2.1", MatchType: "Reference", Confidence: 0.75, StartLine: 1, EndLine: 1, OrLater: true},
+			},
+		},
+		{
+			name:  "lgpl isn't gpl",
+			input: "Released under the LGPL-2.1.",
+			want: Matches{
+				{Name: "LGPL-2.1", Variant: "Released under the LGPL-2.1", MatchType: "Reference", Confidence: 0.75, StartLine: 1, EndLine: 1},
+			},
+		},
+		{
+			name: "bsd style",
+			input: `// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.`,
+			want: Matches{
+				{Name: "BSD-3-Clause", Variant: "BSD-style license", MatchType: "Reference", Confidence: 0.5, StartLine: 1, EndLine: 2},
+			},
+		},
+		{
+			name:  "already referenced by url",
+			input: "Licensed under the Apache License, Version 2.0: http://www.apache.org/licenses/LICENSE-2.0",
+			refs: Matches{
+				{Name: "Apache-2.0", MatchType: "Reference", StartLine: 1, EndLine: 1},
+			},
+			want: nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := detectNameReferences([]byte(tt.input), tt.refs)
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("detectNameReferences() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,125 @@
+// Copyright 2022 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build !core
+// +build !core
+
+package classifier
+
+import (
+	"bytes"
+	"context"
+)
+
+// ScoreRegion scores lines startLine to endLine of the content, counting from
+// 1, against each license of the corpus, or only the named licenses if any
+// are given, and returns the confidence of each license whose text the region
+// holds any of, by license name. The confidence of a license is the highest of
+// its variants, headers included, and is truncated as those of matches are.
+// Unlike Match, the region is compared as a whole against every license that
+// shares words with it, whatever its confidence, so that editor integrations
+// can tell what a selected block of text most resembles even when it matches
+// nothing. Comparing a long region against the whole corpus takes seconds, so
+// scoring stops with the context's error once it's done. It returns nil if the
+// region holds no lines of the content. The region is cut to the limit set
+// with WithMaxTokens, and licenses whose diff with it would exceed the limit
+// set with WithMaxDiffSize score 0. This will not modify the contents of the
+// supplied byte slice.
+func (c *Classifier) ScoreRegion(ctx context.Context, in []byte, startLine, endLine int, names ...string) (map[string]float64, error) {
+	if startLine < 1 {
+		startLine = 1
+	}
+	if endLine < startLine || bytes.Count(in, []byte("\n"))+1 < startLine {
+		return nil, nil
+	}
+	if err := c.loadLazy(); err != nil {
+		return nil, err
+	}
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	include := make(map[string]bool)
+	for _, n := range names {
+		include[n] = true
+	}
+	var docs []string
+	for _, l := range c.order(c.docs) {
+		name := LicenseName(l)
+		if (len(include) == 0 || include[name]) && (c.licenses == nil || c.licenses[name]) {
+			docs = append(docs, l)
+		}
+	}
+
+	region := []byte(lineText(in, startLine, endLine))
+	targets := make(map[NumberPolicy]*indexedDocument)
+	for _, l := range docs {
+		numbers := c.docs[l].numbers
+		if _, ok := targets[numbers]; !ok {
+			t, _ := tokenizeStream(bytes.NewReader(region), true, c.dict, false, numbers)
+			t.text = region
+			c.limitTokens(t)
+			targets[numbers] = t
+		}
+	}
+
+	scores := make([]float64, len(docs))
+	c.parallel(c.matchWorkers(), len(docs), func(i int) {
+		if ctx.Err() != nil {
+			return
+		}
+		scores[i] = c.scoreRegion(docs[i], targets[c.docs[docs[i]].numbers])
+	})
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	out := make(map[string]float64)
+	for i, l := range docs {
+		conf := scores[i]
+		if !c.rawConfidence {
+			conf = quantizeConfidence(conf)
+		}
+		if name := LicenseName(l); conf > out[name] {
+			out[name] = conf
+		}
+	}
+	return out, nil
+}
+
+// scoreRegion returns the confidence of the corpus document l in the whole of
+// the region t. A panic while scoring the document scores it 0, as if the
+// region held none of it.
+func (c *Classifier) scoreRegion(l string, t *indexedDocument) (conf float64) {
+	defer func() {
+		if r := recover(); r != nil {
+			conf = 0
+		}
+	}()
+	// A license sharing no words with the region can't score, so it isn't
+	// diffed.
+	if t.size() == 0 || t.tokenSimilarity(c.docs[l]) == 0 {
+		return 0
+	}
+	d := c.expand(l, c.docs[l])
+	if c.exceedsDiffSize(t.size(), d.size()) {
+		return 0
+	}
+	conf, startOffset, endOffset, _ := c.score(l, t, d, 0, t.size())
+	if matched := t.size() - startOffset - endOffset; matched > 0 {
+		conf = c.scoreCase(l, t, d, conf, startOffset, t.size()-endOffset-1)
+	}
+	if conf < 0 {
+		return 0
+	}
+	return conf
+}
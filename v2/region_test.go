@@ -0,0 +1,98 @@
+// Copyright 2022 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build !core
+// +build !core
+
+package classifier
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io/ioutil"
+	"path"
+	"testing"
+)
+
+func TestScoreRegion(t *testing.T) {
+	c, err := classifier()
+	if err != nil {
+		t.Fatalf("couldn't instantiate standard test classifier: %v", err)
+	}
+	mit, err := ioutil.ReadFile(path.Join(baseLicenses, "License", "MIT", "pristine.txt"))
+	if err != nil {
+		t.Fatalf("couldn't read MIT license: %v", err)
+	}
+	mit = bytes.TrimSpace(mit)
+	lines := bytes.Count(mit, []byte("\n")) + 1
+	in := append([]byte("package main\n\nfunc main() {}\n\n"), mit...)
+	in = append(in, "\n\nvar x = 1\n"...)
+
+	score := func(start, end int, names ...string) map[string]float64 {
+		t.Helper()
+		scores, err := c.ScoreRegion(context.Background(), in, start, end, names...)
+		if err != nil {
+			t.Fatalf("ScoreRegion(%d, %d) returned error: %v", start, end, err)
+		}
+		return scores
+	}
+
+	scores := score(5, 4+lines)
+	best := ""
+	for name, conf := range scores {
+		if best == "" || conf > scores[best] {
+			best = name
+		}
+	}
+	if best != "MIT" || scores["MIT"] < 0.99 {
+		t.Errorf("ScoreRegion() = %s with %v, want MIT with 1", best, scores[best])
+	}
+
+	// A region holding only part of the license scores it lower, where Match
+	// would report nothing.
+	if part := score(5, 6); part["MIT"] <= 0 || part["MIT"] >= scores["MIT"] {
+		t.Errorf("ScoreRegion() of part of MIT = %v, want between 0 and %v", part["MIT"], scores["MIT"])
+	}
+
+	filtered := score(5, 4+lines, "Apache-2.0", "MIT")
+	for name := range filtered {
+		if name != "Apache-2.0" && name != "MIT" {
+			t.Errorf("ScoreRegion() filtered to Apache-2.0 and MIT scored %s", name)
+		}
+	}
+	if filtered["MIT"] != scores["MIT"] {
+		t.Errorf("ScoreRegion() filtered to MIT = %v, want %v", filtered["MIT"], scores["MIT"])
+	}
+
+	for _, r := range [][2]int{{10, 9}, {100, 120}} {
+		if got := score(r[0], r[1]); got != nil {
+			t.Errorf("ScoreRegion(%d, %d) = %v, want nil", r[0], r[1], got)
+		}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if got, err := c.ScoreRegion(ctx, in, 5, 4+lines); !errors.Is(err, context.Canceled) || got != nil {
+		t.Errorf("ScoreRegion() with canceled context = %v, %v, want nil, %v", got, err, context.Canceled)
+	}
+}
+
+func TestScoreRegionMaxDiffSize(t *testing.T) {
+	c, mit, _ := limitsClassifier(t, WithMaxDiffSize(50))
+	lines := bytes.Count(mit, []byte("\n")) + 1
+	if scores, err := c.ScoreRegion(context.Background(), mit, 1, lines); err != nil || scores["MIT"] != 0 {
+		t.Errorf("ScoreRegion() of MIT = %v, %v, want 0", scores["MIT"], err)
+	}
+}
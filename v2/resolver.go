@@ -0,0 +1,57 @@
+// Copyright 2022 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package classifier
+
+// NameResolver maps the names of detected licenses to the names an
+// organization uses for them, such as the keys of its license policy.
+type NameResolver interface {
+	// Resolve returns the name and variant to report for a match of the
+	// given type of the named license variant. Returning the variant
+	// unchanged keeps it; returning an empty variant collapses the variants
+	// of the license.
+	Resolve(matchType, name, variant string) (string, string)
+}
+
+// NameResolverFunc adapts a function to a NameResolver.
+type NameResolverFunc func(matchType, name, variant string) (string, string)
+
+// Resolve calls f.
+func (f NameResolverFunc) Resolve(matchType, name, variant string) (string, string) {
+	return f(matchType, name, variant)
+}
+
+// WithNameResolver reports the names of all matches, and of the licenses in
+// their SimilarTo and AppliesTo, as resolved by r.
+func WithNameResolver(r NameResolver) OptionFunc {
+	return func(c *Classifier) {
+		c.resolver = r
+	}
+}
+
+// resolveNames applies the name resolver of the classifier to the matches.
+func (c *Classifier) resolveNames(matches Matches) {
+	if c.resolver == nil {
+		return
+	}
+	for _, m := range matches {
+		m.Name, m.Variant = c.resolver.Resolve(m.MatchType, m.Name, m.Variant)
+		for i, s := range m.SimilarTo {
+			m.SimilarTo[i], _ = c.resolver.Resolve("License", s, "")
+		}
+		if m.AppliesTo != "" {
+			m.AppliesTo, _ = c.resolver.Resolve("License", m.AppliesTo, "")
+		}
+	}
+}
@@ -0,0 +1,61 @@
+// Copyright 2022 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package classifier
+
+import (
+	"io/ioutil"
+	"path"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestNameResolver(t *testing.T) {
+	mit, err := ioutil.ReadFile(path.Join(baseLicenses, "License", "MIT", "pristine.txt"))
+	if err != nil {
+		t.Fatalf("couldn't read MIT license: %v", err)
+	}
+	policy := map[string]string{"MIT": "notice", "Apache-2.0": "notice"}
+	c := NewClassifier(defaultThreshold, WithNameResolver(NameResolverFunc(func(matchType, name, variant string) (string, string) {
+		if p, ok := policy[name]; ok {
+			return p + ":" + name, ""
+		}
+		return name, variant
+	})))
+	c.AddContent("License", "MIT", "pristine.txt", mit)
+	c.SetConfusability(Confusability{"MIT": {{Name: "Apache-2.0", Similarity: 0.9}}})
+
+	got := c.Match(mit).Matches
+	if len(got) != 1 {
+		t.Fatalf("Match() = %v, want a single match", got)
+	}
+	want := &Match{
+		Name:            "notice:MIT",
+		MatchType:       "License",
+		Confidence:      1.0,
+		StartLine:       got[0].StartLine,
+		EndLine:         got[0].EndLine,
+		StartTokenIndex: got[0].StartTokenIndex,
+		EndTokenIndex:   got[0].EndTokenIndex,
+		SimilarTo:       []string{"notice:Apache-2.0"},
+		// The content is the license verbatim.
+		KnownEndTokenIndex: got[0].EndTokenIndex,
+		KnownTokens:        got[0].EndTokenIndex + 1,
+		Stats:              MatchStats{KnownTokensMatched: got[0].EndTokenIndex + 1, ContentTokens: got[0].EndTokenIndex + 1},
+	}
+	if diff := cmp.Diff(want, got[0]); diff != "" {
+		t.Errorf("Match() mismatch (-want +got):\n%s", diff)
+	}
+}
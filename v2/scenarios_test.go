@@ -0,0 +1,96 @@
+// Copyright 2022 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// The scenarios are run by the classifiertest package, which imports the
+// classifier, so they're tested from outside of it.
+package classifier_test
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	classifier "github.com/google/licenseclassifier/v2"
+	"github.com/google/licenseclassifier/v2/classifiertest"
+)
+
+// readScenarios reads the scenarios of dir, leaving out those of the
+// subdirectories that need options of the classifier enabled.
+func readScenarios(t *testing.T, dir string) []*classifiertest.Scenario {
+	t.Helper()
+	all, err := classifiertest.ReadScenarios(os.DirFS(dir))
+	if err != nil {
+		t.Fatalf("ReadScenarios() = %v", err)
+	}
+	var scenarios []*classifiertest.Scenario
+	for _, s := range all {
+		if !strings.HasPrefix(s.Name, "annotations/") {
+			scenarios = append(scenarios, s)
+		}
+	}
+	if len(scenarios) == 0 {
+		t.Fatalf("found no scenarios in %s", dir)
+	}
+	return scenarios
+}
+
+func newClassifier(t *testing.T, options ...classifier.OptionFunc) *classifier.Classifier {
+	t.Helper()
+	c := classifier.NewClassifier(.8, options...)
+	if err := c.LoadLicenses("assets"); err != nil {
+		t.Fatalf("couldn't instantiate test classifier: %v", err)
+	}
+	return c
+}
+
+func TestMatchScenarios(t *testing.T) {
+	shard, shards := classifiertest.ShardFromEnv()
+	r := &classifiertest.Runner{Classifier: newClassifier(t), Shard: shard, Shards: shards}
+	r.Test(t, readScenarios(t, "scenarios"))
+}
+
+// TestAnnotationScenarios runs the scenarios of annotations, which are only
+// reported when enabled with an option.
+func TestAnnotationScenarios(t *testing.T) {
+	c := newClassifier(t, classifier.WithPatentGrants(), classifier.WithTrademarks())
+	r := &classifiertest.Runner{Classifier: c}
+	r.Test(t, readScenarios(t, filepath.Join("scenarios", "annotations")))
+}
+
+func TestMatchFromScenarios(t *testing.T) {
+	c := newClassifier(t)
+	for _, s := range readScenarios(t, "scenarios") {
+		res, err := c.MatchFrom(bytes.NewReader(s.Data))
+		if err != nil {
+			t.Errorf("MatchFrom(%q) returned error: %v", s.Name, err)
+			continue
+		}
+		found := make(map[string]bool)
+		for _, m := range res.Matches {
+			found[m.Name] = true
+		}
+		names := []string{}
+		for name := range found {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		if diff := cmp.Diff(s.Expected, names); diff != "" {
+			t.Errorf("MatchFrom(%q) mismatch (-want +got):\n%s", s.Name, diff)
+		}
+	}
+}
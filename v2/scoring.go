@@ -18,7 +18,7 @@ import (
 	"strings"
 	"unicode"
 
-	"github.com/davecgh/go-spew/spew"
+	"github.com/google/licenseclassifier/v2/textcompare"
 	"github.com/sergi/go-diff/diffmatchpatch"
 )
 
@@ -32,8 +32,9 @@ const (
 
 // score computes a metric of similarity between the known and unknown
 // document, including the offsets into the unknown that yield the content
-// generating the computed similarity.
-func (c *Classifier) score(id string, unknown, known *indexedDocument, unknownStart, unknownEnd int) (float64, int, int) {
+// generating the computed similarity, and how much of the known document the
+// content holds.
+func (c *Classifier) score(id string, unknown, known *indexedDocument, unknownStart, unknownEnd int) (float64, int, int, knownCoverage) {
 	if c.tc.traceScoring(known.s.origin) {
 		c.tc.trace("Scoring %s: [%d-%d]", known.s.origin, unknownStart, unknownEnd)
 	}
@@ -41,11 +42,11 @@ func (c *Classifier) score(id string, unknown, known *indexedDocument, unknownSt
 	knownLength := known.size()
 	diffs := docDiff(id, unknown, unknownStart, unknownEnd, known, 0, knownLength)
 
-	start, end := diffRange(known.Norm, diffs)
+	start, end := diffRange(known.normalizedLength(), diffs)
 	distance := scoreDiffs(id, diffs[start:end])
 
 	if c.tc.traceScoring(known.s.origin) {
-		c.tc.trace("Diffs against %s:\n%s", known.s.origin, spew.Sdump(diffs[start:end]))
+		c.tc.trace("Diffs against %s:\n%s", known.s.origin, dump(diffs[start:end]))
 	}
 
 	if distance < 0 {
@@ -53,7 +54,7 @@ func (c *Classifier) score(id string, unknown, known *indexedDocument, unknownSt
 		if c.tc.traceScoring(known.s.origin) {
 			c.tc.trace("Distance result %v, rejected match", distance)
 		}
-		return 0.0, 0, 0
+		return 0.0, 0, 0, knownCoverage{}
 	}
 
 	// Applying the diffRange-generated offsets provides the run of text from the
@@ -69,47 +70,79 @@ func (c *Classifier) score(id string, unknown, known *indexedDocument, unknownSt
 	// confidence score and better position detection of the source in the
 	// target.
 	conf, so, eo := confidencePercentage(knownLength, distance), textLength(diffs[:start]), textLength(diffs[end:])
+	cov := coverage(knownLength, diffs[start:end])
 
 	if c.tc.traceScoring(known.s.origin) {
-		c.tc.trace("Score result: %v [%d-%d]", conf, so, eo)
+		c.tc.trace("Score result: %v [%d-%d], coverage %.2f, missing tail %.2f", conf, so, eo, cov.held, cov.tail)
 	}
-	return conf, so, eo
+	return conf, so, eo, cov
 }
 
-// confidencePercentage computes a confidence match score for the lengths,
-// handling the cases where source and target lengths differ.
-func confidencePercentage(klen, distance int) float64 {
-	// No text is matched at 100% confidence (avoid divide by zero).
-	if klen == 0 {
-		return 1.0
-	}
+// truncatedTail is the fraction of the tokens of a license that must be
+// missing from its end for a match of it to be suspected of being truncated.
+const truncatedTail = 0.05
 
-	// Return a computed fractional match against the known text.
-	return 1.0 - float64(distance)/float64(klen)
+// knownCoverage describes how much of a known document content holds.
+type knownCoverage struct {
+	held float64 // The fraction of its tokens held unchanged
+	tail float64 // The fraction of its tokens missing from its end
+	// start and end are the indexes of the first and last of its tokens
+	// held, or -1 if none are.
+	start, end int
+	stats      MatchStats
 }
 
-// diffLevenshteinWord computes word-based Levenshtein count.
-func diffLevenshteinWord(diffs []diffmatchpatch.Diff) int {
-	levenshtein := 0
-	insertions := 0
-	deletions := 0
-
-	for _, aDiff := range diffs {
-		switch aDiff.Type {
+// coverage returns how much of a known document of the given length the diffs
+// against it hold. The diffs hold all of the known document, as their equal
+// and inserted text, in order. The tokens missing from its end are those of the
+// trailing insertions, which are the text of the known document that the
+// content lacks. The deletions are the text of the content that the known
+// document lacks.
+func coverage(knownLength int, diffs []diffmatchpatch.Diff) knownCoverage {
+	cov := knownCoverage{start: -1, end: -1}
+	held, tail, pos := 0, 0, 0
+	for _, d := range diffs {
+		n := textcompare.WordCount(d.Text)
+		switch d.Type {
+		case diffmatchpatch.DiffEqual:
+			if cov.start == -1 {
+				cov.start = pos
+			}
+			cov.end = pos + n - 1
+			held += n
+			tail = 0
+			pos += n
+			cov.stats.ContentTokens += n
 		case diffmatchpatch.DiffInsert:
-			insertions += wordLen(aDiff.Text)
+			tail += n
+			pos += n
+			cov.stats.Deletions++
+			cov.stats.DeletedTokens += n
 		case diffmatchpatch.DiffDelete:
-			deletions += wordLen(aDiff.Text)
-		case diffmatchpatch.DiffEqual:
-			// A deletion and an insertion is one substitution.
-			levenshtein += max(insertions, deletions)
-			insertions = 0
-			deletions = 0
+			cov.stats.ContentTokens += n
+			cov.stats.Insertions++
+			cov.stats.InsertedTokens += n
 		}
 	}
+	cov.stats.KnownTokensMatched = held
+	if knownLength == 0 {
+		cov.held = 1.0
+		return cov
+	}
+	cov.held = float64(held) / float64(knownLength)
+	cov.tail = float64(tail) / float64(knownLength)
+	return cov
+}
+
+// confidencePercentage computes a confidence match score for the lengths,
+// handling the cases where source and target lengths differ.
+func confidencePercentage(klen, distance int) float64 {
+	return textcompare.Confidence(klen, distance)
+}
 
-	levenshtein += max(insertions, deletions)
-	return levenshtein
+// diffLevenshteinWord computes word-based Levenshtein count.
+func diffLevenshteinWord(diffs []diffmatchpatch.Diff) int {
+	return textcompare.WordLevenshtein(diffs)
 }
 
 func isVersionNumber(in string) bool {
@@ -224,5 +257,62 @@ func scoreDiffs(id string, diffs []diffmatchpatch.Diff) int {
 			prevDelete = text
 		}
 	}
-	return diffLevenshteinWord(diffs)
+	return diffLevenshteinWord(discountAddress(diffs))
+}
+
+// fsfAddressWords are the words of the postal addresses the Free Software
+// Foundation has had over the years, which GNU license headers quote with
+// many variations in punctuation, abbreviation and line breaks.
+var fsfAddressWords = map[string]bool{
+	"51": true, "franklin": true, "street": true, "st": true, "fifth": true, "floor": true,
+	"59": true, "temple": true, "place": true, "suite": true, "330": true,
+	"675": true, "mass": true, "ave": true, "avenue": true, "cambridge": true,
+	"boston": true, "ma": true, "massachusetts": true, "usa": true,
+	"02110-1301": true, "02111-1307": true, "02139": true,
+}
+
+// maxAddressWords is the most words of a difference in the postal address of
+// the Free Software Foundation that go uncounted.
+const maxAddressWords = 16
+
+// isAddressText reports whether the text consists only of the words of postal
+// addresses of the Free Software Foundation.
+func isAddressText(text string) bool {
+	for _, w := range strings.Fields(text) {
+		if !fsfAddressWords[w] {
+			return false
+		}
+	}
+	return true
+}
+
+// discountAddress returns the diffs without the insertions and deletions that
+// only change the postal address following "free software foundation", so that
+// headers quoting any of its addresses, or none, match equally well. Older
+// headers give addresses the Foundation has since moved from, and many
+// projects drop or reformat the address.
+func discountAddress(diffs []diffmatchpatch.Diff) []diffmatchpatch.Diff {
+	var out []diffmatchpatch.Diff
+	inAddress := false
+	words := 0
+	for _, diff := range diffs {
+		if diff.Type == diffmatchpatch.DiffEqual {
+			if i := strings.LastIndex(diff.Text, "free software foundation"); i != -1 {
+				rest := strings.TrimPrefix(strings.TrimSpace(diff.Text[i+len("free software foundation"):]), "inc")
+				inAddress = isAddressText(rest)
+				words = 0
+			} else {
+				inAddress = inAddress && isAddressText(diff.Text)
+			}
+			out = append(out, diff)
+			continue
+		}
+		if n := textcompare.WordCount(diff.Text); inAddress && isAddressText(diff.Text) && words+n <= maxAddressWords {
+			words += n
+			continue
+		}
+		inAddress = false
+		out = append(out, diff)
+	}
+	return out
 }
@@ -15,10 +15,14 @@
 package classifier
 
 import (
+	"bytes"
 	"fmt"
+	"io/ioutil"
+	"path"
 	"strings"
 	"testing"
 
+	"github.com/google/go-cmp/cmp"
 	"github.com/sergi/go-diff/diffmatchpatch"
 )
 
@@ -184,6 +188,90 @@ func TestScoreDiffs(t *testing.T) {
 			},
 			expected: introducedPhraseChange,
 		},
+		{
+			name:    "different FSF address",
+			license: "Header/GPL-2.0/header.txt",
+			diffs: []diffmatchpatch.Diff{
+				{
+					Type: diffmatchpatch.DiffEqual,
+					Text: "write to the free software foundation inc",
+				},
+				{
+					Type: diffmatchpatch.DiffDelete,
+					Text: "675 mass ave cambridge",
+				},
+				{
+					Type: diffmatchpatch.DiffInsert,
+					Text: "51 franklin street fifth floor boston",
+				},
+				{
+					Type: diffmatchpatch.DiffEqual,
+					Text: "ma",
+				},
+				{
+					Type: diffmatchpatch.DiffDelete,
+					Text: "02139",
+				},
+				{
+					Type: diffmatchpatch.DiffInsert,
+					Text: "02110-1301",
+				},
+				{
+					Type: diffmatchpatch.DiffEqual,
+					Text: "usa",
+				},
+			},
+			expected: 0,
+		},
+		{
+			name:    "missing FSF address",
+			license: "Header/GPL-2.0/header.txt",
+			diffs: []diffmatchpatch.Diff{
+				{
+					Type: diffmatchpatch.DiffEqual,
+					Text: "write to the free software foundation inc",
+				},
+				{
+					Type: diffmatchpatch.DiffInsert,
+					Text: "51 franklin street fifth floor boston ma 02110-1301 usa",
+				},
+			},
+			expected: 0,
+		},
+		{
+			name:    "unknown words in place of the FSF address",
+			license: "Header/GPL-2.0/header.txt",
+			diffs: []diffmatchpatch.Diff{
+				{
+					Type: diffmatchpatch.DiffEqual,
+					Text: "write to the free software foundation inc",
+				},
+				{
+					Type: diffmatchpatch.DiffDelete,
+					Text: "UNKNOWN UNKNOWN UNKNOWN",
+				},
+				{
+					Type: diffmatchpatch.DiffInsert,
+					Text: "51 franklin street fifth floor boston ma 02110-1301 usa",
+				},
+			},
+			expected: 9,
+		},
+		{
+			name:    "address words outside the FSF address",
+			license: "Header/GPL-2.0/header.txt",
+			diffs: []diffmatchpatch.Diff{
+				{
+					Type: diffmatchpatch.DiffEqual,
+					Text: "write to the author at",
+				},
+				{
+					Type: diffmatchpatch.DiffInsert,
+					Text: "51 franklin street",
+				},
+			},
+			expected: 3,
+		},
 	}
 
 	for _, test := range tests {
@@ -281,7 +369,7 @@ func TestScore(t *testing.T) {
 			ud := c.createTargetIndexedDocument([]byte(test.unknown))
 			// The name for the test needs to look like an asset path so we prepend
 			// the directory.
-			conf, so, eo := c.score("License/"+test.name, ud, kd, 0, ud.size())
+			conf, so, eo, _ := c.score("License/"+test.name, ud, kd, 0, ud.size())
 
 			success := true
 			if conf != test.expectedConf {
@@ -303,3 +391,178 @@ func TestScore(t *testing.T) {
 		})
 	}
 }
+
+func TestCoverage(t *testing.T) {
+	tests := []struct {
+		name  string
+		diffs []diffmatchpatch.Diff
+		want  knownCoverage
+	}{
+		{
+			name: "identical",
+			diffs: []diffmatchpatch.Diff{
+				{Type: diffmatchpatch.DiffEqual, Text: "one two three four"},
+			},
+			want: knownCoverage{held: 1.0, start: 0, end: 3, stats: MatchStats{KnownTokensMatched: 4, ContentTokens: 4}},
+		},
+		{
+			name: "missing middle",
+			diffs: []diffmatchpatch.Diff{
+				{Type: diffmatchpatch.DiffEqual, Text: "one"},
+				{Type: diffmatchpatch.DiffInsert, Text: "two"},
+				{Type: diffmatchpatch.DiffEqual, Text: "three four"},
+			},
+			want: knownCoverage{held: 0.75, start: 0, end: 3, stats: MatchStats{KnownTokensMatched: 3, ContentTokens: 3, Deletions: 1, DeletedTokens: 1}},
+		},
+		{
+			name: "missing tail",
+			diffs: []diffmatchpatch.Diff{
+				{Type: diffmatchpatch.DiffEqual, Text: "one two"},
+				{Type: diffmatchpatch.DiffInsert, Text: "three four"},
+			},
+			want: knownCoverage{held: 0.5, tail: 0.5, start: 0, end: 1, stats: MatchStats{KnownTokensMatched: 2, ContentTokens: 2, Deletions: 1, DeletedTokens: 2}},
+		},
+		{
+			name: "replaced tail",
+			diffs: []diffmatchpatch.Diff{
+				{Type: diffmatchpatch.DiffEqual, Text: "one two three"},
+				{Type: diffmatchpatch.DiffDelete, Text: "func main"},
+				{Type: diffmatchpatch.DiffInsert, Text: "four"},
+			},
+			want: knownCoverage{held: 0.75, tail: 0.25, start: 0, end: 2, stats: MatchStats{KnownTokensMatched: 3, ContentTokens: 5, Insertions: 1, InsertedTokens: 2, Deletions: 1, DeletedTokens: 1}},
+		},
+		{
+			name: "missing head",
+			diffs: []diffmatchpatch.Diff{
+				{Type: diffmatchpatch.DiffDelete, Text: "preamble"},
+				{Type: diffmatchpatch.DiffInsert, Text: "one two"},
+				{Type: diffmatchpatch.DiffEqual, Text: "three four"},
+			},
+			want: knownCoverage{held: 0.5, start: 2, end: 3, stats: MatchStats{KnownTokensMatched: 2, ContentTokens: 3, Insertions: 1, InsertedTokens: 1, Deletions: 1, DeletedTokens: 2}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := coverage(4, tt.diffs); got != tt.want {
+				t.Errorf("coverage() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTruncationSuspected(t *testing.T) {
+	c, err := classifier()
+	if err != nil {
+		t.Fatalf("couldn't instantiate standard test classifier: %v", err)
+	}
+	mit, err := ioutil.ReadFile(path.Join(baseLicenses, "License", "MIT", "pristine.txt"))
+	if err != nil {
+		t.Fatalf("couldn't read MIT license: %v", err)
+	}
+	// The end of the warranty disclaimer holds about 10% of the tokens of the
+	// license.
+	truncated := mit[:bytes.Index(mit, []byte("OUT OF OR IN CONNECTION"))]
+	edited := bytes.Replace(mit, []byte("free of charge, "), nil, 1)
+
+	tests := []struct {
+		name string
+		in   []byte
+		want bool
+	}{
+		{"full", mit, false},
+		{"truncated", truncated, true},
+		{"truncated with code", append(append([]byte{}, truncated...), "\n\npackage main\n"...), true},
+		{"edited", edited, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var found bool
+			for _, m := range c.Match(tt.in).Matches {
+				if m.Name != "MIT" || m.MatchType != "License" {
+					continue
+				}
+				found = true
+				if m.TruncationSuspected != tt.want {
+					t.Errorf("Match() TruncationSuspected = %v, want %v", m.TruncationSuspected, tt.want)
+				}
+			}
+			if !found {
+				t.Error("Match() didn't find the MIT license")
+			}
+		})
+	}
+}
+
+func TestKnownTokenRange(t *testing.T) {
+	c, err := classifier()
+	if err != nil {
+		t.Fatalf("couldn't instantiate standard test classifier: %v", err)
+	}
+	apache, err := ioutil.ReadFile(path.Join(baseLicenses, "License", "Apache-2.0", "pristine.txt"))
+	if err != nil {
+		t.Fatalf("couldn't read Apache-2.0 license: %v", err)
+	}
+	// The last section of the terms and the appendix that follows them are
+	// missing from all the variants of the license.
+	truncated := apache[:bytes.Index(apache, []byte("9. Accepting Warranty"))]
+
+	tests := []struct {
+		name    string
+		in      []byte
+		wantEnd bool
+	}{
+		{"full", apache, true},
+		{"truncated", truncated, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var m *Match
+			for _, cand := range c.Match(tt.in).Matches {
+				if cand.Name == "Apache-2.0" && cand.MatchType == "License" {
+					m = cand
+				}
+			}
+			if m == nil {
+				t.Fatal("Match() didn't find the Apache-2.0 license")
+			}
+			if m.KnownStartTokenIndex != 0 {
+				t.Errorf("Match() KnownStartTokenIndex = %d, want 0", m.KnownStartTokenIndex)
+			}
+			if got := m.KnownEndTokenIndex == m.KnownTokens-1; got != tt.wantEnd {
+				t.Errorf("Match() KnownEndTokenIndex = %d of %d tokens, want the last token %v", m.KnownEndTokenIndex, m.KnownTokens, tt.wantEnd)
+			}
+			if m.KnownTokens != c.getIndexedDocument("License", "Apache-2.0", m.Variant).size() {
+				t.Errorf("Match() KnownTokens = %d, want the tokens of the license", m.KnownTokens)
+			}
+		})
+	}
+}
+
+func TestMatchStats(t *testing.T) {
+	mit, err := ioutil.ReadFile(path.Join(baseLicenses, "License", "MIT", "pristine.txt"))
+	if err != nil {
+		t.Fatalf("couldn't read MIT license: %v", err)
+	}
+	// Two words of the license are dropped and three are added.
+	in := bytes.Replace(mit, []byte("free of charge, "), []byte("free "), 1)
+	in = bytes.Replace(in, []byte("to deal\nin the Software"), []byte("to deal\nin the said Software and more"), 1)
+
+	c := NewClassifier(defaultThreshold)
+	c.AddContent("License", "MIT", "pristine.txt", mit)
+	ms := c.Match(in).Matches
+	if len(ms) != 1 {
+		t.Fatalf("Match() = %v, want one match", ms)
+	}
+	m := ms[0]
+	want := MatchStats{
+		KnownTokensMatched: m.KnownTokens - 2,
+		ContentTokens:      m.EndTokenIndex - m.StartTokenIndex + 1,
+		Insertions:         2,
+		InsertedTokens:     3,
+		Deletions:          1,
+		DeletedTokens:      2,
+	}
+	if diff := cmp.Diff(want, m.Stats); diff != "" {
+		t.Errorf("Match() stats mismatch (-want +got):\n%s", diff)
+	}
+}
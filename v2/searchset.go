@@ -19,8 +19,6 @@ import (
 	"hash/crc32"
 	"math"
 	"sort"
-
-	"github.com/davecgh/go-spew/spew"
 )
 
 // searchSet is a set of q-grams that have hashes associated with them,
@@ -74,6 +72,26 @@ func newSearchSet(s *indexedDocument, q int) *searchSet {
 	return sset
 }
 
+// restoreSearchSet rebuilds the searchset of a document from the checksums of
+// its q-grams, as computed by newSearchSet.
+func restoreSearchSet(s *indexedDocument, q int, checksums []uint32) *searchSet {
+	h := make(hash)
+	tokenRanges := make(tokenRanges, len(checksums))
+	for offset, cs := range checksums {
+		tokenRanges[offset] = &tokenRange{offset, offset + q}
+		h.add(cs, offset, offset+q)
+	}
+	sset := &searchSet{
+		Tokens:         s.Tokens,
+		Hashes:         h,
+		Checksums:      checksums,
+		ChecksumRanges: tokenRanges,
+		q:              q,
+	}
+	sset.generateNodeList()
+	return sset
+}
+
 // tokenRange indicates the range of tokens that map to a particular checksum.
 type tokenRange struct {
 	Start int
@@ -170,9 +188,16 @@ func (m matchRanges) Less(i, j int) bool {
 // findPotentialMatches returns the ranges in the target (unknown) text that
 // are best potential matches to the source (known) text.
 func (c *Classifier) findPotentialMatches(src, target *searchSet, confidence float64) matchRanges {
-	matchedRanges := c.getMatchedRanges(src, target, confidence, src.q)
+	return c.potentialMatches(src, targetMatchedRanges(src, target), len(target.Tokens), confidence)
+}
+
+// potentialMatches is findPotentialMatches for a target of targetLength tokens
+// holding the matched q-grams of the source, as returned by
+// targetMatchedRanges.
+func (c *Classifier) potentialMatches(src *searchSet, matched matchRanges, targetLength int, confidence float64) matchRanges {
+	matchedRanges := c.getMatchedRanges(src, matched, targetLength, confidence, src.q)
 	if c.tc.traceSearchset(src.origin) {
-		c.tc.trace("matchedRanges = %s", spew.Sdump(matchedRanges))
+		c.tc.trace("matchedRanges = %s", dump(matchedRanges))
 	}
 	if len(matchedRanges) == 0 {
 		return nil
@@ -191,7 +216,7 @@ func (c *Classifier) findPotentialMatches(src, target *searchSet, confidence flo
 	}
 
 	if c.tc.traceSearchset(src.origin) {
-		c.tc.trace("finalized matchedRanges for %s: %d = %s", src.origin, len(src.Tokens), spew.Sdump(matchedRanges))
+		c.tc.trace("finalized matchedRanges for %s: %d = %s", src.origin, len(src.Tokens), dump(matchedRanges))
 	}
 	return matchedRanges
 }
@@ -309,25 +334,23 @@ func (c *Classifier) fuseRanges(origin string, matched matchRanges, confidence f
 	if c.tc.traceSearchset(origin) {
 		c.tc.trace("filterPasses = %+v", filterPasses)
 		c.tc.trace("filterDrops = %+v", filterDrops)
-		c.tc.trace("claimed = %s", spew.Sdump(claimed))
+		c.tc.trace("claimed = %s", dump(claimed))
 	}
 	return claimed
 }
 
-// getMatchedRanges finds the ranges in the target text that match the source
-// text. The ranges returned are ordered from the entries with the most matched
-// tokens to the least.
-func (c *Classifier) getMatchedRanges(src, target *searchSet, confidence float64, q int) matchRanges {
+// getMatchedRanges finds the ranges in the target text, of targetLength
+// tokens, that match the source text, given all the matched q-grams of the
+// source in the target. The ranges returned are ordered from the entries with
+// the most matched tokens to the least.
+func (c *Classifier) getMatchedRanges(src *searchSet, matched matchRanges, targetLength int, confidence float64, q int) matchRanges {
 	shouldTrace := c.tc.traceSearchset(src.origin)
 
 	if shouldTrace {
 		c.tc.trace("src.origin = %+v", src.origin)
 	}
-	// Assemble a list of all the matched q-grams without any consideration to
-	// error tolerances.
-	matched := targetMatchedRanges(src, target)
 	if shouldTrace {
-		c.tc.trace("matched = %s", spew.Sdump(matched))
+		c.tc.trace("matched = %s", dump(matched))
 	}
 	if len(matched) == 0 {
 		return nil
@@ -346,10 +369,10 @@ func (c *Classifier) getMatchedRanges(src, target *searchSet, confidence float64
 	// significantly since processing token matches is an N^2 (or worse)
 	// operation, so reducing N is a big win.
 
-	runs := c.detectRuns(src.origin, matched, len(target.Tokens), len(src.Tokens), confidence, q)
+	runs := c.detectRuns(src.origin, matched, targetLength, len(src.Tokens), confidence, q)
 
 	if shouldTrace {
-		c.tc.trace("runs = %d: %s", len(runs), spew.Sdump(runs))
+		c.tc.trace("runs = %d: %s", len(runs), dump(runs))
 	}
 
 	// If there are no target runs of source tokens, we're done.
@@ -361,9 +384,9 @@ func (c *Classifier) getMatchedRanges(src, target *searchSet, confidence float64
 	// match ranges into larger matches (with possible errors) to see if we can
 	// produce large enough runs that pass the confidence threshold.
 
-	fr := c.fuseRanges(src.origin, matched, confidence, len(src.Tokens), runs, len(target.Tokens))
+	fr := c.fuseRanges(src.origin, matched, confidence, len(src.Tokens), runs, targetLength)
 	if shouldTrace {
-		c.tc.trace("fr = %s", spew.Sdump(fr))
+		c.tc.trace("fr = %s", dump(fr))
 	}
 	return fr
 }
@@ -447,40 +470,56 @@ func (c *Classifier) detectRuns(origin string, matched matchRanges, targetLength
 	return final
 }
 
+// targetMatchedRanges returns the runs of q-grams of the source found in the
+// target, ordered from the longest run to the shortest.
 func targetMatchedRanges(src, target *searchSet) matchRanges {
-	offsetMappings := make(map[int][]*matchRange)
-
-	var matched matchRanges
+	var hits []qgramHit
 	for _, tgtNode := range target.nodes {
-		sr, ok := src.Hashes[tgtNode.checksum]
-		if !ok {
-			continue
+		for _, sv := range src.Hashes[tgtNode.checksum] {
+			hits = append(hits, qgramHit{tgtNode.tokens.Start, sv.Start})
 		}
+	}
+	return hitRanges(hits, src.q, target.q)
+}
 
-		tv := tgtNode.tokens
-		for _, sv := range sr {
-			offset := tv.Start - sv.Start
-			if om, ok := offsetMappings[offset]; ok {
-				// See if this extends the most recent existing mapping
-				lastIdx := len(om) - 1
-				if om[lastIdx].TargetEnd == tv.End-1 {
-					// This new value extends. Update the value in place
-					om[lastIdx].SrcEnd = sv.End
-					om[lastIdx].TargetEnd = tv.End
-					continue
-				}
+// hitRanges is targetMatchedRanges for the q-grams of the source, of length
+// srcQ, found in the target, of length targetQ, in the order of the target.
+func hitRanges(hits []qgramHit, srcQ, targetQ int) matchRanges {
+	offsetMappings := make(map[int][]*matchRange)
+
+	var matched matchRanges
+	for _, h := range hits {
+		tv := tokenRange{h.target, h.target + targetQ}
+		sv := tokenRange{h.src, h.src + srcQ}
+		offset := tv.Start - sv.Start
+		if om, ok := offsetMappings[offset]; ok {
+			// See if this extends the most recent existing mapping
+			lastIdx := len(om) - 1
+			if om[lastIdx].TargetEnd == tv.End-1 {
+				// This new value extends. Update the value in place
+				om[lastIdx].SrcEnd = sv.End
+				om[lastIdx].TargetEnd = tv.End
+				continue
 			}
-			offsetMappings[offset] = append(offsetMappings[offset], &matchRange{
-				SrcStart:    sv.Start,
-				SrcEnd:      sv.End,
-				TargetStart: tv.Start,
-				TargetEnd:   tv.End,
-			})
 		}
+		offsetMappings[offset] = append(offsetMappings[offset], &matchRange{
+			SrcStart:    sv.Start,
+			SrcEnd:      sv.End,
+			TargetStart: tv.Start,
+			TargetEnd:   tv.End,
+		})
 	}
 
-	// Compute the number of tokens claimed in each run and flatten into a single slice.
-	for _, mr := range offsetMappings {
+	// Compute the number of tokens claimed in each run and flatten into a
+	// single slice. The offsets are visited in order so that runs that sort
+	// equal keep the same order from one match to the next.
+	offsets := make([]int, 0, len(offsetMappings))
+	for offset := range offsetMappings {
+		offsets = append(offsets, offset)
+	}
+	sort.Ints(offsets)
+	for _, offset := range offsets {
+		mr := offsetMappings[offset]
 		for _, m := range mr {
 			m.TokensClaimed = m.TargetEnd - m.TargetStart
 		}
@@ -0,0 +1,99 @@
+// Copyright 2022 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build !core
+// +build !core
+
+package classifier
+
+import (
+	"strings"
+	"unicode"
+)
+
+// licenseWords are words that are common in license texts and uncommon
+// elsewhere.
+var licenseWords = map[string]bool{
+	"agreement":       true,
+	"author":          true,
+	"authors":         true,
+	"conditions":      true,
+	"copies":          true,
+	"copyright":       true,
+	"damages":         true,
+	"derivative":      true,
+	"distribute":      true,
+	"distributed":     true,
+	"distribution":    true,
+	"domain":          true,
+	"express":         true,
+	"granted":         true,
+	"holders":         true,
+	"implied":         true,
+	"liability":       true,
+	"liable":          true,
+	"license":         true,
+	"licensed":        true,
+	"licensee":        true,
+	"licensor":        true,
+	"merchantability": true,
+	"modification":    true,
+	"modifications":   true,
+	"modify":          true,
+	"notice":          true,
+	"permission":      true,
+	"permissions":     true,
+	"permitted":       true,
+	"provided":        true,
+	"redistribute":    true,
+	"redistribution":  true,
+	"rights":          true,
+	"software":        true,
+	"terms":           true,
+	"warranties":      true,
+	"warranty":        true,
+}
+
+const (
+	// minLicenseWords is the number of distinct license words a text must
+	// contain to look like a license.
+	minLicenseWords = 3
+	// minLicenseWordDensity is the fraction of the words of a text that must
+	// be license words for it to look like a license.
+	minLicenseWordDensity = 0.045
+)
+
+// LooksLikeLicense reports whether the text is likely to contain a license,
+// based on how much of it consists of words common to licenses. It is much
+// cheaper than matching and needs no corpus, so it is suitable for choosing
+// which files to classify, but it doesn't identify the license. The text must
+// be dominated by license language: a short source file with a license header
+// looks like a license, while a long one doesn't.
+func LooksLikeLicense(in []byte) bool {
+	words := strings.FieldsFunc(strings.ToLower(string(in)), func(r rune) bool {
+		return !unicode.IsLetter(r)
+	})
+	if len(words) == 0 {
+		return false
+	}
+	hits := 0
+	distinct := make(map[string]bool)
+	for _, w := range words {
+		if licenseWords[w] {
+			hits++
+			distinct[w] = true
+		}
+	}
+	return len(distinct) >= minLicenseWords && float64(hits)/float64(len(words)) >= minLicenseWordDensity
+}
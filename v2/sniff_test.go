@@ -0,0 +1,89 @@
+// Copyright 2022 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build !core
+// +build !core
+
+package classifier
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLooksLikeLicense(t *testing.T) {
+	tests := []struct {
+		file string
+		want bool
+	}{
+		{file: "assets/License/Apache-2.0/pristine.txt", want: true},
+		{file: "assets/License/BSD-3-Clause/pristine.txt", want: true},
+		{file: "assets/License/GPL-3.0/license.txt", want: true},
+		{file: "assets/License/ISC/pristine.txt", want: true},
+		{file: "assets/License/MIT/pristine.txt", want: true},
+		{file: "scenarios/negative/changelog_license_update", want: false},
+		{file: "scenarios/negative/lorem_ipsum", want: false},
+		{file: "scenarios/negative/readme_mentions_mit", want: false},
+		{file: "README.md", want: false},
+		// A long source file with a license header.
+		{file: "classifier.go", want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.file, func(t *testing.T) {
+			b, err := ioutil.ReadFile(filepath.FromSlash(tt.file))
+			if err != nil {
+				t.Fatalf("couldn't read %s: %v", tt.file, err)
+			}
+			if got := LooksLikeLicense(b); got != tt.want {
+				t.Errorf("LooksLikeLicense() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLooksLikeLicenseCorpus(t *testing.T) {
+	// Most of the corpus must look like a license; the exceptions are very
+	// short notices and texts that mostly describe something else.
+	var total, missed int
+	err := filepath.Walk(filepath.Join(baseLicenses, "License"), func(path string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() || !strings.HasSuffix(path, ".txt") {
+			return err
+		}
+		b, err := ioutil.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		total++
+		if !LooksLikeLicense(b) {
+			missed++
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("couldn't read corpus: %v", err)
+	}
+	if missed*20 > total {
+		t.Errorf("LooksLikeLicense() missed %d of %d licenses, want at most 5%%", missed, total)
+	}
+}
+
+func TestLooksLikeLicenseEmpty(t *testing.T) {
+	if LooksLikeLicense(nil) {
+		t.Error("LooksLikeLicense(nil) = true, want false")
+	}
+}
@@ -0,0 +1,204 @@
+// Copyright 2022 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package classifier
+
+import (
+	"sort"
+	"strings"
+)
+
+// spdxExpressions maps the names of licenses in the corpus that aren't SPDX
+// license identifiers to the SPDX expressions for them. The GNU licenses are
+// named by their version alone in the corpus, which SPDX deprecates in favor
+// of the -only and -or-later identifiers.
+var spdxExpressions = map[string]string{
+	"AGPL-1.0":                           "AGPL-1.0-only",
+	"AGPL-3.0":                           "AGPL-3.0-only",
+	"Apache-with-LLVM-Exception":         "Apache-2.0 WITH LLVM-exception",
+	"Apache-with-Runtime-Exception":      "Apache-2.0 WITH Swift-exception",
+	"BSD-0-Clause":                       "0BSD",
+	"BSD-3-Clause-OpenMPI":               "BSD-3-Clause-Open-MPI",
+	"Business-Source-License-1.1":        "BUSL-1.1",
+	"CERN-OHL-WR-v2":                     "CERN-OHL-W-2.0",
+	"CERN-OHL-v1.2":                      "CERN-OHL-1.2",
+	"GNU-All-permissive-Copying-License": "FSFAP",
+	"GPL-1.0":                            "GPL-1.0-only",
+	"GPL-2.0":                            "GPL-2.0-only",
+	"GPL-2.0-with-GCC-exception":         "GPL-2.0-only WITH GCC-exception-2.0",
+	"GPL-2.0-with-autoconf-exception":    "GPL-2.0-only WITH Autoconf-exception-2.0",
+	"GPL-2.0-with-bison-exception":       "GPL-2.0-only WITH Bison-exception-2.2",
+	"GPL-2.0-with-classpath-exception":   "GPL-2.0-only WITH Classpath-exception-2.0",
+	"GPL-2.0-with-font-exception":        "GPL-2.0-only WITH Font-exception-2.0",
+	"GPL-3.0":                            "GPL-3.0-only",
+	"GPL-3.0-with-autoconf-exception":    "GPL-3.0-only WITH Autoconf-exception-3.0",
+	"GPL-3.0-with-bison-exception":       "GPL-3.0-only WITH Bison-exception-2.2",
+	"LGPL-2.0":                           "LGPL-2.0-only",
+	"LGPL-2.1":                           "LGPL-2.1-only",
+	"LGPL-3.0":                           "LGPL-3.0-only",
+	"PublicDomain":                       "LicenseRef-PublicDomain",
+	"cURL":                               "curl",
+	"tcl_tk":                             "TCL",
+	"wxWindows-3.1":                      "LGPL-2.0-or-later WITH WxWindows-exception-3.1",
+}
+
+// spdxIdentifiers are the names of licenses in the corpus that are SPDX
+// license identifiers. Other names are reported as LicenseRef- identifiers,
+// since an expression naming them wouldn't be valid SPDX.
+var spdxIdentifiers = map[string]bool{
+	"AFL-1.1": true, "AFL-1.2": true, "AFL-2.0": true, "AFL-2.1": true, "AFL-3.0": true,
+	"AML": true, "AMPAS": true,
+	"APSL-1.0": true, "APSL-1.1": true, "APSL-1.2": true, "APSL-2.0": true,
+	"Apache-1.0": true, "Apache-1.1": true, "Apache-2.0": true,
+	"Artistic-1.0": true, "Artistic-1.0-Perl": true, "Artistic-1.0-cl8": true, "Artistic-2.0": true,
+	"BSD-2-Clause": true, "BSD-2-Clause-FreeBSD": true, "BSD-2-Clause-NetBSD": true, "BSD-2-Clause-Patent": true,
+	"BSD-3-Clause": true, "BSD-3-Clause-Attribution": true, "BSD-3-Clause-Clear": true, "BSD-3-Clause-LBNL": true,
+	"BSD-4-Clause": true, "BSD-4-Clause-UC": true, "BSD-Protection": true, "BSD-Source-Code": true,
+	"BSL-1.0": true, "Beerware": true, "BitTorrent-1.1": true, "CAL-1.0": true,
+	"CC-BY-1.0": true, "CC-BY-2.0": true, "CC-BY-2.5": true, "CC-BY-3.0": true, "CC-BY-4.0": true,
+	"CC-BY-NC-1.0": true, "CC-BY-NC-2.0": true, "CC-BY-NC-2.5": true, "CC-BY-NC-3.0": true, "CC-BY-NC-4.0": true,
+	"CC-BY-NC-ND-1.0": true, "CC-BY-NC-ND-2.0": true, "CC-BY-NC-ND-2.5": true, "CC-BY-NC-ND-3.0": true, "CC-BY-NC-ND-4.0": true,
+	"CC-BY-NC-SA-1.0": true, "CC-BY-NC-SA-2.0": true, "CC-BY-NC-SA-2.5": true, "CC-BY-NC-SA-3.0": true, "CC-BY-NC-SA-4.0": true,
+	"CC-BY-ND-1.0": true, "CC-BY-ND-2.0": true, "CC-BY-ND-2.5": true, "CC-BY-ND-3.0": true, "CC-BY-ND-4.0": true,
+	"CC-BY-SA-1.0": true, "CC-BY-SA-2.0": true, "CC-BY-SA-2.5": true, "CC-BY-SA-3.0": true, "CC-BY-SA-4.0": true,
+	"CC0-1.0": true, "CDDL-1.0": true, "CDDL-1.1": true, "CDLA-Permissive-1.0": true, "CECILL-2.1": true,
+	"CNRI-Python-GPL-Compatible": true, "CPAL-1.0": true, "CPL-1.0": true, "DRL-1.0": true,
+	"EPL-1.0": true, "EPL-2.0": true, "EUPL-1.0": true, "EUPL-1.1": true, "Elastic-2.0": true,
+	"FTL": true, "FreeImage": true, "HPND-sell-variant": true, "ICU": true, "IJG": true, "IPL-1.0": true,
+	"ISC": true, "ImageMagick": true, "Info-ZIP": true, "JSON": true, "LGPLLR": true,
+	"LPL-1.0": true, "LPL-1.02": true, "LPPL-1.3c": true, "Libpng": true, "Linux-OpenIB": true,
+	"MIT": true, "MIT-Modern-Variant": true, "MPL-1.0": true, "MPL-1.1": true, "MPL-2.0": true,
+	"MPL-2.0-no-copyleft-exception": true, "MS-PL": true, "MS-RL": true, "NAIST-2003": true, "NCSA": true,
+	"NGPL": true, "NPL-1.0": true, "NPL-1.1": true, "OFL-1.1": true,
+	"OSL-1.0": true, "OSL-1.1": true, "OSL-2.0": true, "OSL-2.1": true, "OSL-3.0": true,
+	"OpenSSL": true, "PHP-3.0": true, "PHP-3.01": true, "PostgreSQL": true, "Python-2.0": true,
+	"QPL-1.0": true, "Qhull": true, "Ruby": true, "SGI-B-1.0": true, "SGI-B-1.1": true, "SGI-B-2.0": true,
+	"SISSL": true, "SISSL-1.2": true, "SSPL-1.0": true, "Sleepycat": true, "Spencer-86": true, "SunPro": true,
+	"UPL-1.0": true, "Unicode-DFS-2015": true, "Unicode-DFS-2016": true, "Unicode-TOU": true, "Unlicense": true,
+	"Vim": true, "W3C": true, "W3C-19980720": true, "W3C-20150513": true, "WTFPL": true, "X11": true,
+	"Xnet": true, "ZPL-1.1": true, "ZPL-2.0": true, "ZPL-2.1": true, "Zend-2.0": true, "Zlib": true,
+	"blessing": true, "eGenix": true, "libtiff": true, "zlib-acknowledgement": true,
+}
+
+// spdxMatchTypes are the types of matches that name the license of content.
+var spdxMatchTypes = map[string]bool{
+	"License":             true,
+	"Header":              true,
+	referenceMatchType:    true,
+	publicDomainMatchType: true,
+}
+
+// SPDXExpression combines the licenses of the License, Header, Reference and
+// PublicDomain matches into an SPDX license expression, such as "Apache-2.0 AND
+// MIT", for tools that need a single expression for a file rather than a list
+// of matches. Licenses are joined with AND, in sorted order, each once. A
+// license with an exception, either named as such in the corpus or qualified
+// by an Exception match, is reported as such, for example "GPL-2.0-only WITH
+// Classpath-exception-2.0", and subsumes matches of the license without it.
+// Licenses offered as a choice, as given by the Alternatives of their
+// matches, are joined with OR, in the order the content names them, for
+// example "(MIT OR Apache-2.0) AND Zlib".
+// The GNU licenses are reported as their -or-later identifiers for matches
+// with OrLater set, and as their -only identifiers otherwise. Licenses of the
+// corpus without an SPDX identifier are reported as LicenseRef- identifiers,
+// public-domain dedications as LicenseRef-PublicDomain. The expression is
+// empty if there are no such matches.
+func (d Matches) SPDXExpression() string {
+	seen := make(map[string]bool)
+	orLater := make(map[string]bool)
+	for _, m := range d {
+		if spdxMatchTypes[m.MatchType] && m.OrLater {
+			orLater[m.Name] = true
+		}
+	}
+	exceptions := make(map[string][]string)
+	for _, m := range d {
+		switch {
+		case spdxMatchTypes[m.MatchType]:
+			seen[orLaterExpression(m.Name, m.OrLater)] = true
+		case m.MatchType == exceptionMatchType && m.AppliesTo != "":
+			l := orLaterExpression(m.AppliesTo, orLater[m.AppliesTo])
+			exceptions[l] = append(exceptions[l], m.Name)
+		}
+	}
+
+	// Exception matches qualify the license they apply to, unless its name
+	// already includes an exception.
+	for l, es := range exceptions {
+		if !seen[l] || strings.Contains(l, " WITH ") {
+			continue
+		}
+		delete(seen, l)
+		for _, e := range es {
+			seen[l+" WITH "+e] = true
+		}
+	}
+
+	// A license with an exception subsumes the license it's an exception to.
+	for e := range seen {
+		if i := strings.Index(e, " WITH "); i != -1 {
+			delete(seen, e[:i])
+		}
+	}
+
+	// Licenses offered as a choice are reported as the choice, which
+	// subsumes the licenses it's between.
+	choices := make(map[string]bool)
+	for _, m := range d {
+		if !spdxMatchTypes[m.MatchType] || len(m.Alternatives) < 2 {
+			continue
+		}
+		var alts []string
+		for _, a := range m.Alternatives {
+			e := orLaterExpression(a, orLater[a])
+			delete(seen, e)
+			alts = append(alts, e)
+		}
+		choices[strings.Join(alts, " OR ")] = true
+	}
+	for c := range choices {
+		seen[c] = true
+	}
+
+	var terms []string
+	for e := range seen {
+		if len(seen) > 1 && (strings.Contains(e, " AND ") || strings.Contains(e, " OR ")) {
+			e = "(" + e + ")"
+		}
+		terms = append(terms, e)
+	}
+	sort.Strings(terms)
+	return strings.Join(terms, " AND ")
+}
+
+// spdxExpression returns the SPDX expression for the named license. SPDX
+// identifiers, LicenseRef- identifiers and names that are already
+// expressions, such as those given by a NameResolver, are used as they are.
+// Other names are made into LicenseRef- identifiers, with the characters an
+// identifier can't hold replaced by dashes.
+func spdxExpression(name string) string {
+	if e, ok := spdxExpressions[name]; ok {
+		return e
+	}
+	if spdxIdentifiers[name] || strings.HasPrefix(name, "LicenseRef-") || strings.Contains(name, " ") {
+		return name
+	}
+	return "LicenseRef-" + strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '.', r == '-':
+			return r
+		}
+		return '-'
+	}, name)
+}
@@ -0,0 +1,215 @@
+// Copyright 2022 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package classifier
+
+import (
+	"io/ioutil"
+	"path"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func TestSPDXExpression(t *testing.T) {
+	tests := []struct {
+		name    string
+		matches Matches
+		want    string
+	}{
+		{
+			name: "no matches",
+			want: "",
+		},
+		{
+			name: "single license",
+			matches: Matches{
+				{Name: "MIT", MatchType: "License"},
+			},
+			want: "MIT",
+		},
+		{
+			name: "licenses joined in order",
+			matches: Matches{
+				{Name: "MIT", MatchType: "License"},
+				{Name: "Apache-2.0", MatchType: "Header"},
+				{Name: "MIT", MatchType: "Header"},
+			},
+			want: "Apache-2.0 AND MIT",
+		},
+		{
+			name: "other match types ignored",
+			matches: Matches{
+				{Name: "BSD-3-Clause", MatchType: "License"},
+				{Name: "Copyright", MatchType: "Copyright"},
+				{Name: "Apache-2.0", MatchType: "Supplement"},
+			},
+			want: "BSD-3-Clause",
+		},
+		{
+			name: "exception subsumes license",
+			matches: Matches{
+				{Name: "GPL-2.0", MatchType: "License"},
+				{Name: "GPL-2.0-with-classpath-exception", MatchType: "License"},
+			},
+			want: "GPL-2.0-only WITH Classpath-exception-2.0",
+		},
+		{
+			name: "exception match",
+			matches: Matches{
+				{Name: "GPL-2.0", MatchType: "Header"},
+				{Name: "Classpath-exception-2.0", MatchType: "Exception", AppliesTo: "GPL-2.0"},
+				{Name: "MIT", MatchType: "License"},
+			},
+			want: "GPL-2.0-only WITH Classpath-exception-2.0 AND MIT",
+		},
+		{
+			name: "exception of a license with exception",
+			matches: Matches{
+				{Name: "GPL-2.0-with-bison-exception", MatchType: "License"},
+				{Name: "Bison-exception-2.2", MatchType: "Exception", AppliesTo: "GPL-2.0-with-bison-exception"},
+			},
+			want: "GPL-2.0-only WITH Bison-exception-2.2",
+		},
+		{
+			name: "or later",
+			matches: Matches{
+				{Name: "GPL-2.0", MatchType: "Header", OrLater: true},
+				{Name: "LGPL-2.1", MatchType: "Header"},
+			},
+			want: "GPL-2.0-or-later AND LGPL-2.1-only",
+		},
+		{
+			name: "exception of a license granting any later version",
+			matches: Matches{
+				{Name: "GPL-2.0", MatchType: "Header", OrLater: true},
+				{Name: "Classpath-exception-2.0", MatchType: "Exception", AppliesTo: "GPL-2.0"},
+			},
+			want: "GPL-2.0-or-later WITH Classpath-exception-2.0",
+		},
+		{
+			name: "unassociated exception",
+			matches: Matches{
+				{Name: "MIT", MatchType: "License"},
+				{Name: "GCC-exception-2.0", MatchType: "Exception"},
+			},
+			want: "MIT",
+		},
+		{
+			name: "reference",
+			matches: Matches{
+				{Name: "Artistic-1.0-Perl", MatchType: "Reference"},
+				{Name: "GPL-1.0", MatchType: "Reference"},
+			},
+			want: "Artistic-1.0-Perl AND GPL-1.0-only",
+		},
+		{
+			name: "choice",
+			matches: Matches{
+				{Name: "MIT", MatchType: "Reference", Alternatives: []string{"MIT", "Apache-2.0"}},
+				{Name: "Apache-2.0", MatchType: "License", Alternatives: []string{"MIT", "Apache-2.0"}},
+			},
+			want: "MIT OR Apache-2.0",
+		},
+		{
+			name: "choice and license",
+			matches: Matches{
+				{Name: "GPL-2.0", MatchType: "Reference", OrLater: true, Alternatives: []string{"GPL-2.0", "BSD-3-Clause"}},
+				{Name: "BSD-3-Clause", MatchType: "Reference", Alternatives: []string{"GPL-2.0", "BSD-3-Clause"}},
+				{Name: "Zlib", MatchType: "License"},
+			},
+			want: "(GPL-2.0-or-later OR BSD-3-Clause) AND Zlib",
+		},
+		{
+			name: "license refs",
+			matches: Matches{
+				{Name: "LicenseRef-nacl", MatchType: "License"},
+				{Name: "unicode_org", MatchType: "License"},
+			},
+			want: "LicenseRef-nacl AND LicenseRef-unicode-org",
+		},
+		{
+			name: "names that aren't identifiers",
+			matches: Matches{
+				{Name: "Android-SDK", MatchType: "License"},
+				{Name: "Business-Source-License-1.1", MatchType: "License"},
+				{Name: "BSD-0-Clause", MatchType: "Header"},
+			},
+			want: "0BSD AND BUSL-1.1 AND LicenseRef-Android-SDK",
+		},
+		{
+			name: "resolved expression",
+			matches: Matches{
+				{Name: "MIT OR Apache-2.0", MatchType: "License"},
+				{Name: "Zlib", MatchType: "License"},
+			},
+			want: "(MIT OR Apache-2.0) AND Zlib",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.matches.SPDXExpression(); got != tt.want {
+				t.Errorf("SPDXExpression() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+// spdxLicenseRef matches the LicenseRef- identifiers of an SPDX expression.
+var spdxLicenseRef = regexp.MustCompile(`^LicenseRef-[A-Za-z0-9.-]+$`)
+
+func TestSPDXExpressionCorpus(t *testing.T) {
+	exceptions := make(map[string]bool)
+	fs, err := ioutil.ReadDir(path.Join(baseLicenses, "Exception"))
+	if err != nil {
+		t.Fatalf("couldn't read exceptions: %v", err)
+	}
+	for _, f := range fs {
+		exceptions[f.Name()] = true
+	}
+	// Identifiers that the expressions of the corpus are translated to,
+	// rather than names of the corpus.
+	translated := make(map[string]bool)
+	for _, e := range spdxExpressions {
+		for _, id := range strings.Fields(e) {
+			translated[id] = true
+		}
+	}
+
+	for _, category := range []string{"License", "Header"} {
+		fs, err := ioutil.ReadDir(path.Join(baseLicenses, category))
+		if err != nil {
+			t.Fatalf("couldn't read %s licenses: %v", category, err)
+		}
+		for _, f := range fs {
+			name := f.Name()
+			e := (Matches{{Name: name, MatchType: category}}).SPDXExpression()
+			terms := strings.Fields(e)
+			for i, id := range terms {
+				switch {
+				case id == "WITH" || id == "AND" || id == "OR":
+				case i > 0 && terms[i-1] == "WITH":
+					if !exceptions[id] && id != "Swift-exception" && id != "WxWindows-exception-3.1" {
+						t.Errorf("SPDXExpression() of %s = %q, which names unknown exception %s", name, e, id)
+					}
+				case spdxIdentifiers[id], spdxLicenseRef.MatchString(id):
+				case translated[id] && spdxExpressions[name] != "":
+				default:
+					t.Errorf("SPDXExpression() of %s = %q, which isn't an SPDX identifier or LicenseRef", name, e)
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,106 @@
+// Copyright 2022 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build !core
+// +build !core
+
+package classifier
+
+import (
+	"sort"
+	"unsafe"
+)
+
+// CorpusStats describes the size of the corpus of a classifier, to help decide
+// which licenses to leave out of it where memory is short.
+type CorpusStats struct {
+	// Words is the number of distinct words in the dictionary of the corpus.
+	Words int
+	// IndexBytes is an estimate of the memory held by the indexes of the
+	// documents of the corpus, excluding the dictionary.
+	IndexBytes int64
+	// Licenses are the statistics of each license, sorted by name.
+	Licenses []LicenseStats
+}
+
+// LicenseStats describes the documents of a license in the corpus.
+type LicenseStats struct {
+	Name string
+	// Variants is the number of documents of the license in each category,
+	// such as "License" and "Header".
+	Variants map[string]int
+	// Tokens is the number of tokens of all the documents of the license.
+	Tokens int
+	// IndexBytes is an estimate of the memory held by the indexes of the
+	// documents of the license.
+	IndexBytes int64
+}
+
+// HasHeader reports whether the corpus holds a header of the license.
+func (l LicenseStats) HasHeader() bool {
+	return l.Variants["Header"] > 0
+}
+
+// Stats returns statistics on the corpus of the classifier. Content the
+// classifier loads lazily is loaded first.
+func (c *Classifier) Stats() CorpusStats {
+	c.loadLazy()
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	stats := CorpusStats{Words: len(c.dict.words)}
+	licenses := make(map[string]*LicenseStats)
+	for name, d := range c.docs {
+		l := licenses[LicenseName(name)]
+		if l == nil {
+			l = &LicenseStats{Name: LicenseName(name), Variants: make(map[string]int)}
+			licenses[l.Name] = l
+		}
+		l.Variants[detectionType(name)]++
+		l.Tokens += d.size()
+		n := d.indexBytes()
+		l.IndexBytes += n
+		stats.IndexBytes += n
+	}
+	for _, l := range licenses {
+		stats.Licenses = append(stats.Licenses, *l)
+	}
+	sort.Slice(stats.Licenses, func(i, j int) bool { return stats.Licenses[i].Name < stats.Licenses[j].Name })
+	return stats
+}
+
+// mapEntryBytes approximates the overhead of an entry of a map beyond its key
+// and value.
+const mapEntryBytes = 16
+
+// indexBytes estimates the memory held by the tokens, normalized text,
+// frequency table and searchset of the document.
+func (d *indexedDocument) indexBytes() int64 {
+	n := int64(len(d.Tokens))*int64(unsafe.Sizeof(indexedToken{})) +
+		int64(len(d.Norm)) +
+		int64(len(d.runes))*int64(unsafe.Sizeof(rune(0)))
+	if d.f != nil {
+		n += int64(len(d.f.counts)) * int64(unsafe.Sizeof(tokenID(0))+unsafe.Sizeof(0)+mapEntryBytes)
+	}
+	if s := d.s; s != nil {
+		rangeBytes := int64(unsafe.Sizeof(&tokenRange{}) + unsafe.Sizeof(tokenRange{}))
+		n += int64(len(s.Checksums)) * int64(unsafe.Sizeof(uint32(0)))
+		n += int64(len(s.ChecksumRanges)) * rangeBytes
+		n += int64(len(s.nodes)) * int64(unsafe.Sizeof(&node{})+unsafe.Sizeof(node{}))
+		for _, rs := range s.Hashes {
+			n += int64(unsafe.Sizeof(uint32(0))+unsafe.Sizeof(tokenRanges{})+mapEntryBytes) + int64(len(rs))*rangeBytes
+		}
+	}
+	return n
+}
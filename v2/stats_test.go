@@ -0,0 +1,57 @@
+// Copyright 2022 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build !core
+// +build !core
+
+package classifier
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+)
+
+func TestStats(t *testing.T) {
+	c := NewClassifier(defaultThreshold)
+	c.AddContent("License", "MIT", "a.txt", []byte("permission is hereby granted free of charge"))
+	c.AddContent("License", "MIT", "b.txt", []byte("permission is granted"))
+	c.AddContent("Header", "Apache-2.0", "header.txt", []byte("licensed under the apache license"))
+
+	got := c.Stats()
+	want := CorpusStats{
+		Words: 12,
+		Licenses: []LicenseStats{
+			{Name: "Apache-2.0", Variants: map[string]int{"Header": 1}, Tokens: 5},
+			{Name: "MIT", Variants: map[string]int{"License": 2}, Tokens: 10},
+		},
+	}
+	if diff := cmp.Diff(want, got, cmpopts.IgnoreFields(CorpusStats{}, "IndexBytes"), cmpopts.IgnoreFields(LicenseStats{}, "IndexBytes")); diff != "" {
+		t.Errorf("Stats() mismatch (-want +got):\n%s", diff)
+	}
+	var sum int64
+	for _, l := range got.Licenses {
+		if l.IndexBytes <= 0 {
+			t.Errorf("Stats() IndexBytes of %s = %d, want a positive estimate", l.Name, l.IndexBytes)
+		}
+		sum += l.IndexBytes
+	}
+	if got.IndexBytes != sum {
+		t.Errorf("Stats() IndexBytes = %d, want the sum of the licenses %d", got.IndexBytes, sum)
+	}
+	if !got.Licenses[0].HasHeader() || got.Licenses[1].HasHeader() {
+		t.Errorf("HasHeader() = %v and %v, want true and false", got.Licenses[0].HasHeader(), got.Licenses[1].HasHeader())
+	}
+}
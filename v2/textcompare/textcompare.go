@@ -0,0 +1,149 @@
+// Copyright 2022 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package textcompare compares texts word by word, using the same distance and
+// confidence measures the license classifier uses to score matches. It is
+// useful for comparing arbitrary normalized texts, for example to find
+// near-identical NOTICE entries.
+//
+// Texts are compared as sequences of words separated by whitespace; the
+// comparison is exact, so callers should normalize case and punctuation first
+// if those differences aren't significant. The text of every diff produced or
+// consumed by this package consists of words separated by single spaces.
+package textcompare
+
+import (
+	"strings"
+
+	"github.com/sergi/go-diff/diffmatchpatch"
+)
+
+// Diff returns the word-level differences that turn known into target.
+func Diff(known, target string) []diffmatchpatch.Diff {
+	words := make(map[string]rune)
+	var dict []string
+	encode := func(text string) []rune {
+		fields := strings.Fields(text)
+		out := make([]rune, len(fields))
+		for i, f := range fields {
+			r, ok := words[f]
+			if !ok {
+				r = wordRune(len(dict))
+				words[f] = r
+				dict = append(dict, f)
+			}
+			out[i] = r
+		}
+		return out
+	}
+	k, t := encode(known), encode(target)
+
+	dmp := diffmatchpatch.New()
+	diffs := dmp.DiffMainRunes(k, t, false)
+	for i, d := range diffs {
+		var sb strings.Builder
+		for j, r := range []rune(d.Text) {
+			if j > 0 {
+				sb.WriteByte(' ')
+			}
+			sb.WriteString(dict[runeWord(r)])
+		}
+		diffs[i].Text = sb.String()
+	}
+	return diffs
+}
+
+// The words of a diff are encoded as runes so the character diff can operate
+// on them. The surrogate range isn't valid in strings, so it's skipped.
+const (
+	surrogateMin = 0xD800
+	surrogateMax = 0xDFFF
+)
+
+func wordRune(i int) rune {
+	r := rune(i + 1)
+	if r >= surrogateMin {
+		r += surrogateMax - surrogateMin + 1
+	}
+	return r
+}
+
+func runeWord(r rune) int {
+	if r > surrogateMax {
+		r -= surrogateMax - surrogateMin + 1
+	}
+	return int(r) - 1
+}
+
+// WordLevenshtein returns the word-level Levenshtein distance described by the
+// diffs. Inserted and deleted words between the same pair of equal runs are
+// paired up as substitutions, so each such change counts the larger of the
+// number of inserted and deleted words.
+func WordLevenshtein(diffs []diffmatchpatch.Diff) int {
+	levenshtein := 0
+	insertions := 0
+	deletions := 0
+
+	for _, aDiff := range diffs {
+		switch aDiff.Type {
+		case diffmatchpatch.DiffInsert:
+			insertions += WordCount(aDiff.Text)
+		case diffmatchpatch.DiffDelete:
+			deletions += WordCount(aDiff.Text)
+		case diffmatchpatch.DiffEqual:
+			// A deletion and an insertion is one substitution.
+			levenshtein += max(insertions, deletions)
+			insertions = 0
+			deletions = 0
+		}
+	}
+
+	levenshtein += max(insertions, deletions)
+	return levenshtein
+}
+
+// Confidence returns how closely a text matches a known text of knownLength
+// words that is distance words away from it: 1.0 is an exact match, and the
+// value falls as the distance grows. It is negative when the distance exceeds
+// the length of the known text. An empty known text is always matched with
+// confidence 1.0.
+func Confidence(knownLength, distance int) float64 {
+	// No text is matched at 100% confidence (avoid divide by zero).
+	if knownLength == 0 {
+		return 1.0
+	}
+
+	// Return a computed fractional match against the known text.
+	return 1.0 - float64(distance)/float64(knownLength)
+}
+
+// Similarity returns the confidence with which target matches known.
+func Similarity(known, target string) float64 {
+	return Confidence(len(strings.Fields(known)), WordLevenshtein(Diff(known, target)))
+}
+
+// WordCount returns the number of words in the text of a diff.
+func WordCount(text string) int {
+	if text == "" {
+		return 0
+	}
+	return strings.Count(text, " ") + 1
+}
+
+func max(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,149 @@
+// Copyright 2022 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package textcompare
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/sergi/go-diff/diffmatchpatch"
+)
+
+func TestDiff(t *testing.T) {
+	tests := []struct {
+		name   string
+		known  string
+		target string
+		want   []diffmatchpatch.Diff
+	}{
+		{
+			name:   "identical",
+			known:  "the quick brown fox",
+			target: "the  quick\nbrown fox",
+			want: []diffmatchpatch.Diff{
+				{Type: diffmatchpatch.DiffEqual, Text: "the quick brown fox"},
+			},
+		},
+		{
+			name:   "substitution",
+			known:  "the quick brown fox",
+			target: "the slow brown fox",
+			want: []diffmatchpatch.Diff{
+				{Type: diffmatchpatch.DiffEqual, Text: "the"},
+				{Type: diffmatchpatch.DiffDelete, Text: "quick"},
+				{Type: diffmatchpatch.DiffInsert, Text: "slow"},
+				{Type: diffmatchpatch.DiffEqual, Text: "brown fox"},
+			},
+		},
+		{
+			name:   "empty target",
+			known:  "the fox",
+			target: "",
+			want: []diffmatchpatch.Diff{
+				{Type: diffmatchpatch.DiffDelete, Text: "the fox"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if diff := cmp.Diff(tt.want, Diff(tt.known, tt.target)); diff != "" {
+				t.Errorf("Diff() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestDiffManyWords(t *testing.T) {
+	// Enough distinct words to need runes beyond the surrogate range.
+	var words []string
+	for i := 0; i < 60000; i++ {
+		words = append(words, fmt.Sprintf("w%d", i))
+	}
+	text := strings.Join(words, " ")
+	if got := Similarity(text, text); got != 1.0 {
+		t.Errorf("Similarity() = %v, want 1.0", got)
+	}
+}
+
+func TestWordLevenshtein(t *testing.T) {
+	tests := []struct {
+		name  string
+		diffs []diffmatchpatch.Diff
+		want  int
+	}{
+		{
+			name: "identical",
+			diffs: []diffmatchpatch.Diff{
+				{Type: diffmatchpatch.DiffEqual, Text: "identical words"},
+			},
+			want: 0,
+		},
+		{
+			name: "substitution",
+			diffs: []diffmatchpatch.Diff{
+				{Type: diffmatchpatch.DiffDelete, Text: "one two"},
+				{Type: diffmatchpatch.DiffInsert, Text: "three"},
+				{Type: diffmatchpatch.DiffEqual, Text: "same"},
+			},
+			want: 2,
+		},
+		{
+			name: "separate changes",
+			diffs: []diffmatchpatch.Diff{
+				{Type: diffmatchpatch.DiffDelete, Text: "one"},
+				{Type: diffmatchpatch.DiffEqual, Text: "same"},
+				{Type: diffmatchpatch.DiffInsert, Text: "two three"},
+			},
+			want: 3,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := WordLevenshtein(tt.diffs); got != tt.want {
+				t.Errorf("WordLevenshtein() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestConfidence(t *testing.T) {
+	tests := []struct {
+		length, distance int
+		want             float64
+	}{
+		{length: 0, distance: 5, want: 1.0},
+		{length: 10, distance: 0, want: 1.0},
+		{length: 10, distance: 2, want: 0.8},
+		{length: 10, distance: 20, want: -1.0},
+	}
+
+	for _, tt := range tests {
+		if got := Confidence(tt.length, tt.distance); got != tt.want {
+			t.Errorf("Confidence(%d, %d) = %v, want %v", tt.length, tt.distance, got, tt.want)
+		}
+	}
+}
+
+func TestSimilarity(t *testing.T) {
+	known := "copyright notice must be retained in all copies"
+	target := "copyright notice must be kept in all copies"
+	if got, want := Similarity(known, target), 1.0-1.0/8.0; got != want {
+		t.Errorf("Similarity() = %v, want %v", got, want)
+	}
+}
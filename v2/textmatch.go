@@ -0,0 +1,48 @@
+// Copyright 2022 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package classifier
+
+import (
+	"regexp"
+	"strings"
+)
+
+// referenceMatchType is the MatchType of matches that identify a license by
+// reference rather than by its text.
+const referenceMatchType = "Reference"
+
+// publicDomainMatchType is the MatchType of matches that identify content
+// dedicated to the public domain by a statement too short to be matched
+// against the texts of the corpus.
+const publicDomainMatchType = "PublicDomain"
+
+// phraseRE compiles the pattern of a phrase. The whitespace between the words
+// of the phrase, written \s+ in the pattern, may include the comment markers
+// of the lines it's wrapped across, since such phrases are commonly in the
+// header comments of source files.
+func phraseRE(pattern string) *regexp.Regexp {
+	return regexp.MustCompile(strings.ReplaceAll(pattern, `\s+`, commentedSpace))
+}
+
+// commentedSpace matches whitespace that may include comment markers.
+const commentedSpace = `(?:\s|[*#]|//|--)+`
+
+// phraseText returns the text of a phrase matched by a phraseRE, without the
+// comment markers and line breaks within it.
+func phraseText(b []byte) string {
+	return strings.Join(strings.Fields(commentedSpaceRE.ReplaceAllString(string(b), " ")), " ")
+}
+
+var commentedSpaceRE = regexp.MustCompile(commentedSpace)
@@ -0,0 +1,33 @@
+// Copyright 2022 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build core
+// +build core
+
+package classifier
+
+// The core build matches content against the corpus alone: references,
+// public-domain dedications and choices between licenses, which are detected
+// in the raw text, aren't reported.
+
+// detectText returns no matches in the core build.
+func (c *Classifier) detectText(in []byte) Matches {
+	return nil
+}
+
+// addReferences returns the matches as they are in the core build, which
+// detects no references.
+func addReferences(matches, refs Matches) Matches {
+	return matches
+}
@@ -25,6 +25,35 @@ import (
 
 var eol = "\n"
 
+// maxLineTokens is the number of tokens of a line that are buffered before
+// they are added to the document. Longer lines are processed in pieces of this
+// size; each piece is treated like a line of its own when looking for
+// copyright notices and section numbers, which only appear in much shorter
+// lines.
+const maxLineTokens = 1000
+
+// NumberPolicy controls how the tokenizer treats tokens that start with a
+// digit, such as section and version numbers.
+type NumberPolicy int
+
+const (
+	// NumbersDefault drops numbers that mark a section or list item at the
+	// start of a line and keeps all other numbers.
+	NumbersDefault NumberPolicy = iota
+	// NumbersKeep keeps all numbers, including section markers, so that texts
+	// which differ only in their numbering are told apart.
+	NumbersKeep
+	// NumbersMask replaces every number that isn't a section marker with the
+	// same placeholder, so that texts which differ only in their numbers, such
+	// as version strings, compare equal.
+	NumbersMask
+)
+
+// numberPlaceholder is the token that numbers are replaced with by
+// NumbersMask. It starts with a digit so that normalized text containing it
+// tokenizes the same way again.
+const numberPlaceholder = "0"
+
 func header(in string) bool {
 	if len(in) == 0 {
 		return false
@@ -71,7 +100,17 @@ var ignorableTexts = []*regexp.Regexp{
 // return an error from the provided Reader. If the provided Reader never
 // returns an error, it is safe to assume that tokenizeStream will not return an
 // error.
-func tokenizeStream(src io.Reader, normalize bool, dict *dictionary, updateDict bool) (*indexedDocument, error) {
+func tokenizeStream(src io.Reader, normalize bool, dict *dictionary, updateDict bool, numbers NumberPolicy) (*indexedDocument, error) {
+	doc, _, err := tokenizePrefix(src, normalize, dict, updateDict, numbers, 0)
+	return doc, err
+}
+
+// tokenizePrefix is tokenizeStream, but stops reading src once more than
+// limit tokens have been produced, if limit is positive, at the end of the
+// line holding the last of them or after the piece of a long line that held
+// it. It returns the number of bytes of src tokenized if it stopped early, and
+// -1 otherwise.
+func tokenizePrefix(src io.Reader, normalize bool, dict *dictionary, updateDict bool, numbers NumberPolicy, limit int) (*indexedDocument, int, error) {
 	const bufSize = 1024
 	// The longest UTF-8 encoded rune is 4 bytes, so we keep enough leftover bytes
 	// in the buffer to ensure we never run out of bytes trying to finish
@@ -91,12 +130,15 @@ func tokenizeStream(src io.Reader, normalize bool, dict *dictionary, updateDict
 	ld := newDictionary()
 
 	var doc indexedDocument
+	base := 0 // The offset in src of the start of rbuf
+	cut := -1
 
 	isEOF := func(in error) bool {
 		return in == io.EOF || in == io.ErrUnexpectedEOF
 	}
 
 	// Read out the stream in chunks
+read:
 	for {
 		// Fill up the buffer with bytes to extract runes from
 		// idx is offset to hold any bytes left over from previous reads
@@ -106,7 +148,7 @@ func tokenizeStream(src io.Reader, normalize bool, dict *dictionary, updateDict
 			// buffer.
 			tgt = idx + n
 		} else if err != nil {
-			return nil, err
+			return nil, -1, err
 		}
 
 		for idx = 0; idx < tgt; {
@@ -134,7 +176,7 @@ func tokenizeStream(src io.Reader, normalize bool, dict *dictionary, updateDict
 
 				// If there is something in the line to process, do so now
 				if len(linebuf) > 0 {
-					appendToDoc(&doc, dict, line, linebuf, ld, normalize, updateDict, linebuf)
+					appendToDoc(&doc, dict, line, linebuf, ld, normalize, updateDict, numbers, linebuf)
 					linebuf = nil
 					obuf = nil
 				}
@@ -148,6 +190,10 @@ func tokenizeStream(src io.Reader, normalize bool, dict *dictionary, updateDict
 						Line: line})
 				}
 				line++
+				if limit > 0 && len(doc.Tokens) > limit {
+					cut = base + idx
+					break read
+				}
 				continue
 			}
 
@@ -182,12 +228,22 @@ func tokenizeStream(src io.Reader, normalize bool, dict *dictionary, updateDict
 
 				linebuf = append(linebuf, flushBuf(len(linebuf), obuf, normalize, ld))
 				if deferredWord {
-					appendToDoc(&doc, dict, line, linebuf, ld, normalize, updateDict, linebuf)
+					appendToDoc(&doc, dict, line, linebuf, ld, normalize, updateDict, numbers, linebuf)
 					linebuf = nil
 					deferredWord = false
 					// Increment the line count now so the remainder token is credited
 					// to the previous line number.
 					line++
+				} else if len(linebuf) >= maxLineTokens {
+					// Process very long lines, such as those of minified files,
+					// in pieces rather than buffering the whole line.
+					appendToDoc(&doc, dict, line, linebuf, ld, normalize, updateDict, numbers, linebuf)
+					linebuf = nil
+					if limit > 0 && len(doc.Tokens) > limit {
+						cut = base + idx
+						obuf = nil
+						break read
+					}
 				}
 				obuf = make([]byte, 0)
 				continue
@@ -218,6 +274,7 @@ func tokenizeStream(src io.Reader, normalize bool, dict *dictionary, updateDict
 
 		// Copy the unconsumed bytes at the end of the buffer to the start
 		// of the buffer so the next read appends after them.
+		base += idx
 		n = copy(rbuf, rbuf[idx:])
 		idx = n
 	}
@@ -227,18 +284,19 @@ func tokenizeStream(src io.Reader, normalize bool, dict *dictionary, updateDict
 		linebuf = append(linebuf, flushBuf(len(linebuf), obuf, normalize, ld))
 	}
 	if len(linebuf) > 0 {
-		appendToDoc(&doc, dict, line, linebuf, ld, normalize, updateDict, linebuf)
+		appendToDoc(&doc, dict, line, linebuf, ld, normalize, updateDict, numbers, linebuf)
 	}
 
 	doc.dict = dict
+	doc.numbers = numbers
 	doc.generateFrequencies()
 	doc.runes = diffWordsToRunes(&doc, 0, doc.size())
 	doc.Norm = doc.normalized()
-	return &doc, nil
+	return &doc, cut, nil
 }
 
-func appendToDoc(doc *indexedDocument, dict *dictionary, line int, in []tokenID, ld *dictionary, normalize bool, updateDict bool, linebuf []tokenID) {
-	tokens, m := stringifyLineBuf(dict, line, linebuf, ld, normalize, updateDict)
+func appendToDoc(doc *indexedDocument, dict *dictionary, line int, in []tokenID, ld *dictionary, normalize bool, updateDict bool, numbers NumberPolicy, linebuf []tokenID) {
+	tokens, m := stringifyLineBuf(dict, line, linebuf, ld, normalize, updateDict, numbers)
 	if tokens != nil {
 		doc.Tokens = append(doc.Tokens, tokens...)
 	} else if m != nil {
@@ -246,7 +304,7 @@ func appendToDoc(doc *indexedDocument, dict *dictionary, line int, in []tokenID,
 	}
 }
 
-func stringifyLineBuf(dict *dictionary, line int, in []tokenID, ld *dictionary, normalize bool, updateDict bool) ([]indexedToken, *Match) {
+func stringifyLineBuf(dict *dictionary, line int, in []tokenID, ld *dictionary, normalize bool, updateDict bool, numbers NumberPolicy) ([]indexedToken, *Match) {
 	if len(in) == 0 {
 		return nil, nil
 	}
@@ -272,7 +330,7 @@ func stringifyLineBuf(dict *dictionary, line int, in []tokenID, ld *dictionary,
 
 	var tokens []indexedToken
 	for i, r := range in {
-		txt := cleanupToken(i, ld.getWord(r), normalize)
+		txt := cleanupToken(i, ld.getWord(r), normalize, numbers)
 		if txt != "" {
 			var tokID tokenID
 			if updateDict {
@@ -311,10 +369,10 @@ func flushBuf(pos int, obuf []byte, normalizeWord bool, ld *dictionary) tokenID
 	return ld.add(clean)
 }
 
-func cleanupToken(pos int, in string, normalizeWord bool) string {
+func cleanupToken(pos int, in string, normalizeWord bool, numbers NumberPolicy) string {
 	r, _ := utf8.DecodeRuneInString(in)
 	var out strings.Builder
-	if pos == 0 && header(in) {
+	if pos == 0 && header(in) && !(numbers == NumbersKeep && unicode.IsDigit(r)) {
 		return ""
 	}
 
@@ -336,6 +394,9 @@ func cleanupToken(pos int, in string, normalizeWord bool) string {
 			for strings.HasSuffix(res, ".") {
 				res = res[0 : len(res)-1]
 			}
+			if numbers == NumbersMask {
+				return numberPlaceholder
+			}
 			return res
 		}
 	}
@@ -58,12 +58,35 @@ func TestCleanupToken(t *testing.T) {
 		},
 	}
 	for _, test := range tests {
-		if got := cleanupToken(0, test.input, true); got != test.output {
+		if got := cleanupToken(0, test.input, true, NumbersDefault); got != test.output {
 			t.Errorf("%q: got %q want %q", test.input, got, test.output)
 		}
 	}
 }
 
+func TestCleanupTokenNumbers(t *testing.T) {
+	tests := []struct {
+		input   string
+		pos     int
+		numbers NumberPolicy
+		output  string
+	}{
+		{input: "1.", pos: 0, numbers: NumbersDefault, output: ""},
+		{input: "1.", pos: 0, numbers: NumbersKeep, output: "1"},
+		{input: "1.", pos: 0, numbers: NumbersMask, output: ""},
+		{input: "a.", pos: 0, numbers: NumbersKeep, output: ""},
+		{input: "2.5", pos: 1, numbers: NumbersDefault, output: "2.5"},
+		{input: "2.5", pos: 1, numbers: NumbersKeep, output: "2.5"},
+		{input: "2.5", pos: 1, numbers: NumbersMask, output: numberPlaceholder},
+		{input: "version", pos: 0, numbers: NumbersMask, output: "version"},
+	}
+	for _, test := range tests {
+		if got := cleanupToken(test.pos, test.input, true, test.numbers); got != test.output {
+			t.Errorf("%q at %d with %v: got %q want %q", test.input, test.pos, test.numbers, got, test.output)
+		}
+	}
+}
+
 func TestTokenize(t *testing.T) {
 	tests := []struct {
 		name   string
@@ -76,7 +99,7 @@ ball`,
 			output: &indexedDocument{
 				Tokens: []indexedToken{
 					{
-						ID:   1,
+						ID:   wordID("basketball"),
 						Line: 1,
 					},
 				},
@@ -98,43 +121,43 @@ The AWESOME Project`,
 			output: &indexedDocument{
 				Tokens: []indexedToken{
 					{
-						ID:   1,
+						ID:   wordID("the"),
 						Line: 1,
 					},
 					{
-						ID:   2,
+						ID:   wordID("awesome"),
 						Line: 1,
 					},
 					{
-						ID:   3,
+						ID:   wordID("project"),
 						Line: 1,
 					},
 					{
-						ID:   4,
+						ID:   wordID("license"),
 						Line: 1,
 					},
 					{
-						ID:   5,
+						ID:   wordID("modifications"),
 						Line: 3,
 					},
 					{
-						ID:   6,
+						ID:   wordID("prohibited"),
 						Line: 4,
 					},
 					{
-						ID:   7,
+						ID:   wordID("introduction"),
 						Line: 8,
 					},
 					{
-						ID:   1,
+						ID:   wordID("the"),
 						Line: 10,
 					},
 					{
-						ID:   2,
+						ID:   wordID("awesome"),
 						Line: 10,
 					},
 					{
-						ID:   3,
+						ID:   wordID("project"),
 						Line: 10,
 					},
 				},
@@ -145,7 +168,7 @@ The AWESOME Project`,
 	}
 	for _, test := range tests {
 		t.Run(test.name, func(t *testing.T) {
-			d, err := tokenizeStream(bytes.NewReader([]byte(test.input)), true, newDictionary(), true)
+			d, err := tokenizeStream(bytes.NewReader([]byte(test.input)), true, newDictionary(), true, NumbersDefault)
 			if err != nil {
 				t.Errorf("%s failed: got unexpected error %v", test.name, err)
 			}
@@ -189,7 +212,7 @@ func TestTokenizerBuffering(t *testing.T) {
 		t:        t,
 		schedule: []int{1024, 1020, 1020},
 	}
-	d, err := tokenizeStream(&mr, true, dict, true)
+	d, err := tokenizeStream(&mr, true, dict, true, NumbersDefault)
 	if err != nil {
 		t.Errorf("Read returned unexpected error: %v", err)
 	}
@@ -204,6 +227,29 @@ func TestTokenizerBuffering(t *testing.T) {
 	}
 }
 
+func TestTokenizeLongLine(t *testing.T) {
+	words := make([]string, 2*maxLineTokens+500)
+	for i := range words {
+		words[i] = "word"
+	}
+	in := strings.Join(words, " ") + "\nnext line\n"
+	d, err := tokenizeStream(strings.NewReader(in), true, newDictionary(), true, NumbersDefault)
+	if err != nil {
+		t.Fatalf("tokenizeStream() = %v", err)
+	}
+	if got, want := d.size(), len(words)+2; got != want {
+		t.Fatalf("tokenizeStream() produced %d tokens, want %d", got, want)
+	}
+	for i, tok := range d.Tokens[:len(words)] {
+		if tok.Line != 1 {
+			t.Fatalf("token %d is on line %d, want 1", i, tok.Line)
+		}
+	}
+	if got := d.Tokens[len(words)].Line; got != 2 {
+		t.Errorf("token after the long line is on line %d, want 2", got)
+	}
+}
+
 func TestTokenizer(t *testing.T) {
 	// This test focuses primarily on the textual content extracted and does not look
 	// at the other parts of the document.
@@ -297,7 +343,7 @@ The FreeType Project`,
 	for _, test := range tests {
 		t.Run(test.name, func(t *testing.T) {
 			dict := newDictionary()
-			d, err := tokenizeStream(bytes.NewReader([]byte(test.input)), true, dict, true)
+			d, err := tokenizeStream(bytes.NewReader([]byte(test.input)), true, dict, true, NumbersDefault)
 			if err != nil {
 				t.Errorf("%s failed: got unexpected error %v", test.name, err)
 			}
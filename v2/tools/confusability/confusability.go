@@ -0,0 +1,73 @@
+// Copyright 2022 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// The confusability program compares the texts of every pair of licenses in a
+// corpus directory and writes the pairs that are easily confused to a JSON
+// file. The classifier uses the file, shipped at the top of the corpus
+// directory, to also consider the licenses easily confused with a candidate
+// and to list them in its matches. Run it whenever the corpus changes:
+//
+//	$ confusability -licenses assets -out assets/confusability.json
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+
+	classifier "github.com/google/licenseclassifier/v2"
+)
+
+var (
+	licenses  = flag.String("licenses", "assets", "the license corpus directory")
+	out       = flag.String("out", "", "filename to write the JSON report to; standard output if empty")
+	threshold = flag.Float64("threshold", 0.85, "the similarity from which licenses are considered easily confused")
+)
+
+func init() {
+	flag.Usage = func() {
+		fmt.Fprintf(os.Stderr, `Usage: %s [options]
+
+Report the licenses of a corpus that are easily confused with each other.
+
+Options:
+`, filepath.Base(os.Args[0]))
+		flag.PrintDefaults()
+	}
+}
+
+func main() {
+	flag.Parse()
+
+	c := classifier.NewClassifier(*threshold)
+	if err := c.LoadLicenses(*licenses); err != nil {
+		log.Fatalf("cannot load licenses from %s: %v", *licenses, err)
+	}
+	b, err := json.MarshalIndent(c.ComputeConfusability(*threshold), "", " ")
+	if err != nil {
+		log.Fatalf("cannot encode report: %v", err)
+	}
+	b = append(b, '\n')
+	if *out == "" {
+		os.Stdout.Write(b)
+		return
+	}
+	if err := ioutil.WriteFile(*out, b, 0644); err != nil {
+		log.Fatalf("cannot write report to %s: %v", *out, err)
+	}
+}
@@ -0,0 +1,176 @@
+// Copyright 2022 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// The diff_results program compares two JSON files written by identify_license
+// and reports licenses introduced or removed between the scans, as well as
+// changes in the confidence of findings present in both. Lines starting with
+// '+' are new findings, '-' are removed findings, '~' are confidence changes
+// and '>' are findings of files moved unchanged, as told by their finding
+// identifiers. Lines starting with '*' are licenses no file of the old scan
+// held.
+//
+//	$ diff_results old.json new.json
+//	+ /src/vendor/foo/LICENSE: GPL-3.0 (confidence: 1)
+//	- /src/LICENSE: MIT (confidence: 1)
+//	~ /src/main.go: Apache-2.0 (confidence: 0.9 -> 0.98)
+//	> /src/util.go -> /src/lib/util.go: Apache-2.0
+//	* GPL-3.0
+//
+// Lines starting with '!' are licenses whose header or reference a file lost,
+// either entirely or for a weaker kind of match:
+//
+//	! /src/util.go: Apache-2.0 (Header removed)
+//	! /src/main.go: MIT (Header -> Reference)
+//
+// Files the new scan finds no licenses in are only reported as having lost
+// their license if they're still in the tree, rather than deleted. Scans of
+// two copies of a tree, such as checkouts before and after a vendoring update,
+// are compared by giving their roots with -old_root and -new_root; the files
+// are then looked for in the new tree.
+//
+// With -fail_on, the program exits with status 1 if any of the listed licenses
+// was introduced, and with -fail_on_downgrade if any license was removed from
+// or weakened in a file, which makes it suitable as a presubmit check.
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/google/licenseclassifier/v2/tools/identify_license/results"
+)
+
+var (
+	failOn      = flag.String("fail_on", "", "comma-separated list of license names whose introduction causes a non-zero exit status")
+	jsonFname   = flag.String("json", "", "filename to write the JSON diff to.")
+	printSchema = flag.Bool("print_schema", false, "print the JSON Schema of the JSON diff and exit")
+	oldRoot     = flag.String("old_root", "", "root of the tree of the old scan; paths under it are compared relative to it")
+	newRoot     = flag.String("new_root", "", "root of the tree of the new scan; paths under it are compared relative to it, and files the new scan finds no licenses in are looked for in it")
+
+	failOnDowngrade = flag.Bool("fail_on_downgrade", false, "exit with a non-zero status if any file's license was removed or weakened")
+)
+
+func init() {
+	flag.Usage = func() {
+		fmt.Fprintf(os.Stderr, `Usage: %s <old.json> <new.json>
+
+Compare two identify_license JSON result files.
+
+Options:
+`, filepath.Base(os.Args[0]))
+		flag.PrintDefaults()
+	}
+}
+
+func main() {
+	flag.Parse()
+	if *printSchema {
+		schema, err := results.Schema(results.SchemaDiff)
+		if err != nil {
+			log.Fatalf("cannot print schema: %v", err)
+		}
+		os.Stdout.Write(schema)
+		return
+	}
+	if flag.NArg() != 2 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	before, err := results.ReadJSONResult(flag.Arg(0))
+	if err != nil {
+		log.Fatalf("cannot read %s: %v", flag.Arg(0), err)
+	}
+	after, err := results.ReadJSONResult(flag.Arg(1))
+	if err != nil {
+		log.Fatalf("cannot read %s: %v", flag.Arg(1), err)
+	}
+
+	if *oldRoot != "" {
+		before = before.Rebase(*oldRoot)
+	}
+	if *newRoot != "" {
+		after = after.Rebase(*newRoot)
+	}
+
+	d := results.DiffResults(before, after)
+	d.Downgrades = results.DiffDowngrades(before, after, func(path string) bool {
+		if *newRoot != "" && !filepath.IsAbs(path) {
+			path = filepath.Join(*newRoot, path)
+		}
+		_, err := os.Stat(path)
+		return err == nil
+	})
+	for _, f := range d.Introduced {
+		fmt.Printf("+ %s: %s (confidence: %v)\n", f.Filepath, f.Name, f.Confidence)
+	}
+	for _, f := range d.Removed {
+		fmt.Printf("- %s: %s (confidence: %v)\n", f.Filepath, f.Name, f.Confidence)
+	}
+	for _, c := range d.Changed {
+		fmt.Printf("~ %s: %s (confidence: %v -> %v)\n", c.Filepath, c.Name, c.Old, c.New)
+	}
+	for _, m := range d.Moved {
+		fmt.Printf("> %s -> %s: %s\n", m.OldFilepath, m.Filepath, m.Name)
+	}
+	for _, n := range d.NewLicenses {
+		fmt.Printf("* %s\n", n)
+	}
+	for _, g := range d.Downgrades {
+		if g.Kind == results.DowngradeRemoved {
+			fmt.Printf("! %s: %s (%s removed)\n", g.Filepath, g.Name, g.OldMatchType)
+		} else {
+			fmt.Printf("! %s: %s (%s -> %s)\n", g.Filepath, g.Name, g.OldMatchType, g.NewMatchType)
+		}
+	}
+
+	if len(*jsonFname) > 0 {
+		fc, err := json.MarshalIndent(d, "", " ")
+		if err != nil {
+			log.Fatalf("cannot encode diff: %v", err)
+		}
+		if err := ioutil.WriteFile(*jsonFname, fc, 0644); err != nil {
+			log.Fatalf("Couldn't write JSON output to file %s: %v", *jsonFname, err)
+		}
+	}
+
+	failed := false
+	if *failOnDowngrade {
+		for _, g := range d.Downgrades {
+			log.Printf("%s loses license %s", g.Filepath, g.Name)
+			failed = true
+		}
+	}
+	forbidden := make(map[string]bool)
+	for _, n := range strings.Split(*failOn, ",") {
+		if n = strings.TrimSpace(n); n != "" {
+			forbidden[n] = true
+		}
+	}
+	for _, f := range d.Introduced {
+		if forbidden[f.Name] {
+			log.Printf("%s introduces forbidden license %s", f.Filepath, f.Name)
+			failed = true
+		}
+	}
+	if failed {
+		os.Exit(1)
+	}
+}
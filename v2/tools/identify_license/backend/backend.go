@@ -16,11 +16,17 @@
 package backend
 
 import (
+	"bytes"
 	"context"
+	"errors"
 	"fmt"
 	"io/ioutil"
 	"log"
+	"os"
+	"strconv"
+	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	//gc "google3/devtools/compliance/common/licenseclassifier/classifier"
@@ -44,6 +50,64 @@ type ClassifierBackend struct {
 	results    results.LicenseTypes
 	mu         sync.Mutex
 	classifier *classifier.Classifier
+	snippets   bool
+	bundles    bool
+	quiet      bool
+	// redactor redacts the paths in the progress logged.
+	redactor *results.Redactor
+	// maxLineLength is the average line length above which files are skipped
+	// as minified, or 0 to classify files regardless of their lines.
+	maxLineLength int
+	// sidecars enables attributing the results of REUSE-style .license
+	// sidecar files to the files they describe.
+	sidecars bool
+	// includeExts and excludeExts limit the files classified by extension.
+	includeExts map[string]bool
+	excludeExts map[string]bool
+	// sniffing enables skipping files whose sniffed MIME type can't hold
+	// license text, and archives disables it for archives.
+	sniffing bool
+	archives bool
+	// resolver maps the names of the licenses found to the names reported.
+	resolver classifier.NameResolver
+	// families enables reporting the families of the licenses found.
+	families bool
+	// sampleHead and sampleTail are the bytes of the head and tail of a
+	// file classified when it's too large to classify in full.
+	sampleHead, sampleTail int
+	// strategies overrides the default strategy for classifying files, by
+	// extension.
+	strategies map[string]Strategy
+
+	// maxMatches is the number of results reported for a file, beyond which
+	// its results are counted by a truncation marker, or 0 for no limit.
+	maxMatches  int
+	fileMatches map[string]int
+	truncated   map[string]*truncation
+
+	errorBudget float64
+	errorStats  map[string]int
+	// matchErrors holds the errors, such as recovered panics, of matching
+	// the contents of the files being classified, by file.
+	matchErrors map[string]error
+
+	// cache holds the matches of the contents classified, if enabled with
+	// SetCacheKey or read with NewFromCache.
+	cache *scanCache
+
+	// running counts the calls of ClassifyLicenses and
+	// ClassifyLicensesWithContext that haven't returned, so that Close can
+	// wait for them.
+	running sync.WaitGroup
+}
+
+// defaultThreshold is the confidence threshold of the classifiers of the
+// backends.
+const defaultThreshold = .8
+
+// newBackend creates a backend classifying with the classifier.
+func newBackend(lc *classifier.Classifier) *ClassifierBackend {
+	return &ClassifierBackend{classifier: lc, errorBudget: 1.0}
 }
 
 // New creates a new backend working on the local filesystem.
@@ -56,11 +120,167 @@ func New() (*ClassifierBackend, error) {
 	if err != nil {
 		return nil, err
 	}
-	return &ClassifierBackend{classifier: lc}, nil
+	return newBackend(lc), nil
+}
+
+// NewWithCorpus creates a new backend that matches against the licenses in
+// the supplied corpus directories, which are laid out like the assets
+// directory. The licenses are added to the embedded corpus unless
+// defaultCorpus is false, in which case at least one directory is required.
+// If lint is set, directories with problems, such as stray or empty files or
+// copies of licenses of the corpus under another name, are rejected with a
+// *classifier.CorpusError.
+func NewWithCorpus(dirs []string, defaultCorpus, lint bool) (*ClassifierBackend, error) {
+	if !defaultCorpus && len(dirs) == 0 {
+		return nil, errors.New("no license corpus to match against")
+	}
+	var opts []classifier.OptionFunc
+	if lint {
+		opts = append(opts, classifier.WithCorpusLint())
+	}
+	lc := classifier.NewClassifier(defaultThreshold, opts...)
+	if defaultCorpus {
+		var err error
+		if lc, err = assets.NewClassifier(defaultThreshold, assets.WithClassifierOptions(opts...)); err != nil {
+			return nil, err
+		}
+	}
+	for _, dir := range dirs {
+		if _, err := os.Stat(dir); err != nil {
+			return nil, err
+		}
+		if err := lc.LoadLicenses(dir); err != nil {
+			return nil, fmt.Errorf("unable to load licenses from %q: %w", dir, err)
+		}
+	}
+	return newBackend(lc), nil
+}
+
+// WarmUp prepares the backend's classifier for classifying files.
+func (b *ClassifierBackend) WarmUp() error {
+	return b.classifier.WarmUp()
 }
 
-// Close does nothing here since there's nothing to close.
+// Ready reports whether the backend has been warmed up and can classify
+// files.
+func (b *ClassifierBackend) Ready() bool {
+	return b.classifier.Ready()
+}
+
+// Close releases the license corpus held by the backend, once the files being
+// classified are done.
 func (b *ClassifierBackend) Close() {
+	b.running.Wait()
+	b.classifier.Close()
+}
+
+// SetSPDXSnippets sets whether regions of files delimited by SPDX-SnippetBegin
+// and SPDX-SnippetEnd markers are classified separately from the rest of the
+// file. The results for a snippet carry its bounds, and the license expression
+// it declares with SPDX-License-Identifier is reported as a Reference.
+func (b *ClassifierBackend) SetSPDXSnippets(enabled bool) {
+	b.snippets = enabled
+}
+
+// SetBundles sets whether JavaScript and CSS bundles are segmented at the
+// comments that bundlers preserve, which are marked with /*!, @license or
+// @preserve, and each preserved comment is classified separately. Results
+// found in a preserved comment carry its byte range in the bundle. Source maps
+// are classified by the original sources embedded in them, with results
+// carrying the source they were found in.
+func (b *ClassifierBackend) SetBundles(enabled bool) {
+	b.bundles = enabled
+}
+
+// SetNameResolver sets the resolver that maps the names of the licenses found
+// to the names reported by ResolveNames. The results themselves keep the names
+// of the corpus, which checks such as CheckNotices and the SPDX conclusions
+// rely on.
+func (b *ClassifierBackend) SetNameResolver(r classifier.NameResolver) {
+	b.resolver = r
+}
+
+// SetFamilies sets whether the results of licenses report the family of the
+// license, such as GPL-family, alongside its name, for policies that apply to
+// whole families of licenses.
+func (b *ClassifierBackend) SetFamilies(enabled bool) {
+	b.families = enabled
+}
+
+// SetSidecars sets whether REUSE-style sidecar files are attributed to the
+// files they describe: the results found in foo.png.license are reported for
+// foo.png, since binary files like images can't carry a license header, with
+// the Sidecar of the results naming the sidecar file. It's disabled by
+// default, reporting the results of sidecar files for the sidecars.
+func (b *ClassifierBackend) SetSidecars(enabled bool) {
+	b.sidecars = enabled
+}
+
+// sidecarTarget returns the file that the file is a sidecar of, or "" if it
+// isn't a sidecar or sidecars aren't attributed.
+func (b *ClassifierBackend) sidecarTarget(filename string) string {
+	if !b.sidecars || !strings.HasSuffix(strings.ToLower(filename), ".license") {
+		return ""
+	}
+	target := filename[:len(filename)-len(".license")]
+	if info, err := os.Stat(target); err != nil || !info.Mode().IsRegular() {
+		return ""
+	}
+	return target
+}
+
+// SetQuiet sets whether the progress of classifying each file is logged.
+func (b *ClassifierBackend) SetQuiet(quiet bool) {
+	b.quiet = quiet
+}
+
+// SetRedactor sets the redactor of the paths in the progress logged, so that
+// logs can be shared like the reports whose paths it redacts.
+func (b *ClassifierBackend) SetRedactor(r *results.Redactor) {
+	b.redactor = r
+}
+
+// SetMaxAverageLineLength skips classifying files whose average line length in
+// bytes exceeds n, such as minified JavaScript and CSS, which are slow to
+// classify and rarely produce meaningful matches. A skipped file is reported
+// with a single result with the SkippedMatchType. A length of 0, the default,
+// classifies all files.
+func (b *ClassifierBackend) SetMaxAverageLineLength(n int) {
+	b.maxLineLength = n
+}
+
+// SetMaxMatchesPerFile limits the results reported for a file to the first n,
+// since pathological files, such as concatenations of many sources, can
+// produce thousands of matches that bloat the reports. The results beyond the
+// limit are replaced by a single result with the TruncatedMatchType, whose
+// Variant is the number of results left out. A limit of 0, the default,
+// reports all results.
+func (b *ClassifierBackend) SetMaxMatchesPerFile(n int) {
+	b.maxMatches = n
+}
+
+// SetMaxTokens limits the contents of each file classified to their first n
+// tokens, bounding the time and memory spent on very large files. A file cut
+// short is reported with a result with the TruncatedMatchType whose Name is
+// TruncatedContent, alongside the results found in the tokens kept. A limit of
+// 0, the default, classifies files in full.
+func (b *ClassifierBackend) SetMaxTokens(n int) {
+	classifier.WithMaxTokens(n)(b.classifier)
+}
+
+// SetMaxCandidates limits the regions of each file scored against each license
+// to n, as WithMaxCandidates does. A file with more regions is reported with a
+// TruncatedContent result. A limit of 0, the default, scores all regions.
+func (b *ClassifierBackend) SetMaxCandidates(n int) {
+	classifier.WithMaxCandidates(n)(b.classifier)
+}
+
+// SetMaxDiffSize limits the diffs scoring regions of each file against
+// licenses to n tokens, as WithMaxDiffSize does. A file with regions left
+// unscored is reported with a TruncatedContent result. A limit of 0, the
+// default, scores all regions.
+func (b *ClassifierBackend) SetMaxDiffSize(n int) {
+	classifier.WithMaxDiffSize(n)(b.classifier)
 }
 
 // SetTraceConfiguration injects the supplied trace configuration
@@ -69,7 +289,27 @@ func (b *ClassifierBackend) SetTraceConfiguration(tc *classifier.TraceConfigurat
 }
 
 // ClassifyLicenses runs the license classifier over the given file.
-func (b *ClassifierBackend) ClassifyLicenses(numTasks int, filenames []string, headers bool) (errors []error) {
+func (b *ClassifierBackend) ClassifyLicenses(numTasks int, filenames []string, headers bool) []error {
+	return b.ClassifyLicensesWithContext(context.Background(), numTasks, filenames, headers)
+}
+
+// ClassifyLicensesWithContext runs the license classifier over the given
+// files like ClassifyLicenses, but gives up once the context is done: the
+// files left are not classified, those being matched stop, and the context's
+// error is returned after the errors of the files that failed on their own.
+func (b *ClassifierBackend) ClassifyLicensesWithContext(ctx context.Context, numTasks int, filenames []string, headers bool) []error {
+	b.running.Add(1)
+	defer b.running.Done()
+	errs := b.classifyLicenses(ctx, numTasks, filenames, headers)
+	if err := ctx.Err(); err != nil {
+		b.recordError(err)
+		errs = append(errs, err)
+	}
+	return errs
+}
+
+// classifyLicenses classifies the files, running up to numTasks at once.
+func (b *ClassifierBackend) classifyLicenses(ctx context.Context, numTasks int, filenames []string, headers bool) (errors []error) {
 	// Create a pool from which tasks can later be started. We use a pool because the OS limits
 	// the number of files that can be open at any one time.
 	task := make(chan bool, numTasks)
@@ -79,20 +319,40 @@ func (b *ClassifierBackend) ClassifyLicenses(numTasks int, filenames []string, h
 
 	errs := make(chan error, len(filenames))
 
+	// The scan is aborted once more files have failed than the error budget
+	// allows, for example when a broken mount fails every read.
+	allowed := int(b.errorBudget * float64(len(filenames)))
+	var failed int32
+
 	var wg sync.WaitGroup
 	analyze := func(filename string) {
 		defer func() {
-			wg.Done()
+			// Return the task before finishing, since the channel is closed
+			// once every file is done.
 			task <- true
+			wg.Done()
 		}()
-		if err := b.classifyLicense(filename, headers); err != nil {
+		// A file cut short by the context being done isn't counted as
+		// failed, since the context's error is reported for the scan.
+		if err := b.classifyFile(ctx, filename, headers); err != nil && !canceled(ctx, err) {
+			b.recordError(err)
+			atomic.AddInt32(&failed, 1)
 			errs <- err
 		}
 	}
 
+dispatch:
 	for _, filename := range filenames {
+		select {
+		case <-ctx.Done():
+			break dispatch
+		case <-task:
+		}
+		if int(atomic.LoadInt32(&failed)) > allowed {
+			task <- true
+			break
+		}
 		wg.Add(1)
-		<-task
 		go analyze(filename)
 	}
 	go func() {
@@ -104,60 +364,366 @@ func (b *ClassifierBackend) ClassifyLicenses(numTasks int, filenames []string, h
 	for err := range errs {
 		errors = append(errors, err)
 	}
+	if len(errors) > allowed {
+		// The summary covers this scan alone, while ErrorStats counts the
+		// errors of every scan of the backend.
+		stats := make(map[string]int)
+		for _, err := range errors {
+			stats[errorCategory(err)]++
+		}
+		errors = append(errors, &ErrorBudgetError{
+			Failed: len(errors),
+			Total:  len(filenames),
+			Budget: b.errorBudget,
+			Stats:  stats,
+		})
+	}
 	return errors
 }
 
-// ClassifyLicensesWithContext runs the license classifier over the given file; ensure that it will respect the timeout in the provided context.
-func (b *ClassifierBackend) ClassifyLicensesWithContext(ctx context.Context, numTasks int, filenames []string, headers bool) (errors []error) {
-	done := make(chan bool)
-	go func() {
-		errors = b.ClassifyLicenses(numTasks, filenames, headers)
-		done <- true
-	}()
-	select {
-	case <-ctx.Done():
-		err := ctx.Err()
-		errors = append(errors, err)
-		return errors
-	case <-done:
-		return errors
+// SkippedMatchType is the MatchType of the result reported for a file that
+// wasn't classified. The Name of the result gives the reason.
+const SkippedMatchType = "Skipped"
+
+// SkippedMinified is the reason given for skipping a file that looks minified.
+const SkippedMinified = "Minified"
+
+// EmptyMatchType is the MatchType of the result reported for a license file,
+// such as LICENSE or COPYING, that is empty or holds only whitespace, which
+// audits follow up on since the license it should hold is missing. The Name
+// of the result is EmptyLicenseFile.
+const EmptyMatchType = "Empty"
+
+// EmptyLicenseFile is the name of the result reported for an empty license
+// file.
+const EmptyLicenseFile = "LicenseFile"
+
+// TruncatedMatchType is the MatchType of the result reported for a file in
+// place of the results beyond the limit set with SetMaxMatchesPerFile, whose
+// Name is TruncatedMatches, and of the result reported for a file whose
+// contents were classified only in part due to the limits set with
+// SetMaxTokens, SetMaxCandidates or SetMaxDiffSize, whose Name is
+// TruncatedContent.
+const TruncatedMatchType = "Truncated"
+
+// TruncatedMatches is the name of the result reported for a file whose
+// results were truncated.
+const TruncatedMatches = "Matches"
+
+// TruncatedContent is the name of the result reported for a file whose
+// contents were classified only in part.
+const TruncatedContent = "Content"
+
+// truncation is the result marking the truncated results of a file, and the
+// number of results it stands for.
+type truncation struct {
+	marker *results.LicenseType
+	count  int
+}
+
+// averageLineLength returns the average length in bytes of the lines of the
+// contents.
+func averageLineLength(contents []byte) int {
+	lines := bytes.Count(contents, []byte("\n"))
+	if len(contents) > 0 && contents[len(contents)-1] != '\n' {
+		lines++
+	}
+	if lines == 0 {
+		return 0
 	}
+	return len(contents) / lines
 }
 
 // classifyLicense is called by a Go-function to perform the actual
 // classification of a license.
-func (b *ClassifierBackend) classifyLicense(filename string, headers bool) error {
-	contents, err := ioutil.ReadFile(filename)
+func (b *ClassifierBackend) classifyLicense(ctx context.Context, filename string, headers bool) error {
+	if b.excluded(filename) {
+		return nil
+	}
+
+	sample, err := b.sampled(filename)
 	if err != nil {
-		return fmt.Errorf("unable to read %q: %v", filename, err)
+		return fmt.Errorf("unable to read %q: %w", filename, err)
+	}
+	start := time.Now()
+	if sample {
+		if !b.quiet {
+			log.Printf("Classifying license(s) in the head and tail of: %s", b.redactor.Path(filename))
+		}
+		if err := b.matchSampled(ctx, filename, headers); err != nil {
+			return fmt.Errorf("unable to read %q: %w", filename, err)
+		}
+	} else {
+		contents, err := ioutil.ReadFile(filename)
+		if err != nil {
+			return fmt.Errorf("unable to read %q: %w", filename, err)
+		}
+		if b.skipUnclassifiable(filename, contents) {
+			return nil
+		}
+		if !b.quiet {
+			log.Printf("Classifying license(s): %s", b.redactor.Path(filename))
+		}
+		b.classifyContents(ctx, filename, contents, headers, nil)
+	}
+	if !b.quiet {
+		log.Printf("Finished Classifying License %q: %v", b.redactor.Path(filename), time.Since(start))
+	}
+	return nil
+}
+
+// classifyContents classifies the contents read from a file, which are all of
+// it or, for a sampled file, its head or tail. If annotate isn't nil, it's
+// called on each result before it's recorded.
+func (b *ClassifierBackend) classifyContents(ctx context.Context, filename string, contents []byte, headers bool, annotate func(*results.LicenseType)) {
+	if b.maxLineLength > 0 && averageLineLength(contents) > b.maxLineLength {
+		b.addAnnotated(&results.LicenseType{
+			Filename:  filename,
+			Name:      SkippedMinified,
+			MatchType: SkippedMatchType,
+		}, annotate)
+		return
+	}
+
+	if len(bytes.TrimSpace(contents)) == 0 && results.IsLicenseFile(filename) {
+		b.addAnnotated(&results.LicenseType{
+			Filename:  filename,
+			Name:      EmptyLicenseFile,
+			MatchType: EmptyMatchType,
+		}, annotate)
+		return
+	}
+
+	// The results are identified by their positions in the contents read from
+	// the file, however the contents are segmented for classifying them.
+	hash := results.ContentHash(contents)
+	annotate = chain(annotate, func(r *results.LicenseType) {
+		if r.MatchType != TruncatedMatchType {
+			r.ID = findingID(hash, r)
+		}
+	})
+
+	switch target := b.sidecarTarget(filename); {
+	case target != "":
+		b.matchContents(ctx, target, contents, headers, 0, chain(func(r *results.LicenseType) {
+			r.Sidecar = filename
+		}, annotate))
+	case b.bundles && isSourceMap(filename):
+		b.matchSourceMap(ctx, filename, contents, headers, annotate)
+	case b.bundles && isBundle(filename):
+		b.matchBundle(ctx, filename, contents, headers, annotate)
+	case b.snippets:
+		b.matchSnippets(ctx, filename, contents, headers, annotate)
+	default:
+		b.matchFile(ctx, filename, contents, headers, annotate)
+	}
+}
+
+// findingID returns the ID of a result found in contents whose hash is given.
+// Results found in a source embedded in a source map, whose lines are those of
+// the source, are identified within it.
+func findingID(hash string, r *results.LicenseType) string {
+	if r.Source != "" {
+		hash = results.ContentHash([]byte(hash + "\x00" + r.Source))
+	}
+	return results.FindingID(hash, r.Name, r.StartLine, r.EndLine)
+}
+
+// chain returns a function calling each of the non-nil annotations in turn, or
+// nil if there are none.
+func chain(annotations ...func(*results.LicenseType)) func(*results.LicenseType) {
+	var fs []func(*results.LicenseType)
+	for _, f := range annotations {
+		if f != nil {
+			fs = append(fs, f)
+		}
+	}
+	switch len(fs) {
+	case 0:
+		return nil
+	case 1:
+		return fs[0]
+	}
+	return func(r *results.LicenseType) {
+		for _, f := range fs {
+			f(r)
+		}
+	}
+}
+
+// matchSnippets classifies each SPDX snippet of a file separately from the
+// rest of the file, so that the licenses of the snippets are reported for
+// their regions only.
+func (b *ClassifierBackend) matchSnippets(ctx context.Context, filename string, contents []byte, headers bool, annotate func(*results.LicenseType)) {
+	lines := splitLines(contents)
+	snippets := findSnippets(lines)
+	if len(snippets) == 0 {
+		b.matchContents(ctx, filename, contents, headers, 0, annotate)
+		return
+	}
+
+	b.matchContents(ctx, filename, blankSnippets(lines, snippets), headers, 0, annotate)
+	for i := range snippets {
+		s := &snippets[i]
+		region := lines[s.startLine-1 : s.endLine]
+		b.matchContents(ctx, filename, bytes.Join(region, nil), headers, s.startLine-1, chain(s.annotate, annotate))
+
+		// The license a snippet declares is reported as a reference, since
+		// the snippet generally doesn't contain the text of the license.
+		for _, id := range spdxIdentifiers(region) {
+			line := s.startLine - 1 + id.line
+			b.addAnnotated(&results.LicenseType{
+				Filename:         filename,
+				MatchType:        "Reference",
+				Name:             id.expression,
+				Variant:          "SPDX-License-Identifier",
+				Confidence:       1.0,
+				StartLine:        line,
+				EndLine:          line,
+				SnippetStartLine: s.startLine,
+				SnippetEndLine:   s.endLine,
+			}, annotate)
+		}
 	}
+}
+
+// matchContents classifies the contents, which start after lineOffset lines of
+// the file, and records the matches. If annotate isn't nil, it's called on each
+// result before it's recorded, for example to add the bounds of the snippet
+// the contents were taken from.
+func (b *ClassifierBackend) matchContents(ctx context.Context, filename string, contents []byte, headers bool, lineOffset int, annotate func(*results.LicenseType)) {
+	for _, r := range b.classify(ctx, filename, contents, headers, lineOffset, annotate) {
+		b.addResult(r)
+	}
+}
 
-	matchLoop := func(contents []byte) {
-		for _, m := range b.classifier.Match(contents).Matches {
-			// If not looking for headers, skip them
-			if !headers && m.MatchType == "Header" {
-				continue
+// classify returns the results of classifying the contents as matchContents
+// does, without recording them.
+func (b *ClassifierBackend) classify(ctx context.Context, filename string, contents []byte, headers bool, lineOffset int, annotate func(*results.LicenseType)) []*results.LicenseType {
+	var out []*results.LicenseType
+	hash := results.ContentHash(contents)
+	matches, cached := b.cachedMatches(hash)
+	if !cached {
+		res, err := b.classifier.MatchFromWithContext(ctx, bytes.NewReader(contents))
+		if err != nil {
+			b.noteMatchError(filename, err)
+		} else if b.cache != nil && !res.Truncated {
+			// Matches that failed, for example on a timeout, aren't
+			// cached, so that the next scan tries again, and neither
+			// are those of truncated contents, which depend on the
+			// limits of the scan.
+			b.cache.put(hash, res.Matches)
+		}
+		matches = res.Matches
+		if res.Truncated {
+			r := &results.LicenseType{
+				Filename:  filename,
+				Name:      TruncatedContent,
+				MatchType: TruncatedMatchType,
+			}
+			if annotate != nil {
+				annotate(r)
 			}
+			out = append(out, r)
+		}
+	}
+	for _, m := range matches {
+		// If not looking for headers, skip them
+		if !headers && m.MatchType == "Header" {
+			continue
+		}
 
-			b.mu.Lock()
-			b.results = append(b.results, &results.LicenseType{
-				Filename:   filename,
-				MatchType:  m.MatchType,
-				Name:       m.Name,
-				Variant:    m.Variant,
-				Confidence: m.Confidence,
-				StartLine:  m.StartLine,
-				EndLine:    m.EndLine,
-			})
-			b.mu.Unlock()
+		r := &results.LicenseType{
+			Filename:   filename,
+			MatchType:  m.MatchType,
+			Name:       m.Name,
+			Variant:    m.Variant,
+			Confidence: m.Confidence,
+			StartLine:  m.StartLine + lineOffset,
+			EndLine:    m.EndLine + lineOffset,
+
+			AlternateVariants:   m.AlternateVariants,
+			TruncationSuspected: m.TruncationSuspected,
+		}
+		if b.families && (m.MatchType == "License" || m.MatchType == "Header" || m.MatchType == "Reference") {
+			r.Family = b.classifier.Family(m.Name)
 		}
+		if annotate != nil {
+			annotate(r)
+		}
+		out = append(out, r)
 	}
+	return out
+}
 
-	log.Printf("Classifying license(s): %s", filename)
-	start := time.Now()
-	matchLoop(contents)
-	log.Printf("Finished Classifying License %q: %v", filename, time.Since(start))
-	return nil
+// cachedMatches returns the matches of the contents with the hash cached by an
+// earlier scan, if any.
+func (b *ClassifierBackend) cachedMatches(hash string) (classifier.Matches, bool) {
+	if b.cache == nil {
+		return nil, false
+	}
+	return b.cache.get(hash)
+}
+
+// ResolveNames returns copies of the results with the names and variants of
+// the licenses found mapped by the name resolver, for output. The copies
+// remember the names of the corpus for checks such as CheckNotices. The
+// results the backend reports about files rather than licenses, such as
+// skipped files, are left alone. Without a name resolver the results are
+// returned as they are.
+func (b *ClassifierBackend) ResolveNames(res results.LicenseTypes) results.LicenseTypes {
+	if b.resolver == nil {
+		return res
+	}
+	out := make(results.LicenseTypes, 0, len(res))
+	for _, r := range res {
+		switch r.MatchType {
+		case SkippedMatchType, EmptyMatchType, TruncatedMatchType:
+		default:
+			r = r.Resolved(b.resolver.Resolve(r.MatchType, r.Name, r.Variant))
+		}
+		out = append(out, r)
+	}
+	return out
+}
+
+// addResult records a result, or counts it in the truncation marker of its
+// file if the file already has the maximum number of results.
+func (b *ClassifierBackend) addResult(r *results.LicenseType) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.maxMatches > 0 {
+		if b.fileMatches == nil {
+			b.fileMatches = make(map[string]int)
+			b.truncated = make(map[string]*truncation)
+		}
+		if b.fileMatches[r.Filename] >= b.maxMatches {
+			t, ok := b.truncated[r.Filename]
+			if !ok {
+				t = &truncation{marker: &results.LicenseType{
+					Filename:  r.Filename,
+					Name:      TruncatedMatches,
+					MatchType: TruncatedMatchType,
+				}}
+				b.truncated[r.Filename] = t
+				b.results = append(b.results, t.marker)
+			}
+			t.count++
+			t.marker.Variant = strconv.Itoa(t.count)
+			return
+		}
+		b.fileMatches[r.Filename]++
+	}
+	b.results = append(b.results, r)
+}
+
+// addAnnotated records a result after calling annotate on it, if annotate
+// isn't nil.
+func (b *ClassifierBackend) addAnnotated(r *results.LicenseType, annotate func(*results.LicenseType)) {
+	if annotate != nil {
+		annotate(r)
+	}
+	b.addResult(r)
 }
 
 // GetResults returns the results of the classifications.
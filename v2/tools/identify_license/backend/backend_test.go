@@ -0,0 +1,252 @@
+// Copyright 2022 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package backend
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"sort"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	classifier "github.com/google/licenseclassifier/v2"
+)
+
+// writeFiles writes n copies of the MIT license to dir, and returns their
+// paths.
+func writeFiles(t *testing.T, dir string, n int) []string {
+	t.Helper()
+	mit, err := ioutil.ReadFile(mitText)
+	if err != nil {
+		t.Fatalf("couldn't read MIT license: %v", err)
+	}
+	var files []string
+	for i := 0; i < n; i++ {
+		f := filepath.Join(dir, fmt.Sprintf("LICENSE%d", i))
+		if err := ioutil.WriteFile(f, mit, 0644); err != nil {
+			t.Fatal(err)
+		}
+		files = append(files, f)
+	}
+	return files
+}
+
+func TestClassifyLicensesWithContext(t *testing.T) {
+	dir := t.TempDir()
+	be, err := NewWithCorpus([]string{writeCorpus(t, dir)}, false, false)
+	if err != nil {
+		t.Fatalf("NewWithCorpus() returned error: %v", err)
+	}
+	defer be.Close()
+	be.SetQuiet(true)
+	files := writeFiles(t, dir, 20)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	errs := be.ClassifyLicensesWithContext(ctx, 4, files, false)
+	if len(errs) != 1 || !errors.Is(errs[0], context.Canceled) {
+		t.Fatalf("ClassifyLicensesWithContext() = %v, want context.Canceled", errs)
+	}
+	// No file is classified once the context is done, and nothing is left
+	// running to report results after the call returns.
+	if res := be.GetResults(); len(res) != 0 {
+		t.Errorf("GetResults() after a canceled scan = %v, want none", res)
+	}
+	if got := be.ErrorStats(); got[errorCategory(context.Canceled)] != 1 {
+		t.Errorf("ErrorStats() = %v, want the cancellation counted once", got)
+	}
+
+	if errs := be.ClassifyLicensesWithContext(context.Background(), 4, files, false); len(errs) != 0 {
+		t.Fatalf("ClassifyLicensesWithContext() returned errors: %v", errs)
+	}
+	found := make(map[string]bool)
+	for _, r := range be.GetResults() {
+		if r.Name == "MIT" {
+			found[r.Filename] = true
+		}
+	}
+	if len(found) != len(files) {
+		t.Errorf("found MIT in %d files, want %d", len(found), len(files))
+	}
+}
+
+func TestResolveNames(t *testing.T) {
+	dir := t.TempDir()
+	be, err := NewWithCorpus([]string{writeCorpus(t, dir)}, false, false)
+	if err != nil {
+		t.Fatalf("NewWithCorpus() returned error: %v", err)
+	}
+	defer be.Close()
+	be.SetQuiet(true)
+	be.SetNameResolver(classifier.NameResolverFunc(func(matchType, name, variant string) (string, string) {
+		return "notice:" + name, variant
+	}))
+	files := writeFiles(t, dir, 1)
+	empty := filepath.Join(dir, "COPYING")
+	if err := ioutil.WriteFile(empty, nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if errs := be.ClassifyLicenses(1, append(files, empty), false); len(errs) != 0 {
+		t.Fatalf("ClassifyLicenses() returned errors: %v", errs)
+	}
+
+	res := be.GetResults()
+	sort.Sort(res)
+	var got, resolved []string
+	for _, r := range res {
+		got = append(got, r.MatchType+":"+r.Name)
+	}
+	for _, r := range be.ResolveNames(res) {
+		resolved = append(resolved, r.MatchType+":"+r.Name)
+	}
+	// The results keep the names of the corpus for the checks based on
+	// them, and only the licenses found are resolved for output.
+	if want := []string{"License:MIT", "Empty:" + EmptyLicenseFile}; !cmp.Equal(want, got) {
+		t.Errorf("GetResults() = %v, want %v", got, want)
+	}
+	if want := []string{"License:notice:MIT", "Empty:" + EmptyLicenseFile}; !cmp.Equal(want, resolved) {
+		t.Errorf("ResolveNames() = %v, want %v", resolved, want)
+	}
+}
+
+func TestNewWithCorpusLint(t *testing.T) {
+	corpus := writeCorpus(t, t.TempDir())
+	if err := ioutil.WriteFile(filepath.Join(corpus, "notes.txt"), []byte("Licenses of our vendored code.\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	be, err := NewWithCorpus([]string{corpus}, false, false)
+	if err != nil {
+		t.Fatalf("NewWithCorpus() returned error: %v", err)
+	}
+	be.Close()
+
+	// Linted, the stray file is rejected.
+	var ce *classifier.CorpusError
+	if _, err := NewWithCorpus([]string{corpus}, false, true); !errors.As(err, &ce) {
+		t.Errorf("NewWithCorpus(lint) = %v, want a *classifier.CorpusError", err)
+	}
+}
+
+func TestMaxTokens(t *testing.T) {
+	dir := t.TempDir()
+	be, err := NewWithCorpus([]string{writeCorpus(t, dir)}, false, false)
+	if err != nil {
+		t.Fatalf("NewWithCorpus() returned error: %v", err)
+	}
+	defer be.Close()
+	be.SetQuiet(true)
+	mit, err := ioutil.ReadFile(mitText)
+	if err != nil {
+		t.Fatalf("couldn't read MIT license: %v", err)
+	}
+	be.SetMaxTokens(len(bytes.Fields(mit)) + 20)
+
+	long := filepath.Join(dir, "long.txt")
+	filler := bytes.Repeat([]byte("Lorem ipsum dolor sit amet, consectetur adipiscing elit.\n"), 100)
+	if err := ioutil.WriteFile(long, append(append([]byte(nil), mit...), filler...), 0644); err != nil {
+		t.Fatal(err)
+	}
+	files := append(writeFiles(t, dir, 1), long)
+	if errs := be.ClassifyLicenses(1, files, false); len(errs) != 0 {
+		t.Fatalf("ClassifyLicenses() returned errors: %v", errs)
+	}
+
+	got := make(map[string][]string)
+	for _, r := range be.GetResults() {
+		got[filepath.Base(r.Filename)] = append(got[filepath.Base(r.Filename)], r.MatchType+":"+r.Name)
+	}
+	for _, names := range got {
+		sort.Strings(names)
+	}
+	want := map[string][]string{
+		"LICENSE0": {"License:MIT"},
+		"long.txt": {"License:MIT", TruncatedMatchType + ":" + TruncatedContent},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("GetResults() mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestFindingIDs(t *testing.T) {
+	dir := t.TempDir()
+	corpus := writeCorpus(t, dir)
+	mit, err := ioutil.ReadFile(mitText)
+	if err != nil {
+		t.Fatalf("couldn't read MIT license: %v", err)
+	}
+	// The license follows a snippet, whose lines are blanked when the rest
+	// of the file is classified in snippet mode.
+	contents := "// SPDX-SnippetBegin\n// SPDX-License-Identifier: BSD-3-Clause\nint f();\n// SPDX-SnippetEnd\n\n" + string(mit)
+	file := filepath.Join(dir, "LICENSE")
+	if err := ioutil.WriteFile(file, []byte(contents), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	ids := make(map[bool]string)
+	for _, snippets := range []bool{false, true} {
+		be, err := NewWithCorpus([]string{corpus}, false, false)
+		if err != nil {
+			t.Fatalf("NewWithCorpus() returned error: %v", err)
+		}
+		be.SetQuiet(true)
+		be.SetSPDXSnippets(snippets)
+		if errs := be.ClassifyLicenses(1, []string{file}, false); len(errs) != 0 {
+			t.Fatalf("ClassifyLicenses() returned errors: %v", errs)
+		}
+		for _, r := range be.GetResults() {
+			if r.Name == "MIT" {
+				ids[snippets] = r.ID
+			}
+		}
+		be.Close()
+	}
+	if ids[false] == "" || ids[false] != ids[true] {
+		t.Errorf("MIT has ID %q, and %q in snippet mode, want equal IDs", ids[false], ids[true])
+	}
+}
+
+func TestErrorBudget(t *testing.T) {
+	dir := t.TempDir()
+	be, err := NewWithCorpus([]string{writeCorpus(t, dir)}, false, false)
+	if err != nil {
+		t.Fatalf("NewWithCorpus() returned error: %v", err)
+	}
+	defer be.Close()
+	be.SetQuiet(true)
+	be.SetErrorBudget(0.1)
+	files := []string{filepath.Join(dir, "missing1"), filepath.Join(dir, "missing2")}
+
+	// Each scan's summary counts its own failures, while ErrorStats counts
+	// those of every scan.
+	for scan := 1; scan <= 2; scan++ {
+		errs := be.ClassifyLicenses(1, files, false)
+		var budgetErr *ErrorBudgetError
+		if len(errs) == 0 || !errors.As(errs[len(errs)-1], &budgetErr) {
+			t.Fatalf("ClassifyLicenses() scan %d = %v, want an *ErrorBudgetError last", scan, errs)
+		}
+		want := &ErrorBudgetError{Failed: 1, Total: 2, Budget: 0.1, Stats: map[string]int{ErrorNotExist: 1}}
+		if diff := cmp.Diff(want, budgetErr); diff != "" {
+			t.Errorf("ClassifyLicenses() scan %d summary mismatch (-want +got):\n%s", scan, diff)
+		}
+		if got := be.ErrorStats(); got[ErrorNotExist] != scan {
+			t.Errorf("ErrorStats() after scan %d = %v, want %d %s errors", scan, got, scan, ErrorNotExist)
+		}
+	}
+}
@@ -0,0 +1,248 @@
+// Copyright 2022 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package backend
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/google/licenseclassifier/v2/tools/identify_license/results"
+)
+
+// bundleExtensions are the extensions of files produced by JavaScript and CSS
+// bundlers.
+var bundleExtensions = map[string]bool{
+	".cjs": true,
+	".css": true,
+	".js":  true,
+	".mjs": true,
+}
+
+// isBundle reports whether the file may be a JavaScript or CSS bundle.
+func isBundle(filename string) bool {
+	return bundleExtensions[strings.ToLower(filepath.Ext(filename))]
+}
+
+// isSourceMap reports whether the file is a source map.
+func isSourceMap(filename string) bool {
+	return strings.EqualFold(filepath.Ext(filename), ".map")
+}
+
+// preservedMarkers are the markers of comments that bundlers and minifiers
+// keep in their output, in addition to comments starting with /*!.
+var preservedMarkers = [][]byte{[]byte("@license"), []byte("@preserve")}
+
+// preservedComment is a comment kept by a bundler, located by the byte range
+// [start, end) in the bundle.
+type preservedComment struct {
+	start, end int
+}
+
+// annotate records the range of the comment in a result found in it.
+func (p *preservedComment) annotate(r *results.LicenseType) {
+	r.Offset, r.Extent = p.start, p.end-p.start
+}
+
+// findPreservedComments returns the block comments of a bundle that are marked
+// to be preserved, in order. A comment that isn't terminated extends to the end
+// of the bundle. Comment markers within string literals, and in JavaScript
+// within template literals, regular expression literals and line comments,
+// don't start comments.
+func findPreservedComments(contents []byte, js bool) []preservedComment {
+	var out []preservedComment
+	// prev is the last byte of code before i, which tells a regular
+	// expression literal from a division.
+	var prev byte
+	for i := 0; i < len(contents); {
+		c := contents[i]
+		switch {
+		case c == '/' && i+1 < len(contents) && contents[i+1] == '*':
+			end := len(contents)
+			if j := bytes.Index(contents[i+2:], []byte("*/")); j != -1 {
+				end = i + 2 + j + 2
+			}
+			if isPreserved(contents[i:end]) {
+				out = append(out, preservedComment{start: i, end: end})
+			}
+			i = end
+			continue
+		case js && c == '/' && i+1 < len(contents) && contents[i+1] == '/':
+			if j := bytes.IndexByte(contents[i:], '\n'); j != -1 {
+				i += j
+			} else {
+				i = len(contents)
+			}
+			continue
+		case c == '"' || c == '\'' || js && c == '`':
+			i = skipLiteral(contents, i, c)
+			prev = c
+			continue
+		case js && c == '/' && startsRegexp(contents[:i], prev):
+			i = skipLiteral(contents, i, '/')
+			prev = c
+			continue
+		}
+		if c != ' ' && c != '\t' && c != '\n' && c != '\r' {
+			prev = c
+		}
+		i++
+	}
+	return out
+}
+
+// regexpKeywords are the keywords after which a slash starts a regular
+// expression literal rather than a division.
+var regexpKeywords = map[string]bool{
+	"await": true, "case": true, "delete": true, "do": true, "else": true,
+	"in": true, "instanceof": true, "new": true, "of": true, "return": true,
+	"throw": true, "typeof": true, "void": true, "yield": true,
+}
+
+// startsRegexp reports whether a slash following the code before it, whose
+// last byte is prev, starts a regular expression literal: a slash following an
+// operand, such as a name, a number or a closing parenthesis, is a division.
+func startsRegexp(before []byte, prev byte) bool {
+	if prev == 0 || bytes.IndexByte([]byte("(,=:[!&|?{};+-*%<>~^"), prev) != -1 {
+		return true
+	}
+	word := bytes.TrimRight(before, " \t\r\n")
+	i := len(word)
+	for i > 0 && (word[i-1] >= 'a' && word[i-1] <= 'z') {
+		i--
+	}
+	return regexpKeywords[string(word[i:])]
+}
+
+// skipLiteral returns the position following the string, template or regular
+// expression literal starting at i, which quote delimits. Literals other than
+// templates end at the end of their line if they aren't terminated.
+func skipLiteral(contents []byte, i int, quote byte) int {
+	inClass := false
+	for i++; i < len(contents); i++ {
+		switch c := contents[i]; {
+		case c == '\\':
+			i++
+		case c == '\n' && quote != '`':
+			return i
+		case quote == '/' && c == '[':
+			inClass = true
+		case quote == '/' && c == ']':
+			inClass = false
+		case c == quote && !inClass:
+			i++
+			// The flags of a regular expression follow it.
+			for quote == '/' && i < len(contents) && (contents[i] >= 'a' && contents[i] <= 'z') {
+				i++
+			}
+			return i
+		}
+	}
+	return len(contents)
+}
+
+// isPreserved reports whether a block comment is marked to be preserved.
+func isPreserved(comment []byte) bool {
+	if bytes.HasPrefix(comment, []byte("/*!")) {
+		return true
+	}
+	for _, m := range preservedMarkers {
+		if bytes.Contains(comment, m) {
+			return true
+		}
+	}
+	return false
+}
+
+// blankComments returns a copy of the contents with the bytes of the comments
+// replaced by spaces, preserving line breaks so that the positions of the rest
+// of the bundle don't change.
+func blankComments(contents []byte, comments []preservedComment) []byte {
+	out := append([]byte(nil), contents...)
+	for _, c := range comments {
+		for i := c.start; i < c.end; i++ {
+			if out[i] != '\n' {
+				out[i] = ' '
+			}
+		}
+	}
+	return out
+}
+
+// prioritized returns the comments in the order they're classified: those
+// marked with @license, which bundlers keep for the license they hold, before
+// the other preserved comments, which are often banners naming the version of
+// a library, each in the order of the bundle.
+func prioritized(contents []byte, comments []preservedComment) []preservedComment {
+	out := append([]preservedComment(nil), comments...)
+	sort.SliceStable(out, func(i, j int) bool {
+		return bytes.Contains(contents[out[i].start:out[i].end], []byte("@license")) &&
+			!bytes.Contains(contents[out[j].start:out[j].end], []byte("@license"))
+	})
+	return out
+}
+
+// matchBundle classifies each preserved comment of a bundle separately, so
+// that every license is attributed to the comment it was found in, and then
+// the rest of the bundle. The comments are classified in order of priority,
+// so that the licenses of the comments marked with @license are the first
+// results of the bundle, which SetMaxMatchesPerFile keeps.
+func (b *ClassifierBackend) matchBundle(ctx context.Context, filename string, contents []byte, headers bool, annotate func(*results.LicenseType)) {
+	comments := findPreservedComments(contents, !strings.EqualFold(filepath.Ext(filename), ".css"))
+	ordered := prioritized(contents, comments)
+	for i := range ordered {
+		c := &ordered[i]
+		lineOffset := bytes.Count(contents[:c.start], []byte("\n"))
+		b.matchContents(ctx, filename, contents[c.start:c.end], headers, lineOffset, chain(c.annotate, annotate))
+	}
+	if len(comments) == 0 {
+		b.matchContents(ctx, filename, contents, headers, 0, annotate)
+		return
+	}
+	b.matchContents(ctx, filename, blankComments(contents, comments), headers, 0, annotate)
+}
+
+// sourceMap holds the fields of a source map needed to classify the original
+// sources embedded in it.
+type sourceMap struct {
+	Sources        []string  `json:"sources"`
+	SourcesContent []*string `json:"sourcesContent"`
+}
+
+// matchSourceMap classifies each original source embedded in a source map
+// separately. A source map that can't be parsed, or doesn't embed its sources,
+// is classified as it is.
+func (b *ClassifierBackend) matchSourceMap(ctx context.Context, filename string, contents []byte, headers bool, annotate func(*results.LicenseType)) {
+	var sm sourceMap
+	if err := json.Unmarshal(contents, &sm); err != nil || len(sm.SourcesContent) == 0 {
+		b.matchContents(ctx, filename, contents, headers, 0, annotate)
+		return
+	}
+	for i, content := range sm.SourcesContent {
+		if content == nil {
+			continue
+		}
+		source := ""
+		if i < len(sm.Sources) {
+			source = sm.Sources[i]
+		}
+		b.matchContents(ctx, filename, []byte(*content), headers, 0, chain(func(r *results.LicenseType) {
+			r.Source = source
+		}, annotate))
+	}
+}
@@ -0,0 +1,179 @@
+// Copyright 2022 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package backend
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestFindPreservedComments(t *testing.T) {
+	tests := []struct {
+		name     string
+		contents string
+		js       bool
+		want     []string
+	}{
+		{
+			name:     "markers",
+			contents: "/*! banner */var a=1;/* plain */var b=2;/** @license MIT */var c;/* @preserve x */",
+			js:       true,
+			want:     []string{"/*! banner */", "/** @license MIT */", "/* @preserve x */"},
+		},
+		{
+			name:     "unterminated",
+			contents: "var a;/*! banner",
+			js:       true,
+			want:     []string{"/*! banner"},
+		},
+		{
+			name:     "strings",
+			contents: `var a="/*! not a comment */",b='/* @license */';/*! kept */`,
+			js:       true,
+			want:     []string{"/*! kept */"},
+		},
+		{
+			name:     "escaped quote",
+			contents: `var a="\" /*! not a comment */";/*! kept */`,
+			js:       true,
+			want:     []string{"/*! kept */"},
+		},
+		{
+			name:     "template",
+			contents: "var a=`\n/*! not a comment */\n`;/*! kept */",
+			js:       true,
+			want:     []string{"/*! kept */"},
+		},
+		{
+			name:     "regular expression",
+			contents: `var re=/[/]\/*! not a comment */g;/*! kept */`,
+			js:       true,
+			want:     []string{"/*! kept */"},
+		},
+		{
+			name:     "regular expression after keyword",
+			contents: `function f(s){return /\/*! not a comment */.test(s)}/*! kept */`,
+			js:       true,
+			want:     []string{"/*! kept */"},
+		},
+		{
+			name:     "division",
+			contents: "var a=b/2/*! kept */,c=d/2;",
+			js:       true,
+			want:     []string{"/*! kept */"},
+		},
+		{
+			name:     "line comment",
+			contents: "// see /*! not a comment */\n/*! kept */",
+			js:       true,
+			want:     []string{"/*! kept */"},
+		},
+		{
+			name:     "css",
+			contents: `a{background:url(//example.com/x.png)}b{content:"/*! not a comment */"}/*! kept */`,
+			want:     []string{"/*! kept */"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			for _, c := range findPreservedComments([]byte(tt.contents), tt.js) {
+				got = append(got, tt.contents[c.start:c.end])
+			}
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("findPreservedComments() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestPrioritized(t *testing.T) {
+	contents := []byte("/*! v1.0 */a;/** @preserve */b;/** @license MIT */c;/*! @license ISC */")
+	var got []string
+	for _, c := range prioritized(contents, findPreservedComments(contents, true)) {
+		got = append(got, string(contents[c.start:c.end]))
+	}
+	want := []string{"/** @license MIT */", "/*! @license ISC */", "/*! v1.0 */", "/** @preserve */"}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("prioritized() mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestBlankComments(t *testing.T) {
+	contents := []byte("a;/*! x\ny */b;")
+	got := string(blankComments(contents, findPreservedComments(contents, true)))
+	if want := "a;     \n    b;"; got != want {
+		t.Errorf("blankComments() = %q, want %q", got, want)
+	}
+}
+
+func TestMatchBundle(t *testing.T) {
+	dir := t.TempDir()
+	be, err := NewWithCorpus([]string{writeCorpus(t, dir)}, false, false)
+	if err != nil {
+		t.Fatalf("NewWithCorpus() returned error: %v", err)
+	}
+	defer be.Close()
+	be.SetQuiet(true)
+	be.SetBundles(true)
+	mit, err := ioutil.ReadFile(mitText)
+	if err != nil {
+		t.Fatalf("couldn't read MIT license: %v", err)
+	}
+
+	prefix := "/*! lib v1.0 */\nvar a=\"/*!\";\n"
+	comment := "/**\n * @license\n" + string(mit) + " */"
+	bundle := filepath.Join(dir, "bundle.js")
+	if err := ioutil.WriteFile(bundle, []byte(prefix+comment+"\nvar b=a/2;\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	src := string(mit)
+	sm, err := json.Marshal(sourceMap{Sources: []string{"webpack:///lib.js"}, SourcesContent: []*string{&src}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	smFile := filepath.Join(dir, "bundle.js.map")
+	if err := ioutil.WriteFile(smFile, sm, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if errs := be.ClassifyLicenses(1, []string{bundle, smFile}, false); len(errs) != 0 {
+		t.Fatalf("ClassifyLicenses() returned errors: %v", errs)
+	}
+
+	type found struct {
+		File           string
+		Name           string
+		StartLine      int
+		Offset, Extent int
+		Source         string
+	}
+	var got []found
+	for _, r := range be.GetResults() {
+		if r.MatchType == "License" {
+			got = append(got, found{filepath.Base(r.Filename), r.Name, r.StartLine, r.Offset, r.Extent, r.Source})
+		}
+	}
+	want := []found{
+		{File: "bundle.js", Name: "MIT", StartLine: 5, Offset: len(prefix), Extent: len(comment)},
+		{File: "bundle.js.map", Name: "MIT", StartLine: 1, Source: "webpack:///lib.js"},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("GetResults() mismatch (-want +got):\n%s", diff)
+	}
+}
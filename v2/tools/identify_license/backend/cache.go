@@ -0,0 +1,200 @@
+// Copyright 2022 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package backend
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/gob"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"runtime/debug"
+	"strings"
+	"sync"
+
+	classifier "github.com/google/licenseclassifier/v2"
+	"github.com/google/licenseclassifier/v2/tools/version"
+)
+
+// cacheVersion is the version of the format written by WriteCache, which
+// changes whenever the data saved or its meaning does.
+const cacheVersion = 1
+
+// ErrStaleCache is returned by NewFromCache for a cache written by another
+// version of the tool, or for another license corpus, whose results can't be
+// reused.
+var ErrStaleCache = errors.New("cache was written for another version of the tool or license corpus")
+
+// savedCache is the form of the cache written by WriteCache: the indexed
+// license corpus, so that it needn't be indexed again, and the matches of the
+// contents classified, so that unchanged files needn't be classified again.
+type savedCache struct {
+	Version int
+	Key     string
+	Corpus  []byte
+	// Matches holds the matches of the contents classified, by the hash of
+	// the contents.
+	Matches map[string]classifier.Matches
+}
+
+// scanCache holds the matches of the contents classified by the hash of the
+// contents. The matches of contents found in the cache read are reused; the
+// matches of the contents classified in this scan are the ones written, so
+// that the cache doesn't grow with contents that are no longer scanned.
+type scanCache struct {
+	key string
+
+	mu   sync.Mutex
+	read map[string]classifier.Matches
+	seen map[string]classifier.Matches
+	hits int
+}
+
+// get returns the matches cached for the contents with the hash, if any.
+func (s *scanCache) get(hash string) (classifier.Matches, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	ms, ok := s.read[hash]
+	if ok {
+		s.hits++
+		s.seen[hash] = ms
+	}
+	return ms, ok
+}
+
+// put records the matches of the contents with the hash.
+func (s *scanCache) put(hash string, ms classifier.Matches) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.seen[hash] = ms
+}
+
+// CacheKey returns the key of the caches of scans with the license corpus
+// directories, with or without the embedded corpus: a digest of the build of
+// the tool, of the embedded corpus and of the files of the directories. A
+// cache is only reused by scans with the same key.
+func CacheKey(dirs []string, defaultCorpus bool) (string, error) {
+	v, err := version.GetForCorpus(dirs, defaultCorpus)
+	if err != nil {
+		return "", err
+	}
+	build, err := buildID()
+	if err != nil {
+		return "", err
+	}
+	h := sha256.New()
+	fmt.Fprintf(h, "%d\x00%s\x00%s\x00%s\x00%t\x00", cacheVersion, v.Commit, build, v.Corpus, defaultCorpus)
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// buildID identifies the build of the tool, so that caches aren't reused
+// across upgrades of the tool or of the classifier it's built with. The commit
+// is only known to builds stamped with -ldflags, so the versions and checksums
+// of the modules the tool is built from are used, or a digest of the
+// executable itself for builds from a working tree, whose versions are
+// unknown.
+func buildID() (string, error) {
+	var b strings.Builder
+	info, ok := debug.ReadBuildInfo()
+	if ok && info.Main.Version != "" && info.Main.Version != "(devel)" {
+		fmt.Fprintf(&b, "%s@%s %s\n", info.Main.Path, info.Main.Version, info.Main.Sum)
+		for _, m := range info.Deps {
+			if m.Replace != nil {
+				m = m.Replace
+			}
+			fmt.Fprintf(&b, "%s@%s %s\n", m.Path, m.Version, m.Sum)
+		}
+		return b.String(), nil
+	}
+	exe, err := os.Executable()
+	if err != nil {
+		return "", err
+	}
+	f, err := os.Open(exe)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// NewFromCache creates a new backend from a cache written by WriteCache for
+// the key, as returned by CacheKey. The backend matches against the license
+// corpus of the cache, without indexing it again, and reuses the matches of
+// files whose contents were classified by the scan that wrote it. A cache
+// written with another key is rejected with ErrStaleCache.
+func NewFromCache(r io.Reader, key string) (*ClassifierBackend, error) {
+	var saved savedCache
+	if err := gob.NewDecoder(r).Decode(&saved); err != nil {
+		return nil, fmt.Errorf("invalid cache: %w", err)
+	}
+	if saved.Version != cacheVersion || saved.Key != key {
+		return nil, ErrStaleCache
+	}
+	lc := classifier.NewClassifier(defaultThreshold)
+	if err := lc.LoadCorpus(bytes.NewReader(saved.Corpus)); err != nil {
+		return nil, fmt.Errorf("invalid cache: %w", err)
+	}
+	b := newBackend(lc)
+	b.SetCacheKey(key)
+	b.cache.read = saved.Matches
+	return b, nil
+}
+
+// SetCacheKey enables recording the matches of the contents classified, to be
+// written with WriteCache under the key, as returned by CacheKey.
+func (b *ClassifierBackend) SetCacheKey(key string) {
+	b.cache = &scanCache{key: key, seen: make(map[string]classifier.Matches)}
+}
+
+// CacheHits returns the number of contents whose matches were reused from the
+// cache the backend was created from.
+func (b *ClassifierBackend) CacheHits() int {
+	if b.cache == nil {
+		return 0
+	}
+	b.cache.mu.Lock()
+	defer b.cache.mu.Unlock()
+	return b.cache.hits
+}
+
+// WriteCache writes the license corpus of the backend and the matches of the
+// contents it classified, so that a later scan, such as that of the next run
+// of a CI job, can be created from them with NewFromCache. Recording matches
+// must have been enabled with SetCacheKey before classifying.
+func (b *ClassifierBackend) WriteCache(w io.Writer) error {
+	if b.cache == nil {
+		return errors.New("no cache key set")
+	}
+	var corpus bytes.Buffer
+	if err := b.classifier.SaveCorpus(&corpus); err != nil {
+		return err
+	}
+	b.cache.mu.Lock()
+	defer b.cache.mu.Unlock()
+	return gob.NewEncoder(w).Encode(&savedCache{
+		Version: cacheVersion,
+		Key:     b.cache.key,
+		Corpus:  corpus.Bytes(),
+		Matches: b.cache.seen,
+	})
+}
@@ -0,0 +1,143 @@
+// Copyright 2022 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package backend
+
+import (
+	"bytes"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// mitText is the MIT license, from the corpus of the classifier.
+const mitText = "../../../assets/License/MIT/pristine.txt"
+
+// writeCorpus writes a license corpus directory holding the MIT license to
+// dir, and returns its path.
+func writeCorpus(t *testing.T, dir string) string {
+	t.Helper()
+	mit, err := ioutil.ReadFile(mitText)
+	if err != nil {
+		t.Fatalf("couldn't read MIT license: %v", err)
+	}
+	corpus := filepath.Join(dir, "corpus")
+	if err := os.MkdirAll(filepath.Join(corpus, "License", "MIT"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(corpus, "License", "MIT", "license.txt"), mit, 0644); err != nil {
+		t.Fatal(err)
+	}
+	return corpus
+}
+
+func TestCacheKey(t *testing.T) {
+	corpus := writeCorpus(t, t.TempDir())
+	key, err := CacheKey([]string{corpus}, false)
+	if err != nil {
+		t.Fatalf("CacheKey() returned error: %v", err)
+	}
+	if again, err := CacheKey([]string{corpus}, false); err != nil || again != key {
+		t.Errorf("CacheKey() = %q, %v, want %q", again, err, key)
+	}
+	if other, err := CacheKey([]string{corpus}, true); err != nil || other == key {
+		t.Errorf("CacheKey() with the embedded corpus = %q, %v, want a different key", other, err)
+	}
+
+	// Editing a license of the corpus changes the key.
+	f := filepath.Join(corpus, "License", "MIT", "license.txt")
+	b, err := ioutil.ReadFile(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(f, append(b, "Extra terms.\n"...), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if edited, err := CacheKey([]string{corpus}, false); err != nil || edited == key {
+		t.Errorf("CacheKey() of an edited corpus = %q, %v, want a different key", edited, err)
+	}
+}
+
+func TestBuildID(t *testing.T) {
+	id, err := buildID()
+	if err != nil {
+		t.Fatalf("buildID() returned error: %v", err)
+	}
+	if id == "" {
+		t.Error("buildID() is empty")
+	}
+}
+
+func TestCacheRoundTrip(t *testing.T) {
+	dir := t.TempDir()
+	corpus := writeCorpus(t, dir)
+	mit, err := ioutil.ReadFile(mitText)
+	if err != nil {
+		t.Fatalf("couldn't read MIT license: %v", err)
+	}
+	file := filepath.Join(dir, "LICENSE")
+	if err := ioutil.WriteFile(file, mit, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	key, err := CacheKey([]string{corpus}, false)
+	if err != nil {
+		t.Fatalf("CacheKey() returned error: %v", err)
+	}
+	be, err := NewWithCorpus([]string{corpus}, false, false)
+	if err != nil {
+		t.Fatalf("NewWithCorpus() returned error: %v", err)
+	}
+	be.SetCacheKey(key)
+	if errs := be.ClassifyLicenses(1, []string{file}, false); len(errs) != 0 {
+		t.Fatalf("ClassifyLicenses() returned errors: %v", errs)
+	}
+	want := be.GetResults()
+	if len(want) == 0 {
+		t.Fatal("ClassifyLicenses() found no licenses")
+	}
+	var buf bytes.Buffer
+	if err := be.WriteCache(&buf); err != nil {
+		t.Fatalf("WriteCache() returned error: %v", err)
+	}
+
+	cached, err := NewFromCache(bytes.NewReader(buf.Bytes()), key)
+	if err != nil {
+		t.Fatalf("NewFromCache() returned error: %v", err)
+	}
+	if errs := cached.ClassifyLicenses(1, []string{file}, false); len(errs) != 0 {
+		t.Fatalf("ClassifyLicenses() from the cache returned errors: %v", errs)
+	}
+	if got := cached.CacheHits(); got != 1 {
+		t.Errorf("CacheHits() = %d, want 1", got)
+	}
+	got := cached.GetResults()
+	if len(got) != len(want) {
+		t.Fatalf("GetResults() from the cache = %d results, want %d", len(got), len(want))
+	}
+	for i := range want {
+		if got[i].Name != want[i].Name || got[i].Confidence != want[i].Confidence {
+			t.Errorf("GetResults()[%d] from the cache = %s (%v), want %s (%v)", i, got[i].Name, got[i].Confidence, want[i].Name, want[i].Confidence)
+		}
+	}
+
+	if _, err := NewFromCache(bytes.NewReader(buf.Bytes()), "other"); !errors.Is(err, ErrStaleCache) {
+		t.Errorf("NewFromCache() with another key = %v, want ErrStaleCache", err)
+	}
+	if _, err := NewFromCache(bytes.NewReader([]byte("garbage")), key); err == nil {
+		t.Error("NewFromCache() of an invalid cache succeeded")
+	}
+}
@@ -0,0 +1,146 @@
+// Copyright 2022 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package backend
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"runtime/debug"
+	"sort"
+	"strings"
+
+	classifier "github.com/google/licenseclassifier/v2"
+)
+
+// Categories of errors reported by ErrorStats.
+const (
+	ErrorNotExist   = "not-exist"
+	ErrorPermission = "permission"
+	ErrorContext    = "context"
+	ErrorPanic      = "panic"
+	ErrorOther      = "other"
+)
+
+// errorCategory returns the category an error is counted under.
+func errorCategory(err error) string {
+	var pe *classifier.PanicError
+	switch {
+	case errors.As(err, &pe):
+		return ErrorPanic
+	case errors.Is(err, fs.ErrNotExist):
+		return ErrorNotExist
+	case errors.Is(err, fs.ErrPermission):
+		return ErrorPermission
+	case errors.Is(err, context.Canceled), errors.Is(err, context.DeadlineExceeded):
+		return ErrorContext
+	default:
+		return ErrorOther
+	}
+}
+
+// ErrorBudgetError is returned when more files fail to be classified than the
+// error budget of the backend allows. The files that weren't attempted before
+// the scan was aborted aren't counted as failures.
+type ErrorBudgetError struct {
+	Failed int     // The number of files that failed
+	Total  int     // The number of files in the scan
+	Budget float64 // The fraction of files allowed to fail
+	// Stats counts the failures by category.
+	Stats map[string]int
+}
+
+func (e *ErrorBudgetError) Error() string {
+	var categories []string
+	for c, n := range e.Stats {
+		categories = append(categories, fmt.Sprintf("%s: %d", c, n))
+	}
+	sort.Strings(categories)
+	return fmt.Sprintf("aborted after %d of %d files failed, exceeding the error budget of %v (%s)",
+		e.Failed, e.Total, e.Budget, strings.Join(categories, ", "))
+}
+
+// SetErrorBudget sets the fraction of files, between 0 and 1, that may fail to
+// be classified before a scan is aborted. When the budget is exceeded, no
+// further files are classified and the errors returned end with an
+// *ErrorBudgetError summarizing the failures. The default budget of 1 never
+// aborts a scan.
+func (b *ClassifierBackend) SetErrorBudget(fraction float64) {
+	b.errorBudget = fraction
+}
+
+// ErrorStats returns the number of errors encountered by the backend so far,
+// by category.
+func (b *ClassifierBackend) ErrorStats() map[string]int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	out := make(map[string]int, len(b.errorStats))
+	for c, n := range b.errorStats {
+		out[c] = n
+	}
+	return out
+}
+
+// classifyFile classifies a file like classifyLicense, reporting a panic while
+// classifying it, or while matching one of the licenses in it, as an error for
+// the file wrapping a *classifier.PanicError, so that the other files are still
+// classified. The results found in the file apart from the failed work are
+// kept.
+func (b *ClassifierBackend) classifyFile(ctx context.Context, filename string, headers bool) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("unable to classify %q: %w", filename, &classifier.PanicError{Value: r, Stack: debug.Stack()})
+		}
+	}()
+	if err := b.classifyLicense(ctx, filename, headers); err != nil {
+		return err
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if err, ok := b.matchErrors[filename]; ok {
+		delete(b.matchErrors, filename)
+		return fmt.Errorf("unable to classify %q: %w", filename, err)
+	}
+	return nil
+}
+
+// canceled reports whether the error is that of the context being done.
+func canceled(ctx context.Context, err error) bool {
+	return ctx.Err() != nil && errors.Is(err, ctx.Err())
+}
+
+// noteMatchError records the first error, such as a recovered panic, of matching
+// the contents of a file, to be reported by classifyFile.
+func (b *ClassifierBackend) noteMatchError(filename string, err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.matchErrors == nil {
+		b.matchErrors = make(map[string]error)
+	}
+	if _, ok := b.matchErrors[filename]; !ok {
+		b.matchErrors[filename] = err
+	}
+}
+
+// recordError counts the error in the statistics of the backend.
+func (b *ClassifierBackend) recordError(err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.errorStats == nil {
+		b.errorStats = make(map[string]int)
+	}
+	b.errorStats[errorCategory(err)]++
+}
@@ -0,0 +1,185 @@
+// Copyright 2022 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package backend
+
+import (
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/google/licenseclassifier/v2/tools/identify_license/results"
+)
+
+// SkippedBinary is the reason given for skipping a file whose sniffed MIME
+// type can't hold license text. The Variant of the result is the MIME type.
+const SkippedBinary = "Binary"
+
+// sniffLen is the number of bytes at the start of a file used to determine
+// its MIME type.
+const sniffLen = 512
+
+// binaryTypes are the prefixes of the MIME types of files that are skipped
+// when sniffing.
+var binaryTypes = []string{
+	"application/octet-stream",
+	"application/pdf",
+	"application/vnd.ms-fontobject",
+	"application/wasm",
+	"audio/",
+	"font/",
+	"image/",
+	"video/",
+}
+
+// archiveTypes are the MIME types of archives, which are skipped unless
+// archive scanning is enabled.
+var archiveTypes = []string{
+	"application/x-gzip",
+	"application/x-rar-compressed",
+	"application/x-tar",
+	"application/zip",
+}
+
+// archiveExtensions are the extensions of archives, whose MIME types can't all
+// be sniffed.
+var archiveExtensions = map[string]bool{
+	".7z":  true,
+	".aar": true,
+	".jar": true,
+	".tar": true,
+	".tgz": true,
+	".war": true,
+	".whl": true,
+}
+
+// SetExtensions limits the files classified by their extensions, such as
+// ".go": if include isn't empty, only files with one of its extensions are
+// classified, and files with one of the extensions in exclude never are.
+// Files left out by their extension are skipped without being read or
+// reported.
+func (b *ClassifierBackend) SetExtensions(include, exclude []string) {
+	b.includeExts = extensionSet(include)
+	b.excludeExts = extensionSet(exclude)
+}
+
+// SetSniffing sets whether the MIME type of each file is sniffed from its
+// first bytes, and files that can't hold license text, such as images, media,
+// fonts and other binaries, are skipped and reported with a single result with
+// the SkippedMatchType, so that scans of trees with large binary assets don't
+// spend most of their time on them. It's disabled by default.
+func (b *ClassifierBackend) SetSniffing(enabled bool) {
+	b.sniffing = enabled
+}
+
+// SetArchives sets whether archives such as zip and tar files are classified
+// rather than skipped when sniffing. They're classified as they are, without
+// being unpacked, which finds the licenses in uncompressed archives such as
+// tar files.
+func (b *ClassifierBackend) SetArchives(enabled bool) {
+	b.archives = enabled
+}
+
+// extensionSet returns the set of the extensions, normalized to lower case
+// with a leading dot, or nil if there are none. Empty extensions are ignored.
+func extensionSet(exts []string) map[string]bool {
+	var set map[string]bool
+	for _, e := range exts {
+		if e = strings.ToLower(strings.TrimSpace(e)); e == "" {
+			continue
+		}
+		if !strings.HasPrefix(e, ".") {
+			e = "." + e
+		}
+		if set == nil {
+			set = make(map[string]bool)
+		}
+		set[e] = true
+	}
+	return set
+}
+
+// excluded reports whether the file is left out by its extension.
+func (b *ClassifierBackend) excluded(filename string) bool {
+	ext := strings.ToLower(filepath.Ext(filename))
+	if b.includeExts != nil && !b.includeExts[ext] {
+		return true
+	}
+	return b.excludeExts[ext]
+}
+
+// hasSidecar reports whether the file is described by a REUSE-style sidecar
+// file whose results are attributed to it.
+func (b *ClassifierBackend) hasSidecar(filename string) bool {
+	if !b.sidecars {
+		return false
+	}
+	info, err := os.Stat(filename + ".license")
+	return err == nil && info.Mode().IsRegular()
+}
+
+// sniff returns the MIME type of contents starting with head, as determined
+// from their first bytes.
+func sniff(head []byte) string {
+	if len(head) == 0 {
+		return "text/plain"
+	}
+	if len(head) > sniffLen {
+		head = head[:sniffLen]
+	}
+	return http.DetectContentType(head)
+}
+
+// skipUnclassifiable reports whether the file, whose contents start with head,
+// is skipped when sniffing, and records the result of skipping it.
+func (b *ClassifierBackend) skipUnclassifiable(filename string, head []byte) bool {
+	if !b.sniffing {
+		return false
+	}
+	mimeType := sniff(head)
+	if !b.unclassifiable(filename, mimeType) {
+		return false
+	}
+	// The license of a file described by a sidecar file is reported from
+	// the sidecar.
+	if !b.hasSidecar(filename) {
+		b.addResult(&results.LicenseType{
+			Filename:  filename,
+			Name:      SkippedBinary,
+			Variant:   mimeType,
+			MatchType: SkippedMatchType,
+		})
+	}
+	return true
+}
+
+// unclassifiable reports whether the file, of the sniffed MIME type, is
+// skipped.
+func (b *ClassifierBackend) unclassifiable(filename, mimeType string) bool {
+	if archiveExtensions[strings.ToLower(filepath.Ext(filename))] {
+		return !b.archives
+	}
+	for _, t := range archiveTypes {
+		if mimeType == t {
+			return !b.archives
+		}
+	}
+	for _, t := range binaryTypes {
+		if strings.HasPrefix(mimeType, t) {
+			return true
+		}
+	}
+	return false
+}
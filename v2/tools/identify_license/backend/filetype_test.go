@@ -0,0 +1,196 @@
+// Copyright 2022 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package backend
+
+import (
+	"bytes"
+	"io/ioutil"
+	"path/filepath"
+	"sort"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestSniff(t *testing.T) {
+	tests := []struct {
+		name string
+		head []byte
+		want string
+	}{
+		{"empty", nil, "text/plain"},
+		{"text", []byte("Copyright 2022 Google Inc."), "text/plain; charset=utf-8"},
+		{"png", []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR"), "image/png"},
+		{"zip", []byte("PK\x03\x04\x14\x00"), "application/zip"},
+		// Bytes past those sniffed don't change the type.
+		{"long text", append(bytes.Repeat([]byte("a"), sniffLen), 0), "text/plain; charset=utf-8"},
+	}
+	for _, tt := range tests {
+		if got := sniff(tt.head); got != tt.want {
+			t.Errorf("sniff(%s) = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestSniffing(t *testing.T) {
+	dir := t.TempDir()
+	mit, err := ioutil.ReadFile(mitText)
+	if err != nil {
+		t.Fatalf("couldn't read MIT license: %v", err)
+	}
+	// The license text in an image's metadata is found unless binaries are
+	// skipped.
+	png := filepath.Join(dir, "logo.png")
+	if err := ioutil.WriteFile(png, append([]byte("\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR\n"), mit...), 0644); err != nil {
+		t.Fatal(err)
+	}
+	zip := filepath.Join(dir, "vendor.zip")
+	if err := ioutil.WriteFile(zip, append([]byte("PK\x03\x04\x14\x00\n"), mit...), 0644); err != nil {
+		t.Fatal(err)
+	}
+	files := append(writeFiles(t, dir, 1), png, zip)
+
+	tests := []struct {
+		name     string
+		sniffing bool
+		archives bool
+		want     []string
+	}{
+		{
+			name: "default",
+			want: []string{"LICENSE0 License:MIT", "logo.png License:MIT", "vendor.zip License:MIT"},
+		},
+		{
+			name:     "sniffing",
+			sniffing: true,
+			want:     []string{"LICENSE0 License:MIT", "logo.png Skipped:Binary:image/png", "vendor.zip Skipped:Binary:application/zip"},
+		},
+		{
+			name:     "sniffing with archives",
+			sniffing: true,
+			archives: true,
+			want:     []string{"LICENSE0 License:MIT", "logo.png Skipped:Binary:image/png", "vendor.zip License:MIT"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			be, err := NewWithCorpus([]string{writeCorpus(t, t.TempDir())}, false, false)
+			if err != nil {
+				t.Fatalf("NewWithCorpus() returned error: %v", err)
+			}
+			defer be.Close()
+			be.SetQuiet(true)
+			if tt.sniffing {
+				be.SetSniffing(true)
+			}
+			be.SetArchives(tt.archives)
+			if errs := be.ClassifyLicenses(1, files, false); len(errs) != 0 {
+				t.Fatalf("ClassifyLicenses() returned errors: %v", errs)
+			}
+			var got []string
+			for _, r := range be.GetResults() {
+				s := filepath.Base(r.Filename) + " " + r.MatchType + ":" + r.Name
+				if r.MatchType == SkippedMatchType {
+					s += ":" + r.Variant
+				}
+				got = append(got, s)
+			}
+			sort.Strings(got)
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("GetResults() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestSidecars(t *testing.T) {
+	dir := t.TempDir()
+	mit, err := ioutil.ReadFile(mitText)
+	if err != nil {
+		t.Fatalf("couldn't read MIT license: %v", err)
+	}
+	png := filepath.Join(dir, "logo.png")
+	if err := ioutil.WriteFile(png, []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	// A sidecar is attributed to the file it describes, and one describing
+	// no file is reported for itself.
+	var files []string
+	for _, name := range []string{"logo.png.license", "missing.png.license"} {
+		f := filepath.Join(dir, name)
+		if err := ioutil.WriteFile(f, mit, 0644); err != nil {
+			t.Fatal(err)
+		}
+		files = append(files, f)
+	}
+	files = append(files, png)
+
+	tests := []struct {
+		name     string
+		sidecars bool
+		sniffing bool
+		want     []string
+	}{
+		{
+			name: "default",
+			want: []string{"logo.png.license License:MIT", "missing.png.license License:MIT"},
+		},
+		{
+			name:     "sidecars",
+			sidecars: true,
+			want:     []string{"logo.png License:MIT (logo.png.license)", "missing.png.license License:MIT"},
+		},
+		{
+			name:     "sidecars with sniffing",
+			sidecars: true,
+			sniffing: true,
+			want:     []string{"logo.png License:MIT (logo.png.license)", "missing.png.license License:MIT"},
+		},
+		{
+			name:     "sniffing",
+			sniffing: true,
+			want:     []string{"logo.png Skipped:Binary", "logo.png.license License:MIT", "missing.png.license License:MIT"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			be, err := NewWithCorpus([]string{writeCorpus(t, t.TempDir())}, false, false)
+			if err != nil {
+				t.Fatalf("NewWithCorpus() returned error: %v", err)
+			}
+			defer be.Close()
+			be.SetQuiet(true)
+			if tt.sidecars {
+				be.SetSidecars(true)
+			}
+			be.SetSniffing(tt.sniffing)
+			if errs := be.ClassifyLicenses(1, files, false); len(errs) != 0 {
+				t.Fatalf("ClassifyLicenses() returned errors: %v", errs)
+			}
+			var got []string
+			for _, r := range be.GetResults() {
+				s := filepath.Base(r.Filename) + " " + r.MatchType + ":" + r.Name
+				if r.Sidecar != "" {
+					s += " (" + filepath.Base(r.Sidecar) + ")"
+				}
+				got = append(got, s)
+			}
+			sort.Strings(got)
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("GetResults() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
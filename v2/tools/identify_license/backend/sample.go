@@ -0,0 +1,121 @@
+// Copyright 2022 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package backend
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"os"
+	"unicode/utf8"
+
+	"github.com/google/licenseclassifier/v2/tools/identify_license/results"
+)
+
+// SetSampling sets the backend to classify only the first head and the last
+// tail bytes of files larger than their sum, since the license of a file is
+// almost always found at its start or end, rather than read giant files in
+// full. The samples are cut at line boundaries where possible, and never
+// inside a UTF-8 sequence. Results found in a sampled file are marked as
+// Sampled, and those found in its tail carry the offset of the tail, since
+// their lines are counted from the start of the tail. A head of 0, the
+// default, classifies files in full.
+func (b *ClassifierBackend) SetSampling(head, tail int) {
+	b.sampleHead, b.sampleTail = head, tail
+}
+
+// sampled reports whether the file is large enough to be sampled.
+func (b *ClassifierBackend) sampled(filename string) (bool, error) {
+	if b.sampleHead <= 0 {
+		return false, nil
+	}
+	info, err := os.Stat(filename)
+	if err != nil {
+		return false, err
+	}
+	return info.Size() > int64(b.sampleHead+b.sampleTail), nil
+}
+
+// matchSampled classifies the head and tail of a file too large to be read in
+// full, each as the whole of a smaller file would be.
+func (b *ClassifierBackend) matchSampled(ctx context.Context, filename string, headers bool) error {
+	f, err := os.Open(filename)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return err
+	}
+
+	head := make([]byte, b.sampleHead)
+	if _, err := io.ReadFull(f, head); err != nil {
+		return err
+	}
+	if b.skipUnclassifiable(filename, head) {
+		return nil
+	}
+	b.classifyContents(ctx, filename, trimHead(head), headers, func(r *results.LicenseType) {
+		r.Sampled = true
+	})
+	if b.sampleTail <= 0 {
+		return nil
+	}
+
+	offset := info.Size() - int64(b.sampleTail)
+	tail := make([]byte, b.sampleTail)
+	if _, err := f.ReadAt(tail, offset); err != nil && err != io.EOF {
+		return err
+	}
+	skip := trimTail(tail)
+	offset += int64(skip)
+	b.classifyContents(ctx, filename, tail[skip:], headers, func(r *results.LicenseType) {
+		r.Sampled = true
+		r.SampleOffset = offset
+	})
+	return nil
+}
+
+// trimHead cuts the head of a file after its last complete line, or failing
+// that its last complete UTF-8 sequence.
+func trimHead(b []byte) []byte {
+	if i := bytes.LastIndexByte(b, '\n'); i != -1 {
+		return b[:i+1]
+	}
+	for i := len(b) - 1; i >= 0 && i >= len(b)-utf8.UTFMax; i-- {
+		if utf8.RuneStart(b[i]) {
+			if !utf8.FullRune(b[i:]) {
+				return b[:i]
+			}
+			break
+		}
+	}
+	return b
+}
+
+// trimTail returns the number of bytes to drop from the start of the tail of
+// a file so that it starts with its first complete line, or failing that its
+// first complete UTF-8 sequence.
+func trimTail(b []byte) int {
+	if i := bytes.IndexByte(b, '\n'); i != -1 {
+		return i + 1
+	}
+	i := 0
+	for i < len(b) && i < utf8.UTFMax && !utf8.RuneStart(b[i]) {
+		i++
+	}
+	return i
+}
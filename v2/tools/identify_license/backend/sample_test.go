@@ -0,0 +1,123 @@
+// Copyright 2022 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package backend
+
+import (
+	"bytes"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+)
+
+func TestTrimHead(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{"abc\ndef\ngh", "abc\ndef\n"},
+		{"abc\n", "abc\n"},
+		{"abc", "abc"},
+		{"abcé", "abcé"},
+		// A sequence cut short by the end of the head is dropped.
+		{"abc\xc3", "abc"},
+		{"abc\xe2\x82", "abc"},
+		{"", ""},
+	}
+	for _, tt := range tests {
+		if got := string(trimHead([]byte(tt.in))); got != tt.want {
+			t.Errorf("trimHead(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestTrimTail(t *testing.T) {
+	tests := []struct {
+		in   string
+		want int
+	}{
+		{"gh\nabc\ndef", 3},
+		{"\nabc", 1},
+		{"abc", 0},
+		{"éabc", 0},
+		// A sequence whose start was cut off by the start of the tail is
+		// dropped.
+		{"\xa9abc", 1},
+		{"\x82\xacabc", 2},
+		{"", 0},
+	}
+	for _, tt := range tests {
+		if got := trimTail([]byte(tt.in)); got != tt.want {
+			t.Errorf("trimTail(%q) = %d, want %d", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestMatchSampled(t *testing.T) {
+	dir := t.TempDir()
+	mit, err := ioutil.ReadFile(mitText)
+	if err != nil {
+		t.Fatalf("couldn't read MIT license: %v", err)
+	}
+	filler := bytes.Repeat([]byte("filler\n"), 4096)
+
+	// The license is at the start of one file and the end of the other.
+	head := filepath.Join(dir, "head.txt")
+	if err := ioutil.WriteFile(head, append(append([]byte(nil), mit...), filler...), 0644); err != nil {
+		t.Fatal(err)
+	}
+	tail := filepath.Join(dir, "tail.txt")
+	if err := ioutil.WriteFile(tail, append(append([]byte(nil), filler...), mit...), 0644); err != nil {
+		t.Fatal(err)
+	}
+	// The head of a file is skipped as minified as the whole of a smaller
+	// file would be.
+	minified := filepath.Join(dir, "minified.js")
+	if err := ioutil.WriteFile(minified, bytes.Repeat([]byte("x"), 3*len(filler)), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	be, err := NewWithCorpus([]string{writeCorpus(t, dir)}, false, false)
+	if err != nil {
+		t.Fatalf("NewWithCorpus() returned error: %v", err)
+	}
+	defer be.Close()
+	be.SetQuiet(true)
+	be.SetSampling(len(mit)+64, len(mit)+64)
+	be.SetMaxAverageLineLength(1000)
+	if errs := be.ClassifyLicenses(1, []string{head, tail, minified}, false); len(errs) != 0 {
+		t.Fatalf("ClassifyLicenses() returned errors: %v", errs)
+	}
+
+	got := make(map[string]int64)
+	for _, r := range be.GetResults() {
+		if !r.Sampled {
+			t.Errorf("result %s in %s isn't marked as sampled", r.Name, r.Filename)
+		}
+		got[filepath.Base(r.Filename)+" "+r.Name] = r.SampleOffset
+	}
+	if off, ok := got["head.txt MIT"]; !ok || off != 0 {
+		t.Errorf("MIT in the head: found %v at offset %d, want found at offset 0", ok, off)
+	}
+	// The tail starts after the first line break within it, which is the end
+	// of a line of filler.
+	line := int64(len("filler\n"))
+	wantOffset := (int64(len(filler)-64)/line + 1) * line
+	if off, ok := got["tail.txt MIT"]; !ok || off != wantOffset {
+		t.Errorf("MIT in the tail: found %v at offset %d, want found at offset %d", ok, off, wantOffset)
+	}
+	if _, ok := got["minified.js "+SkippedMinified]; !ok {
+		t.Errorf("minified.js wasn't skipped as minified: %v", got)
+	}
+}
@@ -0,0 +1,109 @@
+// Copyright 2022 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package backend
+
+import (
+	"bytes"
+	"regexp"
+
+	"github.com/google/licenseclassifier/v2/tools/identify_license/results"
+)
+
+var (
+	snippetBeginRE = regexp.MustCompile(`\bSPDX-SnippetBegin\b`)
+	snippetEndRE   = regexp.MustCompile(`\bSPDX-SnippetEnd\b`)
+	// spdxIDRE captures the license expression of an SPDX-License-Identifier
+	// tag, excluding the end of a comment that may follow it on the line.
+	spdxIDRE = regexp.MustCompile(`\bSPDX-License-Identifier:\s*(.*?)\s*(?:\*/|-->|\*\)|$)`)
+)
+
+// snippet is a region of a file delimited by SPDX-SnippetBegin and
+// SPDX-SnippetEnd markers. Line numbers are 1-based and include the lines
+// carrying the markers.
+type snippet struct {
+	startLine, endLine int
+}
+
+// annotate records the bounds of the snippet in a result found in it.
+func (s *snippet) annotate(r *results.LicenseType) {
+	r.SnippetStartLine, r.SnippetEndLine = s.startLine, s.endLine
+}
+
+// findSnippets returns the snippets of a file in order. A begin marker without
+// a matching end marker extends to the end of the file; nested markers are
+// ignored, since SPDX snippets don't nest.
+func findSnippets(lines [][]byte) []snippet {
+	var out []snippet
+	start := 0
+	for i, l := range lines {
+		switch {
+		case start == 0 && snippetBeginRE.Match(l):
+			start = i + 1
+		case start != 0 && snippetEndRE.Match(l):
+			out = append(out, snippet{startLine: start, endLine: i + 1})
+			start = 0
+		}
+	}
+	if start != 0 {
+		out = append(out, snippet{startLine: start, endLine: len(lines)})
+	}
+	return out
+}
+
+// spdxID is a license expression declared by an SPDX-License-Identifier tag.
+type spdxID struct {
+	line       int
+	expression string
+}
+
+// spdxIdentifiers returns the SPDX-License-Identifier tags in the lines, with
+// 1-based line numbers relative to the first of the lines.
+func spdxIdentifiers(lines [][]byte) []spdxID {
+	var out []spdxID
+	for i, l := range lines {
+		if m := spdxIDRE.FindSubmatch(l); m != nil && len(m[1]) > 0 {
+			out = append(out, spdxID{line: i + 1, expression: string(m[1])})
+		}
+	}
+	return out
+}
+
+// splitLines splits contents into lines, keeping the line terminators so the
+// lines can be joined back into the original contents.
+func splitLines(contents []byte) [][]byte {
+	return bytes.SplitAfter(contents, []byte("\n"))
+}
+
+// blankSnippets returns a copy of the contents with the lines of the snippets
+// emptied, preserving the line numbering of the rest of the file.
+func blankSnippets(lines [][]byte, snippets []snippet) []byte {
+	inSnippet := make([]bool, len(lines)+1)
+	for _, s := range snippets {
+		for l := s.startLine; l <= s.endLine; l++ {
+			inSnippet[l] = true
+		}
+	}
+	var out []byte
+	for i, l := range lines {
+		if inSnippet[i+1] {
+			if bytes.HasSuffix(l, []byte("\n")) {
+				out = append(out, '\n')
+			}
+			continue
+		}
+		out = append(out, l...)
+	}
+	return out
+}
@@ -0,0 +1,99 @@
+// Copyright 2022 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package backend
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"sort"
+
+	"github.com/google/licenseclassifier/v2/tools/identify_license/results"
+)
+
+// ClassifySplitLicenses looks for licenses whose text is split across several
+// license files in a single directory, such as a LICENSE file accompanied by a
+// COPYING.extra holding the warranty disclaimer. The license files of each
+// directory are classified as a single document, and any license found that
+// way but not in an individual file is reported as a directory-level result:
+// its Filename is the directory and its line numbers are zero.
+//
+// This should be called after ClassifyLicenses so that licenses detected in
+// the individual files are known.
+func (b *ClassifierBackend) ClassifySplitLicenses(filenames []string) []error {
+	dirs := make(map[string][]string)
+	for _, f := range filenames {
+		if results.IsLicenseFile(f) {
+			d := filepath.Dir(f)
+			dirs[d] = append(dirs[d], f)
+		}
+	}
+
+	b.mu.Lock()
+	found := make(map[string]bool)
+	for _, r := range b.results {
+		found[filepath.Dir(r.Filename)+"\x00"+r.Name] = true
+	}
+	b.mu.Unlock()
+
+	var errs []error
+	for dir, files := range dirs {
+		if len(files) < 2 {
+			continue
+		}
+		// Concatenate the parts with the most likely start of the license first,
+		// so LICENSE precedes COPYING.extra and LICENSE precedes LICENSE.extra.
+		sort.Slice(files, func(i, j int) bool {
+			ri, rj := results.LicenseFileRank(files[i]), results.LicenseFileRank(files[j])
+			if ri != rj {
+				return ri < rj
+			}
+			if len(files[i]) != len(files[j]) {
+				return len(files[i]) < len(files[j])
+			}
+			return files[i] < files[j]
+		})
+
+		var buf bytes.Buffer
+		for _, f := range files {
+			contents, err := ioutil.ReadFile(f)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("unable to read %q: %v", f, err))
+				continue
+			}
+			buf.Write(contents)
+			buf.WriteString("\n")
+		}
+
+		for _, m := range b.classifier.Match(buf.Bytes()).Matches {
+			if m.MatchType != "License" {
+				continue
+			}
+			if found[dir+"\x00"+m.Name] {
+				continue
+			}
+			found[dir+"\x00"+m.Name] = true
+			b.addResult(&results.LicenseType{
+				Filename:   dir,
+				MatchType:  m.MatchType,
+				Name:       m.Name,
+				Variant:    m.Variant,
+				Confidence: m.Confidence,
+			})
+		}
+	}
+	return errs
+}
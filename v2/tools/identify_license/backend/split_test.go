@@ -0,0 +1,102 @@
+// Copyright 2022 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package backend
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// writeSplitMIT writes the MIT license split into its grant, in licenseDir's
+// LICENSE, and its warranty disclaimer, in extraDir's COPYING.extra, and
+// returns the paths of the parts.
+func writeSplitMIT(t *testing.T, licenseDir, extraDir string) []string {
+	t.Helper()
+	mit, err := ioutil.ReadFile(mitText)
+	if err != nil {
+		t.Fatalf("couldn't read MIT license: %v", err)
+	}
+	i := strings.Index(string(mit), "THE SOFTWARE IS PROVIDED")
+	files := []string{filepath.Join(licenseDir, "LICENSE"), filepath.Join(extraDir, "COPYING.extra")}
+	for j, part := range []string{string(mit[:i]), string(mit[i:])} {
+		if err := os.MkdirAll(filepath.Dir(files[j]), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(files[j], []byte(part), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return files
+}
+
+func TestClassifySplitLicenses(t *testing.T) {
+	corpus := writeCorpus(t, t.TempDir())
+	tests := []struct {
+		name  string
+		files func(t *testing.T, dir string) []string
+		want  bool
+	}{
+		{
+			name: "parts in one directory",
+			files: func(t *testing.T, dir string) []string {
+				return writeSplitMIT(t, dir, dir)
+			},
+			want: true,
+		},
+		{
+			name: "parts in sibling directories",
+			files: func(t *testing.T, dir string) []string {
+				return writeSplitMIT(t, filepath.Join(dir, "a"), filepath.Join(dir, "b"))
+			},
+			want: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			be, err := NewWithCorpus([]string{corpus}, false, false)
+			if err != nil {
+				t.Fatalf("NewWithCorpus() returned error: %v", err)
+			}
+			defer be.Close()
+			be.SetQuiet(true)
+			dir := t.TempDir()
+			files := tt.files(t, dir)
+			if errs := be.ClassifyLicenses(1, files, false); len(errs) != 0 {
+				t.Fatalf("ClassifyLicenses() returned errors: %v", errs)
+			}
+			if res := be.GetResults(); len(res) != 0 {
+				t.Fatalf("ClassifyLicenses() of the parts = %v, want no results", res)
+			}
+			if errs := be.ClassifySplitLicenses(files); len(errs) != 0 {
+				t.Fatalf("ClassifySplitLicenses() returned errors: %v", errs)
+			}
+			var got bool
+			for _, r := range be.GetResults() {
+				if r.Name != "MIT" || r.Filename != dir || r.StartLine != 0 || r.EndLine != 0 {
+					t.Errorf("ClassifySplitLicenses() reported %+v, want only MIT for directory %s", r, dir)
+					continue
+				}
+				got = true
+			}
+			if got != tt.want {
+				t.Errorf("ClassifySplitLicenses() found MIT for the directory = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
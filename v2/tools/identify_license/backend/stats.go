@@ -0,0 +1,25 @@
+// Copyright 2022 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build !core
+// +build !core
+
+package backend
+
+import classifier "github.com/google/licenseclassifier/v2"
+
+// CorpusStats returns statistics on the license corpus of the backend.
+func (b *ClassifierBackend) CorpusStats() classifier.CorpusStats {
+	return b.classifier.Stats()
+}
@@ -0,0 +1,299 @@
+// Copyright 2022 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package backend
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/google/licenseclassifier/v2/tools/identify_license/results"
+)
+
+// Strategy selects the contents of a file that are classified.
+type Strategy int
+
+const (
+	// WholeFile classifies the whole file. It suits license files, READMEs
+	// and other text.
+	WholeFile Strategy = iota
+	// CommentsOnly classifies only the comments of a source file, so that
+	// code and string literals can't produce spurious matches.
+	CommentsOnly
+	// Both classifies the comments of a source file and then the whole file,
+	// so that licenses outside comments, such as in docstrings, are found
+	// too. Results of the whole file already found in the comments aren't
+	// reported twice. Each file is matched twice, so it takes about twice as
+	// long as the other strategies.
+	Both
+)
+
+var strategyNames = map[Strategy]string{
+	WholeFile:    "whole",
+	CommentsOnly: "comments",
+	Both:         "both",
+}
+
+// String returns the name used for the strategy on the command line.
+func (s Strategy) String() string {
+	if name, ok := strategyNames[s]; ok {
+		return name
+	}
+	return fmt.Sprintf("Strategy(%d)", int(s))
+}
+
+// ParseStrategy returns the strategy with the given name: "whole", "comments"
+// or "both".
+func ParseStrategy(name string) (Strategy, error) {
+	for s, n := range strategyNames {
+		if n == name {
+			return s, nil
+		}
+	}
+	return WholeFile, fmt.Errorf("unknown strategy %q", name)
+}
+
+// commentSyntax describes the comments of a language.
+type commentSyntax struct {
+	line       []string // Markers of comments running to the end of the line
+	blockStart string   // Marker starting a block comment, if any
+	blockEnd   string   // Marker ending a block comment
+	quotes     string   // Characters delimiting string literals
+	// chars are the characters delimiting character literals only, such as
+	// the quote of Rust, which also starts lifetimes such as 'a.
+	chars string
+}
+
+var (
+	cSyntax         = commentSyntax{line: []string{"//"}, blockStart: "/*", blockEnd: "*/", quotes: `"'`}
+	rustSyntax      = commentSyntax{line: []string{"//"}, blockStart: "/*", blockEnd: "*/", quotes: `"`, chars: `'`}
+	cssSyntax       = commentSyntax{blockStart: "/*", blockEnd: "*/", quotes: `"'`}
+	hashSyntax      = commentSyntax{line: []string{"#"}, quotes: `"'`}
+	phpSyntax       = commentSyntax{line: []string{"//", "#"}, blockStart: "/*", blockEnd: "*/", quotes: `"'`}
+	dashSyntax      = commentSyntax{line: []string{"--"}, quotes: `"'`}
+	sqlSyntax       = commentSyntax{line: []string{"--"}, blockStart: "/*", blockEnd: "*/", quotes: `"'`}
+	luaSyntax       = commentSyntax{line: []string{"--"}, blockStart: "--[[", blockEnd: "]]", quotes: `"'`}
+	haskellSyntax   = commentSyntax{line: []string{"--"}, blockStart: "{-", blockEnd: "-}", quotes: `"`}
+	semicolonSyntax = commentSyntax{line: []string{";"}, quotes: `"`}
+	markupSyntax    = commentSyntax{blockStart: "<!--", blockEnd: "-->"}
+)
+
+// commentSyntaxes maps the extensions of source files to the syntax of their
+// comments. Files with other extensions are treated as text.
+var commentSyntaxes = map[string]commentSyntax{
+	".c":      cSyntax,
+	".cc":     cSyntax,
+	".cpp":    cSyntax,
+	".cs":     cSyntax,
+	".cxx":    cSyntax,
+	".dart":   cSyntax,
+	".go":     cSyntax,
+	".groovy": cSyntax,
+	".h":      cSyntax,
+	".hh":     cSyntax,
+	".hpp":    cSyntax,
+	".java":   cSyntax,
+	".js":     cSyntax,
+	".jsx":    cSyntax,
+	".kt":     cSyntax,
+	".kts":    cSyntax,
+	".m":      cSyntax,
+	".mm":     cSyntax,
+	".proto":  cSyntax,
+	".rs":     rustSyntax,
+	".scala":  cSyntax,
+	".swift":  cSyntax,
+	".ts":     cSyntax,
+	".tsx":    cSyntax,
+	".css":    cssSyntax,
+	".bash":   hashSyntax,
+	".cmake":  hashSyntax,
+	".pl":     hashSyntax,
+	".pm":     hashSyntax,
+	".py":     hashSyntax,
+	".r":      hashSyntax,
+	".rb":     hashSyntax,
+	".sh":     hashSyntax,
+	".tf":     hashSyntax,
+	".toml":   hashSyntax,
+	".yaml":   hashSyntax,
+	".yml":    hashSyntax,
+	".php":    phpSyntax,
+	".ada":    dashSyntax,
+	".adb":    dashSyntax,
+	".ads":    dashSyntax,
+	".sql":    sqlSyntax,
+	".lua":    luaSyntax,
+	".hs":     haskellSyntax,
+	".asm":    semicolonSyntax,
+	".clj":    semicolonSyntax,
+	".el":     semicolonSyntax,
+	".lisp":   semicolonSyntax,
+	".scm":    semicolonSyntax,
+	".htm":    markupSyntax,
+	".html":   markupSyntax,
+	".svg":    markupSyntax,
+	".xml":    markupSyntax,
+}
+
+// SetStrategy sets the strategy for classifying files with the extension, such
+// as ".go". By default, files are classified with WholeFile, which is also the
+// only strategy for files whose comments aren't known. Files that are
+// segmented as bundles or SPDX snippets are classified whole.
+func (b *ClassifierBackend) SetStrategy(ext string, s Strategy) {
+	if b.strategies == nil {
+		b.strategies = make(map[string]Strategy)
+	}
+	b.strategies[strings.ToLower(ext)] = s
+}
+
+// strategy returns the strategy for classifying the file, and the syntax of
+// its comments.
+func (b *ClassifierBackend) strategy(filename string) (Strategy, commentSyntax) {
+	ext := strings.ToLower(filepath.Ext(filename))
+	syntax, ok := commentSyntaxes[ext]
+	if !ok {
+		return WholeFile, syntax
+	}
+	if s, ok := b.strategies[ext]; ok {
+		return s, syntax
+	}
+	return WholeFile, syntax
+}
+
+// matchFile classifies the contents of a file according to its strategy.
+func (b *ClassifierBackend) matchFile(ctx context.Context, filename string, contents []byte, headers bool, annotate func(*results.LicenseType)) {
+	s, syntax := b.strategy(filename)
+	if s == WholeFile {
+		b.matchContents(ctx, filename, contents, headers, 0, annotate)
+		return
+	}
+	inComments := b.classify(ctx, filename, extractComments(contents, syntax), headers, 0, annotate)
+	for _, r := range inComments {
+		b.addResult(r)
+	}
+	if s == CommentsOnly {
+		return
+	}
+	for _, r := range b.classify(ctx, filename, contents, headers, 0, annotate) {
+		if !foundIn(r, inComments) {
+			b.addResult(r)
+		}
+	}
+}
+
+// foundIn reports whether the result overlaps one for the same license in the
+// results.
+func foundIn(r *results.LicenseType, in []*results.LicenseType) bool {
+	for _, o := range in {
+		if o.Name == r.Name && o.MatchType == r.MatchType && o.StartLine <= r.EndLine && r.StartLine <= o.EndLine {
+			return true
+		}
+	}
+	return false
+}
+
+// extractComments returns a copy of the contents with everything but the text
+// of the comments replaced by spaces, preserving line breaks so that the lines
+// of the matches don't change. String literals are skipped so that comment
+// markers in them, such as in URLs, aren't taken for comments.
+func extractComments(contents []byte, syntax commentSyntax) []byte {
+	out := bytes.Repeat([]byte(" "), len(contents))
+	for i := 0; i < len(contents); {
+		switch c := contents[i]; {
+		case c == '\n':
+			out[i] = '\n'
+			i++
+		case syntax.blockStart != "" && bytes.HasPrefix(contents[i:], []byte(syntax.blockStart)):
+			start := i + len(syntax.blockStart)
+			end, next := len(contents), len(contents)
+			if j := bytes.Index(contents[start:], []byte(syntax.blockEnd)); j != -1 {
+				end = start + j
+				next = end + len(syntax.blockEnd)
+			}
+			copy(out[start:end], contents[start:end])
+			i = next
+		case hasAnyPrefix(contents[i:], syntax.line):
+			end := len(contents)
+			if j := bytes.IndexByte(contents[i:], '\n'); j != -1 {
+				end = i + j
+			}
+			copy(out[i:end], contents[i:end])
+			i = end
+		case strings.IndexByte(syntax.quotes, c) != -1:
+			i = skipString(contents, i)
+		case strings.IndexByte(syntax.chars, c) != -1:
+			i = skipChar(contents, i)
+		default:
+			i++
+		}
+	}
+	return out
+}
+
+// hasAnyPrefix reports whether b starts with one of the prefixes.
+func hasAnyPrefix(b []byte, prefixes []string) bool {
+	for _, p := range prefixes {
+		if bytes.HasPrefix(b, []byte(p)) {
+			return true
+		}
+	}
+	return false
+}
+
+// skipString returns the position after the string literal starting with the
+// quote at position i. A literal that isn't terminated ends with its line, so
+// that a stray quote, such as an apostrophe, doesn't hide the comments below.
+func skipString(contents []byte, i int) int {
+	quote := contents[i]
+	for i++; i < len(contents); i++ {
+		switch contents[i] {
+		case '\\':
+			i++
+		case quote:
+			return i + 1
+		case '\n':
+			return i
+		}
+	}
+	return len(contents)
+}
+
+// skipChar returns the position after the character literal starting with the
+// quote at position i, such as 'x' or '\n'. A quote that doesn't start a
+// character literal, such as that of the Rust lifetime 'a, is skipped alone.
+func skipChar(contents []byte, i int) int {
+	quote := contents[i]
+	j := i + 1
+	if j < len(contents) && contents[j] == '\\' {
+		// Escapes such as '\u{1F600}' are short and end on the line.
+		for j += 2; j < len(contents) && j < i+12 && contents[j] != '\n'; j++ {
+			if contents[j] == quote {
+				return j + 1
+			}
+		}
+		return i + 1
+	}
+	if j < len(contents) {
+		_, size := utf8.DecodeRune(contents[j:])
+		if j+size < len(contents) && contents[j+size] == quote {
+			return j + size + 1
+		}
+	}
+	return i + 1
+}
@@ -0,0 +1,147 @@
+// Copyright 2022 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package backend
+
+import (
+	"testing"
+)
+
+func TestExtractComments(t *testing.T) {
+	tests := []struct {
+		name   string
+		syntax commentSyntax
+		in     string
+		want   string
+	}{
+		{
+			name:   "line and block comments",
+			syntax: cSyntax,
+			in:     "x := 1 // one\n/* two\nthree */ y := 2",
+			want:   "       // one\n   two\nthree          ",
+		},
+		{
+			name:   "markers in strings",
+			syntax: cSyntax,
+			in:     "url := \"http://example.com\" // site",
+			want:   "                            // site",
+		},
+		{
+			name:   "escaped quote",
+			syntax: cSyntax,
+			in:     `s := "a \" // b" // c`,
+			want:   `                 // c`,
+		},
+		{
+			name:   "unterminated string ends with its line",
+			syntax: hashSyntax,
+			in:     "it's\n# license",
+			want:   "    \n# license",
+		},
+		{
+			name:   "unterminated block comment",
+			syntax: cSyntax,
+			in:     "x /* license",
+			want:   "     license",
+		},
+		{
+			name:   "rust lifetime",
+			syntax: rustSyntax,
+			in:     "fn f<'a>(x: &str) {} // license",
+			want:   "                     // license",
+		},
+		{
+			name:   "rust character literals",
+			syntax: rustSyntax,
+			in:     "let c = '/'; let d = '\\''; let e = '\\u{2F}'; // license",
+			want:   "                                             // license",
+		},
+		{
+			name:   "markup",
+			syntax: markupSyntax,
+			in:     "<p>it's</p><!-- license -->",
+			want:   "                license    ",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := string(extractComments([]byte(tt.in), tt.syntax)); got != tt.want {
+				t.Errorf("extractComments(%q) = %q, want %q", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSkipString(t *testing.T) {
+	tests := []struct {
+		in   string
+		want int
+	}{
+		{`"abc" x`, 5},
+		{`"a\"b" x`, 6},
+		{"\"abc\nx", 4},
+		{`"abc`, 4},
+	}
+	for _, tt := range tests {
+		if got := skipString([]byte(tt.in), 0); got != tt.want {
+			t.Errorf("skipString(%q) = %d, want %d", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestSkipChar(t *testing.T) {
+	tests := []struct {
+		in   string
+		want int
+	}{
+		{`'a' x`, 3},
+		{`'\n' x`, 4},
+		{`'\'' x`, 4},
+		{`'é' x`, 4},
+		{`'a>(x)`, 1},
+		{`'static str`, 1},
+		{`'`, 1},
+	}
+	for _, tt := range tests {
+		if got := skipChar([]byte(tt.in), 0); got != tt.want {
+			t.Errorf("skipChar(%q) = %d, want %d", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestStrategy(t *testing.T) {
+	b := &ClassifierBackend{}
+	if s, _ := b.strategy("main.go"); s != WholeFile {
+		t.Errorf("strategy(main.go) = %v, want whole", s)
+	}
+	b.SetStrategy(".GO", Both)
+	if s, _ := b.strategy("main.go"); s != Both {
+		t.Errorf("strategy(main.go) with .GO=both = %v, want both", s)
+	}
+	// Files whose comments aren't known are classified whole.
+	b.SetStrategy(".txt", CommentsOnly)
+	if s, _ := b.strategy("LICENSE.txt"); s != WholeFile {
+		t.Errorf("strategy(LICENSE.txt) = %v, want whole", s)
+	}
+
+	for _, name := range []string{"whole", "comments", "both"} {
+		s, err := ParseStrategy(name)
+		if err != nil || s.String() != name {
+			t.Errorf("ParseStrategy(%q) = %v, %v", name, s, err)
+		}
+	}
+	if _, err := ParseStrategy("some"); err == nil {
+		t.Error("ParseStrategy(some) succeeded")
+	}
+}
@@ -23,11 +23,69 @@
 //	$ identifylicense <LICENSE_OR_DIRECTORY>  <LICENSE_OR_DIRECTORY> ...
 //	LICENSE2: MIT (confidence: 0.987)
 //	LICENSE1: BSD-2-Clause (confidence: 0.833)
+//
+// With --corpus_stats, the size of the license corpus is printed instead, per
+// license and in total, along with the time it takes to load, to help decide
+// which licenses to trim from it for memory-constrained deployments:
+//
+//	$ identifylicense [--licenses=<DIRS>] --corpus_stats
+//
+// With --sqlite, the results and errors of a scan are also written to a SQLite
+// database, with files, matches and errors tables, which --query answers
+// common questions from, such as the files with GPL-3.0 matches above a
+// confidence of 0.9:
+//
+//	$ identifylicense --sqlite=scan.db <LICENSE_OR_DIRECTORY> ...
+//	$ identifylicense --sqlite=scan.db --query=files --query_license=GPL-3.0 --query_min_confidence=0.9
+//
+// The binary must be built with a database/sql driver for SQLite registered
+// under the name given by --sql_driver. Building with the sqlite tag links
+// github.com/mattn/go-sqlite3, which requires cgo:
+//
+//	$ go build -tags sqlite ./tools/identify_license
+//
+// The exit status tells scripts how the scan went:
+//
+//	0  every file was read and at least one license was identified
+//	1  the scan couldn't be run or was aborted by the error budget
+//	3  some files couldn't be read or classified; the results for the others
+//	   are still reported
+//	4  every file was read but no license was identified, or every file was
+//	   skipped
+//
+// License files such as LICENSE and COPYING that are empty, or hold only
+// whitespace, are reported as Empty:LicenseFile rather than left out of the
+// results.
+//
+// With --cache_out, the indexed license corpus and the matches of the contents
+// of the files classified are written to a single file that CI jobs can keep
+// between runs. With --cache_in, a scan reads that file back, skipping the
+// indexing of the corpus and reusing the matches of files whose contents are
+// unchanged, so that repeated scans of mostly unchanged trees are fast. A
+// cache written by another version of the tool, or for other --licenses
+// directories, is ignored.
+//
+//	$ identifylicense --cache_in=scan.cache --cache_out=scan.cache <LICENSE_OR_DIRECTORY> ...
+//
+// With --name_map, the licenses found are reported by the names an
+// organization uses for them, as given by a JSON object mapping license names
+// to reported names:
+//
+//	{"GPL-2.0": "restricted:GPL-2.0", "MIT": "notice:MIT"}
+//
+// The NOTICE checks and the licenses concluded for files and directories keep
+// the names of the corpus, which they are based on.
+//
+// With --max_matches_per_file, the results of a file beyond the limit, such as
+// those of a concatenation of thousands of sources, are replaced by a single
+// Truncated:Matches result whose variant is the number of results left out.
 package main
 
 import (
+	"bufio"
 	"context"
 	"encoding/json"
+	"errors"
 	"flag"
 	"fmt"
 	"strings"
@@ -44,23 +102,79 @@ import (
 	"time"
 
 	classifier "github.com/google/licenseclassifier/v2"
+	"github.com/google/licenseclassifier/v2/assets"
 	"github.com/google/licenseclassifier/v2/tools/identify_license/backend"
 	"github.com/google/licenseclassifier/v2/tools/identify_license/results"
+	"github.com/google/licenseclassifier/v2/tools/version"
 )
 
 var (
 	headers       = flag.Bool("headers", false, "match license headers")
 	jsonFname     = flag.String("json", "", "filename to write JSON output to.")
+	jsonReport    = flag.Bool("json_report", false, "write --json as an object holding the version of the tool and license corpus and any --check_notices findings along with the results, rather than an array of the results")
 	includeText   = flag.Bool("include_text", false, "include the license text in the JSON output")
+	htmlFname     = flag.String("html", "", "filename to write a standalone HTML report to, for reviewers without tools to process the JSON output")
+	denyLicenses  = flag.String("deny_licenses", "", "comma-separated list of licenses, or license categories such as restricted or FORBIDDEN, that violate the license policy and are highlighted in the HTML report")
+	contextLines  = flag.Int("context_lines", 0, "include up to this many lines of the file before and after each match in the JSON output")
 	numTasks      = flag.Int("tasks", 1000, "the number of license scanning tasks running concurrently")
 	timeout       = flag.Duration("timeout", 24*time.Hour, "timeout before giving up on classifying a file.")
 	tracePhases   = flag.String("trace_phases", "", "comma-separated list of phases of the license classifier to trace")
 	traceLicenses = flag.String("trace_licenses", "", "comma-separated list of licenses for the license classifier to trace")
 	ignorePaths   = flag.String("ignore_paths_re", "", "comma-separated list of regular expressions that match file paths to ignore")
+	maxDepth      = flag.Int("max_depth", -1, "maximum directory depth to descend below each argument; negative means unlimited")
+	oneFilesystem = flag.Bool("one_filesystem", false, "don't descend into directories on other filesystems than the argument's")
+	skipSparse    = flag.Bool("skip_sparse", false, "skip sparse files, whose allocated size is a small fraction of their apparent size, such as virtual files and sparse images")
+	checkNotices  = flag.Bool("check_notices", false, "report directories whose licenses require a NOTICE file but have none, and NOTICE files without a license file, after the per-file results")
+	concludeDirs  = flag.Bool("conclude_directories", false, "print the license concluded for each directory after the per-file results")
+	concludeFiles = flag.Bool("conclude_files", false, "print the single license concluded for each file after the per-file results")
+	splitLicenses = flag.Bool("split_licenses", false, "also report licenses whose text is split across several license files in a directory")
+	errorBudget   = flag.Float64("error_budget", 1.0, "fraction of files that may fail to be classified before the scan is aborted")
+	spdxSnippets  = flag.Bool("spdx_snippets", false, "classify regions delimited by SPDX-SnippetBegin and SPDX-SnippetEnd separately from the rest of the file")
+	includeExts   = flag.String("include_extensions", "", "comma-separated list of extensions, such as .go,.txt, of the only files to classify")
+	excludeExts   = flag.String("exclude_extensions", "", "comma-separated list of extensions of files not to classify")
+	sniffing      = flag.Bool("skip_binaries", false, "skip files whose sniffed MIME type can't hold license text, such as images, media and other binaries, reporting them as Skipped:Binary")
+	archives      = flag.Bool("archives", false, "classify archives such as zip and tar files as they are rather than skipping them with --skip_binaries")
+	sidecars      = flag.Bool("sidecars", false, "report the results of REUSE-style .license sidecar files, such as image.png.license, for the file they describe rather than for the sidecar")
+	families      = flag.Bool("families", false, "report the family of each license found, such as GPL-family or BSD-family, alongside its name")
+	bundles       = flag.Bool("bundles", false, "classify the comments preserved in JavaScript and CSS bundles (/*!, @license, @preserve) and the sources embedded in source maps separately")
+	licenseDirs   = flag.String("licenses", "", "comma-separated list of license corpus directories, laid out like the assets directory, to match against in addition to the embedded corpus")
+	noDefault     = flag.Bool("no_default_corpus", false, "don't match against the embedded license corpus, only against the --licenses directories")
+	lintCorpus    = flag.Bool("lint_licenses", false, "validate the --licenses directories as they're loaded, failing on stray or empty files, misnamed files and copies of licenses of the corpus under another name")
+	quiet         = flag.Bool("quiet", false, "don't log the progress of classifying each file")
+	strategies    = flag.String("strategies", "", "comma-separated list of extension=strategy pairs, such as .go=comments,.py=both, overriding how files are classified: whole (the whole file), comments (only its comments) or both; by default files are classified whole")
+	redactPrefix  = flag.String("redact_prefixes", "", "comma-separated list of path prefixes to strip from the paths in all outputs")
+	redactHash    = flag.Bool("redact_hash", false, "replace each element of the paths in all outputs with a hash of it, keeping file extensions")
+	redactSalt    = flag.String("redact_salt", "", "secret mixed into the hashes of --redact_hash so that common names can't be recovered from them")
+	printSchema   = flag.String("print_schema", "", "print the JSON Schema of an output format (results or diff) and exit")
+	printVersion  = flag.Bool("version", false, "print the version of the tool and its license corpus and exit")
+	corpusStats   = flag.Bool("corpus_stats", false, "print the size of the license corpus, per license and in total, and the time it takes to load, and exit")
+	sampleHead    = flag.Int("sample_head_kb", 0, "classify only the first this many KiB, and the last --sample_tail_kb KiB, of files larger than their sum, reporting the results as Sampled; 0 classifies files in full")
+	sampleTail    = flag.Int("sample_tail_kb", 64, "the KiB at the end of files classified with --sample_head_kb")
+	maxLineLength = flag.Int("max_average_line_length", 0, "skip files whose average line length in bytes exceeds this, such as minified JavaScript and CSS, reporting them as Skipped:Minified; 0 classifies all files")
+	sqliteFname   = flag.String("sqlite", "", "filename of a SQLite database to write the results and errors to, or to query with --query")
+	query         = flag.String("query", "", "query the --sqlite results database rather than scanning files: files (the files holding --query_license), licenses (the number of files holding each license) or errors")
+	queryLicense  = flag.String("query_license", "", "the license, such as GPL-3.0, whose files --query=files lists")
+	queryMinConf  = flag.Float64("query_min_confidence", 0, "the minimum confidence of the matches counted by --query")
+	sqlDriver     = flag.String("sql_driver", "sqlite3", "the name of the database/sql driver for SQLite linked into the binary, used for --sqlite")
+	cacheIn       = flag.String("cache_in", "", "filename of a cache written by --cache_out to reuse the license corpus and unchanged files' matches from; a missing or stale cache is ignored")
+	cacheOut      = flag.String("cache_out", "", "filename to write the indexed license corpus and the matches of the files classified to, for --cache_in of a later scan")
+	nameMap       = flag.String("name_map", "", "filename of a JSON object mapping license names, such as GPL-2.0, to the names to report them by in the results, such as the keys of a license policy")
+	maxMatches    = flag.Int("max_matches_per_file", 0, "report at most this many results for a file, replacing the rest with a Truncated:Matches result whose variant is the number left out; 0 reports all results")
+	maxTokens     = flag.Int("max_tokens", 0, "classify only the first this many tokens of each file, reporting files cut short with a Truncated:Content result; 0 classifies files in full")
+	maxCandidates = flag.Int("max_candidates", 0, "score at most this many regions of each file against each license, reporting files with more with a Truncated:Content result; 0 scores all regions")
+	maxDiffSize   = flag.Int("max_diff_size", 0, "leave unscored the regions of files whose diffs against a license would exceed this many tokens, reporting those files with a Truncated:Content result; 0 scores all regions")
+)
+
+// Exit codes for outcomes other than success. Fatal errors exit with 1.
+const (
+	exitUnreadable   = 3 // Some files couldn't be read or classified
+	exitUnclassified = 4 // No license was identified
 )
 
 // expandFiles recursively returns a list of files stored in a list of
 // directories. If an input is not a directory, it is added to the output list.
+// Only regular files are returned; devices, pipes and sockets are skipped since
+// reading them can block or never terminate.
 func expandFiles(ctx context.Context, paths []string) ([]string, error) {
 	var finalPaths []string
 
@@ -69,10 +183,16 @@ func expandFiles(ctx context.Context, paths []string) ([]string, error) {
 		return nil, fmt.Errorf("could not parse ignore paths: %v", err)
 	}
 
-	handleFile := func(path string) {
+	handleFile := func(path string, info os.FileInfo) {
 		if shouldIgnore(ip, path) {
 			return
 		}
+		if info.Mode()&(os.ModeDevice|os.ModeCharDevice|os.ModeNamedPipe|os.ModeSocket|os.ModeIrregular) != 0 {
+			return
+		}
+		if *skipSparse && isSparse(info) {
+			return
+		}
 		finalPaths = append(finalPaths, path)
 	}
 
@@ -82,17 +202,34 @@ func expandFiles(ctx context.Context, paths []string) ([]string, error) {
 			return nil, err
 		}
 
+		root, err := os.Stat(p)
+		if err != nil {
+			return nil, err
+		}
+		rootDev, hasDev := deviceID(root)
+
 		err = filepath.Walk(p, func(path string, info os.FileInfo, err error) error {
 			if err != nil {
 				return err
 			}
 			if info.IsDir() {
+				if path == p {
+					return nil
+				}
 				if shouldIgnore(ip, info.Name()) {
 					return fs.SkipDir
 				}
+				if *maxDepth >= 0 && depth(p, path) > *maxDepth {
+					return fs.SkipDir
+				}
+				if *oneFilesystem && hasDev {
+					if dev, ok := deviceID(info); ok && dev != rootDev {
+						return fs.SkipDir
+					}
+				}
 				return nil // walk the directory
 			}
-			handleFile(path)
+			handleFile(path, info)
 			return nil
 		})
 		if err != nil {
@@ -102,6 +239,31 @@ func expandFiles(ctx context.Context, paths []string) ([]string, error) {
 	return finalPaths, nil
 }
 
+// Small files may be stored inline in filesystem metadata, and files on
+// compressing filesystems occupy a fraction of their size, so neither has all
+// its blocks allocated. A file is only taken to be sparse if the part of it
+// without blocks is at least sparseMinGap bytes and less than a sparseRatio-th
+// of it is allocated.
+const (
+	sparseMinGap = 1 << 20
+	sparseRatio  = 8
+)
+
+// sparse reports whether a file of the size, with the number of bytes
+// allocated, is sparse.
+func sparse(size, allocated int64) bool {
+	return size-allocated >= sparseMinGap && allocated*sparseRatio < size
+}
+
+// depth returns how many directories below root the supplied path is.
+func depth(root, path string) int {
+	rel, err := filepath.Rel(root, path)
+	if err != nil || rel == "." {
+		return 0
+	}
+	return strings.Count(rel, string(os.PathSeparator)) + 1
+}
+
 func shouldIgnore(ignorePaths []*regexp.Regexp, path string) bool {
 	for _, r := range ignorePaths {
 		if exactRegexMatch(r, path) {
@@ -130,24 +292,117 @@ func parseIgnorePaths() (out []*regexp.Regexp, err error) {
 	return out, nil
 }
 
-// outputJSON writes the output formatted as JSON to a file.
-func outputJSON(filename *string, res results.LicenseTypes, includeText bool) error {
+// outputJSON writes the output formatted as JSON to a file: an array of the
+// results, or with report set, a results.Report of the version v.
+func outputJSON(filename *string, res results.LicenseTypes, notices []*results.NoticeFinding, includeText bool, contextLines int, redactor *results.Redactor, report bool, v version.Info) error {
 	d, err := results.NewJSONResult(res, includeText)
 	if err != nil {
 		return err
 	}
-	fc, err := json.MarshalIndent(d, "", " ")
+	if err := d.AddContext(contextLines); err != nil {
+		return err
+	}
+	// The paths are redacted once the text of the files has been read.
+	redactor.JSONResult(d)
+	var out interface{} = d
+	if report {
+		out = results.Report{Version: v, Results: d, Notices: notices}
+	}
+	fc, err := json.MarshalIndent(out, "", " ")
 	if err != nil {
 		return err
 	}
 	return ioutil.WriteFile(*filename, fc, 0644)
 }
 
+// outputHTML writes the output formatted as an HTML report to a file, with the
+// licenses denied by the policy highlighted.
+func outputHTML(filename string, res results.LicenseTypes, notices []*results.NoticeFinding, deny string, redactor *results.Redactor, v version.Info) error {
+	d, err := results.NewJSONResult(res, true)
+	if err != nil {
+		return err
+	}
+	redactor.JSONResult(d)
+
+	denied := make(map[string]bool)
+	if deny != "" {
+		for _, l := range strings.Split(deny, ",") {
+			denied[l] = true
+		}
+	}
+	category := func(name string) string {
+		return assets.LicenseType(name)
+	}
+	policy := results.HTMLPolicy{
+		Category: category,
+		Violates: func(c *results.Classification) bool {
+			if c.MatchType != "License" && c.MatchType != "Header" {
+				return false
+			}
+			cat := category(c.Name)
+			return denied[c.Name] || (cat != "" && denied[cat])
+		},
+	}
+
+	f, err := os.Create(filename)
+	if err != nil {
+		return err
+	}
+	report := &results.Report{Version: v, Results: d, Notices: notices}
+	if err := report.WriteHTML(f, policy); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// newRedactor returns the redactor for the paths in the outputs, or nil if
+// they aren't redacted.
+func newRedactor() *results.Redactor {
+	if *redactPrefix == "" && !*redactHash {
+		return nil
+	}
+	r := &results.Redactor{Hash: *redactHash, Salt: *redactSalt}
+	if *redactPrefix != "" {
+		r.StripPrefixes = strings.Split(*redactPrefix, ",")
+	}
+	return r
+}
+
+// setStrategies sets the strategies for classifying files given as a
+// comma-separated list of extension=strategy pairs.
+func setStrategies(be *backend.ClassifierBackend, list string) error {
+	if list == "" {
+		return nil
+	}
+	for _, pair := range strings.Split(list, ",") {
+		i := strings.Index(pair, "=")
+		if i == -1 {
+			return fmt.Errorf("%q isn't an extension=strategy pair", pair)
+		}
+		s, err := backend.ParseStrategy(pair[i+1:])
+		if err != nil {
+			return err
+		}
+		ext := pair[:i]
+		if !strings.HasPrefix(ext, ".") {
+			ext = "." + ext
+		}
+		be.SetStrategy(ext, s)
+	}
+	return nil
+}
+
 func init() {
 	flag.Usage = func() {
-		fmt.Fprintf(os.Stderr, `Usage: %s <licensefile> ...
+		fmt.Fprintf(os.Stderr, `Usage: %[1]s <licensefile> ...
+       %[1]s --corpus_stats
+       %[1]s --sqlite=<DB> --query=files --query_license=<NAME> [--query_min_confidence=<C>]
+       %[1]s --sqlite=<DB> --query=licenses [--query_min_confidence=<C>]
+       %[1]s --sqlite=<DB> --query=errors
 
-Identify an unknown license.
+Identify an unknown license, print statistics on the license corpus, or query
+the results database written by a scan with --sqlite.
 
 Options:
 `, filepath.Base(os.Args[0]))
@@ -155,16 +410,173 @@ Options:
 	}
 }
 
+// readCache creates a backend from the cache file written by writeCache with
+// the key.
+func readCache(filename, key string) (*backend.ClassifierBackend, error) {
+	f, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return backend.NewFromCache(bufio.NewReader(f), key)
+}
+
+// readNameMap reads the JSON object of --name_map, mapping license names to
+// the names to report them by, as a name resolver. Names it doesn't hold are
+// reported as they are.
+func readNameMap(filename string) (classifier.NameResolver, error) {
+	b, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	var names map[string]string
+	if err := json.Unmarshal(b, &names); err != nil {
+		return nil, err
+	}
+	return classifier.NameResolverFunc(func(matchType, name, variant string) (string, string) {
+		if n, ok := names[name]; ok {
+			return n, variant
+		}
+		return name, variant
+	}), nil
+}
+
+// writeCache writes the cache of the backend to the file. The cache is written
+// to a temporary file that replaces the file once complete, so that an
+// interrupted scan doesn't leave a truncated cache behind, even when the file
+// is also the one the cache was read from.
+func writeCache(filename string, be *backend.ClassifierBackend) error {
+	f, err := ioutil.TempFile(filepath.Dir(filename), filepath.Base(filename)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+	w := bufio.NewWriter(f)
+	if err := be.WriteCache(w); err != nil {
+		f.Close()
+		return err
+	}
+	if err := w.Flush(); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	// Temporary files are only readable by their owner.
+	if err := os.Chmod(f.Name(), 0644); err != nil {
+		return err
+	}
+	return os.Rename(f.Name(), filename)
+}
+
 func main() {
 	flag.Parse()
+	os.Exit(run())
+}
+
+// run classifies the files named on the command line, reports the results and
+// returns the exit code.
+func run() int {
+	if *printSchema != "" {
+		schema, err := results.Schema(*printSchema)
+		if err != nil {
+			log.Fatalf("cannot print schema: %v", err)
+		}
+		os.Stdout.Write(schema)
+		return 0
+	}
+	if *printVersion {
+		fmt.Printf("%s %s\n", filepath.Base(os.Args[0]), version.Get())
+		return 0
+	}
+
+	if *query != "" {
+		runQuery(*query)
+		return 0
+	}
+	if *sqliteFname != "" {
+		// The scan is pointless if its results can't be written.
+		if err := checkSQLDriver(); err != nil {
+			log.Fatalf("cannot write --sqlite: %v", err)
+		}
+	}
 
-	be, err := backend.New()
+	var dirs []string
+	if *licenseDirs != "" {
+		dirs = strings.Split(*licenseDirs, ",")
+	}
+	ver, err := version.GetForCorpus(dirs, !*noDefault)
 	if err != nil {
-		log.Fatalf("cannot create license classifier: %v", err)
+		log.Fatalf("cannot read license corpus: %v", err)
+	}
+	start := time.Now()
+	var cacheKey string
+	if *cacheIn != "" || *cacheOut != "" {
+		var err error
+		if cacheKey, err = backend.CacheKey(dirs, !*noDefault); err != nil {
+			log.Fatalf("cannot compute the cache key: %v", err)
+		}
+	}
+	var be *backend.ClassifierBackend
+	if *cacheIn != "" {
+		var err error
+		if be, err = readCache(*cacheIn, cacheKey); err != nil {
+			log.Printf("not using cache %s: %v", *cacheIn, err)
+		}
+	}
+	if be == nil {
+		var err error
+		if be, err = backend.NewWithCorpus(dirs, !*noDefault, *lintCorpus); err != nil {
+			log.Fatalf("cannot create license classifier: %v", err)
+		}
+		if *cacheOut != "" {
+			be.SetCacheKey(cacheKey)
+		}
+	}
+	if *corpusStats {
+		if err := be.WarmUp(); err != nil {
+			log.Fatalf("cannot load license corpus: %v", err)
+		}
+		if err := printCorpusStats(be, time.Since(start)); err != nil {
+			log.Fatalf("cannot print corpus statistics: %v", err)
+		}
+		return 0
 	}
 
 	paths, err := expandFiles(context.Background(), flag.Args())
 	defer be.Close()
+	if err != nil {
+		log.Printf("cannot read the files to scan: %v", err)
+		return 1
+	}
+	redactor := newRedactor()
+	be.SetQuiet(*quiet)
+	be.SetRedactor(redactor)
+	be.SetMaxAverageLineLength(*maxLineLength)
+	be.SetMaxMatchesPerFile(*maxMatches)
+	be.SetMaxTokens(*maxTokens)
+	be.SetMaxCandidates(*maxCandidates)
+	be.SetMaxDiffSize(*maxDiffSize)
+	be.SetSampling(*sampleHead*1024, *sampleTail*1024)
+	if err := setStrategies(be, *strategies); err != nil {
+		log.Fatalf("invalid --strategies: %v", err)
+	}
+	be.SetSPDXSnippets(*spdxSnippets)
+	be.SetBundles(*bundles)
+	be.SetSidecars(*sidecars)
+	be.SetFamilies(*families)
+	be.SetExtensions(strings.Split(*includeExts, ","), strings.Split(*excludeExts, ","))
+	be.SetSniffing(*sniffing)
+	be.SetArchives(*archives)
+	be.SetErrorBudget(*errorBudget)
+	if *nameMap != "" {
+		r, err := readNameMap(*nameMap)
+		if err != nil {
+			log.Fatalf("cannot read --name_map: %v", err)
+		}
+		be.SetNameResolver(r)
+	}
 	be.SetTraceConfiguration(
 		&classifier.TraceConfiguration{
 			TracePhases:   *tracePhases,
@@ -173,32 +585,132 @@ func main() {
 
 	ctx, cancel := context.WithTimeout(context.Background(), *timeout)
 	defer cancel()
-	if errs := be.ClassifyLicensesWithContext(ctx, *numTasks, paths, *headers); errs != nil {
-		be.Close()
+	exitCode := 0
+	errs := be.ClassifyLicensesWithContext(ctx, *numTasks, paths, *headers)
+	if errs != nil {
+		var budgetErr *backend.ErrorBudgetError
 		for _, err := range errs {
-			log.Printf("classify license failed: %v", err)
+			if errors.As(err, &budgetErr) {
+				continue
+			}
+			log.Printf("classify license failed: %v", redactor.Text(err.Error()))
+			var pe *classifier.PanicError
+			if errors.As(err, &pe) {
+				log.Printf("stack of the panic, for a bug report:\n%s", pe.Stack)
+			}
+		}
+		log.Printf("errors by category: %v", be.ErrorStats())
+		if budgetErr != nil {
+			be.Close()
+			log.Fatalf("cannot classify licenses: %v", budgetErr)
 		}
-		log.Fatal("cannot classify licenses")
+		exitCode = exitUnreadable
 	}
 
-	results := be.GetResults()
-	if len(results) == 0 {
-		log.Fatal("Couldn't classify license(s)")
+	if *splitLicenses {
+		if errs := be.ClassifySplitLicenses(paths); errs != nil {
+			for _, err := range errs {
+				log.Printf("classify split licenses failed: %v", redactor.Text(err.Error()))
+			}
+		}
 	}
 
-	sort.Sort(results)
-	for _, r := range results {
+	if *cacheIn != "" && !*quiet {
+		log.Printf("reused the cached matches of %d contents from %s", be.CacheHits(), *cacheIn)
+	}
+	if *cacheOut != "" {
+		if err := writeCache(*cacheOut, be); err != nil {
+			log.Printf("cannot write cache %s: %v", *cacheOut, err)
+		}
+	}
+
+	res := be.GetResults()
+	classified := 0
+	for _, r := range res {
+		if r.MatchType != backend.SkippedMatchType && r.MatchType != backend.EmptyMatchType {
+			classified++
+		}
+	}
+	if classified == 0 {
+		log.Print("Couldn't classify license(s)")
+		if exitCode == 0 {
+			exitCode = exitUnclassified
+		}
+	}
+
+	sort.Sort(res)
+	out := be.ResolveNames(res)
+	for _, r := range out {
 		name := r.Name
 		if r.MatchType != "License" && r.MatchType != "Header" {
 			name = fmt.Sprintf("%s:%s", r.MatchType, r.Name)
 		}
-		fmt.Printf("%s %s (variant: %v, confidence: %v, start: %v, end: %v)\n",
-			r.Filename, name, r.Variant, r.Confidence, r.StartLine, r.EndLine)
+		snippet := ""
+		if r.SnippetStartLine > 0 {
+			snippet = fmt.Sprintf(", snippet: %v-%v", r.SnippetStartLine, r.SnippetEndLine)
+		}
+		if r.Extent > 0 {
+			snippet += fmt.Sprintf(", bytes: %v-%v", r.Offset, r.Offset+r.Extent)
+		}
+		if r.Source != "" {
+			snippet += fmt.Sprintf(", source: %s", redactor.Path(r.Source))
+		}
+		if r.TruncationSuspected {
+			snippet += ", truncation suspected"
+		}
+		switch {
+		case r.SampleOffset > 0:
+			snippet += fmt.Sprintf(", sampled tail from byte: %v", r.SampleOffset)
+		case r.Sampled:
+			snippet += ", sampled head"
+		}
+		fmt.Printf("%s %s (variant: %v, confidence: %v, start: %v, end: %v%s)\n",
+			redactor.Path(r.Filename), name, r.Variant, r.Confidence, r.StartLine, r.EndLine, snippet)
+	}
+	if *concludeFiles {
+		jr, err := results.NewJSONResult(res, false)
+		if err != nil {
+			log.Fatalf("Couldn't conclude file licenses: %v", err)
+		}
+		for _, f := range jr {
+			if fc := results.Concluded(f); fc != nil {
+				fmt.Printf("%s: %s (confidence: %v)\n", redactor.Path(fc.Filepath), fc.License, fc.Confidence)
+			}
+		}
+	}
+	if *concludeDirs {
+		for _, dc := range results.ConcludeDirectories(res) {
+			fmt.Printf("%s: %s (source: %s)\n", redactor.Path(dc.Directory), strings.Join(dc.Licenses, " AND "), dc.Source)
+		}
+	}
+	var notices []*results.NoticeFinding
+	if *checkNotices {
+		notices = results.CheckNotices(paths, out)
+		redactor.Notices(notices)
+		for _, n := range notices {
+			switch n.Kind {
+			case results.NoticeMissing:
+				fmt.Printf("%s: %s (no NOTICE file for %s)\n", n.Directory, n.Kind, strings.Join(n.Licenses, ", "))
+			case results.NoticeOrphaned:
+				fmt.Printf("%s: %s (no license file)\n", n.Notice, n.Kind)
+			}
+		}
 	}
 	if len(*jsonFname) > 0 {
-		err = outputJSON(jsonFname, results, *includeText)
+		err = outputJSON(jsonFname, out, notices, *includeText, *contextLines, redactor, *jsonReport, ver)
 		if err != nil {
 			log.Fatalf("Couldn't write JSON output to file %s: %v", *jsonFname, err)
 		}
 	}
+	if *htmlFname != "" {
+		if err := outputHTML(*htmlFname, out, notices, *denyLicenses, redactor, ver); err != nil {
+			log.Fatalf("Couldn't write HTML report to file %s: %v", *htmlFname, err)
+		}
+	}
+	if *sqliteFname != "" {
+		if err := outputSQL(*sqliteFname, out, errs, redactor); err != nil {
+			log.Fatalf("Couldn't write results to database %s: %v", *sqliteFname, err)
+		}
+	}
+	return exitCode
 }
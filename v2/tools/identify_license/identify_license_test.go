@@ -0,0 +1,146 @@
+// Copyright 2022 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"flag"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestRunMissingPath(t *testing.T) {
+	if err := flag.CommandLine.Parse([]string{filepath.Join(t.TempDir(), "missing")}); err != nil {
+		t.Fatal(err)
+	}
+	if got := run(); got != 1 {
+		t.Errorf("run() of a missing path = %d, want 1", got)
+	}
+}
+
+// writeTree writes the files, named by their slash-separated paths, under a
+// new directory, and returns the directory.
+func writeTree(t *testing.T, files ...string) string {
+	t.Helper()
+	dir := t.TempDir()
+	for _, f := range files {
+		path := filepath.Join(dir, filepath.FromSlash(f))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(path, []byte("Licensed under the MIT license."), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+// expand returns the files expandFiles finds under dir, relative to it.
+func expand(t *testing.T, dir string) []string {
+	t.Helper()
+	paths, err := expandFiles(context.Background(), []string{dir})
+	if err != nil {
+		t.Fatalf("expandFiles() returned error: %v", err)
+	}
+	var out []string
+	for _, p := range paths {
+		rel, err := filepath.Rel(dir, p)
+		if err != nil {
+			t.Fatal(err)
+		}
+		out = append(out, filepath.ToSlash(rel))
+	}
+	sort.Strings(out)
+	return out
+}
+
+func TestExpandFilesMaxDepth(t *testing.T) {
+	dir := writeTree(t, "LICENSE", "a/LICENSE", "a/b/LICENSE", "a/b/c/LICENSE")
+	defer func(d int) { *maxDepth = d }(*maxDepth)
+	tests := []struct {
+		depth int
+		want  []string
+	}{
+		{depth: -1, want: []string{"LICENSE", "a/LICENSE", "a/b/LICENSE", "a/b/c/LICENSE"}},
+		{depth: 0, want: []string{"LICENSE"}},
+		{depth: 2, want: []string{"LICENSE", "a/LICENSE", "a/b/LICENSE"}},
+	}
+	for _, tt := range tests {
+		*maxDepth = tt.depth
+		if diff := cmp.Diff(tt.want, expand(t, dir)); diff != "" {
+			t.Errorf("expandFiles() with --max_depth=%d mismatch (-want +got):\n%s", tt.depth, diff)
+		}
+	}
+}
+
+func TestExpandFilesOneFilesystem(t *testing.T) {
+	// The directories of the tree are on the filesystem of its root, so
+	// they're all walked.
+	dir := writeTree(t, "LICENSE", "a/LICENSE", "a/b/LICENSE")
+	defer func(o bool) { *oneFilesystem = o }(*oneFilesystem)
+	*oneFilesystem = true
+	if diff := cmp.Diff([]string{"LICENSE", "a/LICENSE", "a/b/LICENSE"}, expand(t, dir)); diff != "" {
+		t.Errorf("expandFiles() with --one_filesystem mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestSparse(t *testing.T) {
+	tests := []struct {
+		name            string
+		size, allocated int64
+		want            bool
+	}{
+		{name: "fully allocated", size: 1 << 20, allocated: 1 << 20, want: false},
+		{name: "inline data", size: 60, allocated: 0, want: false},
+		{name: "small unallocated file", size: 64 << 10, allocated: 0, want: false},
+		{name: "compressed", size: 10 << 20, allocated: 4 << 20, want: false},
+		{name: "virtual file", size: 4 << 20, allocated: 0, want: true},
+		{name: "sparse image", size: 1 << 30, allocated: 1 << 20, want: true},
+	}
+	for _, tt := range tests {
+		if got := sparse(tt.size, tt.allocated); got != tt.want {
+			t.Errorf("sparse(%d, %d) for a %s = %v, want %v", tt.size, tt.allocated, tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestExpandFilesSkipSparse(t *testing.T) {
+	dir := writeTree(t, "LICENSE")
+	f, err := os.Create(filepath.Join(dir, "image"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := f.Truncate(64 << 20); err != nil {
+		t.Fatal(err)
+	}
+	f.Close()
+	info, err := os.Stat(filepath.Join(dir, "image"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !isSparse(info) {
+		t.Skip("the platform or filesystem doesn't report sparse files")
+	}
+	defer func(s bool) { *skipSparse = s }(*skipSparse)
+	*skipSparse = true
+	if diff := cmp.Diff([]string{"LICENSE"}, expand(t, dir)); diff != "" {
+		t.Errorf("expandFiles() with --skip_sparse mismatch (-want +got):\n%s", diff)
+	}
+}
@@ -0,0 +1,232 @@
+// Copyright 2022 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package results
+
+import (
+	"path/filepath"
+	"sort"
+	"strings"
+
+	classifier "github.com/google/licenseclassifier/v2"
+)
+
+// LicenseFileRank returns how likely a file is to hold the start of a license
+// based on its name: lower values are more likely. It returns -1 if the name
+// doesn't look like a file holding license text at all.
+func LicenseFileRank(path string) int {
+	return classifier.LicenseFileRank(path)
+}
+
+// IsLicenseFile reports whether the base name of the path looks like a file
+// holding license text, such as LICENSE, COPYING or NOTICE.
+func IsLicenseFile(path string) bool {
+	return LicenseFileRank(path) != -1
+}
+
+// manifestFiles are the base names of package manifests that commonly declare
+// the license of a package.
+var manifestFiles = map[string]bool{
+	"bower.json":     true,
+	"cargo.toml":     true,
+	"composer.json":  true,
+	"description":    true,
+	"meta.json":      true,
+	"meta.yml":       true,
+	"package.json":   true,
+	"pom.xml":        true,
+	"pyproject.toml": true,
+	"setup.cfg":      true,
+	"setup.py":       true,
+}
+
+// manifestSuffixes are the extensions of package manifests whose base name
+// varies with the package.
+var manifestSuffixes = []string{".cabal", ".gemspec", ".nuspec"}
+
+// IsManifestFile reports whether the base name of the path looks like a
+// package manifest, such as package.json or Cargo.toml.
+func IsManifestFile(path string) bool {
+	base := strings.ToLower(filepath.Base(path))
+	if manifestFiles[base] {
+		return true
+	}
+	for _, s := range manifestSuffixes {
+		if strings.HasSuffix(base, s) {
+			return true
+		}
+	}
+	return false
+}
+
+// Sources of a concluded license, in order of precedence.
+const (
+	SourceLicenseFile = "LicenseFile"
+	SourceHeader      = "Header"
+	SourceManifest    = "Manifest"
+)
+
+// DirectoryConclusion is the license concluded for a directory from the
+// results of the files it contains.
+type DirectoryConclusion struct {
+	Directory string
+	// Licenses are the names of the concluded licenses, sorted.
+	Licenses []string
+	// Source describes which kind of evidence the conclusion is based on.
+	Source string
+}
+
+// ConcludeDirectories concludes the licenses of each directory holding
+// results. Licenses found in license files (LICENSE, COPYING, ...) take
+// precedence over licenses found in headers of other files, which take
+// precedence over licenses found in package manifests. Only the evidence of
+// the highest precedence present in a directory contributes to its
+// conclusion, so a stray header in a directory with a LICENSE file doesn't
+// change the result. Directory-level results, such as those reported by the
+// backend for licenses split across several files, count as license files.
+func ConcludeDirectories(lt LicenseTypes) []*DirectoryConclusion {
+	// evidence[dir][source] is the set of license names found.
+	evidence := make(map[string]map[string]map[string]bool)
+	for _, l := range lt {
+		switch l.MatchType {
+		case "License", "Header", "Reference":
+		default:
+			continue
+		}
+
+		dir, source := filepath.Dir(l.Filename), SourceHeader
+		switch {
+		case l.EndLine == 0:
+			dir, source = l.Filename, SourceLicenseFile
+		case IsLicenseFile(l.Filename):
+			source = SourceLicenseFile
+		case IsManifestFile(l.Filename):
+			source = SourceManifest
+		}
+
+		if evidence[dir] == nil {
+			evidence[dir] = make(map[string]map[string]bool)
+		}
+		if evidence[dir][source] == nil {
+			evidence[dir][source] = make(map[string]bool)
+		}
+		evidence[dir][source][l.Name] = true
+	}
+
+	var out []*DirectoryConclusion
+	for dir, sources := range evidence {
+		for _, source := range []string{SourceLicenseFile, SourceHeader, SourceManifest} {
+			names, ok := sources[source]
+			if !ok {
+				continue
+			}
+			dc := &DirectoryConclusion{Directory: dir, Source: source}
+			for n := range names {
+				dc.Licenses = append(dc.Licenses, n)
+			}
+			sort.Strings(dc.Licenses)
+			out = append(out, dc)
+			break
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Directory < out[j].Directory })
+	return out
+}
+
+// FileConclusion is the license concluded for a single file from its
+// classifications.
+type FileConclusion struct {
+	Filepath string
+	// License is the concluded license: a single license name, or the names
+	// joined with " AND " when different parts of the file are under
+	// different licenses.
+	License string
+	// Licenses are the names of the concluded licenses, sorted.
+	Licenses []string
+	// Confidence is the mean confidence of the classifications the
+	// conclusion is based on, weighted by the number of lines they cover.
+	Confidence float64
+}
+
+// Concluded concludes a single license for a file from its License and Header
+// classifications. Classifications whose lines overlap are competing
+// detections of the same text, so each group of overlapping classifications
+// votes for one license: every classification votes for its license with its
+// confidence weighted by the number of lines it covers, and the license with
+// the most votes wins, ties going to the name that sorts first. Licenses that
+// win separate parts of the file are all part of the conclusion. Concluded
+// returns nil if the file has no License or Header classifications.
+func Concluded(file *FileClassifications) *FileConclusion {
+	var cs Classifications
+	for _, c := range file.Classifications {
+		if c.MatchType == "License" || c.MatchType == "Header" {
+			cs = append(cs, c)
+		}
+	}
+	if len(cs) == 0 {
+		return nil
+	}
+	sort.Slice(cs, func(i, j int) bool {
+		if cs[i].StartLine != cs[j].StartLine {
+			return cs[i].StartLine < cs[j].StartLine
+		}
+		return cs[i].EndLine < cs[j].EndLine
+	})
+
+	fc := &FileConclusion{Filepath: file.Filepath}
+	names := make(map[string]bool)
+	var weighted, lines float64
+	for start := 0; start < len(cs); {
+		// Extend the group while the next classification overlaps it.
+		end, last := start+1, cs[start].EndLine
+		for ; end < len(cs) && cs[end].StartLine <= last; end++ {
+			if cs[end].EndLine > last {
+				last = cs[end].EndLine
+			}
+		}
+		group := cs[start:end]
+
+		votes := make(map[string]float64)
+		for _, c := range group {
+			votes[c.Name] += c.Confidence * float64(coverage(c))
+		}
+		winner := ""
+		for name, v := range votes {
+			if winner == "" || v > votes[winner] || (v == votes[winner] && name < winner) {
+				winner = name
+			}
+		}
+		names[winner] = true
+		for _, c := range group {
+			if c.Name == winner {
+				weighted += c.Confidence * float64(coverage(c))
+				lines += float64(coverage(c))
+			}
+		}
+		start = end
+	}
+
+	for n := range names {
+		fc.Licenses = append(fc.Licenses, n)
+	}
+	sort.Strings(fc.Licenses)
+	fc.License = strings.Join(fc.Licenses, " AND ")
+	fc.Confidence = weighted / lines
+	return fc
+}
+
+// coverage returns the number of lines covered by a classification.
+func coverage(c *Classification) int {
+	return c.EndLine - c.StartLine + 1
+}
@@ -0,0 +1,179 @@
+// Copyright 2022 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package results
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestConcludeDirectories(t *testing.T) {
+	tests := []struct {
+		name string
+		lt   LicenseTypes
+		want []*DirectoryConclusion
+	}{
+		{
+			name: "license file",
+			lt: LicenseTypes{
+				{Filename: "a/LICENSE", Name: "MIT", MatchType: "License", StartLine: 1, EndLine: 20},
+			},
+			want: []*DirectoryConclusion{
+				{Directory: "a", Licenses: []string{"MIT"}, Source: SourceLicenseFile},
+			},
+		},
+		{
+			name: "license file over headers and manifests",
+			lt: LicenseTypes{
+				{Filename: "a/main.go", Name: "Apache-2.0", MatchType: "Header", StartLine: 1, EndLine: 13},
+				{Filename: "a/package.json", Name: "ISC", MatchType: "Reference", StartLine: 4, EndLine: 4},
+				{Filename: "a/COPYING", Name: "GPL-2.0", MatchType: "License", StartLine: 1, EndLine: 339},
+			},
+			want: []*DirectoryConclusion{
+				{Directory: "a", Licenses: []string{"GPL-2.0"}, Source: SourceLicenseFile},
+			},
+		},
+		{
+			name: "headers over manifests",
+			lt: LicenseTypes{
+				{Filename: "a/main.go", Name: "Apache-2.0", MatchType: "Header", StartLine: 1, EndLine: 13},
+				{Filename: "a/util.go", Name: "BSD-3-Clause", MatchType: "Header", StartLine: 1, EndLine: 3},
+				{Filename: "a/package.json", Name: "ISC", MatchType: "Reference", StartLine: 4, EndLine: 4},
+			},
+			want: []*DirectoryConclusion{
+				{Directory: "a", Licenses: []string{"Apache-2.0", "BSD-3-Clause"}, Source: SourceHeader},
+			},
+		},
+		{
+			name: "manifest",
+			lt: LicenseTypes{
+				{Filename: "a/package.json", Name: "ISC", MatchType: "Reference", StartLine: 4, EndLine: 4},
+			},
+			want: []*DirectoryConclusion{
+				{Directory: "a", Licenses: []string{"ISC"}, Source: SourceManifest},
+			},
+		},
+		{
+			name: "directory-level result",
+			lt: LicenseTypes{
+				{Filename: "a/main.go", Name: "Apache-2.0", MatchType: "Header", StartLine: 1, EndLine: 13},
+				{Filename: "a", Name: "MIT", MatchType: "License"},
+			},
+			want: []*DirectoryConclusion{
+				{Directory: "a", Licenses: []string{"MIT"}, Source: SourceLicenseFile},
+			},
+		},
+		{
+			name: "directory-level result of a parent",
+			lt: LicenseTypes{
+				{Filename: "a", Name: "MIT", MatchType: "License"},
+				{Filename: "a/b/main.go", Name: "Apache-2.0", MatchType: "Header", StartLine: 1, EndLine: 13},
+			},
+			want: []*DirectoryConclusion{
+				{Directory: "a", Licenses: []string{"MIT"}, Source: SourceLicenseFile},
+				{Directory: "a/b", Licenses: []string{"Apache-2.0"}, Source: SourceHeader},
+			},
+		},
+		{
+			name: "other match types",
+			lt: LicenseTypes{
+				{Filename: "a/LICENSE", Name: "Empty", MatchType: "Empty"},
+				{Filename: "a/app.min.js", Name: "Minified", MatchType: "Skipped"},
+			},
+			want: nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if diff := cmp.Diff(tt.want, ConcludeDirectories(tt.lt)); diff != "" {
+				t.Errorf("ConcludeDirectories() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestConcluded(t *testing.T) {
+	tests := []struct {
+		name string
+		cs   Classifications
+		want *FileConclusion
+	}{
+		{
+			name: "single license",
+			cs: Classifications{
+				{Name: "MIT", MatchType: "License", Confidence: 0.9, StartLine: 1, EndLine: 20},
+			},
+			want: &FileConclusion{Filepath: "f", License: "MIT", Licenses: []string{"MIT"}, Confidence: 0.9},
+		},
+		{
+			name: "overlapping detections",
+			cs: Classifications{
+				{Name: "BSD-2-Clause", MatchType: "License", Confidence: 0.8, StartLine: 1, EndLine: 20},
+				{Name: "BSD-3-Clause", MatchType: "License", Confidence: 0.9, StartLine: 1, EndLine: 24},
+			},
+			want: &FileConclusion{Filepath: "f", License: "BSD-3-Clause", Licenses: []string{"BSD-3-Clause"}, Confidence: 0.9},
+		},
+		{
+			name: "tie",
+			cs: Classifications{
+				{Name: "MIT-0", MatchType: "License", Confidence: 1, StartLine: 1, EndLine: 10},
+				{Name: "MIT", MatchType: "License", Confidence: 1, StartLine: 1, EndLine: 10},
+			},
+			want: &FileConclusion{Filepath: "f", License: "MIT", Licenses: []string{"MIT"}, Confidence: 1},
+		},
+		{
+			name: "headers and licenses",
+			cs: Classifications{
+				{Name: "MIT", MatchType: "License", Confidence: 1, StartLine: 30, EndLine: 49},
+				{Name: "Apache-2.0", MatchType: "Header", Confidence: 0.8, StartLine: 1, EndLine: 10},
+			},
+			want: &FileConclusion{Filepath: "f", License: "Apache-2.0 AND MIT", Licenses: []string{"Apache-2.0", "MIT"}, Confidence: (0.8*10 + 1*20) / 30},
+		},
+		{
+			name: "header within a license",
+			cs: Classifications{
+				{Name: "Apache-2.0", MatchType: "License", Confidence: 1, StartLine: 1, EndLine: 200},
+				{Name: "BSD-3-Clause", MatchType: "Header", Confidence: 0.9, StartLine: 180, EndLine: 190},
+			},
+			want: &FileConclusion{Filepath: "f", License: "Apache-2.0", Licenses: []string{"Apache-2.0"}, Confidence: 1},
+		},
+		{
+			name: "skipped and empty",
+			cs: Classifications{
+				{Name: "Minified", MatchType: "Skipped"},
+				{Name: "LicenseFile", MatchType: "Empty"},
+			},
+			want: nil,
+		},
+		{
+			name: "references only",
+			cs: Classifications{
+				{Name: "MIT", MatchType: "Reference", Confidence: 0.75, StartLine: 3, EndLine: 3},
+			},
+			want: nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Concluded(&FileClassifications{Filepath: "f", Classifications: tt.cs})
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("Concluded() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,333 @@
+// Copyright 2022 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package results
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Finding is a single license detected in a file, independent of where in the
+// file it was found.
+type Finding struct {
+	// ID is the stable identifier of the finding in its scan, if the scan
+	// recorded one; see FindingID.
+	ID         string `json:",omitempty"`
+	Filepath   string
+	Name       string
+	MatchType  string `json:",omitempty"`
+	Confidence float64
+}
+
+// ConfidenceChange records a license found in both scans of a file whose
+// confidence differs between them.
+type ConfidenceChange struct {
+	Filepath string
+	Name     string
+	Old      float64
+	New      float64
+}
+
+// Move records a finding that a later scan finds unchanged under another
+// path, as happens when a file is moved or renamed.
+type Move struct {
+	ID          string
+	Name        string
+	OldFilepath string
+	Filepath    string
+}
+
+// ResultDiff describes how a scan differs from an earlier one.
+type ResultDiff struct {
+	// Introduced are findings in the new scan that were not in the old scan.
+	Introduced []*Finding
+	// Removed are findings in the old scan that are not in the new scan.
+	Removed []*Finding
+	// Changed are findings present in both scans with different confidence.
+	Changed []*ConfidenceChange
+	// NewLicenses are license names found in the new scan that appeared in no
+	// file of the old scan.
+	NewLicenses []string
+	// Moved are findings of the old scan that the new scan holds with the
+	// same identifier under another path. They're reported as neither
+	// introduced nor removed.
+	Moved []*Move `json:",omitempty"`
+	// Downgrades are the licenses of files whose header or reference the new
+	// scan finds removed or weakened. DiffResults leaves them to be set by
+	// DiffDowngrades, which needs to know which files are still in the tree.
+	Downgrades []*Downgrade `json:",omitempty"`
+}
+
+// Kinds of Downgrade.
+const (
+	// DowngradeRemoved is a license no longer found in the file at all.
+	DowngradeRemoved = "removed"
+	// DowngradeWeakened is a license still found in the file, but only by a
+	// weaker kind of match, such as a header replaced by a reference.
+	DowngradeWeakened = "weakened"
+)
+
+// Downgrade records a license of a file that a later scan no longer finds in
+// it as strongly as an earlier one did, as happens when a refactoring or a
+// vendoring update drops or shortens a license header.
+type Downgrade struct {
+	Filepath string
+	Name     string
+	// Kind is DowngradeRemoved or DowngradeWeakened.
+	Kind string
+	// OldMatchType and NewMatchType are the strongest kinds of match of the
+	// license in the file in each scan. NewMatchType is empty if the license
+	// was removed.
+	OldMatchType string
+	NewMatchType string `json:",omitempty"`
+}
+
+// matchTypeStrength ranks the kinds of match that declare the license of a
+// file, from the full text of the license to a mere reference to it. Other
+// kinds of match, such as copyrights, don't declare a license so can't be
+// downgraded.
+var matchTypeStrength = map[string]int{
+	"Reference": 1,
+	"Header":    2,
+	"License":   3,
+}
+
+// ReadJSONResult reads a JSON file written by identify_license.
+func ReadJSONResult(filename string) (JSONResult, error) {
+	b, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	// Files written without --json_report hold just the results.
+	if bytes.HasPrefix(bytes.TrimSpace(b), []byte("[")) {
+		var jr JSONResult
+		if err := json.Unmarshal(b, &jr); err != nil {
+			return nil, err
+		}
+		return jr, nil
+	}
+	var r Report
+	if err := json.Unmarshal(b, &r); err != nil {
+		return nil, err
+	}
+	return r.Results, nil
+}
+
+type findingKey struct {
+	filepath, name, matchType string
+}
+
+// findings flattens the result into the highest confidence finding of each
+// license in each file.
+func findings(jr JSONResult) map[findingKey]*Finding {
+	out := make(map[findingKey]*Finding)
+	for _, fc := range jr {
+		for _, c := range fc.Classifications {
+			k := findingKey{fc.Filepath, c.Name, c.MatchType}
+			if f, ok := out[k]; ok && f.Confidence >= c.Confidence {
+				continue
+			}
+			out[k] = &Finding{
+				ID:         c.ID,
+				Filepath:   fc.Filepath,
+				Name:       c.Name,
+				MatchType:  c.MatchType,
+				Confidence: c.Confidence,
+			}
+		}
+	}
+	return out
+}
+
+// DiffResults compares two scans of the same tree. Findings are correlated by
+// file path, license name and match type, so moving a license within a file
+// is not reported as a change. Findings left over are then correlated by their
+// identifiers, which don't depend on the path of the file, so moving a file
+// unchanged is reported as a move rather than as a license removed and
+// another introduced.
+func DiffResults(before, after JSONResult) *ResultDiff {
+	of, nf := findings(before), findings(after)
+	d := &ResultDiff{}
+
+	oldNames := make(map[string]bool)
+	for _, f := range of {
+		oldNames[f.Name] = true
+	}
+	newNames := make(map[string]bool)
+
+	for k, f := range nf {
+		o, ok := of[k]
+		switch {
+		case !ok:
+			d.Introduced = append(d.Introduced, f)
+		case o.Confidence != f.Confidence:
+			d.Changed = append(d.Changed, &ConfidenceChange{
+				Filepath: f.Filepath,
+				Name:     f.Name,
+				Old:      o.Confidence,
+				New:      f.Confidence,
+			})
+		}
+		if !oldNames[f.Name] && !newNames[f.Name] {
+			newNames[f.Name] = true
+			d.NewLicenses = append(d.NewLicenses, f.Name)
+		}
+	}
+	for k, f := range of {
+		if _, ok := nf[k]; !ok {
+			d.Removed = append(d.Removed, f)
+		}
+	}
+
+	sortFindings(d.Introduced)
+	sortFindings(d.Removed)
+	// The findings are paired in order, so that files moved along with
+	// identical copies are paired the same way every time.
+	d.Introduced, d.Removed, d.Moved = moves(d.Introduced, d.Removed)
+	sort.Slice(d.Changed, func(i, j int) bool {
+		if d.Changed[i].Filepath != d.Changed[j].Filepath {
+			return d.Changed[i].Filepath < d.Changed[j].Filepath
+		}
+		return d.Changed[i].Name < d.Changed[j].Name
+	})
+	sort.Strings(d.NewLicenses)
+	return d
+}
+
+// moves pairs the introduced and removed findings that have the same
+// identifier, and returns the findings left unpaired with the moves, keeping
+// their order.
+func moves(introduced, removed []*Finding) (in, out []*Finding, moved []*Move) {
+	byID := make(map[string][]*Finding)
+	for _, f := range removed {
+		if f.ID != "" {
+			byID[f.ID] = append(byID[f.ID], f)
+		}
+	}
+	paired := make(map[*Finding]bool)
+	for _, f := range introduced {
+		olds := byID[f.ID]
+		if f.ID == "" || len(olds) == 0 {
+			in = append(in, f)
+			continue
+		}
+		o := olds[0]
+		byID[f.ID] = olds[1:]
+		paired[o] = true
+		moved = append(moved, &Move{ID: f.ID, Name: f.Name, OldFilepath: o.Filepath, Filepath: f.Filepath})
+	}
+	for _, f := range removed {
+		if !paired[f] {
+			out = append(out, f)
+		}
+	}
+	return in, out, moved
+}
+
+func sortFindings(f []*Finding) {
+	sort.Slice(f, func(i, j int) bool {
+		if f[i].Filepath != f[j].Filepath {
+			return f[i].Filepath < f[j].Filepath
+		}
+		if f[i].Name != f[j].Name {
+			return f[i].Name < f[j].Name
+		}
+		return f[i].MatchType < f[j].MatchType
+	})
+}
+
+// DiffDowngrades compares two scans of the same tree and returns the licenses
+// whose strongest match in a file is weaker in the new scan than in the old
+// one, or missing altogether. A file the new scan holds no findings for may
+// have been deleted rather than stripped of its license, so it's only reported
+// if exists reports that it's still in the tree. If exists is nil, every such
+// file is assumed to still be in the tree, so that no stripped license is
+// missed at the cost of also reporting the licenses of deleted files.
+func DiffDowngrades(before, after JSONResult, exists func(path string) bool) []*Downgrade {
+	of, nf := strongest(before), strongest(after)
+	scanned := make(map[string]bool)
+	for _, fc := range after {
+		scanned[fc.Filepath] = true
+	}
+
+	var out []*Downgrade
+	for k, o := range of {
+		if !scanned[k.filepath] && exists != nil && !exists(k.filepath) {
+			continue
+		}
+		n, ok := nf[k]
+		switch {
+		case !ok:
+			out = append(out, &Downgrade{
+				Filepath:     k.filepath,
+				Name:         k.name,
+				Kind:         DowngradeRemoved,
+				OldMatchType: o,
+			})
+		case matchTypeStrength[n] < matchTypeStrength[o]:
+			out = append(out, &Downgrade{
+				Filepath:     k.filepath,
+				Name:         k.name,
+				Kind:         DowngradeWeakened,
+				OldMatchType: o,
+				NewMatchType: n,
+			})
+		}
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Filepath != out[j].Filepath {
+			return out[i].Filepath < out[j].Filepath
+		}
+		return out[i].Name < out[j].Name
+	})
+	return out
+}
+
+// strongest returns the strongest kind of match declaring each license of
+// each file of the result, keyed by file path and license name.
+func strongest(jr JSONResult) map[findingKey]string {
+	out := make(map[findingKey]string)
+	for _, fc := range jr {
+		for _, c := range fc.Classifications {
+			if matchTypeStrength[c.MatchType] == 0 {
+				continue
+			}
+			k := findingKey{filepath: fc.Filepath, name: c.Name}
+			if matchTypeStrength[c.MatchType] > matchTypeStrength[out[k]] {
+				out[k] = c.MatchType
+			}
+		}
+	}
+	return out
+}
+
+// Rebase returns the result with the paths of its files made relative to
+// root, so that scans of two copies of a tree, such as checkouts before and
+// after a refactoring, can be compared. Paths outside root are left alone.
+func (jr JSONResult) Rebase(root string) JSONResult {
+	out := make(JSONResult, 0, len(jr))
+	for _, fc := range jr {
+		p := fc.Filepath
+		if rel, err := filepath.Rel(root, p); err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			p = rel
+		}
+		out = append(out, &FileClassifications{Filepath: p, Classifications: fc.Classifications})
+	}
+	return out
+}
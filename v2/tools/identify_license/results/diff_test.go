@@ -0,0 +1,183 @@
+// Copyright 2022 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package results
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+// file returns the classifications of a file holding a match of each license
+// of kinds, a list of alternating license names and match types.
+func file(path string, kinds ...string) *FileClassifications {
+	fc := &FileClassifications{Filepath: path}
+	for i := 0; i+1 < len(kinds); i += 2 {
+		fc.Classifications = append(fc.Classifications, &Classification{
+			Name:       kinds[i],
+			MatchType:  kinds[i+1],
+			Confidence: 1,
+		})
+	}
+	return fc
+}
+
+func TestDiffResults(t *testing.T) {
+	// withID gives the file's findings identifiers, as if its contents hashed
+	// to hash.
+	withID := func(fc *FileClassifications, hash string) *FileClassifications {
+		for _, c := range fc.Classifications {
+			c.ID = FindingID(hash, c.Name, c.StartLine, c.EndLine)
+		}
+		return fc
+	}
+	changed := file("c.go", "MIT", "Header")
+	changed.Classifications[0].Confidence = .9
+	before := JSONResult{
+		file("a.go", "MIT", "Header"),
+		file("b.go", "Apache-2.0", "Header"),
+		changed,
+		withID(file("old/d.go", "BSD-3-Clause", "Header"), "d"),
+		withID(file("e.go", "ISC", "Header"), "e"),
+	}
+	after := JSONResult{
+		file("a.go", "MIT", "Header", "GPL-3.0", "Reference"),
+		file("c.go", "MIT", "Header"),
+		withID(file("new/d.go", "BSD-3-Clause", "Header"), "d"),
+		// The same license in changed contents isn't a move.
+		withID(file("f.go", "ISC", "Header"), "f"),
+	}
+	d := DiffResults(before, after)
+
+	want := &ResultDiff{
+		Introduced: []*Finding{
+			{Filepath: "a.go", Name: "GPL-3.0", MatchType: "Reference", Confidence: 1},
+			{ID: FindingID("f", "ISC", 0, 0), Filepath: "f.go", Name: "ISC", MatchType: "Header", Confidence: 1},
+		},
+		Removed: []*Finding{
+			{Filepath: "b.go", Name: "Apache-2.0", MatchType: "Header", Confidence: 1},
+			{ID: FindingID("e", "ISC", 0, 0), Filepath: "e.go", Name: "ISC", MatchType: "Header", Confidence: 1},
+		},
+		Changed: []*ConfidenceChange{
+			{Filepath: "c.go", Name: "MIT", Old: .9, New: 1},
+		},
+		NewLicenses: []string{"GPL-3.0"},
+		Moved: []*Move{
+			{ID: FindingID("d", "BSD-3-Clause", 0, 0), Name: "BSD-3-Clause", OldFilepath: "old/d.go", Filepath: "new/d.go"},
+		},
+	}
+	if diff := cmp.Diff(want, d); diff != "" {
+		t.Errorf("DiffResults() mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestDiffResultsMovedCopies(t *testing.T) {
+	// Identical files moved together are paired in order.
+	finding := func(path string) *FileClassifications {
+		fc := file(path, "MIT", "Header")
+		fc.Classifications[0].ID = "id"
+		return fc
+	}
+	before := JSONResult{finding("old/b.go"), finding("old/a.go")}
+	after := JSONResult{finding("new/b.go"), finding("new/a.go")}
+	want := []*Move{
+		{ID: "id", Name: "MIT", OldFilepath: "old/a.go", Filepath: "new/a.go"},
+		{ID: "id", Name: "MIT", OldFilepath: "old/b.go", Filepath: "new/b.go"},
+	}
+	d := DiffResults(before, after)
+	if diff := cmp.Diff(want, d.Moved); diff != "" {
+		t.Errorf("DiffResults() moves mismatch (-want +got):\n%s", diff)
+	}
+	if len(d.Introduced) != 0 || len(d.Removed) != 0 {
+		t.Errorf("DiffResults() = %d introduced and %d removed, want none", len(d.Introduced), len(d.Removed))
+	}
+}
+
+func TestDiffDowngrades(t *testing.T) {
+	before := JSONResult{
+		file("a.go", "MIT", "Header"),
+		file("b.go", "MIT", "Header"),
+		file("c.go", "Apache-2.0", "Header", "Apache-2.0", "Reference"),
+		file("deleted.go", "MIT", "Header"),
+		file("stripped.go", "MIT", "Header"),
+		file("LICENSE", "MIT", "License"),
+	}
+	after := JSONResult{
+		file("a.go", "MIT", "Header"),
+		file("b.go", "MIT", "Reference"),
+		file("c.go", "BSD-3-Clause", "Header"),
+		file("LICENSE", "MIT", "License", "MIT", "Reference"),
+	}
+	exists := func(path string) bool {
+		return path != "deleted.go"
+	}
+
+	want := []*Downgrade{
+		{Filepath: "b.go", Name: "MIT", Kind: DowngradeWeakened, OldMatchType: "Header", NewMatchType: "Reference"},
+		{Filepath: "c.go", Name: "Apache-2.0", Kind: DowngradeRemoved, OldMatchType: "Header"},
+		{Filepath: "stripped.go", Name: "MIT", Kind: DowngradeRemoved, OldMatchType: "Header"},
+	}
+	if diff := cmp.Diff(want, DiffDowngrades(before, after, exists)); diff != "" {
+		t.Errorf("DiffDowngrades() mismatch (-want +got):\n%s", diff)
+	}
+
+	// Without a way to tell deleted files apart, the files the new scan
+	// holds no findings for are all reported.
+	want = []*Downgrade{
+		{Filepath: "b.go", Name: "MIT", Kind: DowngradeWeakened, OldMatchType: "Header", NewMatchType: "Reference"},
+		{Filepath: "c.go", Name: "Apache-2.0", Kind: DowngradeRemoved, OldMatchType: "Header"},
+		{Filepath: "deleted.go", Name: "MIT", Kind: DowngradeRemoved, OldMatchType: "Header"},
+		{Filepath: "stripped.go", Name: "MIT", Kind: DowngradeRemoved, OldMatchType: "Header"},
+	}
+	if diff := cmp.Diff(want, DiffDowngrades(before, after, nil)); diff != "" {
+		t.Errorf("DiffDowngrades(nil) mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestDiffDowngradesIgnoresOtherMatchTypes(t *testing.T) {
+	before := JSONResult{file("a.go", "Copyright", "Copyright", "MIT", "Header")}
+	after := JSONResult{file("a.go", "MIT", "Header")}
+	if got := DiffDowngrades(before, after, nil); len(got) != 0 {
+		t.Errorf("DiffDowngrades() = %v, want no downgrades", got)
+	}
+}
+
+func TestRebase(t *testing.T) {
+	root := filepath.FromSlash("/src/old")
+	jr := JSONResult{
+		file(filepath.FromSlash("/src/old/LICENSE"), "MIT", "License"),
+		file(filepath.FromSlash("/src/old/pkg/a.go"), "MIT", "Header"),
+		file(filepath.FromSlash("/src/older/b.go"), "MIT", "Header"),
+		file(filepath.FromSlash("/src/c.go"), "MIT", "Header"),
+	}
+	var got []string
+	for _, fc := range jr.Rebase(root) {
+		got = append(got, fc.Filepath)
+	}
+	want := []string{
+		"LICENSE",
+		filepath.FromSlash("pkg/a.go"),
+		filepath.FromSlash("/src/older/b.go"),
+		filepath.FromSlash("/src/c.go"),
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("Rebase() paths mismatch (-want +got):\n%s", diff)
+	}
+	// The result rebased is left alone.
+	if jr[0].Filepath != filepath.FromSlash("/src/old/LICENSE") {
+		t.Errorf("Rebase() changed the result rebased: %q", jr[0].Filepath)
+	}
+}
@@ -0,0 +1,116 @@
+// Copyright 2022 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package results
+
+import (
+	_ "embed"
+	"html/template"
+	"io"
+	"sort"
+)
+
+// reportHTML is the template of the HTML report, a single page with its
+// styles and scripts inline so that it can be opened without a server.
+//
+//go:embed report.html
+var reportHTML string
+
+var reportTemplate = template.Must(template.New("report").Parse(reportHTML))
+
+// HTMLPolicy describes the license policy that the classifications of an HTML
+// report are checked against.
+type HTMLPolicy struct {
+	// Category returns the category of a license, such as "notice" or
+	// "restricted", shown alongside it, or "" if it has none.
+	Category func(name string) string
+	// Violates reports whether a classification violates the policy, which
+	// highlights it in the report.
+	Violates func(c *Classification) bool
+}
+
+// htmlFinding is a row of the findings table of the HTML report.
+type htmlFinding struct {
+	File     string
+	Category string
+	Violates bool
+	*Classification
+}
+
+// htmlLicense is a row of the license summary of the HTML report.
+type htmlLicense struct {
+	Name     string
+	Category string
+	Violates bool
+	Files    int
+}
+
+// WriteHTML writes the report as a standalone HTML page for reviewers without
+// tools to process the JSON output: a summary of the licenses found and the
+// number of files holding each, and a table of the classifications of each
+// file, both sortable by any column, with the classifications that violate the
+// policy highlighted. The text of a classification, if the report holds it, is
+// shown when its row is clicked.
+func (r *Report) WriteHTML(w io.Writer, policy HTMLPolicy) error {
+	var findings []htmlFinding
+	licenses := make(map[string]*htmlLicense)
+	files := make(map[string]map[string]bool)
+	violations := 0
+	for _, f := range r.Results {
+		for _, c := range f.Classifications {
+			hf := htmlFinding{File: f.Filepath, Classification: c}
+			if policy.Category != nil {
+				hf.Category = policy.Category(c.Name)
+			}
+			if policy.Violates != nil && policy.Violates(c) {
+				hf.Violates = true
+				violations++
+			}
+			findings = append(findings, hf)
+
+			l, ok := licenses[c.Name]
+			if !ok {
+				l = &htmlLicense{Name: c.Name, Category: hf.Category}
+				licenses[c.Name] = l
+				files[c.Name] = make(map[string]bool)
+			}
+			l.Violates = l.Violates || hf.Violates
+			if !files[c.Name][f.Filepath] {
+				files[c.Name][f.Filepath] = true
+				l.Files++
+			}
+		}
+	}
+	var summary []*htmlLicense
+	for _, l := range licenses {
+		summary = append(summary, l)
+	}
+	sort.Slice(summary, func(i, j int) bool { return summary[i].Name < summary[j].Name })
+
+	return reportTemplate.Execute(w, struct {
+		Version    string
+		Files      int
+		Violations int
+		Licenses   []*htmlLicense
+		Findings   []htmlFinding
+		Notices    []*NoticeFinding
+	}{
+		Version:    r.Version.String(),
+		Files:      len(r.Results),
+		Violations: violations,
+		Licenses:   summary,
+		Findings:   findings,
+		Notices:    r.Notices,
+	})
+}
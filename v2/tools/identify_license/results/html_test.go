@@ -0,0 +1,72 @@
+// Copyright 2022 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package results
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestWriteHTML(t *testing.T) {
+	r := &Report{
+		Results: JSONResult{
+			file("a/LICENSE", "MIT", "License"),
+			file("a/main.go", "MIT", "Header", "GPL-2.0", "Header"),
+			{
+				Filepath: "b/x.go",
+				Classifications: []*Classification{
+					{Name: "Apache-2.0", MatchType: "Header", Confidence: 1, Text: "<Licensed under the Apache License>"},
+				},
+			},
+		},
+		Notices: []*NoticeFinding{{Kind: NoticeMissing, Directory: "b", Licenses: []string{"Apache-2.0"}}},
+	}
+	categories := map[string]string{"MIT": "notice", "Apache-2.0": "notice", "GPL-2.0": "restricted"}
+	policy := HTMLPolicy{
+		Category: func(name string) string { return categories[name] },
+		Violates: func(c *Classification) bool { return categories[c.Name] == "restricted" },
+	}
+	var b bytes.Buffer
+	if err := r.WriteHTML(&b, policy); err != nil {
+		t.Fatalf("WriteHTML() = %v", err)
+	}
+	got := b.String()
+	for _, want := range []string{
+		"3 files scanned",
+		"1 classifications violate the license policy",
+		// MIT is found in two files, and the summary row of GPL-2.0 is
+		// highlighted.
+		`<tr><td>MIT</td><td>notice</td><td class="number">2</td></tr>`,
+		`<tr class="violation"><td>GPL-2.0</td><td>restricted</td><td class="number">1</td></tr>`,
+		`<tr class="violation"><td>a/main.go</td><td>GPL-2.0</td><td>restricted</td><td>Header</td>`,
+		// The text of a classification is escaped.
+		"<pre>&lt;Licensed under the Apache License&gt;</pre>",
+		"<td>" + NoticeMissing + "</td><td>b</td><td>Apache-2.0</td>",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("WriteHTML() output lacks %q:\n%s", want, got)
+		}
+	}
+	// A report without violations or notices says nothing of them.
+	r = &Report{Results: JSONResult{file("a/LICENSE", "MIT", "License")}}
+	b.Reset()
+	if err := r.WriteHTML(&b, HTMLPolicy{}); err != nil {
+		t.Fatalf("WriteHTML() = %v", err)
+	}
+	if got := b.String(); strings.Contains(got, "violate") || strings.Contains(got, "class=\"violation\"") {
+		t.Errorf("WriteHTML() without a policy reports violations:\n%s", got)
+	}
+}
@@ -0,0 +1,142 @@
+// Copyright 2022 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package results
+
+import (
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// noticeLicenses are the licenses whose terms require the NOTICE file of the
+// work, if it has one, to be redistributed with it.
+var noticeLicenses = map[string]bool{
+	"Apache-2.0":                    true,
+	"Apache-2.0-Modified":           true,
+	"Apache-with-LLVM-Exception":    true,
+	"Apache-with-Runtime-Exception": true,
+	"ImageMagick":                   true,
+}
+
+// Kinds of notice findings.
+const (
+	// NoticeMissing is a directory whose license files carry a license that
+	// requires notices to be redistributed, but that has no NOTICE file.
+	// Such a license only requires a NOTICE file if the original work had
+	// one, so the finding calls for a review rather than proving a problem.
+	NoticeMissing = "MissingNotice"
+	// NoticeOrphaned is a NOTICE file in a directory without a license file,
+	// so the terms it belongs to can't be determined.
+	NoticeOrphaned = "OrphanedNotice"
+)
+
+// NoticeFinding is a problem found pairing NOTICE files with license files.
+type NoticeFinding struct {
+	Kind      string
+	Directory string
+	// Licenses are the licenses of the directory that require notices, for
+	// NoticeMissing findings.
+	Licenses []string `json:",omitempty"`
+	// Notice is the NOTICE file, for NoticeOrphaned findings.
+	Notice string `json:",omitempty"`
+}
+
+// noticeExtensions are the extensions of NOTICE files.
+var noticeExtensions = map[string]bool{
+	"":      true,
+	".md":   true,
+	".rst":  true,
+	".text": true,
+	".txt":  true,
+}
+
+// IsNoticeFile reports whether the base name of the path looks like a NOTICE
+// file, such as NOTICE or NOTICES.txt.
+func IsNoticeFile(path string) bool {
+	base := strings.ToLower(filepath.Base(path))
+	ext := filepath.Ext(base)
+	stem := strings.TrimSuffix(base, ext)
+	return (stem == "notice" || stem == "notices") && noticeExtensions[ext]
+}
+
+// CheckNotices pairs the NOTICE files among the scanned files with the license
+// files in the same directory, and reports the directories whose licenses
+// require notices but have no NOTICE file, as well as the NOTICE files without
+// a license file next to them. The licenses of a directory are those found in
+// its license files by the results, including directory-level results. The
+// licenses requiring notices are recognized by their names in the corpus, and
+// reported by the names of the results, which may have been Resolved.
+func CheckNotices(filenames []string, lt LicenseTypes) []*NoticeFinding {
+	notices := make(map[string][]string)
+	licensed := make(map[string]bool)
+	for _, f := range filenames {
+		dir := filepath.Dir(f)
+		switch {
+		case IsNoticeFile(f):
+			notices[dir] = append(notices[dir], f)
+		case IsLicenseFile(f):
+			licensed[dir] = true
+		}
+	}
+
+	requiring := make(map[string]map[string]bool)
+	for _, l := range lt {
+		if l.MatchType != "License" || !noticeLicenses[l.CorpusName()] {
+			continue
+		}
+		dir := filepath.Dir(l.Filename)
+		switch {
+		case l.EndLine == 0:
+			dir = l.Filename
+		case !IsLicenseFile(l.Filename) || IsNoticeFile(l.Filename):
+			continue
+		}
+		if requiring[dir] == nil {
+			requiring[dir] = make(map[string]bool)
+		}
+		requiring[dir][l.Name] = true
+	}
+
+	var out []*NoticeFinding
+	for dir, names := range requiring {
+		if len(notices[dir]) > 0 {
+			continue
+		}
+		nf := &NoticeFinding{Kind: NoticeMissing, Directory: dir}
+		for n := range names {
+			nf.Licenses = append(nf.Licenses, n)
+		}
+		sort.Strings(nf.Licenses)
+		out = append(out, nf)
+	}
+	for dir, files := range notices {
+		if licensed[dir] {
+			continue
+		}
+		for _, f := range files {
+			out = append(out, &NoticeFinding{Kind: NoticeOrphaned, Directory: dir, Notice: f})
+		}
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Directory != out[j].Directory {
+			return out[i].Directory < out[j].Directory
+		}
+		if out[i].Kind != out[j].Kind {
+			return out[i].Kind < out[j].Kind
+		}
+		return out[i].Notice < out[j].Notice
+	})
+	return out
+}
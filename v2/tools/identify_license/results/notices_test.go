@@ -0,0 +1,102 @@
+// Copyright 2022 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package results
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestIsNoticeFile(t *testing.T) {
+	tests := []struct {
+		path string
+		want bool
+	}{
+		{"a/NOTICE", true},
+		{"a/NOTICES.txt", true},
+		{"a/notice.md", true},
+		{"a/NOTICE.go", false},
+		{"a/NOTICE_TEMPLATE", false},
+		{"a/LICENSE", false},
+	}
+	for _, tt := range tests {
+		if got := IsNoticeFile(tt.path); got != tt.want {
+			t.Errorf("IsNoticeFile(%q) = %v, want %v", tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestCheckNotices(t *testing.T) {
+	apache := func(filename string) *LicenseType {
+		return &LicenseType{Filename: filename, Name: "Apache-2.0", MatchType: "License", Confidence: 1, StartLine: 1, EndLine: 202}
+	}
+	filenames := []string{
+		"noticed/LICENSE", "noticed/NOTICE",
+		"missing/LICENSE",
+		"orphaned/NOTICE", "orphaned/main.go",
+		"header/main.go",
+		"mit/LICENSE",
+	}
+	lt := LicenseTypes{
+		apache("noticed/LICENSE"),
+		apache("missing/LICENSE"),
+		// A header in a source file doesn't make the directory require a
+		// NOTICE file.
+		apache("header/main.go"),
+		{Filename: "mit/LICENSE", Name: "MIT", MatchType: "License", Confidence: 1, StartLine: 1, EndLine: 21},
+		// A directory-level result, such as one for a license split across
+		// files, applies to the directory itself.
+		{Filename: "split", Name: "Apache-2.0", MatchType: "License", Confidence: 1},
+	}
+	want := []*NoticeFinding{
+		{Kind: NoticeMissing, Directory: "missing", Licenses: []string{"Apache-2.0"}},
+		{Kind: NoticeOrphaned, Directory: "orphaned", Notice: "orphaned/NOTICE"},
+		{Kind: NoticeMissing, Directory: "split", Licenses: []string{"Apache-2.0"}},
+	}
+	if diff := cmp.Diff(want, CheckNotices(filenames, lt)); diff != "" {
+		t.Errorf("CheckNotices() mismatch (-want +got):\n%s", diff)
+	}
+
+	// Licenses reported under resolved names are still recognized, and the
+	// findings give their resolved names.
+	resolved := make(LicenseTypes, len(lt))
+	for i, l := range lt {
+		resolved[i] = l.Resolved("notice:"+l.Name, l.Variant)
+	}
+	want = []*NoticeFinding{
+		{Kind: NoticeMissing, Directory: "missing", Licenses: []string{"notice:Apache-2.0"}},
+		{Kind: NoticeOrphaned, Directory: "orphaned", Notice: "orphaned/NOTICE"},
+		{Kind: NoticeMissing, Directory: "split", Licenses: []string{"notice:Apache-2.0"}},
+	}
+	if diff := cmp.Diff(want, CheckNotices(filenames, resolved)); diff != "" {
+		t.Errorf("CheckNotices() of resolved results mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestResolved(t *testing.T) {
+	l := &LicenseType{Name: "Apache-2.0", Variant: "license.txt"}
+	r := l.Resolved("permissive", "")
+	if r.Name != "permissive" || r.Variant != "" || r.CorpusName() != "Apache-2.0" {
+		t.Errorf("Resolved() = %s %q with corpus name %s, want permissive \"\" with corpus name Apache-2.0", r.Name, r.Variant, r.CorpusName())
+	}
+	if l.Name != "Apache-2.0" || l.CorpusName() != "Apache-2.0" {
+		t.Errorf("Resolved() changed the original result to %s", l.Name)
+	}
+	// Resolving again keeps the name of the corpus.
+	if got := r.Resolved("notice", "").CorpusName(); got != "Apache-2.0" {
+		t.Errorf("CorpusName() after resolving twice = %s, want Apache-2.0", got)
+	}
+}
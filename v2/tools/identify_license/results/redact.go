@@ -0,0 +1,139 @@
+// Copyright 2022 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package results
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Redactor rewrites the paths in reports so that they can be shared, for
+// example with external auditors, without revealing the layout of the scanned
+// tree. A nil Redactor leaves paths unchanged.
+type Redactor struct {
+	// StripPrefixes are removed from the start of paths. A prefix matches
+	// whole path elements, so /src matches /src/foo but not /srcfoo. Only
+	// the first prefix that matches a path is removed.
+	StripPrefixes []string
+	// Hash replaces each element of a path with a hash of it, keeping the
+	// extension of the base name. Equal paths hash equally, so results for
+	// the same file or directory can still be grouped.
+	Hash bool
+	// Salt is mixed into the hashes so that common names can't be recovered
+	// by hashing them.
+	Salt string
+}
+
+// Path returns the redacted path.
+func (r *Redactor) Path(path string) string {
+	if r == nil || path == "" {
+		return path
+	}
+	for _, p := range r.StripPrefixes {
+		if rest, ok := stripPrefix(path, p); ok {
+			path = rest
+			break
+		}
+	}
+	if !r.Hash {
+		return path
+	}
+	elems := strings.Split(path, string(filepath.Separator))
+	for i, e := range elems {
+		if e == "" || e == "." || e == ".." {
+			continue
+		}
+		ext := ""
+		if i == len(elems)-1 {
+			ext = filepath.Ext(e)
+		}
+		sum := sha256.Sum256([]byte(r.Salt + e))
+		elems[i] = hex.EncodeToString(sum[:])[:12] + ext
+	}
+	return strings.Join(elems, string(filepath.Separator))
+}
+
+// stripPrefix returns the path without the prefix, and whether the prefix
+// matched whole elements at the start of the path.
+func stripPrefix(path, prefix string) (string, bool) {
+	sep := string(filepath.Separator)
+	if prefix == "" {
+		return path, false
+	}
+	if p := strings.TrimRight(prefix, sep); p != "" {
+		prefix = p
+	}
+	if !strings.HasPrefix(path, prefix) {
+		return path, false
+	}
+	rest := path[len(prefix):]
+	if rest != "" && !strings.HasPrefix(rest, sep) && !strings.HasSuffix(prefix, sep) {
+		return path, false
+	}
+	return strings.TrimLeft(rest, sep), true
+}
+
+// textPathRE matches the quoted strings, and the unquoted words holding a path
+// separator, of text such as error messages, which are the paths it may hold.
+var textPathRE = regexp.MustCompile(`"(?:[^"\\]|\\.)*"|[^\s"]*` + regexp.QuoteMeta(string(filepath.Separator)) + `[^\s":]*`)
+
+// Text returns the text, such as a log message or the message of an error,
+// with the paths in it redacted. Paths are recognized as quoted strings, as
+// the errors of the scan quote the files they're about, and as words holding
+// a path separator, up to a colon, as the errors of the os package give them.
+func (r *Redactor) Text(text string) string {
+	if r == nil {
+		return text
+	}
+	sep := string(filepath.Separator)
+	return textPathRE.ReplaceAllStringFunc(text, func(s string) string {
+		if !strings.HasPrefix(s, `"`) {
+			return r.Path(s)
+		}
+		if u, err := strconv.Unquote(s); err == nil && strings.Contains(u, sep) {
+			return strconv.Quote(r.Path(u))
+		}
+		return s
+	})
+}
+
+// JSONResult redacts the paths of the results in place.
+func (r *Redactor) JSONResult(jr JSONResult) {
+	if r == nil {
+		return
+	}
+	for _, f := range jr {
+		f.Filepath = r.Path(f.Filepath)
+		for _, c := range f.Classifications {
+			c.Source = r.Path(c.Source)
+			c.Sidecar = r.Path(c.Sidecar)
+		}
+	}
+}
+
+// Notices redacts the paths of the notice findings in place.
+func (r *Redactor) Notices(notices []*NoticeFinding) {
+	if r == nil {
+		return
+	}
+	for _, n := range notices {
+		n.Directory = r.Path(n.Directory)
+		n.Notice = r.Path(n.Notice)
+	}
+}
@@ -0,0 +1,128 @@
+// Copyright 2022 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package results
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRedactorPath(t *testing.T) {
+	tests := []struct {
+		name string
+		r    *Redactor
+		path string
+		want string
+	}{
+		{
+			name: "nil redactor",
+			path: "/src/foo/LICENSE",
+			want: "/src/foo/LICENSE",
+		},
+		{
+			name: "prefix",
+			r:    &Redactor{StripPrefixes: []string{"/src"}},
+			path: "/src/foo/LICENSE",
+			want: "foo/LICENSE",
+		},
+		{
+			name: "prefix with trailing separator",
+			r:    &Redactor{StripPrefixes: []string{"/src/"}},
+			path: "/src/foo/LICENSE",
+			want: "foo/LICENSE",
+		},
+		{
+			name: "prefix of an element",
+			r:    &Redactor{StripPrefixes: []string{"/src"}},
+			path: "/srcfoo/LICENSE",
+			want: "/srcfoo/LICENSE",
+		},
+		{
+			name: "whole path",
+			r:    &Redactor{StripPrefixes: []string{"/src/foo"}},
+			path: "/src/foo",
+			want: "",
+		},
+		{
+			name: "first matching prefix",
+			r:    &Redactor{StripPrefixes: []string{"/other", "/src", "/src/foo"}},
+			path: "/src/foo/LICENSE",
+			want: "foo/LICENSE",
+		},
+		{
+			name: "root",
+			r:    &Redactor{StripPrefixes: []string{"/"}},
+			path: "/src/LICENSE",
+			want: "src/LICENSE",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.FromSlash(tt.path)
+			if got, want := tt.r.Path(path), filepath.FromSlash(tt.want); got != want {
+				t.Errorf("Path(%q) = %q, want %q", path, got, want)
+			}
+		})
+	}
+}
+
+func TestRedactorPathHash(t *testing.T) {
+	r := &Redactor{StripPrefixes: []string{"/src"}, Hash: true, Salt: "salt"}
+	got := r.Path(filepath.FromSlash("/src/foo/LICENSE.txt"))
+	elems := strings.Split(got, string(filepath.Separator))
+	if len(elems) != 2 || strings.Contains(got, "foo") || !strings.HasSuffix(got, ".txt") {
+		t.Errorf("Path() = %q, want two hashed elements keeping the extension", got)
+	}
+	if again := r.Path(filepath.FromSlash("/src/foo/LICENSE.txt")); again != got {
+		t.Errorf("Path() = %q, then %q, want equal paths hashed equally", got, again)
+	}
+	if other := (&Redactor{Hash: true, Salt: "other"}).Path(filepath.FromSlash("foo/LICENSE.txt")); other == got {
+		t.Errorf("Path() with another salt = %q, want a different hash", other)
+	}
+}
+
+func TestRedactorText(t *testing.T) {
+	r := &Redactor{StripPrefixes: []string{"/src"}}
+	tests := []struct {
+		text string
+		want string
+	}{
+		{
+			text: `unable to read "/src/foo/LICENSE": open /src/foo/LICENSE: permission denied`,
+			want: `unable to read "foo/LICENSE": open foo/LICENSE: permission denied`,
+		},
+		{
+			text: `unable to classify "/srcfoo/LICENSE": panic`,
+			want: `unable to classify "/srcfoo/LICENSE": panic`,
+		},
+		{
+			text: `errors by category: map[read:1]`,
+			want: `errors by category: map[read:1]`,
+		},
+	}
+	if filepath.Separator != '/' {
+		t.Skip("the messages are written with slashes")
+	}
+	for _, tt := range tests {
+		if got := r.Text(tt.text); got != tt.want {
+			t.Errorf("Text(%q) = %q, want %q", tt.text, got, tt.want)
+		}
+	}
+	var nilRedactor *Redactor
+	if got := nilRedactor.Text(tests[0].text); got != tests[0].text {
+		t.Errorf("Text() of a nil Redactor = %q, want the text unchanged", got)
+	}
+}
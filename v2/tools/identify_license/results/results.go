@@ -19,13 +19,21 @@ package results
 
 import (
 	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
+	"io"
 	"os"
 	"sort"
+	"strings"
+
+	"github.com/google/licenseclassifier/v2/tools/version"
 )
 
 // LicenseType is the assumed type of the unknown license.
 type LicenseType struct {
+	// ID is a stable identifier for this finding; see FindingID.
+	ID         string
 	Filename   string
 	Name       string
 	MatchType  string
@@ -33,6 +41,58 @@ type LicenseType struct {
 	Confidence float64
 	StartLine  int
 	EndLine    int
+	// AlternateVariants are the other variants of the license that matched
+	// equally well.
+	AlternateVariants []string
+	// SnippetStartLine and SnippetEndLine are the bounds of the SPDX snippet
+	// the license was found in, or zero if it wasn't found in a snippet.
+	SnippetStartLine int
+	SnippetEndLine   int
+	// Offset and Extent are the byte range of the preserved comment of a
+	// bundle the license was found in, or zero if it wasn't found in one.
+	Offset int
+	Extent int
+	// Source is the original source, embedded in a source map, that the
+	// license was found in. The lines of the result are lines of that source.
+	Source string
+	// Sidecar is the REUSE-style .license file describing the file that the
+	// license was found in. The lines of the result are lines of the sidecar.
+	Sidecar string
+	// Family is the family of the license, such as GPL-family, if requested.
+	Family string
+	// TruncationSuspected is set when the file holds the start of the license
+	// but lacks its end.
+	TruncationSuspected bool
+	// Sampled is set when the file was too large to classify in full and
+	// only its head and tail were. SampleOffset is the byte offset of the
+	// tail if the license was found in it, in which case the lines of the
+	// result are counted from the start of the tail.
+	Sampled      bool
+	SampleOffset int64
+
+	// corpusName is the name of the license in the corpus, if the result
+	// reports it under another name.
+	corpusName string
+}
+
+// Resolved returns a copy of the result reporting the license under the name
+// and variant given, such as the names of a license policy. Checks of the
+// results that depend on the license, such as CheckNotices, still know it by
+// its name in the corpus.
+func (lt *LicenseType) Resolved(name, variant string) *LicenseType {
+	c := *lt
+	c.corpusName = lt.CorpusName()
+	c.Name, c.Variant = name, variant
+	return &c
+}
+
+// CorpusName returns the name of the license in the corpus, which is Name
+// unless the result was Resolved to another name.
+func (lt *LicenseType) CorpusName() string {
+	if lt.corpusName != "" {
+		return lt.corpusName
+	}
+	return lt.Name
 }
 
 // LicenseTypes is a list of LicenseType objects.
@@ -58,11 +118,46 @@ func (lt LicenseTypes) Less(i, j int) bool {
 
 // Classification is the license classification for a segment of a file.
 type Classification struct {
+	ID         string `json:",omitempty"`
 	Name       string
+	MatchType  string `json:",omitempty"`
 	Confidence float64
 	StartLine  int
 	EndLine    int
-	Text       string `json:",omitempty"`
+	// AlternateVariants are the other variants of the license that matched
+	// equally well, if any.
+	AlternateVariants []string `json:",omitempty"`
+	// SnippetStartLine and SnippetEndLine are the bounds of the SPDX snippet
+	// the classification applies to, if any.
+	SnippetStartLine int `json:",omitempty"`
+	SnippetEndLine   int `json:",omitempty"`
+	// Offset and Extent are the byte range of the preserved comment of a
+	// bundle the classification applies to, if any.
+	Offset int `json:",omitempty"`
+	Extent int `json:",omitempty"`
+	// Source is the original source in a source map the classification
+	// applies to, if any.
+	Source string `json:",omitempty"`
+	// Sidecar is the .license file describing the file the classification
+	// was found in, if any. The lines of the classification are its lines.
+	Sidecar string `json:",omitempty"`
+	// Family is the family of the license, such as GPL-family, if requested
+	// and the license belongs to one.
+	Family string `json:",omitempty"`
+	// TruncationSuspected is set when the file holds the start of the
+	// license but lacks its end, so the full text should be requested.
+	TruncationSuspected bool `json:",omitempty"`
+	// Sampled is set when the file was too large to classify in full and
+	// only its head and tail were. SampleOffset is the byte offset of the
+	// tail if the classification was found in it, in which case its lines
+	// are counted from the start of the tail.
+	Sampled      bool   `json:",omitempty"`
+	SampleOffset int64  `json:",omitempty"`
+	Text         string `json:",omitempty"`
+	// ContextBefore and ContextAfter are the lines of the file surrounding the
+	// classification, if requested with AddContext.
+	ContextBefore string `json:",omitempty"`
+	ContextAfter  string `json:",omitempty"`
 }
 
 // Classifications contains all license classifications for a file
@@ -74,6 +169,25 @@ type FileClassifications struct {
 	Classifications Classifications
 }
 
+// ContentHash returns the hex-encoded SHA-256 digest of a file's contents, as
+// used when computing finding identifiers.
+func ContentHash(contents []byte) string {
+	sum := sha256.Sum256(contents)
+	return hex.EncodeToString(sum[:])
+}
+
+// FindingID returns a deterministic identifier for a detection of the named
+// license over the lines [startLine, endLine] of a file whose contents hash to
+// contentHash. The identifier does not depend on the path of the file, or on
+// how its contents were segmented to classify them, such as into SPDX snippets
+// or the preserved comments of a bundle, so it can be recorded in suppression
+// or baseline files that survive files being moved and scans changing modes.
+func FindingID(contentHash, name string, startLine, endLine int) string {
+	h := sha256.New()
+	fmt.Fprintf(h, "%s\x00%s\x00%d\x00%d", contentHash, name, startLine, endLine)
+	return hex.EncodeToString(h.Sum(nil))[:32]
+}
+
 // JSONResult is the format for the jr JSON file
 type JSONResult []*FileClassifications
 
@@ -81,31 +195,83 @@ func (jr JSONResult) Len() int           { return len(jr) }
 func (jr JSONResult) Swap(i, j int)      { jr[i], jr[j] = jr[j], jr[i] }
 func (jr JSONResult) Less(i, j int) bool { return jr[i].Filepath < jr[j].Filepath }
 
+// Report is the JSON output of identify_license: the results along with the
+// version of the tool and corpus that produced them.
+type Report struct {
+	Version version.Info
+	Results JSONResult
+	// Notices are the problems found pairing NOTICE files with license
+	// files, if they were checked.
+	Notices []*NoticeFinding `json:",omitempty"`
+}
+
+// maxTextLineLength is the number of bytes of a line included in the text of
+// a classification. Longer lines, such as those of minified files, are
+// truncated.
+const maxTextLineLength = 4096
+
 // readFileLines will read a specified range of lines of a file
 func readFileLines(filename string, startLine, endLine int) (string, error) {
-	f, err := os.Open(filename)
+	text, last, err := readLines(filename, startLine, endLine)
 	if err != nil {
 		return "", err
 	}
+	if last < endLine {
+		return "", fmt.Errorf(
+			"line %d was the last line read from file %s, but endLine was set to %d", last, filename, endLine)
+	}
+	return text, nil
+}
+
+// readLines reads the lines of a file in the range [startLine, endLine], or
+// up to the end of the file if it's shorter, and returns them along with the
+// number of the last line read.
+func readLines(filename string, startLine, endLine int) (string, int, error) {
+	f, err := os.Open(filename)
+	if err != nil {
+		return "", 0, err
+	}
 	defer f.Close()
 
-	scanner := bufio.NewScanner(f)
-	lines := ""
+	// A bufio.Reader is used rather than a bufio.Scanner, which fails on
+	// lines longer than its buffer.
+	r := bufio.NewReader(f)
+	var lines strings.Builder
 	i := 0
-	for scanner.Scan() {
+	for i < endLine {
+		line, err := r.ReadString('\n')
+		if line == "" && err != nil {
+			if err == io.EOF {
+				break
+			}
+			return "", 0, err
+		}
 		i++ // lines are 1-indexed
 		if i < startLine {
 			continue
-		} else if i > endLine {
-			break
 		}
-		lines += scanner.Text() + "\n"
+		line = strings.TrimSuffix(strings.TrimSuffix(line, "\n"), "\r")
+		if len(line) > maxTextLineLength {
+			line = strings.ToValidUTF8(line[:maxTextLineLength], "") + "..."
+		}
+		lines.WriteString(line + "\n")
 	}
-	if i < endLine {
-		return "", fmt.Errorf(
-			"line %d was the last line read from file %s, but endLine was set to %d", i, filename, endLine)
+	return lines.String(), i, nil
+}
+
+// readFileRange reads extent bytes of a file starting at offset.
+func readFileRange(filename string, offset, extent int) (string, error) {
+	f, err := os.Open(filename)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	b := make([]byte, extent)
+	if _, err := f.ReadAt(b, int64(offset)); err != nil {
+		return "", fmt.Errorf("reading bytes %d-%d of file %s: %w", offset, offset+extent, filename, err)
 	}
-	return lines, nil
+	return string(b), nil
 }
 
 // NewJSONResult creates a new JSONResult object from a LicenseTypes object.
@@ -118,13 +284,39 @@ func NewJSONResult(licenses LicenseTypes, includeText bool) (JSONResult, error)
 			fMap[l.Filename] = currF
 		}
 		c := &Classification{
+			ID:         l.ID,
 			Name:       l.Name,
+			MatchType:  l.MatchType,
 			Confidence: l.Confidence,
 			StartLine:  l.StartLine,
 			EndLine:    l.EndLine,
+
+			SnippetStartLine: l.SnippetStartLine,
+			SnippetEndLine:   l.SnippetEndLine,
+			Offset:           l.Offset,
+			Extent:           l.Extent,
+			Source:           l.Source,
 		}
-		if includeText {
-			text, err := readFileLines(l.Filename, l.StartLine, l.EndLine)
+		c.AlternateVariants = l.AlternateVariants
+		c.Sidecar = l.Sidecar
+		c.Family = l.Family
+		c.TruncationSuspected = l.TruncationSuspected
+		c.Sampled, c.SampleOffset = l.Sampled, l.SampleOffset
+		// Directory-level results carry no line information, and the lines
+		// of results in a source map are lines of the embedded source, and
+		// those of results in the tail of a sampled file are counted from
+		// the start of the tail.
+		if includeText && l.EndLine > 0 && l.Source == "" && l.SampleOffset == 0 {
+			var text string
+			var err error
+			switch {
+			case l.Extent > 0:
+				text, err = readFileRange(l.Filename, l.Offset, l.Extent)
+			case l.Sidecar != "":
+				text, err = readFileLines(l.Sidecar, l.StartLine, l.EndLine)
+			default:
+				text, err = readFileLines(l.Filename, l.StartLine, l.EndLine)
+			}
 			if err != nil {
 				return nil, err
 			}
@@ -140,3 +332,42 @@ func NewJSONResult(licenses LicenseTypes, includeText bool) (JSONResult, error)
 	sort.Sort(jr)
 	return jr, nil
 }
+
+// AddContext sets the ContextBefore and ContextAfter of the classifications to
+// up to n lines of the file before and after each of them, so that reviewers
+// can see where a license was found without opening the file. Classifications
+// without lines, or whose lines are those of a source embedded in a source
+// map, get no context.
+func (jr JSONResult) AddContext(n int) error {
+	if n <= 0 {
+		return nil
+	}
+	for _, fc := range jr {
+		for _, c := range fc.Classifications {
+			if c.EndLine <= 0 || c.Source != "" || c.SampleOffset > 0 {
+				continue
+			}
+			filename := fc.Filepath
+			if c.Sidecar != "" {
+				filename = c.Sidecar
+			}
+			var err error
+			if c.StartLine > 1 {
+				if c.ContextBefore, _, err = readLines(filename, max(1, c.StartLine-n), c.StartLine-1); err != nil {
+					return err
+				}
+			}
+			if c.ContextAfter, _, err = readLines(filename, c.EndLine+1, c.EndLine+n); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func max(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,58 @@
+// Copyright 2022 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package results
+
+import (
+	"embed"
+	"fmt"
+	"io/fs"
+	"sort"
+	"strings"
+)
+
+// schemaFS holds the JSON Schemas of the output formats of the tools, one per
+// format, named after it.
+//
+//go:embed schema/*.json
+var schemaFS embed.FS
+
+// Schema formats.
+const (
+	// SchemaResults is the format of the results written by identify_license.
+	SchemaResults = "results"
+	// SchemaDiff is the format of the diff written by diff_results.
+	SchemaDiff = "diff"
+)
+
+// Schema returns the JSON Schema of the named output format.
+func Schema(format string) ([]byte, error) {
+	b, err := schemaFS.ReadFile("schema/" + format + ".json")
+	if err != nil {
+		return nil, fmt.Errorf("unknown output format %q, want one of %s", format, strings.Join(SchemaFormats(), ", "))
+	}
+	return b, nil
+}
+
+// SchemaFormats returns the names of the output formats that have a schema,
+// sorted.
+func SchemaFormats() []string {
+	entries, _ := fs.ReadDir(schemaFS, "schema")
+	var out []string
+	for _, e := range entries {
+		out = append(out, strings.TrimSuffix(e.Name(), ".json"))
+	}
+	sort.Strings(out)
+	return out
+}
@@ -0,0 +1,125 @@
+// Copyright 2022 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package results
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+// readSchema returns the parsed JSON Schema of the format.
+func readSchema(t *testing.T, format string) map[string]interface{} {
+	t.Helper()
+	b, err := Schema(format)
+	if err != nil {
+		t.Fatalf("Schema(%q) returned error: %v", format, err)
+	}
+	var schema map[string]interface{}
+	if err := json.Unmarshal(b, &schema); err != nil {
+		t.Fatalf("Schema(%q) isn't valid JSON: %v", format, err)
+	}
+	return schema
+}
+
+func TestSchema(t *testing.T) {
+	if diff := cmp.Diff([]string{SchemaDiff, SchemaResults}, SchemaFormats()); diff != "" {
+		t.Errorf("SchemaFormats() mismatch (-want +got):\n%s", diff)
+	}
+	for _, format := range SchemaFormats() {
+		if schema := readSchema(t, format); schema["$schema"] == nil {
+			t.Errorf("Schema(%q) doesn't declare its $schema", format)
+		}
+	}
+	if _, err := Schema("xml"); err == nil {
+		t.Error("Schema() of an unknown format succeeded, want error")
+	}
+}
+
+// checkSchemaFields reports the JSON fields of the type, and of the structs it
+// holds, that the schema node doesn't describe. References are resolved
+// against the $defs of the schema.
+func checkSchemaFields(t *testing.T, schema, node map[string]interface{}, typ reflect.Type, path string) {
+	t.Helper()
+	if ref, ok := node["$ref"].(string); ok {
+		defs, _ := schema["$defs"].(map[string]interface{})
+		def, ok := defs[strings.TrimPrefix(ref, "#/$defs/")].(map[string]interface{})
+		if !ok {
+			t.Errorf("%s refers to %s, which isn't defined", path, ref)
+			return
+		}
+		node = def
+	}
+	switch typ.Kind() {
+	case reflect.Ptr:
+		checkSchemaFields(t, schema, node, typ.Elem(), path)
+	case reflect.Slice:
+		items, ok := node["items"].(map[string]interface{})
+		if !ok {
+			t.Errorf("%s doesn't describe its items", path)
+			return
+		}
+		checkSchemaFields(t, schema, items, typ.Elem(), path+"[]")
+	case reflect.Struct:
+		props, _ := node["properties"].(map[string]interface{})
+		for i := 0; i < typ.NumField(); i++ {
+			f := typ.Field(i)
+			name := strings.Split(f.Tag.Get("json"), ",")[0]
+			if f.PkgPath != "" || name == "-" {
+				continue
+			}
+			if name == "" {
+				name = f.Name
+			}
+			prop, ok := props[name].(map[string]interface{})
+			if !ok {
+				t.Errorf("the schema doesn't describe %s.%s", path, name)
+				continue
+			}
+			checkSchemaFields(t, schema, prop, f.Type, path+"."+name)
+		}
+	}
+}
+
+func TestSchemaFields(t *testing.T) {
+	results := readSchema(t, SchemaResults)
+	checkSchemaFields(t, results, map[string]interface{}{"$ref": "#/$defs/Report"}, reflect.TypeOf(Report{}), "Report")
+
+	// The fields of a LicenseType are written as those of its Classification,
+	// except for those written elsewhere or left out of the JSON output.
+	notClassified := map[string]bool{
+		"Filename": true, // the Filepath of its FileClassifications
+		"Variant":  true,
+	}
+	defs, _ := results["$defs"].(map[string]interface{})
+	classification, _ := defs["Classification"].(map[string]interface{})
+	props, _ := classification["properties"].(map[string]interface{})
+	typ := reflect.TypeOf(LicenseType{})
+	for i := 0; i < typ.NumField(); i++ {
+		f := typ.Field(i)
+		if f.PkgPath != "" || notClassified[f.Name] {
+			continue
+		}
+		if _, ok := props[f.Name]; !ok {
+			t.Errorf("the schema of a Classification doesn't describe LicenseType.%s", f.Name)
+		}
+	}
+
+	diff := readSchema(t, SchemaDiff)
+	checkSchemaFields(t, diff, diff, reflect.TypeOf(ResultDiff{}), "ResultDiff")
+}
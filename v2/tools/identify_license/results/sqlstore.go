@@ -0,0 +1,169 @@
+// Copyright 2022 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package results
+
+import (
+	"database/sql"
+	"fmt"
+)
+
+// sqlSchema creates the tables of a results database: a row of files for each
+// file with results, a row of matches for each result, and a row of errors
+// for each error of the scan. The statements are in the dialect of SQLite,
+// which the tool writes results databases with.
+var sqlSchema = []string{
+	`CREATE TABLE IF NOT EXISTS files (
+		id INTEGER PRIMARY KEY,
+		path TEXT NOT NULL UNIQUE
+	)`,
+	`CREATE TABLE IF NOT EXISTS matches (
+		file_id INTEGER NOT NULL REFERENCES files(id),
+		finding_id TEXT,
+		name TEXT NOT NULL,
+		match_type TEXT NOT NULL,
+		variant TEXT,
+		confidence REAL,
+		start_line INTEGER,
+		end_line INTEGER,
+		family TEXT
+	)`,
+	`CREATE INDEX IF NOT EXISTS matches_name ON matches (name, confidence)`,
+	`CREATE TABLE IF NOT EXISTS errors (
+		message TEXT NOT NULL
+	)`,
+}
+
+// WriteSQL writes the results, and the errors of the scan, to a results
+// database, creating its tables if they don't exist. The results are written
+// in a single transaction, so that a failed write leaves the database as it
+// was.
+func WriteSQL(db *sql.DB, lt LicenseTypes, errs []error) (err error) {
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			tx.Rollback()
+		}
+	}()
+	for _, s := range sqlSchema {
+		if _, err := tx.Exec(s); err != nil {
+			return fmt.Errorf("creating results tables: %w", err)
+		}
+	}
+
+	files := make(map[string]int64)
+	for _, r := range lt {
+		id, ok := files[r.Filename]
+		if !ok {
+			if _, err := tx.Exec(`INSERT OR IGNORE INTO files (path) VALUES (?)`, r.Filename); err != nil {
+				return err
+			}
+			if err := tx.QueryRow(`SELECT id FROM files WHERE path = ?`, r.Filename).Scan(&id); err != nil {
+				return err
+			}
+			files[r.Filename] = id
+		}
+		if _, err := tx.Exec(`INSERT INTO matches (file_id, finding_id, name, match_type, variant, confidence, start_line, end_line, family) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+			id, r.ID, r.Name, r.MatchType, r.Variant, r.Confidence, r.StartLine, r.EndLine, r.Family); err != nil {
+			return err
+		}
+	}
+	for _, e := range errs {
+		if _, err := tx.Exec(`INSERT INTO errors (message) VALUES (?)`, e.Error()); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// SQLMatch is a match read back from a results database.
+type SQLMatch struct {
+	Filename   string
+	Name       string
+	MatchType  string
+	Confidence float64
+	StartLine  int
+	EndLine    int
+}
+
+// QueryFiles returns the matches of the license, such as GPL-3.0, with at
+// least the given confidence in a results database, ordered by file and line.
+func QueryFiles(db *sql.DB, license string, minConfidence float64) ([]SQLMatch, error) {
+	rows, err := db.Query(`SELECT files.path, matches.name, matches.match_type, matches.confidence, matches.start_line, matches.end_line
+		FROM matches JOIN files ON files.id = matches.file_id
+		WHERE matches.name = ? AND matches.confidence >= ?
+		ORDER BY files.path, matches.start_line`, license, minConfidence)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []SQLMatch
+	for rows.Next() {
+		var m SQLMatch
+		if err := rows.Scan(&m.Filename, &m.Name, &m.MatchType, &m.Confidence, &m.StartLine, &m.EndLine); err != nil {
+			return nil, err
+		}
+		out = append(out, m)
+	}
+	return out, rows.Err()
+}
+
+// SQLLicenseCount is the number of files a license was found in.
+type SQLLicenseCount struct {
+	Name  string
+	Files int
+}
+
+// QueryLicenses returns the number of files each license was found in with at
+// least the given confidence in a results database, most common first. Only
+// License, Header and Reference matches are counted.
+func QueryLicenses(db *sql.DB, minConfidence float64) ([]SQLLicenseCount, error) {
+	rows, err := db.Query(`SELECT name, COUNT(DISTINCT file_id) AS n FROM matches
+		WHERE match_type IN ('License', 'Header', 'Reference') AND confidence >= ?
+		GROUP BY name ORDER BY n DESC, name`, minConfidence)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []SQLLicenseCount
+	for rows.Next() {
+		var c SQLLicenseCount
+		if err := rows.Scan(&c.Name, &c.Files); err != nil {
+			return nil, err
+		}
+		out = append(out, c)
+	}
+	return out, rows.Err()
+}
+
+// QueryErrors returns the errors recorded in a results database.
+func QueryErrors(db *sql.DB) ([]string, error) {
+	rows, err := db.Query(`SELECT message FROM errors ORDER BY rowid`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []string
+	for rows.Next() {
+		var m string
+		if err := rows.Scan(&m); err != nil {
+			return nil, err
+		}
+		out = append(out, m)
+	}
+	return out, rows.Err()
+}
@@ -0,0 +1,210 @@
+// Copyright 2022 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package results
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+// fakeDB is a database/sql driver that records the statements executed
+// against it, and answers queries with canned rows, so that the SQL of the
+// results database can be tested without linking SQLite.
+type fakeDB struct {
+	// execs are the statements executed, with their arguments.
+	execs []fakeExec
+	// queries are the queries run, with their arguments.
+	queries []fakeExec
+	// rows are the rows returned by queries starting with each prefix.
+	rows map[string][][]driver.Value
+	// failOn fails the statements holding it.
+	failOn string
+	// ids are the IDs of the paths inserted into the files table.
+	ids                   map[string]int64
+	committed, rolledBack bool
+}
+
+type fakeExec struct {
+	query string
+	args  []driver.Value
+}
+
+func (db *fakeDB) Connect(context.Context) (driver.Conn, error) { return db, nil }
+func (db *fakeDB) Driver() driver.Driver                        { return db }
+func (db *fakeDB) Open(string) (driver.Conn, error)             { return db, nil }
+func (db *fakeDB) Close() error                                 { return nil }
+func (db *fakeDB) Begin() (driver.Tx, error)                    { return db, nil }
+func (db *fakeDB) Commit() error                                { db.committed = true; return nil }
+func (db *fakeDB) Rollback() error                              { db.rolledBack = true; return nil }
+
+func (db *fakeDB) Prepare(query string) (driver.Stmt, error) {
+	return &fakeStmt{db, strings.Join(strings.Fields(query), " ")}, nil
+}
+
+type fakeStmt struct {
+	db    *fakeDB
+	query string
+}
+
+func (s *fakeStmt) Close() error  { return nil }
+func (s *fakeStmt) NumInput() int { return -1 }
+
+func (s *fakeStmt) Exec(args []driver.Value) (driver.Result, error) {
+	if s.db.failOn != "" && strings.Contains(s.query, s.db.failOn) {
+		return nil, errors.New("exec failed")
+	}
+	s.db.execs = append(s.db.execs, fakeExec{s.query, args})
+	if strings.HasPrefix(s.query, "INSERT OR IGNORE INTO files") {
+		path := args[0].(string)
+		if _, ok := s.db.ids[path]; !ok {
+			s.db.ids[path] = int64(len(s.db.ids) + 1)
+		}
+	}
+	return driver.RowsAffected(1), nil
+}
+
+func (s *fakeStmt) Query(args []driver.Value) (driver.Rows, error) {
+	s.db.queries = append(s.db.queries, fakeExec{s.query, args})
+	if strings.HasPrefix(s.query, "SELECT id FROM files") {
+		return &fakeRows{cols: []string{"id"}, rows: [][]driver.Value{{s.db.ids[args[0].(string)]}}}, nil
+	}
+	for prefix, rows := range s.db.rows {
+		if strings.HasPrefix(s.query, prefix) {
+			cols := make([]string, len(rows[0]))
+			return &fakeRows{cols: cols, rows: rows}, nil
+		}
+	}
+	return &fakeRows{}, nil
+}
+
+type fakeRows struct {
+	cols []string
+	rows [][]driver.Value
+}
+
+func (r *fakeRows) Columns() []string { return r.cols }
+func (r *fakeRows) Close() error      { return nil }
+
+func (r *fakeRows) Next(dest []driver.Value) error {
+	if len(r.rows) == 0 {
+		return io.EOF
+	}
+	copy(dest, r.rows[0])
+	r.rows = r.rows[1:]
+	return nil
+}
+
+func newFakeDB() (*fakeDB, *sql.DB) {
+	f := &fakeDB{ids: make(map[string]int64), rows: make(map[string][][]driver.Value)}
+	return f, sql.OpenDB(f)
+}
+
+func TestWriteSQL(t *testing.T) {
+	f, db := newFakeDB()
+	defer db.Close()
+	lt := LicenseTypes{
+		{ID: "a", Filename: "LICENSE", Name: "MIT", MatchType: "License", Variant: "license.txt", Confidence: 1, StartLine: 1, EndLine: 20},
+		{ID: "b", Filename: "LICENSE", Name: "Copyright", MatchType: "Copyright", Confidence: 1, StartLine: 1, EndLine: 1},
+		{ID: "c", Filename: "main.go", Name: "Apache-2.0", MatchType: "Header", Variant: "header.txt", Confidence: 0.9, StartLine: 2, EndLine: 14, Family: "Apache-family"},
+	}
+	if err := WriteSQL(db, lt, []error{errors.New("unreadable: secret.bin")}); err != nil {
+		t.Fatalf("WriteSQL() returned error: %v", err)
+	}
+	if !f.committed || f.rolledBack {
+		t.Errorf("WriteSQL() committed %v, rolled back %v; want committed", f.committed, f.rolledBack)
+	}
+
+	var got []fakeExec
+	for _, e := range f.execs[len(sqlSchema):] {
+		got = append(got, e)
+	}
+	const insertMatch = "INSERT INTO matches (file_id, finding_id, name, match_type, variant, confidence, start_line, end_line, family) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)"
+	want := []fakeExec{
+		{"INSERT OR IGNORE INTO files (path) VALUES (?)", []driver.Value{"LICENSE"}},
+		{insertMatch, []driver.Value{int64(1), "a", "MIT", "License", "license.txt", 1.0, int64(1), int64(20), ""}},
+		{insertMatch, []driver.Value{int64(1), "b", "Copyright", "Copyright", "", 1.0, int64(1), int64(1), ""}},
+		{"INSERT OR IGNORE INTO files (path) VALUES (?)", []driver.Value{"main.go"}},
+		{insertMatch, []driver.Value{int64(2), "c", "Apache-2.0", "Header", "header.txt", 0.9, int64(2), int64(14), "Apache-family"}},
+		{"INSERT INTO errors (message) VALUES (?)", []driver.Value{"unreadable: secret.bin"}},
+	}
+	if diff := cmp.Diff(want, got, cmp.AllowUnexported(fakeExec{})); diff != "" {
+		t.Errorf("WriteSQL() statements mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestWriteSQLRollback(t *testing.T) {
+	f, db := newFakeDB()
+	defer db.Close()
+	f.failOn = "INSERT INTO matches"
+	lt := LicenseTypes{{Filename: "LICENSE", Name: "MIT", MatchType: "License"}}
+	if err := WriteSQL(db, lt, nil); err == nil {
+		t.Fatal("WriteSQL() succeeded, want error")
+	}
+	if f.committed || !f.rolledBack {
+		t.Errorf("WriteSQL() committed %v, rolled back %v; want rolled back", f.committed, f.rolledBack)
+	}
+}
+
+func TestQuerySQL(t *testing.T) {
+	f, db := newFakeDB()
+	defer db.Close()
+	f.rows["SELECT files.path"] = [][]driver.Value{
+		{"a/COPYING", "GPL-3.0", "License", 1.0, int64(1), int64(600)},
+		{"b/main.c", "GPL-3.0", "Header", 0.95, int64(3), int64(15)},
+	}
+	f.rows["SELECT name, COUNT"] = [][]driver.Value{
+		{"GPL-3.0", int64(2)},
+		{"MIT", int64(1)},
+	}
+	f.rows["SELECT message"] = [][]driver.Value{{"unreadable: a/secret.bin"}}
+
+	files, err := QueryFiles(db, "GPL-3.0", 0.9)
+	if err != nil {
+		t.Fatalf("QueryFiles() returned error: %v", err)
+	}
+	wantFiles := []SQLMatch{
+		{Filename: "a/COPYING", Name: "GPL-3.0", MatchType: "License", Confidence: 1, StartLine: 1, EndLine: 600},
+		{Filename: "b/main.c", Name: "GPL-3.0", MatchType: "Header", Confidence: 0.95, StartLine: 3, EndLine: 15},
+	}
+	if diff := cmp.Diff(wantFiles, files); diff != "" {
+		t.Errorf("QueryFiles() mismatch (-want +got):\n%s", diff)
+	}
+	if q := f.queries[len(f.queries)-1]; !cmp.Equal(q.args, []driver.Value{"GPL-3.0", 0.9}) {
+		t.Errorf("QueryFiles() queried with %v, want [GPL-3.0 0.9]", q.args)
+	}
+
+	counts, err := QueryLicenses(db, 0.5)
+	if err != nil {
+		t.Fatalf("QueryLicenses() returned error: %v", err)
+	}
+	if diff := cmp.Diff([]SQLLicenseCount{{"GPL-3.0", 2}, {"MIT", 1}}, counts); diff != "" {
+		t.Errorf("QueryLicenses() mismatch (-want +got):\n%s", diff)
+	}
+
+	msgs, err := QueryErrors(db)
+	if err != nil {
+		t.Fatalf("QueryErrors() returned error: %v", err)
+	}
+	if diff := cmp.Diff([]string{"unreadable: a/secret.bin"}, msgs); diff != "" {
+		t.Errorf("QueryErrors() mismatch (-want +got):\n%s", diff)
+	}
+}
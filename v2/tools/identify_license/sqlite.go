@@ -0,0 +1,120 @@
+// Copyright 2022 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"text/tabwriter"
+
+	"github.com/google/licenseclassifier/v2/tools/identify_license/results"
+)
+
+// checkSQLDriver returns an error if the binary wasn't built with the
+// database/sql driver for SQLite named by --sql_driver, such as
+// github.com/mattn/go-sqlite3, which the results database requires.
+func checkSQLDriver() error {
+	for _, d := range sql.Drivers() {
+		if d == *sqlDriver {
+			return nil
+		}
+	}
+	return fmt.Errorf("no database/sql driver named %q is linked into this binary; build it with -tags sqlite, or with a blank import of another SQLite driver", *sqlDriver)
+}
+
+// openSQL opens the results database.
+func openSQL(filename string) (*sql.DB, error) {
+	if err := checkSQLDriver(); err != nil {
+		return nil, err
+	}
+	return sql.Open(*sqlDriver, filename)
+}
+
+// outputSQL writes the results, and the errors of the scan, to the results
+// database. The errors name the files they're for, so they're left out when
+// paths are redacted.
+func outputSQL(filename string, res results.LicenseTypes, errs []error, redactor *results.Redactor) error {
+	db, err := openSQL(filename)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+	if redactor != nil {
+		redacted := make(results.LicenseTypes, len(res))
+		for i, r := range res {
+			c := *r
+			c.Filename = redactor.Path(r.Filename)
+			redacted[i] = &c
+		}
+		redactedErrs := make([]error, len(errs))
+		for i, err := range errs {
+			redactedErrs[i] = errors.New(redactor.Text(err.Error()))
+		}
+		res, errs = redacted, redactedErrs
+	}
+	return results.WriteSQL(db, res, errs)
+}
+
+// runQuery runs the --query against the results database given by --sqlite:
+// files, licenses or errors.
+func runQuery(query string) {
+	if *sqliteFname == "" {
+		log.Fatal("--query requires --sqlite naming the results database")
+	}
+	db, err := openSQL(*sqliteFname)
+	if err != nil {
+		log.Fatalf("cannot open results database: %v", err)
+	}
+	defer db.Close()
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 8, 2, ' ', 0)
+	switch query {
+	case "files":
+		if *queryLicense == "" {
+			log.Fatal("--query=files requires --query_license")
+		}
+		matches, err := results.QueryFiles(db, *queryLicense, *queryMinConf)
+		if err != nil {
+			log.Fatalf("query failed: %v", err)
+		}
+		fmt.Fprintln(w, "FILE\tTYPE\tCONFIDENCE\tLINES")
+		for _, m := range matches {
+			fmt.Fprintf(w, "%s\t%s\t%v\t%d-%d\n", m.Filename, m.MatchType, m.Confidence, m.StartLine, m.EndLine)
+		}
+	case "licenses":
+		counts, err := results.QueryLicenses(db, *queryMinConf)
+		if err != nil {
+			log.Fatalf("query failed: %v", err)
+		}
+		fmt.Fprintln(w, "LICENSE\tFILES")
+		for _, c := range counts {
+			fmt.Fprintf(w, "%s\t%d\n", c.Name, c.Files)
+		}
+	case "errors":
+		msgs, err := results.QueryErrors(db)
+		if err != nil {
+			log.Fatalf("query failed: %v", err)
+		}
+		for _, m := range msgs {
+			fmt.Fprintln(w, m)
+		}
+	default:
+		log.Fatalf("unknown --query %q: want files, licenses or errors", query)
+	}
+	w.Flush()
+}
@@ -0,0 +1,51 @@
+// Copyright 2022 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build !core
+// +build !core
+
+package main
+
+import (
+	"fmt"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/google/licenseclassifier/v2/tools/identify_license/backend"
+)
+
+// printCorpusStats prints the statistics of the license corpus of the
+// backend, one license per line, followed by their totals.
+func printCorpusStats(be *backend.ClassifierBackend, load time.Duration) error {
+	stats := be.CorpusStats()
+	w := tabwriter.NewWriter(os.Stdout, 0, 8, 2, ' ', 0)
+	fmt.Fprintln(w, "License\tVariants\tHeaders\tTokens\tIndex KiB")
+	tokens, variants := 0, 0
+	for _, l := range stats.Licenses {
+		n := 0
+		for _, v := range l.Variants {
+			n += v
+		}
+		variants += n
+		tokens += l.Tokens
+		fmt.Fprintf(w, "%s\t%d\t%d\t%d\t%d\n", l.Name, n, l.Variants["Header"], l.Tokens, l.IndexBytes/1024)
+	}
+	w.Flush()
+	fmt.Printf("\n%d licenses, %d variants, %d tokens\n", len(stats.Licenses), variants, tokens)
+	fmt.Printf("dictionary: %d words\n", stats.Words)
+	fmt.Printf("index memory estimate: %d KiB\n", stats.IndexBytes/1024)
+	fmt.Printf("load time: %v\n", load.Round(time.Millisecond))
+	return nil
+}
@@ -0,0 +1,500 @@
+// Copyright 2022 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// The license_lsp program is a language server, speaking the Language Server
+// Protocol over standard input and output, that reports license diagnostics
+// for the buffers open in an editor. Editors start it as the language server
+// of the files to check:
+//
+//	$ license_lsp -require_headers Apache-2.0
+//
+// Once a buffer is opened, or has stopped changing for -debounce, it's
+// classified, and the server publishes diagnostics for:
+//
+//   - a missing license header, when -require_headers names the license
+//     families whose headers files must carry;
+//   - a header of a license outside those families, in place of a required
+//     header;
+//   - a header that differs from the canonical text of its license.
+//
+// Hovering over the lines of a license, header, reference or copyright notice
+// found in a buffer shows what the classifier detected there, with its
+// confidence. A family names a license, such as Apache-2.0, along with its
+// related licenses named with it as a prefix, as with MatchHeaders.
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	classifier "github.com/google/licenseclassifier/v2"
+	"github.com/google/licenseclassifier/v2/assets"
+)
+
+var (
+	requireHeaders = flag.String("require_headers", "", "comma-separated license families, such as Apache-2.0, one of whose headers every buffer must carry")
+	licenseDirs    = flag.String("licenses", "", "comma-separated list of license corpus directories, laid out like the assets directory, to match against in addition to the embedded corpus")
+	threshold      = flag.Float64("threshold", 0.8, "the confidence from which licenses are reported")
+	debounce       = flag.Duration("debounce", 250*time.Millisecond, "how long a changed buffer must stay unchanged before it's classified again")
+)
+
+func init() {
+	flag.Usage = func() {
+		fmt.Fprintf(os.Stderr, `Usage: %s [options]
+
+Serve license diagnostics and hovers for open buffers over the Language Server
+Protocol on standard input and output.
+
+Options:
+`, filepath.Base(os.Args[0]))
+		flag.PrintDefaults()
+	}
+}
+
+// LSP error codes and constants used by the server.
+const (
+	codeParseError     = -32700
+	codeInvalidParams  = -32602
+	codeMethodNotFound = -32601
+
+	syncFull = 1
+
+	severityWarning     = 2
+	severityInformation = 3
+)
+
+// message is a JSON-RPC request, notification or response.
+type message struct {
+	JSONRPC string           `json:"jsonrpc"`
+	ID      *json.RawMessage `json:"id,omitempty"`
+	Method  string           `json:"method,omitempty"`
+	Params  json.RawMessage  `json:"params,omitempty"`
+}
+
+type rpcError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+type position struct {
+	Line      int `json:"line"`
+	Character int `json:"character"`
+}
+
+type lspRange struct {
+	Start position `json:"start"`
+	End   position `json:"end"`
+}
+
+type diagnostic struct {
+	Range    lspRange `json:"range"`
+	Severity int      `json:"severity"`
+	Source   string   `json:"source"`
+	Message  string   `json:"message"`
+}
+
+type textDocumentItem struct {
+	URI  string `json:"uri"`
+	Text string `json:"text"`
+}
+
+type textDocumentIdentifier struct {
+	URI string `json:"uri"`
+}
+
+type didOpenParams struct {
+	TextDocument textDocumentItem `json:"textDocument"`
+}
+
+type didChangeParams struct {
+	TextDocument   textDocumentIdentifier `json:"textDocument"`
+	ContentChanges []struct {
+		Text string `json:"text"`
+	} `json:"contentChanges"`
+}
+
+type didCloseParams struct {
+	TextDocument textDocumentIdentifier `json:"textDocument"`
+}
+
+type hoverParams struct {
+	TextDocument textDocumentIdentifier `json:"textDocument"`
+	Position     position               `json:"position"`
+}
+
+// server holds the classifier and the matches of the open buffers.
+type server struct {
+	c        *classifier.Classifier
+	required []string
+	delay    time.Duration // How long changes are debounced for
+
+	mu       sync.Mutex
+	matches  map[string]classifier.Matches // By the URI of the buffer
+	pending  map[string]context.CancelFunc // The classifications not yet published, by URI
+	shutdown bool
+
+	wmu sync.Mutex // Serializes the messages written to w
+	w   io.Writer
+}
+
+func main() {
+	flag.Parse()
+	log.SetPrefix("license_lsp: ")
+
+	c, err := assets.NewClassifier(*threshold)
+	if err != nil {
+		log.Fatalf("cannot create license classifier: %v", err)
+	}
+	if *licenseDirs != "" {
+		for _, dir := range strings.Split(*licenseDirs, ",") {
+			if err := c.LoadLicenses(dir); err != nil {
+				log.Fatalf("cannot load licenses from %s: %v", dir, err)
+			}
+		}
+	}
+	s := &server{
+		c:       c,
+		delay:   *debounce,
+		matches: make(map[string]classifier.Matches),
+		pending: make(map[string]context.CancelFunc),
+		w:       os.Stdout,
+	}
+	for _, f := range strings.Split(*requireHeaders, ",") {
+		if f = strings.TrimSpace(f); f != "" {
+			s.required = append(s.required, f)
+		}
+	}
+	if err := s.serve(bufio.NewReader(os.Stdin)); err != nil {
+		log.Fatal(err)
+	}
+}
+
+// serve handles the messages read until the client exits.
+func (s *server) serve(r *bufio.Reader) error {
+	for {
+		body, err := readMessage(r)
+		if err == io.EOF {
+			return errors.New("client closed the connection without exiting")
+		}
+		if err != nil {
+			return err
+		}
+		var m message
+		if err := json.Unmarshal(body, &m); err != nil {
+			s.respondError(nil, codeParseError, err.Error())
+			continue
+		}
+		if m.Method == "exit" {
+			s.mu.Lock()
+			shutdown := s.shutdown
+			s.mu.Unlock()
+			if !shutdown {
+				os.Exit(1)
+			}
+			return nil
+		}
+		s.handle(&m)
+	}
+}
+
+// handle handles a request or notification.
+func (s *server) handle(m *message) {
+	var err error
+	switch m.Method {
+	case "initialize":
+		s.respond(m.ID, map[string]interface{}{
+			"capabilities": map[string]interface{}{
+				"textDocumentSync": syncFull,
+				"hoverProvider":    true,
+			},
+			"serverInfo": map[string]string{"name": "license_lsp"},
+		})
+	case "shutdown":
+		s.mu.Lock()
+		s.shutdown = true
+		for uri, cancel := range s.pending {
+			cancel()
+			delete(s.pending, uri)
+		}
+		s.mu.Unlock()
+		s.respond(m.ID, nil)
+	case "textDocument/didOpen":
+		var p didOpenParams
+		if err = json.Unmarshal(m.Params, &p); err == nil {
+			s.update(p.TextDocument.URI, []byte(p.TextDocument.Text), 0)
+		}
+	case "textDocument/didChange":
+		var p didChangeParams
+		// The server asks for full synchronization, so the last change
+		// holds the whole buffer.
+		if err = json.Unmarshal(m.Params, &p); err == nil && len(p.ContentChanges) > 0 {
+			s.update(p.TextDocument.URI, []byte(p.ContentChanges[len(p.ContentChanges)-1].Text), s.delay)
+		}
+	case "textDocument/didClose":
+		var p didCloseParams
+		if err = json.Unmarshal(m.Params, &p); err == nil {
+			s.mu.Lock()
+			if cancel, ok := s.pending[p.TextDocument.URI]; ok {
+				cancel()
+				delete(s.pending, p.TextDocument.URI)
+			}
+			delete(s.matches, p.TextDocument.URI)
+			s.mu.Unlock()
+			s.publish(p.TextDocument.URI, nil)
+		}
+	case "textDocument/hover":
+		var p hoverParams
+		if err = json.Unmarshal(m.Params, &p); err == nil {
+			s.mu.Lock()
+			matches := s.matches[p.TextDocument.URI]
+			s.mu.Unlock()
+			s.respond(m.ID, hover(matches, p.Position))
+		}
+	default:
+		// Notifications the server doesn't handle, such as initialized and
+		// didSave, are ignored.
+		if m.ID != nil {
+			s.respondError(m.ID, codeMethodNotFound, "method not supported: "+m.Method)
+		}
+	}
+	if err != nil {
+		if m.ID != nil {
+			s.respondError(m.ID, codeInvalidParams, err.Error())
+		} else {
+			log.Printf("invalid %s notification: %v", m.Method, err)
+		}
+	}
+}
+
+// update classifies the buffer after the delay, in the background, and
+// publishes its diagnostics. A classification of the buffer still pending or
+// running is canceled, since its results would be stale, so that a buffer
+// being edited is only classified once the edits pause.
+func (s *server) update(uri string, text []byte, delay time.Duration) {
+	ctx, cancel := context.WithCancel(context.Background())
+	s.mu.Lock()
+	if stale, ok := s.pending[uri]; ok {
+		stale()
+	}
+	s.pending[uri] = cancel
+	s.mu.Unlock()
+	time.AfterFunc(delay, func() {
+		s.classify(ctx, uri, text)
+	})
+}
+
+// classify classifies the buffer and publishes its diagnostics, unless the
+// context is canceled first.
+func (s *server) classify(ctx context.Context, uri string, text []byte) {
+	if ctx.Err() != nil {
+		return
+	}
+	res, err := s.c.MatchWithContext(ctx, text)
+	if ctx.Err() != nil {
+		return
+	}
+	if err != nil {
+		log.Printf("cannot classify %s: %v", uri, err)
+	}
+	var headers classifier.Matches
+	if len(s.required) > 0 {
+		headers = s.c.MatchHeaders(text, s.required...)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	// The buffer may have changed or closed while it was classified.
+	if ctx.Err() != nil {
+		return
+	}
+	s.pending[uri]()
+	delete(s.pending, uri)
+	s.matches[uri] = res.Matches
+	s.publish(uri, diagnose(res.Matches, headers, s.required))
+}
+
+// diagnose returns the diagnostics of the license headers of a buffer with the
+// matches. The headers are those of the required families found with
+// MatchHeaders, if any families are required.
+func diagnose(matches, headers classifier.Matches, required []string) []diagnostic {
+	diags := []diagnostic{}
+	var others classifier.Matches
+	found := make(map[string]bool)
+	for _, m := range headers {
+		found[m.Name] = true
+	}
+	for _, m := range matches {
+		if m.MatchType != "Header" {
+			continue
+		}
+		if len(required) == 0 {
+			headers = append(headers, m)
+		} else if !found[m.Name] {
+			others = append(others, m)
+		}
+	}
+	if len(required) > 0 && len(headers) == 0 {
+		want := strings.Join(required, ", ")
+		if len(others) == 0 {
+			diags = append(diags, diagnostic{
+				Range:    lspRange{position{0, 0}, position{1, 0}},
+				Severity: severityWarning,
+				Message:  fmt.Sprintf("Missing license header: want a header of %s", want),
+			})
+		}
+		for _, m := range others {
+			diags = append(diags, diagnostic{
+				Range:    matchRange(m),
+				Severity: severityWarning,
+				Message:  fmt.Sprintf("License header of %s: want a header of %s", m.Name, want),
+			})
+		}
+	}
+	for _, m := range headers {
+		if m.Confidence < 1 {
+			diags = append(diags, diagnostic{
+				Range:    matchRange(m),
+				Severity: severityInformation,
+				Message:  fmt.Sprintf("License header of %s differs from its canonical text (confidence: %v)", m.Name, m.Confidence),
+			})
+		}
+	}
+	for i := range diags {
+		diags[i].Source = "licenseclassifier"
+	}
+	return diags
+}
+
+// hover returns the hover of the matches of a buffer on the line of the
+// position, or nil if there are none.
+func hover(matches classifier.Matches, pos position) interface{} {
+	var lines []string
+	var r lspRange
+	for _, m := range matches {
+		if pos.Line+1 < m.StartLine || pos.Line+1 > m.EndLine {
+			continue
+		}
+		if len(lines) == 0 {
+			r = matchRange(m)
+		}
+		line := fmt.Sprintf("**%s** (%s", m.Name, m.MatchType)
+		if m.Variant != "" && (m.MatchType == "License" || m.MatchType == "Header") {
+			line += ", variant: " + m.Variant
+		}
+		line += fmt.Sprintf(", confidence: %v, lines %d-%d)", m.Confidence, m.StartLine, m.EndLine)
+		lines = append(lines, line)
+	}
+	if len(lines) == 0 {
+		return nil
+	}
+	return map[string]interface{}{
+		"contents": map[string]string{
+			"kind":  "markdown",
+			"value": strings.Join(lines, "\n\n"),
+		},
+		"range": r,
+	}
+}
+
+// publish publishes the diagnostics of a buffer.
+func (s *server) publish(uri string, diags []diagnostic) {
+	if diags == nil {
+		diags = []diagnostic{}
+	}
+	s.write(map[string]interface{}{
+		"jsonrpc": "2.0",
+		"method":  "textDocument/publishDiagnostics",
+		"params": map[string]interface{}{
+			"uri":         uri,
+			"diagnostics": diags,
+		},
+	})
+}
+
+// respond sends the result of a request.
+func (s *server) respond(id *json.RawMessage, result interface{}) {
+	s.write(map[string]interface{}{
+		"jsonrpc": "2.0",
+		"id":      id,
+		"result":  result,
+	})
+}
+
+// respondError sends the error of a request.
+func (s *server) respondError(id *json.RawMessage, code int, msg string) {
+	s.write(map[string]interface{}{
+		"jsonrpc": "2.0",
+		"id":      id,
+		"error":   rpcError{Code: code, Message: msg},
+	})
+}
+
+// write sends a message to the client.
+func (s *server) write(v interface{}) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		log.Printf("cannot encode message: %v", err)
+		return
+	}
+	s.wmu.Lock()
+	defer s.wmu.Unlock()
+	if _, err := fmt.Fprintf(s.w, "Content-Length: %d\r\n\r\n%s", len(b), b); err != nil {
+		log.Fatalf("cannot write to client: %v", err)
+	}
+}
+
+// readMessage reads the body of a message framed by LSP headers.
+func readMessage(r *bufio.Reader) ([]byte, error) {
+	length := -1
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return nil, err
+		}
+		line = strings.TrimRight(line, "\r\n")
+		if line == "" {
+			break
+		}
+		if i := strings.IndexByte(line, ':'); i != -1 && strings.EqualFold(line[:i], "Content-Length") {
+			if length, err = strconv.Atoi(strings.TrimSpace(line[i+1:])); err != nil {
+				return nil, fmt.Errorf("invalid Content-Length header %q", line)
+			}
+		}
+	}
+	if length < 0 {
+		return nil, errors.New("message without a Content-Length header")
+	}
+	body := make([]byte, length)
+	if _, err := io.ReadFull(r, body); err != nil {
+		return nil, err
+	}
+	return body, nil
+}
+
+// matchRange returns the range of the lines of a match.
+func matchRange(m *classifier.Match) lspRange {
+	return lspRange{position{m.StartLine - 1, 0}, position{m.EndLine, 0}}
+}
@@ -0,0 +1,224 @@
+// Copyright 2022 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	classifier "github.com/google/licenseclassifier/v2"
+)
+
+func TestReadMessage(t *testing.T) {
+	tests := []struct {
+		name    string
+		in      string
+		want    string
+		wantErr bool
+	}{
+		{
+			name: "message",
+			in:   "Content-Length: 2\r\n\r\n{}",
+			want: "{}",
+		},
+		{
+			name: "other headers",
+			in:   "Content-Type: application/vscode-jsonrpc\r\ncontent-length: 7\r\n\r\n{\"a\":1}",
+			want: `{"a":1}`,
+		},
+		{
+			name:    "no length",
+			in:      "Content-Type: application/vscode-jsonrpc\r\n\r\n{}",
+			wantErr: true,
+		},
+		{
+			name:    "invalid length",
+			in:      "Content-Length: two\r\n\r\n{}",
+			wantErr: true,
+		},
+		{
+			name:    "short body",
+			in:      "Content-Length: 10\r\n\r\n{}",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := readMessage(bufio.NewReader(strings.NewReader(tt.in)))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("readMessage() error = %v, want error %v", err, tt.wantErr)
+			}
+			if string(got) != tt.want {
+				t.Errorf("readMessage() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+
+	// Messages are read one after the other.
+	r := bufio.NewReader(strings.NewReader("Content-Length: 1\r\n\r\n1Content-Length: 1\r\n\r\n2"))
+	for _, want := range []string{"1", "2"} {
+		if got, err := readMessage(r); err != nil || string(got) != want {
+			t.Errorf("readMessage() = %q, %v, want %q", got, err, want)
+		}
+	}
+	if _, err := readMessage(r); err != io.EOF {
+		t.Errorf("readMessage() at the end = %v, want io.EOF", err)
+	}
+}
+
+func header(name string, conf float64, start, end int) *classifier.Match {
+	return &classifier.Match{Name: name, MatchType: "Header", Confidence: conf, StartLine: start, EndLine: end}
+}
+
+func TestDiagnose(t *testing.T) {
+	apache := header("Apache-2.0", 1, 1, 13)
+	modified := header("Apache-2.0", 0.9, 1, 13)
+	mit := header("MIT", 1, 1, 3)
+	copyright := &classifier.Match{Name: "Copyright", MatchType: "Copyright", Confidence: 1, StartLine: 1, EndLine: 1}
+
+	tests := []struct {
+		name     string
+		matches  classifier.Matches
+		headers  classifier.Matches
+		required []string
+		want     []string
+	}{
+		{
+			name:     "required header",
+			matches:  classifier.Matches{copyright, apache},
+			headers:  classifier.Matches{apache},
+			required: []string{"Apache-2.0"},
+			want:     []string{},
+		},
+		{
+			name:     "missing header",
+			matches:  classifier.Matches{copyright},
+			required: []string{"Apache-2.0"},
+			want:     []string{"Missing license header: want a header of Apache-2.0"},
+		},
+		{
+			name:     "other header",
+			matches:  classifier.Matches{mit},
+			required: []string{"Apache-2.0", "BSD"},
+			want:     []string{"License header of MIT: want a header of Apache-2.0, BSD"},
+		},
+		{
+			name:     "modified header",
+			matches:  classifier.Matches{modified},
+			headers:  classifier.Matches{modified},
+			required: []string{"Apache-2.0"},
+			want:     []string{"License header of Apache-2.0 differs from its canonical text (confidence: 0.9)"},
+		},
+		{
+			name:    "any header",
+			matches: classifier.Matches{mit, modified},
+			want:    []string{"License header of Apache-2.0 differs from its canonical text (confidence: 0.9)"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := []string{}
+			for _, d := range diagnose(tt.matches, tt.headers, tt.required) {
+				if d.Source != "licenseclassifier" {
+					t.Errorf("diagnose() source = %q, want licenseclassifier", d.Source)
+				}
+				got = append(got, d.Message)
+			}
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("diagnose() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestHover(t *testing.T) {
+	matches := classifier.Matches{
+		{Name: "Copyright", MatchType: "Copyright", Confidence: 1, StartLine: 1, EndLine: 1},
+		{Name: "MIT", Variant: "header.txt", MatchType: "Header", Confidence: 0.95, StartLine: 1, EndLine: 3},
+	}
+	got, err := json.Marshal(hover(matches, position{Line: 0}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `{"contents":{"kind":"markdown","value":"**Copyright** (Copyright, confidence: 1, lines 1-1)\n\n**MIT** (Header, variant: header.txt, confidence: 0.95, lines 1-3)"},"range":{"start":{"line":0,"character":0},"end":{"line":1,"character":0}}}`
+	if string(got) != want {
+		t.Errorf("hover() = %s, want %s", got, want)
+	}
+	if got := hover(matches, position{Line: 3}); got != nil {
+		t.Errorf("hover() past the matches = %v, want nil", got)
+	}
+}
+
+// syncBuffer is a buffer safe to write to from the server's goroutines.
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+func TestUpdateDebounces(t *testing.T) {
+	c := classifier.NewClassifier(0.8)
+	if err := c.LoadLicenses("../../assets"); err != nil {
+		t.Fatalf("couldn't load licenses: %v", err)
+	}
+	var out syncBuffer
+	s := &server{
+		c:        c,
+		required: []string{"Apache-2.0"},
+		delay:    50 * time.Millisecond,
+		matches:  make(map[string]classifier.Matches),
+		pending:  make(map[string]context.CancelFunc),
+		w:        &out,
+	}
+	// Only the last of the changes in quick succession is classified.
+	for i := 0; i < 5; i++ {
+		s.update("file:///a.go", []byte("package a\n"), s.delay)
+	}
+	deadline := time.Now().Add(10 * time.Second)
+	for time.Now().Before(deadline) {
+		s.mu.Lock()
+		pending := len(s.pending)
+		s.mu.Unlock()
+		if pending == 0 {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if got := strings.Count(out.String(), "publishDiagnostics"); got != 1 {
+		t.Errorf("update() published %d times, want once:\n%s", got, out.String())
+	}
+	if !strings.Contains(out.String(), "Missing license header") {
+		t.Errorf("update() published %s, want a missing header", out.String())
+	}
+}
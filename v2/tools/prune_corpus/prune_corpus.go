@@ -0,0 +1,197 @@
+// Copyright 2022 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// The prune_corpus program writes the part of the embedded license corpus
+// holding only the licenses a product cares about, such as those of a policy
+// allowlist, to a directory, along with a Go package that embeds it. Tools
+// that embed the trimmed corpus in place of the assets package are much
+// smaller. Run it with go generate whenever the allowlist or the corpus
+// changes:
+//
+//	//go:generate go run github.com/google/licenseclassifier/v2/tools/prune_corpus -licenses MIT,Apache-2.0,BSD-3-Clause -out corpus
+//
+// The licenses may instead be listed one per line in a file given with
+// -allowlist, where blank lines and lines starting with # are ignored.
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"flag"
+	"fmt"
+	"go/format"
+	"io/fs"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/template"
+
+	"github.com/google/licenseclassifier/v2/assets"
+)
+
+var (
+	licenses  = flag.String("licenses", "", "comma-separated names of the licenses to keep, such as MIT,Apache-2.0")
+	allowlist = flag.String("allowlist", "", "file listing the names of the licenses to keep, one per line")
+	out       = flag.String("out", "", "the directory to write the pruned corpus and its package to")
+	pkg       = flag.String("package", "", "the name of the generated package; the base name of -out if empty")
+)
+
+func init() {
+	flag.Usage = func() {
+		fmt.Fprintf(os.Stderr, `Usage: %s [options]
+
+Write the embedded license corpus, pruned to the licenses named, to a directory
+along with a Go package that embeds it.
+
+Options:
+`, filepath.Base(os.Args[0]))
+		flag.PrintDefaults()
+	}
+}
+
+// embedTemplate is the Go package written next to the pruned corpus.
+var embedTemplate = template.Must(template.New("embed").Parse(`// Code generated by prune_corpus; DO NOT EDIT.
+
+// Package {{.Package}} embeds the license corpus pruned to {{.Licenses}}.
+package {{.Package}}
+
+import (
+	"embed"
+
+	classifier "github.com/google/licenseclassifier/v2"
+)
+
+//go:embed */*/* confusability.json
+var corpus embed.FS
+
+// NewClassifier returns a classifier with the supplied threshold and options
+// loaded with the pruned corpus.
+func NewClassifier(threshold float64, options ...classifier.OptionFunc) (*classifier.Classifier, error) {
+	c := classifier.NewClassifier(threshold, options...)
+	if err := c.LoadLicensesFS(corpus); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+`))
+
+func main() {
+	flag.Parse()
+	if *out == "" {
+		log.Fatal("-out is required")
+	}
+	names, err := licenseNames()
+	if err != nil {
+		log.Fatal(err)
+	}
+	if len(names) == 0 {
+		log.Fatal("no licenses named with -licenses or -allowlist")
+	}
+	sub := assets.Subset(names...)
+	for _, n := range names {
+		if m, _ := fs.Glob(sub, "*/"+n); len(m) == 0 {
+			log.Fatalf("license %s isn't in the corpus", n)
+		}
+	}
+
+	// Categories written by an earlier run are replaced, so that licenses
+	// dropped from the list are dropped from the corpus.
+	categories, err := assets.ReadLicenseDir()
+	if err != nil {
+		log.Fatalf("cannot read the corpus: %v", err)
+	}
+	for _, c := range categories {
+		if c.IsDir() {
+			if err := os.RemoveAll(filepath.Join(*out, c.Name())); err != nil {
+				log.Fatalf("cannot remove %s: %v", c.Name(), err)
+			}
+		}
+	}
+	if err := write(sub, *out); err != nil {
+		log.Fatalf("cannot write the pruned corpus: %v", err)
+	}
+
+	name := *pkg
+	if name == "" {
+		abs, err := filepath.Abs(*out)
+		if err != nil {
+			log.Fatal(err)
+		}
+		name = filepath.Base(abs)
+	}
+	var b bytes.Buffer
+	err = embedTemplate.Execute(&b, struct {
+		Package  string
+		Licenses string
+	}{name, strings.Join(names, ", ")})
+	if err != nil {
+		log.Fatalf("cannot generate package: %v", err)
+	}
+	src, err := format.Source(b.Bytes())
+	if err != nil {
+		log.Fatalf("cannot format package %s: %v", name, err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(*out, "embed.go"), src, 0644); err != nil {
+		log.Fatalf("cannot write package: %v", err)
+	}
+}
+
+// licenseNames returns the names of the licenses given with -licenses and
+// -allowlist.
+func licenseNames() ([]string, error) {
+	var names []string
+	for _, n := range strings.Split(*licenses, ",") {
+		if n = strings.TrimSpace(n); n != "" {
+			names = append(names, n)
+		}
+	}
+	if *allowlist == "" {
+		return names, nil
+	}
+	f, err := os.Open(*allowlist)
+	if err != nil {
+		return nil, fmt.Errorf("cannot read allowlist: %v", err)
+	}
+	defer f.Close()
+	s := bufio.NewScanner(f)
+	for s.Scan() {
+		if n := strings.TrimSpace(s.Text()); n != "" && !strings.HasPrefix(n, "#") {
+			names = append(names, n)
+		}
+	}
+	if err := s.Err(); err != nil {
+		return nil, fmt.Errorf("cannot read allowlist: %v", err)
+	}
+	return names, nil
+}
+
+// write copies the files of the file system to the directory.
+func write(fsys fs.FS, dir string) error {
+	return fs.WalkDir(fsys, ".", func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(dir, filepath.FromSlash(path))
+		if d.IsDir() {
+			return os.MkdirAll(target, 0755)
+		}
+		b, err := fs.ReadFile(fsys, path)
+		if err != nil {
+			return err
+		}
+		return ioutil.WriteFile(target, b, 0644)
+	})
+}
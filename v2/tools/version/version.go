@@ -0,0 +1,126 @@
+// Copyright 2022 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package version describes the build of the license classifier tools, so
+// that their results can be traced to the tool and corpus that produced them.
+// The values are stamped at build time with -ldflags, for example:
+//
+//	go build -ldflags "\
+//	  -X github.com/google/licenseclassifier/v2/tools/version.Commit=$(git rev-parse HEAD) \
+//	  -X github.com/google/licenseclassifier/v2/tools/version.BuildDate=$(date -u +%Y-%m-%dT%H:%M:%SZ)" \
+//	  ./tools/identify_license
+//
+// Corpus may be stamped too; otherwise the fingerprint of the embedded corpus
+// is computed when the version is read. Tools matching against other license
+// corpora report the fingerprint of the corpus they load with GetForCorpus.
+package version
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io/fs"
+	"io/ioutil"
+	"path/filepath"
+
+	"github.com/google/licenseclassifier/v2/assets"
+)
+
+// Values stamped at build time. Those that aren't stamped are reported as
+// unknown.
+var (
+	// Commit is the git commit the tool was built from.
+	Commit string
+	// BuildDate is the time the tool was built, in RFC 3339 format.
+	BuildDate string
+	// Corpus is the fingerprint of the license corpus embedded in the tool.
+	Corpus string
+)
+
+const unknown = "unknown"
+
+// Info is the version of a tool.
+type Info struct {
+	Commit    string
+	BuildDate string
+	Corpus    string
+}
+
+// Get returns the version of the running tool.
+func Get() Info {
+	info := Info{
+		Commit:    Commit,
+		BuildDate: BuildDate,
+		Corpus:    Corpus,
+	}
+	if info.Corpus == "" {
+		if fp, err := assets.Fingerprint(); err == nil {
+			info.Corpus = fp
+		}
+	}
+	for _, v := range []*string{&info.Commit, &info.BuildDate, &info.Corpus} {
+		if *v == "" {
+			*v = unknown
+		}
+	}
+	return info
+}
+
+// GetForCorpus returns the version of the running tool matching against the
+// license corpus directories, laid out like the assets directory, with or
+// without the embedded corpus. Its Corpus is the fingerprint of the licenses
+// loaded: a digest of the fingerprint of the embedded corpus, if it's used,
+// and of the files of the directories. Like the fingerprint of the embedded
+// corpus, it depends on the paths of the files within the directories but not
+// on where the directories are, so a corpus copied elsewhere keeps its
+// fingerprint.
+func GetForCorpus(dirs []string, embedded bool) (Info, error) {
+	info := Get()
+	if embedded && len(dirs) == 0 {
+		return info, nil
+	}
+	h := sha256.New()
+	if embedded {
+		fmt.Fprintf(h, "%s\x00", info.Corpus)
+	}
+	for i, dir := range dirs {
+		fmt.Fprintf(h, "%d\x00", i)
+		err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+			if err != nil || d.IsDir() {
+				return err
+			}
+			b, err := ioutil.ReadFile(path)
+			if err != nil {
+				return err
+			}
+			rel, err := filepath.Rel(dir, path)
+			if err != nil {
+				return err
+			}
+			fmt.Fprintf(h, "%s\x00%d\x00", filepath.ToSlash(rel), len(b))
+			h.Write(b)
+			return nil
+		})
+		if err != nil {
+			return Info{}, err
+		}
+	}
+	info.Corpus = hex.EncodeToString(h.Sum(nil))[:16]
+	return info, nil
+}
+
+// String returns the version on a single line.
+func (i Info) String() string {
+	return fmt.Sprintf("commit %s, corpus %s, built %s", i.Commit, i.Corpus, i.BuildDate)
+}
@@ -0,0 +1,83 @@
+// Copyright 2022 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package version
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// writeCorpus writes the files, keyed by their slash-separated paths, to a
+// corpus directory and returns it.
+func writeCorpus(t *testing.T, files map[string]string) string {
+	t.Helper()
+	dir := t.TempDir()
+	for name, text := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(path, []byte(text), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func TestGetForCorpus(t *testing.T) {
+	files := map[string]string{
+		"License/MIT/license.txt": "Permission is hereby granted",
+		"Header/MIT/header.txt":   "Licensed under the MIT license",
+	}
+	corpus := func(t *testing.T, dirs ...string) string {
+		t.Helper()
+		info, err := GetForCorpus(dirs, false)
+		if err != nil {
+			t.Fatalf("GetForCorpus() returned error: %v", err)
+		}
+		return info.Corpus
+	}
+	want := corpus(t, writeCorpus(t, files))
+
+	if got := corpus(t, writeCorpus(t, files)); got != want {
+		t.Errorf("GetForCorpus() of a copy of the corpus = %s, want %s", got, want)
+	}
+
+	moved := map[string]string{
+		"License/MIT/copying.txt": files["License/MIT/license.txt"],
+		"Header/MIT/header.txt":   files["Header/MIT/header.txt"],
+	}
+	if got := corpus(t, writeCorpus(t, moved)); got == want {
+		t.Errorf("GetForCorpus() of a corpus with a renamed file = %s, want it to differ", got)
+	}
+
+	changed := map[string]string{
+		"License/MIT/license.txt": files["License/MIT/license.txt"] + ", free of charge",
+		"Header/MIT/header.txt":   files["Header/MIT/header.txt"],
+	}
+	if got := corpus(t, writeCorpus(t, changed)); got == want {
+		t.Errorf("GetForCorpus() of a corpus with a changed file = %s, want it to differ", got)
+	}
+
+	info, err := GetForCorpus(nil, true)
+	if err != nil {
+		t.Fatalf("GetForCorpus() of the embedded corpus returned error: %v", err)
+	}
+	if got, want := info, Get(); got != want {
+		t.Errorf("GetForCorpus() of the embedded corpus = %v, want %v", got, want)
+	}
+}
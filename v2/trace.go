@@ -16,6 +16,7 @@ package classifier
 
 import (
 	"fmt"
+	"reflect"
 	"strings"
 )
 
@@ -100,6 +101,11 @@ func (t *TraceConfiguration) trace(f string, args ...interface{}) {
 	t.Tracer(f, args...)
 }
 
+// enabled reports whether any phase is traced for any license.
+func (t *TraceConfiguration) enabled() bool {
+	return t != nil && len(t.tracePhases) > 0 && len(t.traceLicenses) > 0
+}
+
 func (t *TraceConfiguration) traceSearchset(lic string) bool {
 	return t.isTraceLicense(lic) && t.shouldTrace("searchset")
 }
@@ -119,3 +125,19 @@ func (t *TraceConfiguration) traceFrequency(lic string) bool {
 // TraceFunc works like fmt.Printf to emit tracing data for the
 // classifier.
 type TraceFunc func(string, ...interface{})
+
+// dump formats a value for a trace, with the elements of a slice on lines of
+// their own. It stands in for a pretty-printer so that tracing doesn't add a
+// dependency to the library.
+func dump(v interface{}) string {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Slice {
+		return fmt.Sprintf("%+v\n", v)
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, "(len=%d)\n", rv.Len())
+	for i := 0; i < rv.Len(); i++ {
+		fmt.Fprintf(&b, "  %d: %+v\n", i, rv.Index(i).Interface())
+	}
+	return b.String()
+}
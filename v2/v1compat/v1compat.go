@@ -0,0 +1,176 @@
+// Copyright 2022 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package v1compat exposes the API of the v1 license classifier, backed by
+// the v2 classifier and its license corpus. Code written against v1 can switch
+// to it by changing its imports, and then migrate to the v2 API incrementally.
+//
+// The Match and Matches types mirror those of the v1 stringclassifier package.
+// The v2 classifier locates matches by line, so the offset and extent of a
+// match span the whole lines it was found on.
+package v1compat
+
+import (
+	"math"
+	"sort"
+	"strings"
+
+	classifier "github.com/google/licenseclassifier/v2"
+	"github.com/google/licenseclassifier/v2/assets"
+)
+
+// DefaultConfidenceThreshold is the minimum confidence percentage we're willing
+// to accept in order to say that a match is good.
+const DefaultConfidenceThreshold = 0.80
+
+// Match is a license found in an unknown text.
+type Match struct {
+	Name       string  // Name of the license that was matched
+	Confidence float64 // Confidence percentage
+	Offset     int     // The offset into the unknown string the match was made
+	Extent     int     // The length from the offset into the unknown string
+}
+
+// Matches is a list of Match-es. This is here mainly so that the list can be
+// sorted.
+type Matches []*Match
+
+func (m Matches) Len() int      { return len(m) }
+func (m Matches) Swap(i, j int) { m[i], m[j] = m[j], m[i] }
+func (m Matches) Less(i, j int) bool {
+	if math.Abs(m[j].Confidence-m[i].Confidence) < math.SmallestNonzeroFloat64 {
+		if m[i].Name == m[j].Name {
+			if m[i].Offset > m[j].Offset {
+				return false
+			}
+			if m[i].Offset == m[j].Offset {
+				return m[i].Extent > m[j].Extent
+			}
+			return true
+		}
+		return m[i].Name < m[j].Name
+	}
+	return m[i].Confidence > m[j].Confidence
+}
+
+// Names returns an unsorted slice of the names of the matched licenses.
+func (m Matches) Names() []string {
+	var names []string
+	for _, n := range m {
+		names = append(names, n.Name)
+	}
+	return names
+}
+
+// License is a classifier with the API of the v1 License.
+type License struct {
+	c *classifier.Classifier
+
+	// Threshold is the lowest confidence of the matches reported.
+	Threshold float64
+}
+
+// New creates a license classifier that matches against the embedded v2
+// license corpus.
+func New(threshold float64) (*License, error) {
+	c, err := assets.NewClassifier(threshold)
+	if err != nil {
+		return nil, err
+	}
+	return &License{c: c, Threshold: threshold}, nil
+}
+
+// NewFromClassifier creates a license classifier backed by a v2 classifier,
+// such as one with a custom corpus.
+func NewFromClassifier(c *classifier.Classifier, threshold float64) *License {
+	return &License{c: c, Threshold: threshold}
+}
+
+// WithinConfidenceThreshold returns true if the confidence value is above or
+// equal to the confidence threshold.
+func (c *License) WithinConfidenceThreshold(conf float64) bool {
+	return conf > c.Threshold || math.Abs(conf-c.Threshold) < math.SmallestNonzeroFloat64
+}
+
+// NearestMatch returns the license that most closely matches the contents, or
+// nil if none does. Unlike v1, which always returns the nearest license, the
+// v2 classifier doesn't report licenses below its threshold.
+func (c *License) NearestMatch(contents string) *Match {
+	ms := c.matches(contents, true)
+	if len(ms) == 0 {
+		return nil
+	}
+	return ms[0]
+}
+
+// MultipleMatch matches all licenses within an unknown text.
+func (c *License) MultipleMatch(contents string, includeHeaders bool) Matches {
+	var matches Matches
+	for _, m := range c.matches(contents, includeHeaders) {
+		if c.WithinConfidenceThreshold(m.Confidence) {
+			matches = append(matches, m)
+		}
+	}
+	return matches
+}
+
+// matches returns the licenses, and headers if requested, found in the
+// contents, most confident first.
+func (c *License) matches(contents string, includeHeaders bool) Matches {
+	lines := lineOffsets(contents)
+	seen := make(map[Match]bool)
+	var matches Matches
+	for _, v := range c.c.Match([]byte(contents)).Matches {
+		switch v.MatchType {
+		case "License":
+		case "Header":
+			if !includeHeaders {
+				continue
+			}
+		default:
+			continue
+		}
+		end := v.EndLine
+		if end >= len(lines) {
+			end = len(lines) - 1
+		}
+		m := Match{
+			Name:       v.Name,
+			Confidence: v.Confidence,
+			Offset:     lines[v.StartLine-1],
+			Extent:     lines[end] - lines[v.StartLine-1],
+		}
+		if !seen[m] {
+			seen[m] = true
+			matches = append(matches, &m)
+		}
+	}
+	sort.Sort(matches)
+	return matches
+}
+
+// lineOffsets returns the offsets at which the lines of the contents start,
+// followed by the length of the contents, so that line n, counting from 1,
+// spans the offsets [n-1] to [n].
+func lineOffsets(contents string) []int {
+	offsets := []int{0}
+	for i := strings.IndexByte(contents, '\n'); i != -1; {
+		offsets = append(offsets, offsets[len(offsets)-1]+i+1)
+		i = strings.IndexByte(contents[offsets[len(offsets)-1]:], '\n')
+	}
+	if offsets[len(offsets)-1] != len(contents) {
+		offsets = append(offsets, len(contents))
+	}
+	return offsets
+}
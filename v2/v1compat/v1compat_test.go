@@ -0,0 +1,86 @@
+// Copyright 2022 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v1compat
+
+import (
+	"io/ioutil"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestMultipleMatch(t *testing.T) {
+	mit, err := ioutil.ReadFile("../assets/License/MIT/pristine.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	header, err := ioutil.ReadFile("../assets/Header/Apache-2.0/header.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	intro := "Some code\nof a project.\n\n"
+	contents := intro + string(mit) + "\n" + string(header)
+
+	c, err := New(DefaultConfidenceThreshold)
+	if err != nil {
+		t.Fatalf("New() = %v", err)
+	}
+
+	got := c.MultipleMatch(contents, false)
+	// The match spans the lines of the license, up to its trailing blank line.
+	want := Matches{{Name: "MIT", Confidence: 1.0, Offset: len(intro), Extent: len(strings.TrimRight(string(mit), "\n")) + 1}}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("MultipleMatch() headers excluded: unexpected diff (-want +got):\n%s", diff)
+	}
+
+	got = c.MultipleMatch(contents, true)
+	if names := got.Names(); !cmp.Equal(names, []string{"Apache-2.0", "MIT"}) {
+		t.Errorf("MultipleMatch() headers included = %v, want [Apache-2.0 MIT]", names)
+	}
+	for _, m := range got {
+		if m.Name != "Apache-2.0" {
+			continue
+		}
+		if text := contents[m.Offset : m.Offset+m.Extent]; strings.TrimSpace(text) != strings.TrimSpace(string(header)) {
+			t.Errorf("MultipleMatch() header spans %q, want the lines of the header", text)
+		}
+	}
+
+	if m := c.NearestMatch(string(mit)); m == nil || m.Name != "MIT" {
+		t.Errorf("NearestMatch() = %+v, want MIT", m)
+	}
+	if m := c.NearestMatch("nothing to see here"); m != nil {
+		t.Errorf("NearestMatch() = %+v, want nil", m)
+	}
+}
+
+func TestLineOffsets(t *testing.T) {
+	tests := []struct {
+		contents string
+		want     []int
+	}{
+		{"", []int{0}},
+		{"a", []int{0, 1}},
+		{"a\n", []int{0, 2}},
+		{"ab\ncd", []int{0, 3, 5}},
+		{"ab\ncd\n", []int{0, 3, 6}},
+	}
+	for _, tt := range tests {
+		if got := lineOffsets(tt.contents); !cmp.Equal(got, tt.want) {
+			t.Errorf("lineOffsets(%q) = %v, want %v", tt.contents, got, tt.want)
+		}
+	}
+}
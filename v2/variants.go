@@ -0,0 +1,75 @@
+// Copyright 2022 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package classifier
+
+import "sort"
+
+// canonicalVariants are the names of the variants holding the canonical text
+// of a license or header, which are preferred over other variants that match
+// equally well.
+var canonicalVariants = map[string]int{
+	"license.txt":  0,
+	"pristine.txt": 1,
+	"header.txt":   2,
+}
+
+// preferVariant reports whether variant a is preferred over variant b when
+// both match equally well: canonical variants come first, and other variants
+// in name order.
+func preferVariant(a, b string) bool {
+	ra, aok := canonicalVariants[a]
+	rb, bok := canonicalVariants[b]
+	switch {
+	case aok && bok:
+		return ra < rb
+	case aok != bok:
+		return aok
+	}
+	return a < b
+}
+
+// collapseVariants merges matches of different variants of the same license
+// that tie: they cover the same lines with the same reported confidence. The
+// preferred variant is kept and the others are listed in its
+// AlternateVariants, so that the choice doesn't depend on the order the
+// variants were matched in and the tie remains visible.
+func collapseVariants(matches Matches) Matches {
+	var out Matches
+	for _, m := range matches {
+		merged := false
+		for _, o := range out {
+			if o.Name != m.Name || o.MatchType != m.MatchType || o.StartLine != m.StartLine || o.EndLine != m.EndLine ||
+				quantizeConfidence(o.Confidence) != quantizeConfidence(m.Confidence) {
+				continue
+			}
+			if preferVariant(m.Variant, o.Variant) {
+				o.Variant, m.Variant = m.Variant, o.Variant
+				o.Confidence, o.StartTokenIndex, o.EndTokenIndex = m.Confidence, m.StartTokenIndex, m.EndTokenIndex
+			}
+			o.AlternateVariants = append(o.AlternateVariants, m.Variant)
+			merged = true
+			break
+		}
+		if !merged {
+			out = append(out, m)
+		}
+	}
+	for _, m := range out {
+		sort.Slice(m.AlternateVariants, func(i, j int) bool {
+			return preferVariant(m.AlternateVariants[i], m.AlternateVariants[j])
+		})
+	}
+	return out
+}
@@ -0,0 +1,62 @@
+// Copyright 2022 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package classifier
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestPreferVariant(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want bool
+	}{
+		{"license.txt", "a.txt", true},
+		{"a.txt", "license.txt", false},
+		{"license.txt", "pristine.txt", true},
+		{"a.txt", "b.txt", true},
+		{"hashicorp.txt", "a.txt", false},
+	}
+	for _, tt := range tests {
+		if got := preferVariant(tt.a, tt.b); got != tt.want {
+			t.Errorf("preferVariant(%q, %q) = %v, want %v", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+func TestAlternateVariants(t *testing.T) {
+	text := []byte("This software may be used, copied and modified by anyone for any purpose without restriction.")
+	c := NewClassifier(defaultThreshold)
+	// The variants are added in an order that differs from the preferred
+	// one, to check the choice doesn't depend on it.
+	for _, v := range []string{"b.txt", "license.txt", "a.txt"} {
+		c.AddContent("License", "Tied", v, text)
+	}
+
+	for i := 0; i < 5; i++ {
+		got := c.Match(text).Matches
+		if len(got) != 1 {
+			t.Fatalf("Match() = %v, want a single match", got)
+		}
+		if got[0].Variant != "license.txt" {
+			t.Errorf("Match() variant = %q, want %q", got[0].Variant, "license.txt")
+		}
+		if diff := cmp.Diff([]string{"a.txt", "b.txt"}, got[0].AlternateVariants); diff != "" {
+			t.Errorf("Match() alternate variants mismatch (-want +got):\n%s", diff)
+		}
+	}
+}
@@ -0,0 +1,78 @@
+// Copyright 2022 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package classifier
+
+// warningMatchTypes are the types of matches reported as warnings when their
+// confidence falls in the warning band.
+var warningMatchTypes = map[string]bool{
+	"License": true,
+	"Header":  true,
+}
+
+// matchThreshold returns the lowest confidence of the candidates searched
+// for: the warning threshold if it's set below the threshold.
+func (c *Classifier) matchThreshold() float64 {
+	if c.warn > 0 && c.warn < c.threshold {
+		return c.warn
+	}
+	return c.threshold
+}
+
+// accept reports whether a candidate of the named corpus document with the
+// given confidence is a match or a warning, by the confidence reported for it.
+func (c *Classifier) accept(name string, conf float64) bool {
+	conf = c.reportedConfidence(conf)
+	if conf >= c.threshold {
+		return true
+	}
+	return conf >= c.matchThreshold() && warningMatchTypes[detectionType(name)]
+}
+
+// warning reports whether an accepted candidate with the given confidence is
+// a warning, by the confidence reported for it.
+func (c *Classifier) warning(conf float64) bool {
+	return c.reportedConfidence(conf) < c.threshold
+}
+
+// splitWarnings separates the warnings from the candidates, so that they
+// can't displace confident matches.
+func splitWarnings(candidates Matches) (rest, warnings Matches) {
+	for _, m := range candidates {
+		if m.Warning {
+			warnings = append(warnings, m)
+		} else {
+			rest = append(rest, m)
+		}
+	}
+	return rest, warnings
+}
+
+// outsideMatches returns the warnings that don't overlap any of the matches.
+func outsideMatches(warnings, matches Matches) Matches {
+	var out Matches
+	for _, w := range warnings {
+		keep := true
+		for _, m := range matches {
+			if overlaps(w, m) {
+				keep = false
+				break
+			}
+		}
+		if keep {
+			out = append(out, w)
+		}
+	}
+	return out
+}
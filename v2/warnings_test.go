@@ -0,0 +1,108 @@
+// Copyright 2022 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package classifier
+
+import (
+	"bytes"
+	"io/ioutil"
+	"path"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestWarnThreshold(t *testing.T) {
+	mit, err := ioutil.ReadFile(path.Join(baseLicenses, "License", "MIT", "pristine.txt"))
+	if err != nil {
+		t.Fatalf("couldn't read MIT license: %v", err)
+	}
+	isc, err := ioutil.ReadFile(path.Join(baseLicenses, "License", "ISC", "license.txt"))
+	if err != nil {
+		t.Fatalf("couldn't read ISC license: %v", err)
+	}
+	// Dropping the notice condition leaves the MIT license below the
+	// threshold but within the warning band.
+	fuzzy := bytes.Replace(mit, []byte("The above copyright notice and this permission notice shall be included in all\ncopies or substantial portions of the Software.\n"), nil, 1)
+	in := append(append(append([]byte{}, isc...), "\n\n"...), fuzzy...)
+
+	newClassifier := func(options ...OptionFunc) *Classifier {
+		c := NewClassifier(.9, options...)
+		c.AddContent("License", "MIT", "pristine.txt", mit)
+		c.AddContent("License", "ISC", "license.txt", isc)
+		return c
+	}
+	summary := func(ms Matches) map[string]bool {
+		out := make(map[string]bool)
+		for _, m := range ms {
+			out[m.Name] = m.Warning
+		}
+		return out
+	}
+
+	if diff := cmp.Diff(map[string]bool{"ISC": false}, summary(newClassifier().Match(in).Matches)); diff != "" {
+		t.Errorf("Match() without warnings mismatch (-want +got):\n%s", diff)
+	}
+	c := newClassifier(WithWarnThreshold(.75))
+	got := c.Match(in).Matches
+	if diff := cmp.Diff(map[string]bool{"ISC": false, "MIT": true}, summary(got)); diff != "" {
+		t.Errorf("Match() with warnings mismatch (-want +got):\n%s", diff)
+	}
+	for _, m := range got {
+		if m.Warning && (m.Confidence >= .9 || m.Confidence < .75) {
+			t.Errorf("Match() warning %s has confidence %v, want it within [0.75, 0.9)", m.Name, m.Confidence)
+		}
+	}
+}
+
+func TestWarningReportedConfidence(t *testing.T) {
+	// A confidence just below the threshold is reported as the threshold,
+	// so the match isn't a warning, unless raw confidences are reported.
+	conf := .8 - 1e-12
+	tests := []struct {
+		name    string
+		options []OptionFunc
+		want    bool
+	}{
+		{name: "quantized", want: false},
+		{name: "raw", options: []OptionFunc{WithRawConfidence()}, want: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := NewClassifier(.8, append(tt.options, WithWarnThreshold(.75))...)
+			if !c.accept(c.generateDocName("License", "MIT", "pristine.txt"), conf) {
+				t.Errorf("accept(%v) = false, want true", conf)
+			}
+			if got := c.warning(conf); got != tt.want {
+				t.Errorf("warning(%v) = %v, want %v", conf, got, tt.want)
+			}
+		})
+	}
+
+	// Without a warning threshold, the candidate is a match rather than
+	// dropped.
+	if c := NewClassifier(.8); !c.accept(c.generateDocName("License", "MIT", "pristine.txt"), conf) {
+		t.Errorf("accept(%v) without a warning threshold = false, want true", conf)
+	}
+}
+
+func TestOutsideMatches(t *testing.T) {
+	match := &Match{Name: "MIT", StartLine: 10, EndLine: 20}
+	inside := &Match{Name: "X11", Warning: true, StartLine: 12, EndLine: 25}
+	outside := &Match{Name: "ISC", Warning: true, StartLine: 30, EndLine: 40}
+	got := outsideMatches(Matches{inside, outside}, Matches{match})
+	if diff := cmp.Diff(Matches{outside}, got); diff != "" {
+		t.Errorf("outsideMatches() mismatch (-want +got):\n%s", diff)
+	}
+}